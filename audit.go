@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func printAuditUsage(programName string) {
+	fmt.Printf("Usage: %s audit query -log FILE [OPTIONS]\n", programName)
+	fmt.Printf("       %s audit fingerprint [-log FILE | -key FILE] < password\n", programName)
+	fmt.Println("Search the audit log written by generate -audit-log, so security can")
+	fmt.Println("answer what was generated, when and by whom. The log holds fingerprints")
+	fmt.Println("keyed with the secret in FILE.key, never passwords; audit fingerprint")
+	fmt.Println("computes the one of a known password to look it up.")
+	fmt.Println("Options:")
+	fmt.Println("  -log FILE    Audit log to read (default: $PASSGEN_AUDIT_LOG)")
+	fmt.Println("  -since WHEN  Only entries from WHEN on: a duration such as 7d or 36h, a")
+	fmt.Println("               date (YYYY-MM-DD) or an RFC 3339 time")
+	fmt.Println("  -until WHEN  Only entries before WHEN, in the same forms")
+	fmt.Println("  -label GLOB  Only entries whose label matches GLOB, e.g. 'prod*'")
+	fmt.Println("  -user NAME   Only entries made by NAME")
+	fmt.Println("  -o FORMAT    Output format: text, json or csv (default: text)")
+	fmt.Println("  -key FILE    Fingerprint key of audit fingerprint, for records sent only to")
+	fmt.Println("               -audit-sink (default: FILE.key of -log)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s audit query -log /var/log/passgen.jsonl -since 7d -label 'prod*'\n", programName)
+}
+
+// auditRecord is one line of the audit log: who generated how many
+// passwords with which policy, and where they went. Passwords themselves
+// are only identified by their fingerprints.
+type auditRecord struct {
+	Time         string   `json:"time"`
+	User         string   `json:"user"`
+	Host         string   `json:"host"`
+	Command      string   `json:"command"`
+	Mode         string   `json:"mode"`
+	Label        string   `json:"label,omitempty"`
+	Note         string   `json:"note,omitempty"`
+	Count        int      `json:"count"`
+	Length       int      `json:"length"`
+	Fingerprints []string `json:"fingerprints"`
+	DeliveredTo  []string `json:"deliveredTo"`
+}
+
+// auditKeyPath returns where the fingerprint key of the audit log at
+// logPath is kept: next to it, or for records that only go to an
+// -audit-sink, in the user's config directory.
+func auditKeyPath(logPath string) string {
+	if logPath != "" {
+		return logPath + ".key"
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".passgen-audit.key"
+	}
+	return filepath.Join(dir, "passgen", "audit.key")
+}
+
+// loadAuditKey reads the secret key of audit fingerprints at path,
+// creating it when create is set and there is none. Without the key a
+// fingerprint in the log cannot be brute-forced back to a short or
+// predictable password, so a key others can read is refused.
+func loadAuditKey(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return key, f.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("audit key %s is accessible by other users; restrict it with chmod 600", path)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) < 16 {
+		return nil, fmt.Errorf("audit key %s is not a hex key of at least 16 bytes", path)
+	}
+	return key, nil
+}
+
+// auditFingerprint identifies a password in the audit log: the first four
+// bytes of its HMAC-SHA256 under the log's key, in hex. Unlike Fingerprint
+// it reveals nothing to someone who can read the log but not the key.
+func auditFingerprint(key []byte, password string) string {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, password)
+	return hex.EncodeToString(mac.Sum(nil)[:4])
+}
+
+// auditSinkNames lists the destinations -audit-sink accepts besides files.
+var auditSinkNames = []string{"syslog", "journald"}
+
+// auditSink sends audit records to a system log. Like the file log, it only
+// ever sees metadata and fingerprints.
+type auditSink interface {
+	write(rec auditRecord) error
+	Close() error
+}
+
+// stampAudit fills in when, by whom and where a record was made.
+func stampAudit(rec auditRecord, now time.Time) auditRecord {
+	rec.Time = now.UTC().Format(time.RFC3339)
+	if u, err := user.Current(); err == nil {
+		rec.User = u.Username
+	}
+	rec.Host, _ = os.Hostname()
+	return rec
+}
+
+// summary describes a record in one line for people reading logs.
+func (rec auditRecord) summary() string {
+	label := rec.Label
+	if label == "" {
+		label = "-"
+	}
+	return fmt.Sprintf("%s@%s  %s  %d × %d %s  to %s", rec.User, rec.Host, label,
+		rec.Count, rec.Length, rec.Mode, strings.Join(rec.DeliveredTo, ", "))
+}
+
+// appendAudit adds a record to the log at path, one JSON object per line.
+// The log is created readable by its owner only.
+func appendAudit(path string, rec auditRecord, now time.Time) error {
+	data, err := json.Marshal(stampAudit(rec, now))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// openAuditLog opens the log for reading. A log that other users can write
+// to could have been tampered with, so it is refused.
+func openAuditLog(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		f.Close()
+		return nil, fmt.Errorf("audit log %s is writable by other users and cannot be trusted; restrict it with chmod 600", path)
+	}
+	return f, nil
+}
+
+// auditQuery selects audit records. Zero fields match everything.
+type auditQuery struct {
+	Since, Until time.Time
+	Label        string
+	User         string
+}
+
+func (q auditQuery) matches(rec auditRecord) (bool, error) {
+	t, err := time.Parse(time.RFC3339, rec.Time)
+	if err != nil {
+		return false, fmt.Errorf("invalid time %q", rec.Time)
+	}
+	if !q.Since.IsZero() && t.Before(q.Since) || !q.Until.IsZero() && !t.Before(q.Until) {
+		return false, nil
+	}
+	if q.User != "" && rec.User != q.User {
+		return false, nil
+	}
+	if q.Label != "" {
+		ok, err := path.Match(q.Label, rec.Label)
+		if err != nil {
+			return false, fmt.Errorf("invalid label pattern %q", q.Label)
+		}
+		return ok, nil
+	}
+	return true, nil
+}
+
+// queryAudit returns the records of the log in r that match q, in order.
+func queryAudit(r io.Reader, q auditQuery) ([]auditRecord, error) {
+	var found []auditRecord
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var rec auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		ok, err := q.matches(rec)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if ok {
+			found = append(found, rec)
+		}
+	}
+	return found, scanner.Err()
+}
+
+// parseSince parses a point in time given as a duration before now, such as
+// "7d" or "36h", or as a date or RFC 3339 time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use a duration such as 7d, YYYY-MM-DD or RFC 3339)", s)
+	}
+	return t, nil
+}
+
+// runAudit implements the audit subcommand.
+func runAudit(programName string, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		printAuditUsage(programName)
+		return nil
+	}
+	switch args[0] {
+	case "query":
+	case "fingerprint":
+		return runAuditFingerprint(programName, args[1:])
+	default:
+		return fmt.Errorf("unknown audit command %q (use query or fingerprint)", args[0])
+	}
+
+	fs := flag.NewFlagSet("audit query", flag.ContinueOnError)
+	logPath := fs.String("log", os.Getenv(envName("audit-log")), "Audit log to read")
+	since := fs.String("since", "", "Only entries from WHEN on")
+	until := fs.String("until", "", "Only entries before WHEN")
+	label := fs.String("label", "", "Only entries whose label matches GLOB")
+	userName := fs.String("user", "", "Only entries made by NAME")
+	format := fs.String("o", "text", "Output format: text, json or csv")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printAuditUsage(programName) }
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *help {
+		printAuditUsage(programName)
+		return nil
+	}
+	if *logPath == "" {
+		return fmt.Errorf("-log is required")
+	}
+	if *format != "text" && *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown output format %q (use text, json or csv)", *format)
+	}
+	now := time.Now()
+	q := auditQuery{Label: *label, User: *userName}
+	var err error
+	if *since != "" {
+		if q.Since, err = parseSince(*since, now); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		if q.Until, err = parseSince(*until, now); err != nil {
+			return err
+		}
+	}
+
+	f, err := openAuditLog(*logPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	records, err := queryAudit(f, q)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *logPath, err)
+	}
+
+	switch *format {
+	case "json":
+		if records == nil {
+			records = []auditRecord{}
+		}
+		return writeJSON(os.Stdout, records)
+	case "csv":
+		return writeAuditCSV(os.Stdout, records)
+	}
+	for _, rec := range records {
+		fmt.Printf("%s  %s\n", rec.Time, rec.summary())
+	}
+	plural := "ies"
+	if len(records) == 1 {
+		plural = "y"
+	}
+	fmt.Printf("%d entr%s\n", len(records), plural)
+	return nil
+}
+
+// writeAuditCSV writes records as CSV with a header row. Lists are joined
+// with spaces.
+func writeAuditCSV(w io.Writer, records []auditRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "user", "host", "command", "mode", "label", "note", "count", "length", "fingerprints", "deliveredTo"})
+	for _, rec := range records {
+		cw.Write([]string{rec.Time, rec.User, rec.Host, rec.Command, rec.Mode, rec.Label, rec.Note,
+			strconv.Itoa(rec.Count), strconv.Itoa(rec.Length),
+			strings.Join(rec.Fingerprints, " "), strings.Join(rec.DeliveredTo, " ")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// runAuditFingerprint implements audit fingerprint: the keyed fingerprint
+// of a password read from stdin, as the audit log records it.
+func runAuditFingerprint(programName string, args []string) error {
+	fs := flag.NewFlagSet("audit fingerprint", flag.ContinueOnError)
+	logPath := fs.String("log", os.Getenv(envName("audit-log")), "Audit log whose key to use")
+	keyPath := fs.String("key", "", "Fingerprint key to use")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printAuditUsage(programName) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printAuditUsage(programName)
+		return nil
+	}
+	if *keyPath == "" {
+		*keyPath = auditKeyPath(*logPath)
+	}
+	key, err := loadAuditKey(*keyPath, false)
+	if err != nil {
+		return err
+	}
+	var password string
+	if isTerminal(os.Stdin) {
+		password, err = readHiddenPassword("Password: ")
+		if err == nil && password == "" {
+			err = fmt.Errorf("no password entered")
+		}
+	} else {
+		password, err = readPassword(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(auditFingerprint(key, password))
+	return nil
+}
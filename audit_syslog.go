@@ -0,0 +1,117 @@
+//go:build !passgen_lite && !windows && !plan9
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	features = append(features, "audit-syslog", "audit-journald")
+}
+
+// journalSocket is where journald accepts native protocol datagrams.
+var journalSocket = "/run/systemd/journal/socket"
+
+// openAuditSink connects to the named audit destination.
+func openAuditSink(name string) (auditSink, error) {
+	switch name {
+	case "syslog":
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTHPRIV, "passgen")
+		if err != nil {
+			return nil, fmt.Errorf("connecting to syslog: %w", err)
+		}
+		return syslogSink{w}, nil
+	case "journald":
+		conn, err := net.Dial("unixgram", journalSocket)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to journald: %w", err)
+		}
+		return journalSink{conn}, nil
+	}
+	return nil, fmt.Errorf("unknown audit sink %q (use %s)", name, strings.Join(auditSinkNames, " or "))
+}
+
+// syslogSink sends records to the local syslog daemon with the authpriv
+// facility, as a CEE message so rsyslog and syslog-ng can parse the fields.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func (s syslogSink) write(rec auditRecord) error {
+	msg, err := syslogMessage(rec)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(msg)
+}
+
+func (s syslogSink) Close() error {
+	return s.w.Close()
+}
+
+// syslogMessage formats a record as "@cee: " followed by its JSON.
+func syslogMessage(rec auditRecord) (string, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	return "@cee: " + string(data), nil
+}
+
+// journalSink sends records to journald as native journal entries, one
+// PASSGEN_* field per record field.
+type journalSink struct {
+	conn net.Conn
+}
+
+func (s journalSink) write(rec auditRecord) error {
+	_, err := s.conn.Write(journalEntry(rec))
+	return err
+}
+
+func (s journalSink) Close() error {
+	return s.conn.Close()
+}
+
+// journalEntry encodes a record in the journald native protocol. Values
+// with newlines, such as a multi-line note, use the length-prefixed form.
+func journalEntry(rec auditRecord) []byte {
+	var b bytes.Buffer
+	field := func(name, value string) {
+		if !strings.Contains(value, "\n") {
+			fmt.Fprintf(&b, "%s=%s\n", name, value)
+			return
+		}
+		b.WriteString(name + "\n")
+		binary.Write(&b, binary.LittleEndian, uint64(len(value)))
+		b.WriteString(value + "\n")
+	}
+	field("MESSAGE", "passgen: "+rec.summary())
+	field("PRIORITY", strconv.Itoa(int(syslog.LOG_INFO)))
+	field("SYSLOG_FACILITY", strconv.Itoa(int(syslog.LOG_AUTHPRIV>>3)))
+	field("SYSLOG_IDENTIFIER", "passgen")
+	field("PASSGEN_TIME", rec.Time)
+	field("PASSGEN_USER", rec.User)
+	field("PASSGEN_HOST", rec.Host)
+	field("PASSGEN_COMMAND", rec.Command)
+	field("PASSGEN_MODE", rec.Mode)
+	if rec.Label != "" {
+		field("PASSGEN_LABEL", rec.Label)
+	}
+	if rec.Note != "" {
+		field("PASSGEN_NOTE", rec.Note)
+	}
+	field("PASSGEN_COUNT", strconv.Itoa(rec.Count))
+	field("PASSGEN_LENGTH", strconv.Itoa(rec.Length))
+	field("PASSGEN_FINGERPRINTS", strings.Join(rec.Fingerprints, " "))
+	field("PASSGEN_DELIVERED_TO", strings.Join(rec.DeliveredTo, ", "))
+	return b.Bytes()
+}
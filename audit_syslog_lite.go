@@ -0,0 +1,11 @@
+//go:build passgen_lite || windows || plan9
+
+package main
+
+import "errors"
+
+// openAuditSink fails, since syslog and journald are only reachable from
+// full builds on Unix systems.
+func openAuditSink(name string) (auditSink, error) {
+	return nil, errors.New("audit sinks are not available in this build")
+}
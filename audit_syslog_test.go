@@ -0,0 +1,100 @@
+//go:build !passgen_lite && !windows && !plan9
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testAuditRecord = auditRecord{
+	Time: "2026-10-16T09:00:00Z", User: "alice", Host: "vm", Command: "generate", Mode: "random",
+	Label: "prod-db", Count: 2, Length: 16,
+	Fingerprints: []string{"5e884898", "a665a459"}, DeliveredTo: []string{"stdout"},
+}
+
+// TestSyslogMessage tests that syslog messages carry the record as CEE JSON
+func TestSyslogMessage(t *testing.T) {
+	msg, err := syslogMessage(testAuditRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := strings.CutPrefix(msg, "@cee: ")
+	if !ok {
+		t.Fatalf("Message %q lacks the @cee: cookie", msg)
+	}
+	var rec auditRecord
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Label != "prod-db" || rec.User != "alice" || len(rec.Fingerprints) != 2 {
+		t.Errorf("Decoded %+v", rec)
+	}
+}
+
+// TestJournalEntry tests the native journal fields, including the binary
+// form for values with newlines
+func TestJournalEntry(t *testing.T) {
+	rec := testAuditRecord
+	rec.Note = "line one\nline two"
+	entry := journalEntry(rec)
+	for _, want := range []string{
+		"MESSAGE=passgen: alice@vm  prod-db  2 × 16 random  to stdout\n",
+		"SYSLOG_IDENTIFIER=passgen\n",
+		"PRIORITY=6\n",
+		"SYSLOG_FACILITY=10\n",
+		"PASSGEN_LABEL=prod-db\n",
+		"PASSGEN_FINGERPRINTS=5e884898 a665a459\n",
+	} {
+		if !bytes.Contains(entry, []byte(want)) {
+			t.Errorf("Entry lacks %q:\n%s", want, entry)
+		}
+	}
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(rec.Note)))
+	want := "PASSGEN_NOTE\n" + string(size[:]) + rec.Note + "\n"
+	if !bytes.Contains(entry, []byte(want)) {
+		t.Errorf("Entry lacks the binary PASSGEN_NOTE field:\n%q", entry)
+	}
+}
+
+// TestJournalSink tests sending a record over the journal socket
+func TestJournalSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal")
+	conn, err := net.ListenPacket("unixgram", socket)
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	old := journalSocket
+	journalSocket = socket
+	defer func() { journalSocket = old }()
+
+	sink, err := openAuditSink("journald")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.write(testAuditRecord); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf[:n], journalEntry(testAuditRecord)) {
+		t.Errorf("Received %q", buf[:n])
+	}
+
+	if _, err := openAuditSink("kafka"); err == nil {
+		t.Error("Expected error for an unknown audit sink")
+	}
+}
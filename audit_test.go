@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestAuditLog tests appending records and querying them back
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC) }
+	for i, rec := range []auditRecord{
+		{Label: "prod-db", Count: 1, Length: 20},
+		{Label: "staging-db", Count: 2, Length: 16},
+		{Label: "prod-web", Count: 1, Length: 24, Fingerprints: []string{"5e884898"}},
+	} {
+		rec.Command, rec.Mode = "generate", "random"
+		if err := appendAudit(path, rec, day(10+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Audit log has mode %o, want 600", perm)
+	}
+
+	tests := []struct {
+		name string
+		q    auditQuery
+		want string
+	}{
+		{"everything", auditQuery{}, "prod-db staging-db prod-web"},
+		{"label glob", auditQuery{Label: "prod*"}, "prod-db prod-web"},
+		{"since", auditQuery{Since: day(11)}, "staging-db prod-web"},
+		{"until", auditQuery{Until: day(11)}, "prod-db"},
+		{"since and label", auditQuery{Since: day(11), Label: "prod*"}, "prod-web"},
+		{"user", auditQuery{User: "nobody-at-all"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := openAuditLog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			records, err := queryAudit(f, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var labels []string
+			for _, rec := range records {
+				labels = append(labels, rec.Label)
+			}
+			if got := strings.Join(labels, " "); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := os.Chmod(path, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openAuditLog(path); err == nil {
+		t.Error("Expected error for a log writable by others")
+	}
+}
+
+// TestQueryAuditErrors tests corrupt lines and bad patterns
+func TestQueryAuditErrors(t *testing.T) {
+	if _, err := queryAudit(strings.NewReader("{\"time\":\"2026-10-16T09:00:00Z\"}\nnot json\n"), auditQuery{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected error on line 2, got %v", err)
+	}
+	if _, err := queryAudit(strings.NewReader("{\"time\":\"2026-10-16T09:00:00Z\"}\n"), auditQuery{Label: "[prod"}); err == nil {
+		t.Error("Expected error for an invalid pattern")
+	}
+}
+
+// TestParseSince tests durations, days and dates
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"7d", time.Date(2026, 10, 9, 12, 0, 0, 0, time.UTC), false},
+		{"36h", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), false},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), false},
+		{"-3d", time.Time{}, true},
+		{"last week", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// TestWriteAuditCSV tests the CSV export
+func TestWriteAuditCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeAuditCSV(&buf, []auditRecord{{Time: "2026-10-16T09:00:00Z", Label: "db", Count: 2, Fingerprints: []string{"aa", "bb"}, DeliveredTo: []string{"stdout", "clipboard"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][0] != "time" || rows[1][5] != "db" || rows[1][9] != "aa bb" || rows[1][10] != "stdout clipboard" {
+		t.Errorf("Unexpected CSV %q", rows)
+	}
+}
+
+// TestAuditFingerprint tests that audit fingerprints are keyed with a
+// private key kept next to the log
+func TestAuditFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "audit.jsonl")
+	keyPath := auditKeyPath(logPath)
+	if keyPath != logPath+".key" {
+		t.Errorf("auditKeyPath = %q", keyPath)
+	}
+	if _, err := loadAuditKey(keyPath, false); err == nil {
+		t.Error("Expected an error for a missing key")
+	}
+	key, err := loadAuditKey(keyPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadAuditKey(keyPath, true)
+	if err != nil || !bytes.Equal(key, again) {
+		t.Fatalf("Reloaded key differs: %v", err)
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Audit key has mode %v, want 600", info.Mode().Perm())
+	}
+
+	fp := auditFingerprint(key, "1234")
+	if len(fp) != 8 || fp == passgen.Fingerprint("1234") {
+		t.Errorf("auditFingerprint = %q, want a keyed fingerprint of 8 hex digits", fp)
+	}
+	if fp == auditFingerprint(make([]byte, 32), "1234") {
+		t.Error("Fingerprints under different keys match")
+	}
+
+	// The record of a run carries the keyed fingerprint
+	out := filepath.Join(dir, "out.csv")
+	if err := runGenerate("passgen", []string{"-audit-log", logPath, "-out", out, "-fingerprint-only"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	records, err := queryAudit(log, auditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := auditFingerprint(key, rows[1][1]); len(records) != 1 || !slices.Equal(records[0].Fingerprints, []string{want}) {
+		t.Errorf("Audit records %+v, want fingerprint %s", records, want)
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(keyPath, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadAuditKey(keyPath, true); err == nil {
+			t.Error("Expected an error for a key others can read")
+		}
+	}
+}
@@ -0,0 +1,190 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	features = append(features, "beacon")
+}
+
+// maxBeaconResponse bounds a drand API response, which is a few hundred
+// bytes.
+const maxBeaconResponse = 1 << 20
+
+// drandInfo is the chain information of a drand beacon.
+type drandInfo struct {
+	Period      int64  `json:"period"`
+	GenesisTime int64  `json:"genesis_time"`
+	Hash        string `json:"hash"`
+}
+
+// roundTime returns when the beacon publishes round.
+func (i *drandInfo) roundTime(round uint64) time.Time {
+	return time.Unix(i.GenesisTime+int64(round-1)*i.Period, 0).UTC()
+}
+
+// drandRound is a round of the drand public API.
+type drandRound struct {
+	Round      uint64 `json:"round"`
+	Randomness string `json:"randomness"`
+	Signature  string `json:"signature"`
+}
+
+// drandClient reads a drand beacon's HTTP API.
+type drandClient struct {
+	client *http.Client
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+func newDrandClient() *drandClient {
+	return &drandClient{client: &http.Client{Timeout: 30 * time.Second}, now: time.Now, sleep: time.Sleep}
+}
+
+// planBeaconRound returns round of the beacon at source, or without a round
+// the first one after now, with its chain and time but no randomness yet.
+// A round already published is refused.
+func planBeaconRound(source string, round uint64) (*beaconRound, error) {
+	return newDrandClient().plan(source, round)
+}
+
+// fetchBeaconRound waits for the planned round b to be published and fills
+// in its randomness.
+func fetchBeaconRound(b *beaconRound, status io.Writer) error {
+	return newDrandClient().fetch(b, status)
+}
+
+// checkBeaconRound compares a round of a transcript with what its beacon
+// published.
+func checkBeaconRound(b *beaconRound) error {
+	return newDrandClient().check(b)
+}
+
+// get decodes the JSON response to a GET of path under source into v. It
+// reports whether the resource exists yet.
+func (c *drandClient) get(source, path string, v any) (bool, error) {
+	// A beacon read in the clear could be swapped for a value the operator
+	// chose
+	if !strings.HasPrefix(source, "https://") {
+		return false, fmt.Errorf("beacon URL %s must use https", source)
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(source, "/")+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", "passgen/"+version)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("reading beacon: %w", err)
+	}
+	defer resp.Body.Close()
+	// drand answers 404 or 425 Too Early for rounds still to come
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusTooEarly {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("reading beacon: %s%s returned %s", source, path, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBeaconResponse)).Decode(v); err != nil {
+		return false, fmt.Errorf("reading beacon: %s%s: %w", source, path, err)
+	}
+	return true, nil
+}
+
+func (c *drandClient) info(source string) (*drandInfo, error) {
+	var info drandInfo
+	found, err := c.get(source, "/info", &info)
+	if err != nil {
+		return nil, err
+	}
+	if !found || info.Period <= 0 || info.Hash == "" {
+		return nil, fmt.Errorf("%s is not a drand beacon", source)
+	}
+	return &info, nil
+}
+
+func (c *drandClient) plan(source string, round uint64) (*beaconRound, error) {
+	info, err := c.info(source)
+	if err != nil {
+		return nil, err
+	}
+	if round == 0 {
+		elapsed := c.now().Unix() - info.GenesisTime
+		if elapsed < 0 {
+			return nil, fmt.Errorf("beacon %s has not started", source)
+		}
+		round = uint64(elapsed/info.Period) + 2
+	}
+	at := info.roundTime(round)
+	if !at.After(c.now()) {
+		return nil, fmt.Errorf("beacon round %d was published at %s; a draw must commit to a round still to come", round, at.Format(time.RFC3339))
+	}
+	return &beaconRound{URL: source, ChainHash: info.Hash, Round: round, Time: at.Format(time.RFC3339)}, nil
+}
+
+func (c *drandClient) fetch(b *beaconRound, status io.Writer) error {
+	// The beacon behind the URL must still be the chain that was planned
+	info, err := c.info(b.URL)
+	if err != nil {
+		return err
+	}
+	if info.Hash != b.ChainHash {
+		return fmt.Errorf("beacon %s is chain %s, not %s", b.URL, info.Hash, b.ChainHash)
+	}
+	at := info.roundTime(b.Round)
+	if wait := at.Sub(c.now()); wait > 0 {
+		fmt.Fprintf(status, "Waiting for beacon round %d at %s\n", b.Round, at.Format(time.RFC3339))
+		c.sleep(wait)
+	}
+	// Nodes publish a round a moment after its time
+	var r drandRound
+	for attempt := 0; ; attempt++ {
+		found, err := c.get(b.URL, "/public/"+strconv.FormatUint(b.Round, 10), &r)
+		if err != nil {
+			return err
+		}
+		if found {
+			break
+		}
+		if attempt == 10 {
+			return fmt.Errorf("beacon %s has not published round %d", b.URL, b.Round)
+		}
+		c.sleep(time.Second)
+	}
+	if r.Round != b.Round {
+		return fmt.Errorf("beacon %s returned round %d for round %d", b.URL, r.Round, b.Round)
+	}
+	b.Randomness, b.Signature = r.Randomness, r.Signature
+	return b.check()
+}
+
+func (c *drandClient) check(b *beaconRound) error {
+	info, err := c.info(b.URL)
+	if err != nil {
+		return err
+	}
+	if info.Hash != b.ChainHash {
+		return fmt.Errorf("beacon %s is chain %s, not %s", b.URL, info.Hash, b.ChainHash)
+	}
+	if at := info.roundTime(b.Round).Format(time.RFC3339); at != b.Time {
+		return fmt.Errorf("beacon round %d was published at %s, not %s", b.Round, at, b.Time)
+	}
+	var r drandRound
+	found, err := c.get(b.URL, "/public/"+strconv.FormatUint(b.Round, 10), &r)
+	if err != nil {
+		return err
+	}
+	if !found || r.Randomness != b.Randomness || r.Signature != b.Signature {
+		return fmt.Errorf("beacon %s did not publish the randomness of round %d in the transcript", b.URL, b.Round)
+	}
+	return nil
+}
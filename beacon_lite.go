@@ -0,0 +1,23 @@
+//go:build passgen_lite
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// planBeaconRound fails, since lite builds do not talk to remote services.
+func planBeaconRound(source string, round uint64) (*beaconRound, error) {
+	return nil, errors.New("-beacon is not available in this build (built with passgen_lite)")
+}
+
+// fetchBeaconRound fails, since lite builds do not talk to remote services.
+func fetchBeaconRound(b *beaconRound, status io.Writer) error {
+	return errors.New("-beacon is not available in this build (built with passgen_lite)")
+}
+
+// checkBeaconRound fails, since lite builds do not talk to remote services.
+func checkBeaconRound(b *beaconRound) error {
+	return errors.New("beacons cannot be read in this build (built with passgen_lite)")
+}
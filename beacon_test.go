@@ -0,0 +1,91 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDrandFetch tests planning, waiting for and reading a drand round
+func TestDrandFetch(t *testing.T) {
+	sig := []byte("signature of round 4")
+	sum := sha256.Sum256(sig)
+	tooEarly := 1
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chain/info":
+			fmt.Fprint(w, `{"period": 3, "genesis_time": 1000, "hash": "c0ffee"}`)
+		case "/chain/public/4":
+			if tooEarly > 0 {
+				tooEarly--
+				w.WriteHeader(http.StatusTooEarly)
+				return
+			}
+			fmt.Fprintf(w, `{"round": 4, "randomness": %q, "signature": %q}`, hex.EncodeToString(sum[:]), hex.EncodeToString(sig))
+		case "/chain/public/5":
+			fmt.Fprintf(w, `{"round": 5, "randomness": %q, "signature": %q}`, strings.Repeat("00", 32), hex.EncodeToString(sig))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	var slept []time.Duration
+	c := &drandClient{
+		client: srv.Client(),
+		now:    func() time.Time { return time.Unix(1007, 0) },
+		sleep:  func(d time.Duration) { slept = append(slept, d) },
+	}
+	var status strings.Builder
+
+	b, err := c.plan(srv.URL+"/chain", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Round != 4 || b.ChainHash != "c0ffee" || b.Signature != "" {
+		t.Errorf("plan() = %+v", b)
+	}
+	if err := c.fetch(b, &status); err != nil {
+		t.Fatal(err)
+	}
+	if b.Round != 4 || b.ChainHash != "c0ffee" || b.Time != "1970-01-01T00:16:49Z" || b.Signature != hex.EncodeToString(sig) {
+		t.Errorf("fetch() = %+v", b)
+	}
+	if len(slept) != 2 || slept[0] != 2*time.Second || !strings.Contains(status.String(), "round 4") {
+		t.Errorf("Slept %v, status %q", slept, status.String())
+	}
+	if err := c.check(b); err != nil {
+		t.Errorf("check() of the fetched round: %v", err)
+	}
+	b.Signature = "00"
+	if err := c.check(b); err == nil {
+		t.Error("check() of a tampered round succeeded")
+	}
+
+	bad, err := c.plan(srv.URL+"/chain", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.fetch(bad, &status); err == nil || !strings.Contains(err.Error(), "not the hash") {
+		t.Errorf("fetch() of a bad round error = %v", err)
+	}
+	other := &beaconRound{URL: srv.URL + "/chain", ChainHash: "decade", Round: 5}
+	if err := c.fetch(other, &status); err == nil || !strings.Contains(err.Error(), "not decade") {
+		t.Errorf("fetch() of a round of another chain error = %v", err)
+	}
+	if _, err := c.plan(srv.URL+"/chain", 2); err == nil || !strings.Contains(err.Error(), "still to come") {
+		t.Errorf("plan() of a published round error = %v", err)
+	}
+	if _, err := c.plan(srv.URL+"/missing", 4); err == nil || !strings.Contains(err.Error(), "not a drand beacon") {
+		t.Errorf("plan() of a missing beacon error = %v", err)
+	}
+	if _, err := c.plan(strings.Replace(srv.URL, "https://", "http://", 1)+"/chain", 4); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("plan() over plain HTTP error = %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// bloomMagic starts every bloom filter file.
+const bloomMagic = "PGBLOOM1"
+
+// bloomHeaderSize is the size of the header that follows the magic: the
+// number of bits, the number of hash functions and the number of entries.
+const bloomHeaderSize = len(bloomMagic) + 8 + 4 + 8
+
+// bloomFilter is a set of SHA-1 hashes that may report hashes it does not
+// hold, at a rate chosen when it is sized, but never misses one it holds.
+// Its file format is the magic, the header in big-endian order and the bit
+// array, so a filter can be queried on disk without loading it.
+type bloomFilter struct {
+	bits    []byte
+	m       uint64
+	k       uint32
+	entries uint64
+}
+
+// newBloomFilter sizes a filter for n entries and a false positive rate p.
+func newBloomFilter(n uint64, p float64) (*bloomFilter, error) {
+	if n == 0 {
+		return nil, errors.New("a bloom filter needs at least one entry")
+	}
+	if p <= 0 || p >= 1 {
+		return nil, errors.New("the false positive rate must be between 0 and 1")
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 7) / 8 * 8
+	k := uint32(max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &bloomFilter{bits: make([]byte, m/8), m: m, k: k}, nil
+}
+
+// bloomIndexes returns the k bit positions of a SHA-1 hash. The hash is
+// already uniform, so its first 16 bytes serve as the two halves of
+// double hashing.
+func bloomIndexes(sum [sha1.Size]byte, m uint64, k uint32) []uint64 {
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	indexes := make([]uint64, k)
+	for i := range indexes {
+		indexes[i] = (h1 + uint64(i)*h2) % m
+	}
+	return indexes
+}
+
+// add inserts a SHA-1 hash.
+func (f *bloomFilter) add(sum [sha1.Size]byte) {
+	for _, i := range bloomIndexes(sum, f.m, f.k) {
+		f.bits[i/8] |= 1 << (i % 8)
+	}
+	f.entries++
+}
+
+// contains reports whether the hash may have been added.
+func (f *bloomFilter) contains(sum [sha1.Size]byte) bool {
+	for _, i := range bloomIndexes(sum, f.m, f.k) {
+		if f.bits[i/8]&(1<<(i%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the filter in its file format.
+func (f *bloomFilter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, 0, bloomHeaderSize)
+	header = append(header, bloomMagic...)
+	header = binary.BigEndian.AppendUint64(header, f.m)
+	header = binary.BigEndian.AppendUint32(header, f.k)
+	header = binary.BigEndian.AppendUint64(header, f.entries)
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(f.bits)
+	return int64(n + m), err
+}
+
+// parseSHA1 decodes a hex SHA-1 hash.
+func parseSHA1(s string) ([sha1.Size]byte, error) {
+	var sum [sha1.Size]byte
+	if len(s) != 2*sha1.Size {
+		return sum, fmt.Errorf("invalid SHA-1 %q", s)
+	}
+	if _, err := hex.Decode(sum[:], []byte(s)); err != nil {
+		return sum, fmt.Errorf("invalid SHA-1 %q", s)
+	}
+	return sum, nil
+}
+
+// bloomFile queries a filter file in place, reading only the bytes that
+// hold the bits of each lookup, so even a filter of the whole Pwned
+// Passwords corpus costs no memory.
+type bloomFile struct {
+	f       *os.File
+	m       uint64
+	k       uint32
+	entries uint64
+}
+
+// openBloomFile opens a filter written by bloomFilter.WriteTo.
+func openBloomFile(path string) (*bloomFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, bloomHeaderSize)
+	if _, err := io.ReadFull(bufio.NewReader(f), header); err != nil || string(header[:len(bloomMagic)]) != bloomMagic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a passgen bloom filter", path)
+	}
+	b := &bloomFile{
+		f:       f,
+		m:       binary.BigEndian.Uint64(header[8:16]),
+		k:       binary.BigEndian.Uint32(header[16:20]),
+		entries: binary.BigEndian.Uint64(header[20:28]),
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if b.m == 0 || b.k == 0 || b.m%8 != 0 || uint64(info.Size()) != uint64(bloomHeaderSize)+b.m/8 {
+		f.Close()
+		return nil, fmt.Errorf("%s is truncated or corrupt", path)
+	}
+	return b, nil
+}
+
+// contains reports whether the hash may be in the filter.
+func (b *bloomFile) contains(sum [sha1.Size]byte) (bool, error) {
+	var buf [1]byte
+	for _, i := range bloomIndexes(sum, b.m, b.k) {
+		if _, err := b.f.ReadAt(buf[:], int64(bloomHeaderSize)+int64(i/8)); err != nil {
+			return false, fmt.Errorf("reading bloom filter: %w", err)
+		}
+		if buf[0]&(1<<(i%8)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// count is a breachCheck: 1 if the password may be in the filter, else 0.
+func (b *bloomFile) count(password string) (int, error) {
+	found, err := b.contains(sha1.Sum([]byte(password)))
+	if err != nil || !found {
+		return 0, err
+	}
+	return 1, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBloomFilter tests membership and the false positive rate
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	f, err := newBloomFilter(n, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		f.add(sha1.Sum([]byte(fmt.Sprint("in", i))))
+	}
+	for i := range n {
+		if !f.contains(sha1.Sum([]byte(fmt.Sprint("in", i)))) {
+			t.Fatalf("Filter misses entry %d", i)
+		}
+	}
+	falsePositives := 0
+	for i := range n {
+		if f.contains(sha1.Sum([]byte(fmt.Sprint("out", i)))) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 0.02 {
+		t.Errorf("False positive rate %.3f, want about 0.01", rate)
+	}
+
+	for _, tt := range []struct {
+		n    uint64
+		rate float64
+	}{{0, 0.01}, {10, 0}, {10, 1}} {
+		if _, err := newBloomFilter(tt.n, tt.rate); err == nil {
+			t.Errorf("newBloomFilter(%d, %g) succeeded", tt.n, tt.rate)
+		}
+	}
+}
+
+// TestBloomFile tests querying a filter file on disk
+func TestBloomFile(t *testing.T) {
+	f, err := newBloomFilter(100, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.add(sha1.Sum([]byte("password")))
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pwned.bloom")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := openBloomFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.f.Close()
+	if b.entries != 1 || b.m != f.m || b.k != f.k {
+		t.Errorf("Header %d entries, %d bits, %d hashes; want 1, %d, %d", b.entries, b.m, b.k, f.m, f.k)
+	}
+	if n, err := b.count("password"); err != nil || n != 1 {
+		t.Errorf("count(password) = %d, %v; want 1", n, err)
+	}
+	if n, err := b.count("xK9#mQ2$vL7p"); err != nil || n != 0 {
+		t.Errorf("count of a random password = %d, %v; want 0", n, err)
+	}
+
+	for name, data := range map[string][]byte{
+		"truncated":    buf.Bytes()[:buf.Len()-1],
+		"not a filter": []byte("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3\n"),
+	} {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-"))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openBloomFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
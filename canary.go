@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// Canary credentials end with a short tag derived from the rest of the
+// password with a local secret key. Anyone holding the key can recognize a
+// canary in leaked data, while to everyone else the tag looks random.
+const (
+	canaryTagLength = 4
+	canaryTagChars  = passgen.Uppercase + passgen.Lowercase + passgen.Numbers
+)
+
+// canaryRecord is one issued canary in the local registry. Only the hash of
+// the credential is stored.
+type canaryRecord struct {
+	SHA256  string    `json:"sha256"`
+	Created time.Time `json:"created"`
+}
+
+// canaryStore is the local registry of issued canaries: a secret key file and
+// a JSON Lines file of records next to it.
+type canaryStore struct {
+	dir string
+	key []byte
+}
+
+// defaultCanaryDir returns the directory used when -canary-dir is not set.
+func defaultCanaryDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".passgen-canaries"
+	}
+	return filepath.Join(dir, "passgen", "canaries")
+}
+
+// openCanaryStore opens the registry in dir, creating it and its secret key
+// on first use.
+func openCanaryStore(dir string) (*canaryStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	keyPath := filepath.Join(dir, "key")
+	key, err := os.ReadFile(keyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(key)), 0600); err != nil {
+			return nil, err
+		}
+		return &canaryStore{dir: dir, key: key}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = hex.DecodeString(strings.TrimSpace(string(key)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid canary key in %s: %v", keyPath, err)
+	}
+	return &canaryStore{dir: dir, key: key}, nil
+}
+
+// tag computes the marker for a password body.
+func (s *canaryStore) tag(body string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(body))
+	sum := mac.Sum(nil)
+
+	tag := make([]byte, canaryTagLength)
+	for i := range tag {
+		tag[i] = canaryTagChars[int(sum[i])%len(canaryTagChars)]
+	}
+	return string(tag)
+}
+
+// mark appends the marker to a password body.
+func (s *canaryStore) mark(body string) string {
+	return body + s.tag(body)
+}
+
+// isMarked reports whether the candidate carries a valid marker.
+func (s *canaryStore) isMarked(candidate string) bool {
+	if len(candidate) <= canaryTagLength {
+		return false
+	}
+	body, tag := candidate[:len(candidate)-canaryTagLength], candidate[len(candidate)-canaryTagLength:]
+	return hmac.Equal([]byte(tag), []byte(s.tag(body)))
+}
+
+func (s *canaryStore) recordsPath() string {
+	return filepath.Join(s.dir, "canaries.jsonl")
+}
+
+// record appends the hashes of issued canaries to the registry.
+func (s *canaryStore) record(passwords []string) error {
+	f, err := os.OpenFile(s.recordsPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	now := time.Now().UTC()
+	for _, password := range passwords {
+		if err := enc.Encode(canaryRecord{SHA256: hashCanary(password), Created: now}); err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// issued loads the registry, keyed by credential hash.
+func (s *canaryStore) issued() (map[string]canaryRecord, error) {
+	records := make(map[string]canaryRecord)
+	f, err := os.Open(s.recordsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		var r canaryRecord
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", s.recordsPath(), err)
+		}
+		records[r.SHA256] = r
+	}
+	return records, nil
+}
+
+func hashCanary(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// canaryMatch is a line of scanned input that looks like one of our canaries.
+type canaryMatch struct {
+	line     int
+	value    string
+	recorded *canaryRecord
+}
+
+// scan checks every whitespace-separated token of the input for
+// the canary marker and looks matches up in the registry.
+func (s *canaryStore) scan(r io.Reader) ([]canaryMatch, error) {
+	issued, err := s.issued()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []canaryMatch
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		for _, token := range strings.Fields(scanner.Text()) {
+			if !s.isMarked(token) {
+				continue
+			}
+			m := canaryMatch{line: line, value: token}
+			if rec, ok := issued[hashCanary(token)]; ok {
+				m.recorded = &rec
+			}
+			matches = append(matches, m)
+		}
+	}
+	return matches, scanner.Err()
+}
+
+// runCanaryCheck scans a file (or stdin for "-") and reports any canaries.
+func runCanaryCheck(store *canaryStore, path string) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	matches, err := store.scan(in)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Println("No canary credentials found")
+		return nil
+	}
+	for _, m := range matches {
+		if m.recorded != nil {
+			fmt.Printf("line %d: canary issued %s\n", m.line, m.recorded.Created.Format(time.RFC3339))
+		} else {
+			fmt.Printf("line %d: carries the canary marker but is not in the registry\n", m.line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCanaryStoreKeyPersistence tests that the key is created once and reused
+func TestCanaryStoreKeyPersistence(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "canaries")
+
+	first, err := openCanaryStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	second, err := openCanaryStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if first.tag("body") != second.tag("body") {
+		t.Error("Reopened store should produce the same tags")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "key"))
+	if err != nil {
+		t.Fatalf("Key file missing: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+// TestCanaryMarking tests that marked passwords are recognized and others are not
+func TestCanaryMarking(t *testing.T) {
+	store, err := openCanaryStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	marked := store.mark("Ab3defgh")
+	if len(marked) != 8+canaryTagLength {
+		t.Errorf("Expected marker of %d characters, got %q", canaryTagLength, marked)
+	}
+	if !store.isMarked(marked) {
+		t.Errorf("Marked password %q not recognized", marked)
+	}
+	if store.isMarked("Ab3defghWXYZ") && store.tag("Ab3defgh") != "WXYZ" {
+		t.Error("Unmarked password recognized as canary")
+	}
+	if store.isMarked("abc") {
+		t.Error("Short string recognized as canary")
+	}
+
+	other, _ := openCanaryStore(t.TempDir())
+	if other.isMarked(marked) {
+		t.Error("Canary recognized with a different key")
+	}
+}
+
+// TestCanaryScan tests finding recorded canaries in leaked data
+func TestCanaryScan(t *testing.T) {
+	store, err := openCanaryStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	recorded := store.mark("Recorded2")
+	unrecorded := store.mark("Unrecorded3")
+	if err := store.record([]string{recorded}); err != nil {
+		t.Fatalf("Failed to record canary: %v", err)
+	}
+
+	dump := "alice Hunter22\nbob " + recorded + "\ncarol " + unrecorded + "\n"
+	matches, err := store.scan(strings.NewReader(dump))
+	if err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(matches))
+	}
+	if matches[0].line != 2 || matches[0].recorded == nil {
+		t.Errorf("Expected recorded canary on line 2, got %+v", matches[0])
+	}
+	if matches[1].line != 3 || matches[1].recorded != nil {
+		t.Errorf("Expected unrecorded canary on line 3, got %+v", matches[1])
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printCapabilitiesUsage(programName string) {
+	fmt.Printf("Usage: %s capabilities [-o text|json]\n", programName)
+	fmt.Println("Describe the modes, charsets, sinks and limits supported by this build,")
+	fmt.Println("so orchestration tools can adapt to different versions.")
+	fmt.Println("Options:")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+}
+
+// capabilities describes what the running binary supports.
+type capabilities struct {
+	Schema   string            `json:"schema"`
+	Version  string            `json:"version"`
+	Commands []string          `json:"commands"`
+	Modes    []string          `json:"modes"`
+	Charsets map[string]string `json:"charsets"`
+	Sinks    []string          `json:"sinks"`
+	Features []string          `json:"features"`
+	Limits   capabilityLimits  `json:"limits"`
+}
+
+type capabilityLimits struct {
+	MinLength int `json:"minLength"`
+	MaxLength int `json:"maxLength"`
+	// MaxCount bounds a run without -stream, which has no limit and
+	// delivers StreamBatchSize passwords at a time.
+	MaxCount        int `json:"maxCount"`
+	StreamBatchSize int `json:"streamBatchSize"`
+}
+
+// currentCapabilities collects the capabilities of this build.
+func currentCapabilities() capabilities {
+	c := capabilities{
+		Schema:   outputSchema,
+		Version:  version,
+		Modes:    []string{"random", "markov", "canary", "passphrase", "pin", "derive", "rotating", "pronounceable", "pattern", "apple-style", "anchor-word", "dual-control", "token"},
+		Charsets: map[string]string{},
+		Sinks:    []string{"stdout", "csv"},
+		Features: append([]string{}, features...),
+		Limits:   capabilityLimits{MinLength: minLength, MaxLength: maxLength, MaxCount: maxCount, StreamBatchSize: streamBatchSize},
+	}
+	for _, cmd := range commands {
+		c.Commands = append(c.Commands, cmd.name)
+	}
+
+	names := []string{"uppercase", "lowercase", "numbers", "special"}
+	for i, charset := range (passgen.Options{IncludeSpecial: true}).Charsets() {
+		c.Charsets[names[i]] = charset
+	}
+	for i, charset := range (passgen.Options{AllowSimilar: true}).Charsets() {
+		c.Charsets[names[i]+"-similar"] = charset
+	}
+
+	// Sinks that run external programs are left out of minimal builds
+	for _, feature := range features {
+		switch feature {
+		case "plugins":
+			c.Sinks = append(c.Sinks, "plugin")
+		case "clipboard", "vault":
+			c.Sinks = append(c.Sinks, feature)
+		}
+	}
+	sort.Strings(c.Features)
+	return c
+}
+
+// runCapabilities implements the capabilities subcommand.
+func runCapabilities(programName string, args []string) error {
+	fs := flag.NewFlagSet("capabilities", flag.ContinueOnError)
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printCapabilitiesUsage(programName) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printCapabilitiesUsage(programName)
+		return nil
+	}
+
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	if *format == "json" {
+		return writeJSON(os.Stdout, currentCapabilities())
+	}
+	printCapabilities(os.Stdout, currentCapabilities())
+	return nil
+}
+
+// printCapabilities writes a human-readable summary of the capabilities.
+func printCapabilities(w io.Writer, c capabilities) {
+	none := func(list []string) string {
+		if len(list) == 0 {
+			return "none"
+		}
+		return strings.Join(list, ", ")
+	}
+
+	fmt.Fprintf(w, "Version: %s\n", c.Version)
+	fmt.Fprintf(w, "Commands: %s\n", none(c.Commands))
+	fmt.Fprintf(w, "Modes: %s\n", none(c.Modes))
+	fmt.Fprintf(w, "Sinks: %s\n", none(c.Sinks))
+	fmt.Fprintf(w, "Features: %s\n", none(c.Features))
+	fmt.Fprintf(w, "Limits: length %d-%d, count up to %d, or any with -stream in batches of %d\n", c.Limits.MinLength, c.Limits.MaxLength, c.Limits.MaxCount, c.Limits.StreamBatchSize)
+	fmt.Fprintln(w, "Charsets:")
+
+	names := make([]string, 0, len(c.Charsets))
+	for name := range c.Charsets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-18s %s\n", name, c.Charsets[name])
+	}
+}
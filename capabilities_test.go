@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestCurrentCapabilities tests that the capabilities reflect this build
+func TestCurrentCapabilities(t *testing.T) {
+	c := currentCapabilities()
+
+	for _, name := range []string{"scrub", "rpc", "capabilities"} {
+		if !slices.Contains(c.Commands, name) {
+			t.Errorf("Commands %v missing %q", c.Commands, name)
+		}
+	}
+	if c.Limits != (capabilityLimits{MinLength: minLength, MaxLength: maxLength, MaxCount: maxCount, StreamBatchSize: streamBatchSize}) {
+		t.Errorf("Limits = %+v", c.Limits)
+	}
+	if strings.ContainsAny(c.Charsets["uppercase"], "IO") {
+		t.Errorf("uppercase charset %q contains similar characters", c.Charsets["uppercase"])
+	}
+	if !strings.ContainsAny(c.Charsets["uppercase-similar"], "IO") {
+		t.Errorf("uppercase-similar charset %q lacks similar characters", c.Charsets["uppercase-similar"])
+	}
+	if got, want := slices.Contains(c.Sinks, "plugin"), slices.Contains(features, "plugins"); got != want {
+		t.Errorf("plugin sink listed = %v, plugins feature = %v", got, want)
+	}
+	for _, sink := range []string{"clipboard", "vault"} {
+		if got, want := slices.Contains(c.Sinks, sink), slices.Contains(features, sink); got != want {
+			t.Errorf("%s sink listed = %v, %s feature = %v", sink, got, sink, want)
+		}
+	}
+}
+
+// TestCapabilitiesJSON tests the field names of the JSON output
+func TestCapabilitiesJSON(t *testing.T) {
+	data, err := json.Marshal(currentCapabilities())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	for _, key := range []string{"schema", "version", "commands", "modes", "charsets", "sinks", "features", "limits"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON output missing %q: %s", key, data)
+		}
+	}
+	if _, ok := decoded["features"].([]any); !ok {
+		t.Errorf("features should be an array even when empty, got %v", decoded["features"])
+	}
+}
+
+// TestPrintCapabilities tests the text output
+func TestPrintCapabilities(t *testing.T) {
+	var buf bytes.Buffer
+	printCapabilities(&buf, currentCapabilities())
+	out := buf.String()
+
+	for _, want := range []string{"Version: ", "Commands: generate", "Limits: length 3-128, count up to 100", "uppercase-similar"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q:\n%s", want, out)
+		}
+	}
+}
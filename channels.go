@@ -0,0 +1,112 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func init() {
+	features = append(features, "clipboard", "vault")
+}
+
+// clipboardTools are the commands tried, in order, to set the clipboard.
+// Each reads the new contents from stdin.
+var clipboardTools = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// commandSink delivers credentials by running an external command with the
+// payload on stdin, so secrets never appear in its arguments.
+type commandSink struct {
+	name    string
+	argv    []string
+	payload func(creds []credential) ([]byte, error)
+}
+
+func (s *commandSink) write(creds []credential) error {
+	data, err := s.payload(creds)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(s.argv[0], s.argv[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v: %s", s.name, err, msg)
+		}
+		return fmt.Errorf("%s: %v", s.name, err)
+	}
+	return nil
+}
+
+func (s *commandSink) String() string {
+	return s.name
+}
+
+// newClipboardSink returns a sink that copies the passwords, one per line,
+// to the system clipboard using the first clipboard tool found on PATH.
+func newClipboardSink() (sink, error) {
+	for _, argv := range clipboardTools {
+		if _, err := exec.LookPath(argv[0]); err == nil {
+			return &commandSink{name: "clipboard", argv: argv, payload: clipboardPayload}, nil
+		}
+	}
+	return nil, fmt.Errorf("-copy needs one of pbcopy, wl-copy, xclip, xsel or clip.exe on PATH")
+}
+
+func clipboardPayload(creds []credential) ([]byte, error) {
+	passwords := make([]string, len(creds))
+	for i, c := range creds {
+		passwords[i] = c.Password
+	}
+	return []byte(strings.Join(passwords, "\n")), nil
+}
+
+// newVaultSink returns a sink that stores the batch as one secret at path
+// in a HashiCorp Vault KV engine with the vault command, which takes its
+// address and token from the usual VAULT_ADDR and VAULT_TOKEN.
+func newVaultSink(path string) (sink, error) {
+	if _, err := exec.LookPath("vault"); err != nil {
+		return nil, fmt.Errorf("-vault-path needs the vault command on PATH")
+	}
+	return &commandSink{name: "vault " + path, argv: []string{"vault", "kv", "put", path, "-"}, payload: vaultPayload}, nil
+}
+
+// vaultPayload maps each credential to a key: its label, or "password"
+// without one, numbered from 2 when the key is taken. A note is stored
+// next to its password under the key with "_note" appended. Keys are never
+// reused, so a label such as web-2 cannot overwrite a numbered web.
+func vaultPayload(creds []credential) ([]byte, error) {
+	data := make(map[string]string, len(creds))
+	free := func(key string) bool {
+		_, password := data[key]
+		_, note := data[key+"_note"]
+		return !password && !note
+	}
+	for _, c := range creds {
+		base := c.Label
+		if base == "" {
+			base = "password"
+		}
+		key := base
+		for n := 2; !free(key); n++ {
+			key = fmt.Sprintf("%s-%d", base, n)
+		}
+		data[key] = c.Password
+		if c.Note != "" {
+			data[key+"_note"] = c.Note
+		}
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,17 @@
+//go:build passgen_lite
+
+package main
+
+import "errors"
+
+var errChannelsUnavailable = errors.New("-copy and -vault-path are not available in this build (built with passgen_lite)")
+
+// newClipboardSink fails, since lite builds do not execute external programs.
+func newClipboardSink() (sink, error) {
+	return nil, errChannelsUnavailable
+}
+
+// newVaultSink fails, since lite builds do not execute external programs.
+func newVaultSink(path string) (sink, error) {
+	return nil, errChannelsUnavailable
+}
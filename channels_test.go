@@ -0,0 +1,72 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCommandSink tests that payloads reach the command on stdin
+func TestCommandSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clipboard")
+	s := &commandSink{name: "clipboard", argv: []string{"sh", "-c", "cat > " + path}, payload: clipboardPayload}
+	if err := s.write([]credential{{Password: "a1"}, {Password: "b2"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a1\nb2" {
+		t.Errorf("Clipboard got %q", data)
+	}
+
+	failing := &commandSink{name: "vault secret/db", argv: []string{"sh", "-c", "echo permission denied >&2; exit 2"}, payload: clipboardPayload}
+	err = failing.write([]credential{{Password: "x"}})
+	if err == nil || !strings.Contains(err.Error(), "vault secret/db") || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected the sink name and stderr in the error, got %v", err)
+	}
+}
+
+// TestVaultPayload tests how credentials map to Vault keys
+func TestVaultPayload(t *testing.T) {
+	data, err := vaultPayload([]credential{{Label: "db", Password: "a"}, {Label: "db", Password: "b"}, {Password: "c"}, {Password: "d<"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"db": "a", "db-2": "b", "password": "c", "password-2": "d<"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("vaultPayload = %v, want %v", got, want)
+	}
+
+	// Literal labels that look numbered and notes keep every password
+	data, err = vaultPayload([]credential{
+		{Label: "web", Password: "a", Note: "temporary password"},
+		{Label: "web", Password: "b"},
+		{Label: "web-2", Password: "c"},
+		{Label: "web_note", Password: "d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{
+		"web": "a", "web_note": "temporary password", "web-2": "b",
+		"web-2-2": "c", "web_note-2": "d",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("vaultPayload = %v, want %v", got, want)
+	}
+}
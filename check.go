@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printCheckUsage(programName string) {
+	fmt.Printf("Usage: %s check [OPTIONS] [< password.txt]\n", programName)
+	fmt.Println("Check an existing password, typed at a hidden prompt or read from the first")
+	fmt.Println("line of stdin. Reports its length, character classes, estimated entropy and")
+	fmt.Println("guessable patterns, and checks it against the embedded list of common")
+	fmt.Println("passwords and any rules, entropy target or system policy. Exits non-zero if")
+	fmt.Println("any check fails.")
+	fmt.Println("Options:")
+	fmt.Println("  -rule EXPR   Require the password to match the expression (repeatable)")
+	fmt.Println("  -min-entropy BITS")
+	fmt.Println("               Require at least BITS of estimated entropy")
+	fmt.Println("  -min-score N Require a strength score of at least N (0-4)")
+	fmt.Println("  -policy FILE Require what a system policy such as pwquality.conf does")
+	fmt.Println("  -policy-format NAME")
+	fmt.Println("               Format of the policy (default: from the file name)")
+	fmt.Println("  -policy-file FILE")
+	fmt.Println("               Require what a passgen policy file, as used by generate, does")
+	fmt.Println("  -blocklist FILE")
+	fmt.Println("               Reject the passwords listed in FILE, one per line (repeatable)")
+	fmt.Println("  -no-blocklist")
+	fmt.Println("               Allow passwords on the embedded list of common passwords")
+	fmt.Println("  -hibp        Reject passwords found in Have I Been Pwned's Pwned Passwords;")
+	fmt.Println("               only the first 5 hex digits of the SHA-1 are sent")
+	fmt.Println("  -hibp-offline FILE")
+	fmt.Println("               Reject passwords in a bloom filter built by hibp download")
+	fmt.Println("  -offline     Skip checks that need the network, such as -hibp")
+	fmt.Println("  -fingerprint FORMAT")
+	fmt.Println("               Print the password's fingerprint (hex or emoji) to compare it with")
+	fmt.Println("               one shown by generate -fingerprint")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  echo 'hunter2' | %s check -min-entropy 60 -rule 'length >= 12'\n", programName)
+}
+
+// runCheck implements the check subcommand.
+func runCheck(programName string, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	checks := addCheckFlags(fs)
+	fingerprint := fs.String("fingerprint", "", "Print the password's fingerprint: hex or emoji")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printCheckUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printCheckUsage(programName)
+		return nil
+	}
+
+	check, err := checks.compile()
+	if err != nil {
+		return err
+	}
+	fingerprintOf, err := fingerprinter(*fingerprint)
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if isTerminal(os.Stdin) {
+		password, err = readHiddenPassword("Password: ")
+	} else {
+		password, err = readPassword(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	if fingerprintOf != nil {
+		fmt.Printf("Fingerprint: %s\n", fingerprintOf(password))
+	}
+	failed, total, err := check.report(os.Stdout, password)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("password failed %d of %d checks", failed, total)
+	}
+	return nil
+}
+
+// readPassword reads the first line of r without its line ending.
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("no password on stdin")
+	}
+	return line, nil
+}
+
+// checkFlags are the options shared by the commands that check existing
+// passwords, check and pam.
+type checkFlags struct {
+	rules        stringList
+	minEntropy   float64
+	minScore     int
+	blocklists   stringList
+	noBlocklist  bool
+	policy       string
+	policyFormat string
+	policyFile   string
+	hibp         bool
+	hibpOffline  string
+	offline      bool
+}
+
+// addCheckFlags defines the shared check options on fs.
+func addCheckFlags(fs *flag.FlagSet) *checkFlags {
+	f := &checkFlags{}
+	fs.Var(&f.rules, "rule", "Require the password to match the expression (repeatable)")
+	fs.Float64Var(&f.minEntropy, "min-entropy", 0, "Require at least this many bits of estimated entropy")
+	fs.IntVar(&f.minScore, "min-score", 0, "Require a strength score of at least N (0-4)")
+	fs.Var(&f.blocklists, "blocklist", "Reject the passwords listed in FILE (repeatable)")
+	fs.BoolVar(&f.noBlocklist, "no-blocklist", false, "Allow passwords on the embedded list of common passwords")
+	fs.StringVar(&f.policy, "policy", "", "Require what the system password policy in FILE does")
+	fs.StringVar(&f.policyFormat, "policy-format", "", "Format of the -policy file")
+	fs.StringVar(&f.policyFile, "policy-file", "", "Require what the passgen policy defined in FILE does")
+	fs.BoolVar(&f.hibp, "hibp", false, "Reject passwords found in Have I Been Pwned's Pwned Passwords")
+	fs.StringVar(&f.hibpOffline, "hibp-offline", "", "Reject passwords in the Pwned Passwords bloom filter FILE")
+	fs.BoolVar(&f.offline, "offline", false, "Skip checks that need the network, such as -hibp")
+	return f
+}
+
+// compile compiles the rules and loads the blocklists and the policy.
+func (f *checkFlags) compile() (*passwordCheck, error) {
+	if f.minScore < 0 || f.minScore > passgen.MaxScore {
+		return nil, fmt.Errorf("minimum strength score must be between 0 and %d", passgen.MaxScore)
+	}
+	c := &passwordCheck{MinEntropy: f.minEntropy, MinScore: f.minScore}
+	for _, expr := range f.rules {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			return nil, err
+		}
+		c.Rules = append(c.Rules, rule)
+	}
+	if len(f.blocklists) > 0 {
+		c.Blocklist = passgen.NewBlocklist()
+		for _, path := range f.blocklists {
+			if err := readBlocklist(c.Blocklist, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	switch {
+	case f.policy != "" && f.policyFile != "":
+		return nil, fmt.Errorf("-policy cannot be combined with -policy-file")
+	case f.policy != "":
+		var err error
+		if c.Policy, err = readPolicy(f.policy, f.policyFormat); err != nil {
+			return nil, err
+		}
+	case f.policyFile != "":
+		s, err := loadPolicyFile(f.policyFile)
+		if err != nil {
+			return nil, err
+		}
+		c.Policy = &s.policy
+	}
+	// As in generate, common passwords fail unless asked otherwise; a
+	// policy that rejects them already reports it
+	c.RejectCommon = !f.noBlocklist && (c.Policy == nil || !c.Policy.RejectCommon)
+	var err error
+	if c.Breached, err = breachOption(f.hibp, f.hibpOffline, f.offline); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// breachCheck returns how often a password appears in known data breaches.
+// Sources that only know whether it appears, such as a bloom filter,
+// return 1.
+type breachCheck func(password string) (int, error)
+
+// breachOption returns the breach check -hibp or -hibp-offline asks for.
+// The filter of -hibp-offline is local and takes precedence; without it,
+// -offline skips -hibp with a warning.
+func breachOption(hibp bool, filterPath string, offline bool) (breachCheck, error) {
+	if filterPath != "" {
+		filter, err := openBloomFile(filterPath)
+		if err != nil {
+			return nil, err
+		}
+		return filter.count, nil
+	}
+	if !hibp {
+		return nil, nil
+	}
+	if offline {
+		fmt.Fprintln(os.Stderr, "Warning: -offline skips the -hibp breach check")
+		return nil, nil
+	}
+	return newHIBPCheck()
+}
+
+// rejectBreached is a post-generate hook that rejects candidates found in
+// known breaches, so another one is generated.
+func rejectBreached(check breachCheck) passgen.PostGenerateFunc {
+	return func(password string) error {
+		n, err := check(password)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return passgen.Reject("breached", "seen %d times in breaches", n)
+		}
+		return nil
+	}
+}
+
+// commonBlocklist is the embedded blocklist of common passwords, shared by
+// every generator so it is built once.
+var commonBlocklist = sync.OnceValue(passgen.CommonPasswords)
+
+// embeddedDictionary holds the words of the embedded wordlists for
+// -no-words, built once like commonBlocklist.
+var embeddedDictionary = sync.OnceValue(func() *passgen.Dictionary {
+	return passgen.EmbeddedDictionary(passgen.DefaultMinWordLength)
+})
+
+func readBlocklist(b *passgen.Blocklist, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := b.Read(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// passwordCheck holds the requirements an existing password is checked
+// against. Zero fields check nothing.
+type passwordCheck struct {
+	Rules      []*passgen.Rule
+	MinEntropy float64
+	// MinScore is the lowest acceptable strength score, see
+	// passgen.EstimateStrength.
+	MinScore  int
+	Blocklist *passgen.Blocklist
+	// RejectCommon fails passwords on the embedded list of common
+	// passwords.
+	RejectCommon bool
+	// Policy is a system password policy the password must satisfy.
+	Policy *systemPolicy
+	// Breached looks the password up in known data breaches.
+	Breached breachCheck
+	// User is a name the password must not contain, ignoring case.
+	User string
+}
+
+// checkResult is the outcome of one requirement.
+type checkResult struct {
+	OK   bool
+	Text string
+}
+
+// results evaluates every requirement against the password.
+func (c *passwordCheck) results(password string) ([]checkResult, error) {
+	var results []checkResult
+	if c.MinEntropy > 0 {
+		bits := passgen.EstimateEntropy(password)
+		results = append(results, checkResult{bits >= c.MinEntropy, fmt.Sprintf("entropy >= %.1f bits", c.MinEntropy)})
+	}
+	if c.MinScore > 0 {
+		score := passgen.EstimateStrength(password).Score
+		results = append(results, checkResult{score >= c.MinScore, fmt.Sprintf("strength score >= %d", c.MinScore)})
+	}
+	if c.Policy != nil {
+		results = append(results, c.Policy.results(password)...)
+	}
+	for _, rule := range c.Rules {
+		ok, err := rule.Eval(password)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, checkResult{ok, rule.String()})
+	}
+	if c.Blocklist != nil {
+		results = append(results, checkResult{!c.Blocklist.Contains(password), "not on the blocklist"})
+	}
+	if c.RejectCommon {
+		results = append(results, checkResult{!commonBlocklist().Contains(password), "not a common password"})
+	}
+	if c.Breached != nil {
+		n, err := c.Breached(password)
+		if err != nil {
+			return nil, err
+		}
+		text := "not in known breaches"
+		if n > 1 {
+			text += fmt.Sprintf(" (seen %d times)", n)
+		}
+		results = append(results, checkResult{n == 0, text})
+	}
+	if c.User != "" {
+		contains := strings.Contains(strings.ToLower(password), strings.ToLower(c.User))
+		results = append(results, checkResult{!contains, "does not contain the user name"})
+	}
+	return results, nil
+}
+
+// report writes a report for the password and returns how many of the
+// checks it fails out of how many.
+func (c *passwordCheck) report(w io.Writer, password string) (failed, total int, err error) {
+	fmt.Fprintf(w, "Length: %d characters\n", len([]rune(password)))
+	fmt.Fprintf(w, "Classes: %s\n", describeClasses(password))
+	fmt.Fprintf(w, "Entropy: %.1f bits\n", passgen.EstimateEntropy(password))
+	strength := passgen.EstimateStrength(password)
+	fmt.Fprintf(w, "Strength: %d/%d, about 10^%.1f guesses\n", strength.Score, passgen.MaxScore, strength.GuessesLog10)
+	for _, pattern := range strength.Patterns {
+		fmt.Fprintf(w, "Pattern: %s\n", pattern)
+	}
+
+	results, err := c.results(password)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, r := range results {
+		status := "PASS"
+		if !r.OK {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s  %s\n", status, r.Text)
+	}
+	return failed, len(results), nil
+}
+
+// classCounts counts the characters of each class in password.
+func classCounts(password string) [passgen.ClassSpecial + 1]int {
+	var counts [passgen.ClassSpecial + 1]int
+	for _, r := range password {
+		counts[passgen.ClassOf(r)]++
+	}
+	return counts
+}
+
+// describeClasses lists the character classes in password with their
+// counts, e.g. "2 uppercase, 6 lowercase, 1 digit (3 of 4)".
+func describeClasses(password string) string {
+	var parts []string
+	for class, n := range classCounts(password) {
+		if n == 0 {
+			continue
+		}
+		name := passgen.CharClass(class).String()
+		if class == int(passgen.ClassDigit) && n > 1 {
+			name = "digits"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, name))
+	}
+	return fmt.Sprintf("%s (%d of %d)", strings.Join(parts, ", "), len(parts), passgen.ClassSpecial+1)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestCheckPassword tests the report and failure count
+func TestCheckPassword(t *testing.T) {
+	var rules []*passgen.Rule
+	for _, expr := range []string{"length >= 12", "digits >= 1"} {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+
+	tests := []struct {
+		password   string
+		minEntropy float64
+		wantFailed int
+		wantTotal  int
+	}{
+		{"correcthorse9", 0, 0, 2},
+		{"short9", 0, 1, 2},
+		{"nodigitshere", 0, 1, 2},
+		{"short", 1000, 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			var out bytes.Buffer
+			check := &passwordCheck{Rules: rules, MinEntropy: tt.minEntropy}
+			failed, total, err := check.report(&out, tt.password)
+			if err != nil {
+				t.Fatalf("report failed: %v", err)
+			}
+			if failed != tt.wantFailed || total != tt.wantTotal {
+				t.Errorf("report = %d of %d failed, want %d of %d\n%s", failed, total, tt.wantFailed, tt.wantTotal, out.String())
+			}
+			if got := strings.Count(out.String(), "FAIL"); got != tt.wantFailed {
+				t.Errorf("Report lists %d failures, want %d:\n%s", got, tt.wantFailed, out.String())
+			}
+		})
+	}
+}
+
+// TestReadPassword tests reading the first line of input
+func TestReadPassword(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"secret\n", "secret", false},
+		{"secret\r\nignored\n", "secret", false},
+		{"no newline", "no newline", false},
+		{" spaced out \n", " spaced out ", false},
+		{"", "", true},
+		{"\n", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := readPassword(strings.NewReader(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("readPassword(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("readPassword(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestPasswordCheckBlocklistAndUser tests the blocklist and user name checks
+func TestPasswordCheckBlocklistAndUser(t *testing.T) {
+	check := &passwordCheck{Blocklist: passgen.NewBlocklist("letmein"), User: "Alice"}
+	tests := []struct {
+		password string
+		failed   []string
+	}{
+		{"Zq9vkL2pXw", nil},
+		{"LetMeIn", []string{"not on the blocklist"}},
+		{"alice2024", []string{"does not contain the user name"}},
+	}
+	for _, tt := range tests {
+		results, err := check.results(tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var failed []string
+		for _, r := range results {
+			if !r.OK {
+				failed = append(failed, r.Text)
+			}
+		}
+		if strings.Join(failed, ";") != strings.Join(tt.failed, ";") {
+			t.Errorf("%q failed %q, want %q", tt.password, failed, tt.failed)
+		}
+	}
+}
+
+// TestDescribeClasses tests the character class summary
+func TestDescribeClasses(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"abc", "3 lowercase (1 of 4)"},
+		{"Ab1", "1 uppercase, 1 lowercase, 1 digit (3 of 4)"},
+		{"AB12$%&", "2 uppercase, 2 digits, 3 special (3 of 4)"},
+	}
+	for _, tt := range tests {
+		if got := describeClasses(tt.password); got != tt.want {
+			t.Errorf("describeClasses(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+// TestPasswordCheckMinScore tests the strength score requirement
+func TestPasswordCheckMinScore(t *testing.T) {
+	check := &passwordCheck{MinScore: 3}
+	for password, want := range map[string]bool{"P@ssw0rd": false, "xK9#mQ2$vL7p": true} {
+		results, err := check.results(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].OK != want {
+			t.Errorf("%q: results %+v, want OK %v", password, results, want)
+		}
+	}
+	if _, err := (&checkFlags{minScore: passgen.MaxScore + 1}).compile(); err == nil {
+		t.Error("Expected an error for a score above the maximum")
+	}
+}
+
+// TestPasswordCheckCommon tests that common passwords fail by default
+func TestPasswordCheckCommon(t *testing.T) {
+	for _, tt := range []struct {
+		flags    checkFlags
+		password string
+		failed   int
+	}{
+		{checkFlags{}, "Password123", 1},
+		{checkFlags{}, "xK9#mQ2$vL7p", 0},
+		{checkFlags{noBlocklist: true}, "Password123", 0},
+	} {
+		check, err := tt.flags.compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		failed, _, err := check.report(io.Discard, tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed != tt.failed {
+			t.Errorf("%q with %+v failed %d checks, want %d", tt.password, tt.flags, failed, tt.failed)
+		}
+	}
+}
+
+// TestPasswordCheckBreached tests the breach check and its errors
+func TestPasswordCheckBreached(t *testing.T) {
+	breaches := map[string]int{"password": 9659365}
+	check := &passwordCheck{Breached: func(password string) (int, error) {
+		return breaches[password], nil
+	}}
+	for password, want := range map[string]bool{"password": false, "xK9#mQ2$vL7p": true} {
+		results, err := check.results(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].OK != want {
+			t.Errorf("%q: results %+v, want OK %v", password, results, want)
+		}
+	}
+
+	check.Breached = func(string) (int, error) { return 0, errors.New("offline") }
+	if _, err := check.results("password"); err == nil {
+		t.Error("Expected a lookup error to fail the check")
+	}
+	if b, err := breachOption(true, "", true); b != nil || err != nil {
+		t.Errorf("breachOption with -offline = %v, %v; want no check", b, err)
+	}
+}
+
+// TestRejectBreached tests that breached candidates are regenerated
+func TestRejectBreached(t *testing.T) {
+	lookups := 0
+	gen, err := passgen.NewGenerator(passgen.WithPostGenerate(rejectBreached(func(string) (int, error) {
+		lookups++
+		if lookups == 1 {
+			return 3, nil
+		}
+		return 0, nil
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.Generate(); err != nil || lookups != 2 {
+		t.Errorf("Generate = %v after %d lookups, want success after 2", err, lookups)
+	}
+
+	gen, err = passgen.NewGenerator(passgen.WithPostGenerate(rejectBreached(func(string) (int, error) {
+		return 0, errors.New("offline")
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.Generate(); err == nil || errors.Is(err, passgen.ErrRejected) {
+		t.Errorf("Generate = %v, want the lookup error", err)
+	}
+}
+
+// TestBreachOptionFilter tests that -hibp-offline uses the local filter
+func TestBreachOptionFilter(t *testing.T) {
+	f, err := newBloomFilter(10, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.add(sha1.Sum([]byte("password")))
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "pwned.bloom")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// -offline does not skip a local filter
+	check, err := breachOption(true, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := check("password"); err != nil || n != 1 {
+		t.Errorf("check(password) = %d, %v; want 1", n, err)
+	}
+	if _, err := breachOption(false, filepath.Join(t.TempDir(), "missing"), false); err == nil {
+		t.Error("Expected an error for a missing filter")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// maxChoices bounds -choices: a longer list is not read before picking,
+// and every candidate shown costs entropy.
+const maxChoices = 20
+
+// chooseCandidate shows n candidates from generate on out, as show
+// formats them, and returns the one picked by number on in. r draws n new
+// candidates; an empty answer or the end of input chooses none.
+func chooseCandidate(generate func() (string, error), show func(string) string, n int, in io.Reader, out io.Writer) (string, error) {
+	scanner := bufio.NewScanner(in)
+	for {
+		candidates := make([]string, n)
+		for i := range candidates {
+			password, err := generate()
+			if err != nil {
+				return "", fmt.Errorf("generating password: %w", err)
+			}
+			candidates[i] = password
+			fmt.Fprintf(out, "%*d) %s\n", len(strconv.Itoa(n)), i+1, show(password))
+		}
+		for {
+			fmt.Fprintf(out, "Choose 1-%d [r for new candidates]: ", n)
+			if !scanner.Scan() {
+				fmt.Fprintln(out)
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", fmt.Errorf("no candidate chosen")
+			}
+			answer := strings.TrimSpace(scanner.Text())
+			if answer == "" {
+				return "", fmt.Errorf("no candidate chosen")
+			}
+			if passgen.ToLowerASCII(answer) == "r" {
+				fmt.Fprintln(out)
+				break
+			}
+			if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= n {
+				return candidates[i-1], nil
+			}
+			fmt.Fprintf(out, "%q is not a candidate\n", answer)
+		}
+	}
+}
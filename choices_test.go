@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestChooseCandidate tests picking, redrawing and declining candidates
+func TestChooseCandidate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"2\n", "p2", false},
+		{" 3 \n", "p3", false},
+		{"4\n0\nx\n1\n", "p1", false},
+		{"r\n1\n", "p4", false},
+		{"R\nr\n3\n", "p9", false},
+		{"\n", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		n := 0
+		generate := func() (string, error) {
+			n++
+			return "p" + strconv.Itoa(n), nil
+		}
+		var out bytes.Buffer
+		got, err := chooseCandidate(generate, strings.ToUpper, 3, strings.NewReader(tt.input), &out)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("chooseCandidate(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+		if !strings.Contains(out.String(), "1) P1\n") {
+			t.Errorf("chooseCandidate(%q) showed %q", tt.input, out.String())
+		}
+	}
+}
+
+// TestGenerateChoicesFlags tests what -choices rules out
+func TestGenerateChoicesFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-choices", "1"}, "between 2 and 20"},
+		{[]string{"-choices", "21"}, "between 2 and 20"},
+		{[]string{"-choices", "3", "-c", "2"}, "cannot be combined with -c"},
+		{[]string{"-choices", "3", "-dice"}, "cannot be combined with -beacon"},
+		{[]string{"-choices", "3", "-fingerprint-only", "-copy"}, "-fingerprint-only"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
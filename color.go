@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// ANSI colors of the character classes in -color output. Letters keep the
+// terminal's own color.
+const (
+	colorDigit   = "\x1b[36m" // cyan
+	colorSpecial = "\x1b[35m" // magenta
+	colorReset   = "\x1b[0m"
+)
+
+// useColor reports whether -color mode colors output written to f. auto
+// colors a terminal unless NO_COLOR is set or TERM is dumb.
+func useColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "never":
+		return false, nil
+	case "always":
+		return true, nil
+	case "auto":
+		return isTerminal(f) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb", nil
+	}
+	return false, fmt.Errorf("unknown -color %q (use auto, always or never)", mode)
+}
+
+// colorize colors the digits and symbols of password differently from its
+// letters, so a 5 stands out from an S and a 0 from an O when it is read
+// out or typed in. Runs of one class share one escape sequence.
+func colorize(password string) string {
+	var b strings.Builder
+	current := ""
+	for _, r := range password {
+		color := ""
+		switch passgen.ClassOf(r) {
+		case passgen.ClassDigit:
+			color = colorDigit
+		case passgen.ClassSpecial:
+			color = colorSpecial
+		}
+		if color != current {
+			if current != "" {
+				b.WriteString(colorReset)
+			}
+			b.WriteString(color)
+			current = color
+		}
+		b.WriteRune(r)
+	}
+	if current != "" {
+		b.WriteString(colorReset)
+	}
+	return b.String()
+}
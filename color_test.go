@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestUseColor tests the -color modes
+func TestUseColor(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		mode    string
+		want    bool
+		wantErr bool
+	}{
+		{"never", false, false},
+		{"always", true, false},
+		// A file is no terminal
+		{"auto", false, false},
+		{"yes", false, true},
+		{"", false, true},
+	}
+	for _, tt := range tests {
+		got, err := useColor(tt.mode, f)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("useColor(%q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("useColor(%q) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+// TestColorize tests that runs of digits and symbols are colored and
+// letters are left as they are
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"abcXYZ", "abcXYZ"},
+		{"S5", "S" + colorDigit + "5" + colorReset},
+		{"a12b", "a" + colorDigit + "12" + colorReset + "b"},
+		{"1!", colorDigit + "1" + colorReset + colorSpecial + "!" + colorReset},
+		{"x#$y", "x" + colorSpecial + "#$" + colorReset + "y"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := colorize(tt.password); got != tt.want {
+			t.Errorf("colorize(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
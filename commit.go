@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func printCommitUsage(programName string) {
+	fmt.Printf("Usage: %s commit -seal FILE [--] [GENERATE OPTIONS]\n", programName)
+	fmt.Println("Seal a random seed and the generate options of a batch, and print their")
+	fmt.Println("SHA-256 commitment. Publish the commitment before a voucher or code drop;")
+	fmt.Println("reveal then generates the batch from the seal, and anyone holding the seal")
+	fmt.Println("can generate it again and check it against the commitment.")
+	fmt.Println("Options:")
+	fmt.Println("  -seal FILE   Where to write the seal, which must not exist yet")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s commit -seal drop.seal -- -c 500 -l 10 -no-lower\n", programName)
+}
+
+func printRevealUsage(programName string) {
+	fmt.Printf("Usage: %s reveal [-commitment HEX] SEAL [DELIVERY OPTIONS]\n", programName)
+	fmt.Println("Generate the batch of a commit seal. The seal fixes the options, so only")
+	fmt.Println("generate options that deliver the passwords, such as -out, -o or -plugin,")
+	fmt.Println("can follow it.")
+	fmt.Println("Options:")
+	fmt.Println("  -commitment HEX")
+	fmt.Println("               Fail unless the seal matches this published commitment")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s reveal -commitment 5d41...c592 drop.seal -o csv -header\n", programName)
+}
+
+// runCommit implements the commit subcommand.
+func runCommit(programName string, args []string) error {
+	fs := flag.NewFlagSet("commit", flag.ContinueOnError)
+	seal := fs.String("seal", "", "Where to write the seal")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printCommitUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printCommitUsage(programName)
+		return nil
+	}
+	if *seal == "" {
+		return fmt.Errorf("-seal is required")
+	}
+	return generate(programName, fs.Args(), &drawRun{sealPath: *seal})
+}
+
+// runReveal implements the reveal subcommand.
+func runReveal(programName string, args []string) error {
+	fs := flag.NewFlagSet("reveal", flag.ContinueOnError)
+	commitment := fs.String("commitment", "", "Fail unless the seal matches this published commitment")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printRevealUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printRevealUsage(programName)
+		return nil
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("give the seal written by commit")
+	}
+	t, err := loadDrawTranscript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if t.Beacon != nil {
+		return fmt.Errorf("%s is the transcript of a -beacon draw; replay it with -replay", fs.Arg(0))
+	}
+	// The seed is checked against the seal's own commitment when drawing
+	if *commitment != "" && !strings.EqualFold(*commitment, t.Commitment) {
+		return fmt.Errorf("%s has commitment %s, not %s", fs.Arg(0), t.Commitment, *commitment)
+	}
+	return replayDraw(programName, t, fs.Args()[1:])
+}
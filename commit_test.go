@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCommitReveal tests sealing a batch and generating it from the seal
+func TestCommitReveal(t *testing.T) {
+	dir := t.TempDir()
+	seal := filepath.Join(dir, "drop.seal")
+	if err := runCommit("passgen", []string{"-seal", seal, "--", "-l", "10", "-c", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := loadDrawTranscript(seal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Beacon != nil || d.Fingerprints != nil || strings.Join(d.Args, " ") != "-c=3 -l=10" {
+		t.Errorf("Seal = %+v", d)
+	}
+
+	if err := runReveal("passgen", []string{"-commitment", strings.ToUpper(d.Commitment), seal, "-o", "json"}); err != nil {
+		t.Errorf("reveal: %v", err)
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"wrong commitment", []string{"-commitment", "00", seal}, "not 00"},
+		{"shaping option", []string{seal, "-s"}, "only options that deliver"},
+		{"no seal", nil, "give the seal"},
+	}
+	for _, tt := range tests {
+		if err := runReveal("passgen", tt.args); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: reveal error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// The commitment covers the options as well as the seed
+	d.Args = []string{"-c=3", "-l=20"}
+	data, _ := json.Marshal(d)
+	tampered := filepath.Join(dir, "tampered.seal")
+	if err := os.WriteFile(tampered, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runReveal("passgen", []string{tampered}); err == nil || !strings.Contains(err.Error(), "do not match the commitment") {
+		t.Errorf("reveal of a tampered seal error = %v", err)
+	}
+
+	if err := runCommit("passgen", []string{"-seal", seal, "--", "-l", "10"}); err == nil {
+		t.Error("commit replaced an existing seal")
+	}
+	if err := runCommit("passgen", []string{"-seal", filepath.Join(dir, "out.seal"), "--", "-out", "x.csv"}); err == nil || !strings.Contains(err.Error(), "give -out to reveal") {
+		t.Errorf("commit -out error = %v", err)
+	}
+	if err := runCommit("passgen", []string{"--", "-l", "10"}); err == nil || !strings.Contains(err.Error(), "-seal") {
+		t.Errorf("commit without -seal error = %v", err)
+	}
+}
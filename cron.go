@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printCronUsage(programName string) {
+	fmt.Printf("Usage: %s cron -manifest FILE [OPTIONS]\n", programName)
+	fmt.Println("Regenerate the secrets in a manifest whose age exceeds their max-age and")
+	fmt.Println("write them to the manifest's sinks. Designed to run unattended: prints a")
+	fmt.Println("summary and exits non-zero only when a secret could not be rotated.")
+	fmt.Println("Options:")
+	fmt.Println("  -manifest FILE  Rotation manifest (see README)")
+	fmt.Println("  -dry-run        Report which secrets are due without rotating them")
+	fmt.Println("  -o FORMAT       Summary format: json or text (default: json)")
+	fmt.Println("  -lock-wait DURATION")
+	fmt.Println("                  How long to wait for an overlapping run to finish (default: 0)")
+	fmt.Println("  -h              Show this help message")
+	fmt.Println("\nExample crontab entry:")
+	fmt.Printf("  0 3 * * * %s cron -manifest /etc/passgen/rotate.yaml\n", programName)
+}
+
+// runCron implements the cron subcommand.
+func runCron(programName string, args []string) error {
+	fs := flag.NewFlagSet("cron", flag.ContinueOnError)
+	manifestPath := fs.String("manifest", "", "Rotation manifest")
+	dryRun := fs.Bool("dry-run", false, "Report which secrets are due without rotating them")
+	format := fs.String("o", "json", "Summary format: json or text")
+	lockWait := fs.Duration("lock-wait", 0, "How long to wait for an overlapping run to finish")
+	// Locks are released when their holder exits, so -lock-stale is only
+	// accepted for crontabs written before
+	fs.Duration("lock-stale", 0, "Ignored")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printCronUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printCronUsage(programName)
+		return nil
+	}
+	if *manifestPath == "" {
+		return fmt.Errorf("-manifest is required")
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	m, err := loadManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+	state := statePath(*manifestPath, m)
+
+	// Overlapping runs would race on the state file and the sinks. A run
+	// that finds the lock held steps aside without failing, since the
+	// other run is doing the work.
+	if !*dryRun {
+		lock, err := acquireLock(state+".lock", *lockWait)
+		if errors.Is(err, errLocked) {
+			fmt.Fprintf(os.Stderr, "Skipped: %v\n", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+	}
+
+	r := &rotator{manifest: m, statePath: state, now: time.Now().Truncate(time.Second), dryRun: *dryRun}
+	if m.Out != "" {
+		if r.out, err = newCSVSink(manifestRelative(*manifestPath, m.Out)); err != nil {
+			return err
+		}
+	}
+
+	summary, err := r.run()
+	if err != nil {
+		return err
+	}
+	summary.Manifest = *manifestPath
+	if *format == "json" {
+		err = writeJSON(os.Stdout, summary)
+	} else {
+		printCronSummary(os.Stdout, summary)
+	}
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d secrets could not be rotated", summary.Failed, len(summary.Secrets))
+	}
+	return nil
+}
+
+// statePath returns where the rotation state of a manifest is kept.
+func statePath(manifestPath string, m *manifest) string {
+	if m.State == "" {
+		return strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ".state.json"
+	}
+	return manifestRelative(manifestPath, m.State)
+}
+
+// manifestRelative resolves a path from a manifest against the manifest's
+// directory, so cron jobs behave the same whatever their working directory.
+func manifestRelative(manifestPath, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(manifestPath), path)
+}
+
+// rotationState records when each secret was last rotated, keyed by label.
+// It never contains the secrets themselves.
+type rotationState struct {
+	Schema  string                   `json:"schema"`
+	Secrets map[string]rotationEntry `json:"secrets"`
+}
+
+type rotationEntry struct {
+	Rotated time.Time `json:"rotated"`
+}
+
+func loadRotationState(path string) (*rotationState, error) {
+	state := &rotationState{Schema: outputSchema, Secrets: make(map[string]rotationEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+	if state.Secrets == nil {
+		state.Secrets = make(map[string]rotationEntry)
+	}
+	return state, nil
+}
+
+// save replaces the state file atomically, so a crash never leaves it
+// truncated.
+func (s *rotationState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Statuses reported for each secret in a cron summary.
+const (
+	cronRotated = "rotated"
+	cronDue     = "due"
+	cronCurrent = "current"
+	cronFailed  = "failed"
+)
+
+// cronSummary is the machine-readable report of a cron run.
+type cronSummary struct {
+	Schema   string        `json:"schema"`
+	Manifest string        `json:"manifest"`
+	Time     time.Time     `json:"time"`
+	DryRun   bool          `json:"dryRun,omitempty"`
+	Rotated  int           `json:"rotated"`
+	Current  int           `json:"current"`
+	Failed   int           `json:"failed"`
+	Secrets  []cronOutcome `json:"secrets"`
+}
+
+// cronOutcome reports what happened to one secret. Next is when the secret
+// will be due again.
+type cronOutcome struct {
+	Label  string     `json:"label"`
+	Status string     `json:"status"`
+	Last   *time.Time `json:"lastRotated,omitempty"`
+	Next   *time.Time `json:"nextDue,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// rotator performs one cron run over a manifest.
+type rotator struct {
+	manifest  *manifest
+	statePath string
+	out       sink
+	now       time.Time
+	dryRun    bool
+}
+
+// run rotates every due secret. A secret that fails is reported in the
+// summary and does not stop the others; only problems with the state file
+// abort the run.
+func (r *rotator) run() (*cronSummary, error) {
+	state, err := loadRotationState(r.statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &cronSummary{Schema: outputSchema, Time: r.now.UTC(), DryRun: r.dryRun, Secrets: []cronOutcome{}}
+	for _, secret := range r.manifest.Secrets {
+		outcome := cronOutcome{Label: secret.Label}
+		entry, seen := state.Secrets[secret.Label]
+		if seen {
+			last, next := entry.Rotated, entry.Rotated.Add(secret.MaxAge)
+			outcome.Last, outcome.Next = &last, &next
+		}
+
+		switch {
+		case seen && r.now.Before(*outcome.Next):
+			outcome.Status = cronCurrent
+			summary.Current++
+		case r.dryRun:
+			outcome.Status = cronDue
+		default:
+			if err := r.rotate(secret); err != nil {
+				outcome.Status = cronFailed
+				outcome.Error = err.Error()
+				summary.Failed++
+				break
+			}
+			now, next := r.now.UTC(), r.now.UTC().Add(secret.MaxAge)
+			outcome.Status, outcome.Last, outcome.Next = cronRotated, &now, &next
+			summary.Rotated++
+
+			// Save after every secret so a later failure cannot make
+			// this one rotate again on the next run
+			state.Secrets[secret.Label] = rotationEntry{Rotated: now}
+			if err := state.save(r.statePath); err != nil {
+				return nil, err
+			}
+		}
+		summary.Secrets = append(summary.Secrets, outcome)
+	}
+	return summary, nil
+}
+
+// rotate generates a new value for the secret and writes it to every sink.
+func (r *rotator) rotate(secret manifestSecret) error {
+	genOpts := []passgen.GeneratorOption{
+		passgen.WithLength(secret.Length),
+		passgen.WithSpecial(secret.Special),
+		passgen.WithSimilar(secret.AllowSimilar),
+		passgen.WithAmbiguity(secret.Ambiguity),
+		passgen.WithExclude(secret.Exclude),
+	}
+	genOpts = append(genOpts, minCountOptions(secret.MinUpper, secret.MinLower, secret.MinDigits, secret.MinSpecial)...)
+	genOpts = append(genOpts, disableOptions(secret.NoUpper, secret.NoLower, secret.NoDigits)...)
+	filterOpts, err := secret.Unicode.options()
+	if err != nil {
+		return err
+	}
+	genOpts = append(genOpts, filterOpts...)
+	for _, expr := range secret.Rules {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithRules(rule))
+	}
+	if secret.NoWalks {
+		genOpts = append(genOpts, passgen.WithoutKeyboardWalks(passgen.DefaultMaxKeyboardWalk))
+	}
+	if secret.NoSequences {
+		genOpts = append(genOpts, passgen.WithoutSequences(passgen.DefaultMaxSequence))
+	}
+	if secret.NoRepeats {
+		genOpts = append(genOpts, passgen.WithoutRepeats(passgen.DefaultMaxRun))
+	}
+	if secret.NoConfusables {
+		genOpts = append(genOpts, passgen.WithoutConfusables())
+	}
+	for _, name := range secret.Encodings {
+		enc, err := passgen.LookupEncoding(name)
+		if err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithEncoding(enc))
+	}
+	gen, err := passgen.NewGenerator(genOpts...)
+	if err != nil {
+		return err
+	}
+	sinks, err := enablePlugins(r.manifest.Plugins, gen.Pipeline())
+	if err != nil {
+		return err
+	}
+	sinks = withRetry(sinks, r.manifest.Retry)
+	if r.out != nil {
+		sinks = append(sinks, r.out)
+	}
+	if r.manifest.VerifySink {
+		sinks, _ = withVerify(sinks)
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		return err
+	}
+	cred := []credential{{Label: secret.Label, Password: password, Note: secret.Note}}
+	return writeSinks(sinks, cred, r.manifest.ContinueOnError)
+}
+
+// printCronSummary writes a human-readable version of the summary.
+func printCronSummary(w io.Writer, s *cronSummary) {
+	for _, o := range s.Secrets {
+		switch o.Status {
+		case cronFailed:
+			fmt.Fprintf(w, "%-20s %s: %s\n", o.Label, o.Status, o.Error)
+		case cronDue:
+			fmt.Fprintf(w, "%-20s %s\n", o.Label, o.Status)
+		default:
+			fmt.Fprintf(w, "%-20s %s, next due %s\n", o.Label, o.Status, o.Next.Format(time.RFC3339))
+		}
+	}
+	fmt.Fprintf(w, "Rotated %d, current %d, failed %d\n", s.Rotated, s.Current, s.Failed)
+}
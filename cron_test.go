@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// failingSink rejects every write
+type failingSink struct{}
+
+func (failingSink) write([]credential) error {
+	return errors.New("sink unavailable")
+}
+
+func testRotator(t *testing.T, out sink, now time.Time) *rotator {
+	t.Helper()
+	m := &manifest{Secrets: []manifestSecret{
+		{Label: "db", MaxAge: 24 * time.Hour, Length: 16},
+		{Label: "web", MaxAge: 7 * 24 * time.Hour, Length: 12, Note: "front end"},
+	}}
+	return &rotator{manifest: m, statePath: filepath.Join(t.TempDir(), "state.json"), out: out, now: now}
+}
+
+// TestRotatorRun tests that only secrets older than their max-age rotate
+func TestRotatorRun(t *testing.T) {
+	start := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+	mem := &memorySink{}
+	r := testRotator(t, mem, start)
+
+	// First run: nothing has been rotated yet
+	summary, err := r.run()
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if summary.Rotated != 2 || summary.Current != 0 || summary.Failed != 0 {
+		t.Errorf("First run summary = %+v", summary)
+	}
+	if len(mem.creds) != 2 || mem.creds[0].Label != "db" || len(mem.creds[0].Password) != 16 || mem.creds[1].Note != "front end" {
+		t.Errorf("Sink got %+v", mem.creds)
+	}
+
+	// Two days later only db is due
+	r.now = start.Add(48 * time.Hour)
+	summary, err = r.run()
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if summary.Rotated != 1 || summary.Current != 1 {
+		t.Errorf("Second run summary = %+v", summary)
+	}
+	if summary.Secrets[0].Status != cronRotated || summary.Secrets[1].Status != cronCurrent {
+		t.Errorf("Statuses = %s, %s", summary.Secrets[0].Status, summary.Secrets[1].Status)
+	}
+	if want := start.Add(7 * 24 * time.Hour); !summary.Secrets[1].Next.Equal(want) {
+		t.Errorf("web next due = %v, want %v", summary.Secrets[1].Next, want)
+	}
+	if len(mem.creds) != 3 {
+		t.Errorf("Expected 3 credentials written, got %d", len(mem.creds))
+	}
+}
+
+// TestRotatorDryRun tests that a dry run reports due secrets without writing
+func TestRotatorDryRun(t *testing.T) {
+	mem := &memorySink{}
+	r := testRotator(t, mem, time.Now())
+	r.dryRun = true
+
+	summary, err := r.run()
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if summary.Rotated != 0 || summary.Secrets[0].Status != cronDue {
+		t.Errorf("Dry run summary = %+v", summary)
+	}
+	if len(mem.creds) != 0 {
+		t.Errorf("Dry run wrote %v", mem.creds)
+	}
+	if _, err := os.Stat(r.statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Dry run should not create the state file")
+	}
+}
+
+// TestRotatorFailure tests that sink failures are reported and retried next run
+func TestRotatorFailure(t *testing.T) {
+	now := time.Now()
+	r := testRotator(t, failingSink{}, now)
+
+	summary, err := r.run()
+	if err != nil {
+		t.Fatalf("Sink failures should not abort the run: %v", err)
+	}
+	if summary.Failed != 2 || summary.Secrets[0].Error != "sink unavailable" {
+		t.Errorf("Summary = %+v", summary)
+	}
+
+	r.out = &memorySink{}
+	summary, err = r.run()
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if summary.Rotated != 2 {
+		t.Errorf("Failed secrets should rotate on the next run, got %+v", summary)
+	}
+}
+
+// TestStatePath tests where rotation state is kept
+func TestStatePath(t *testing.T) {
+	tests := []struct {
+		manifest string
+		state    string
+		want     string
+	}{
+		{"/etc/passgen/rotate.yaml", "", "/etc/passgen/rotate.state.json"},
+		{"/etc/passgen/rotate.yaml", "state.json", "/etc/passgen/state.json"},
+		{"/etc/passgen/rotate.yaml", "/var/lib/passgen.json", "/var/lib/passgen.json"},
+	}
+
+	for _, tt := range tests {
+		got := statePath(filepath.FromSlash(tt.manifest), &manifest{State: filepath.FromSlash(tt.state)})
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("statePath(%q, %q) = %q, want %q", tt.manifest, tt.state, got, tt.want)
+		}
+	}
+}
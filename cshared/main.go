@@ -0,0 +1,87 @@
+// Command cshared builds passgen as a C shared library so Python, Rust, C++
+// and other tooling can link against the same implementation as the CLI:
+//
+//	go build -buildmode=c-shared -o libpassgen.so ./cshared
+//
+// The build also writes libpassgen.h declaring:
+//
+//	char *passgen_generate(int length, int include_special, char **err);
+//	int passgen_check(char *password, char *rules, char **err);
+//	double passgen_entropy(char *password);
+//	void passgen_free(char *ptr);
+//
+// Strings returned through the return value or err are allocated with
+// malloc and must be released with passgen_free.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// setError stores msg in *errOut when the caller asked for errors.
+func setError(errOut **C.char, err error) {
+	if errOut != nil {
+		*errOut = C.CString(err.Error())
+	}
+}
+
+// passgen_generate returns a new password, or NULL with *err set on failure.
+//
+//export passgen_generate
+func passgen_generate(length C.int, includeSpecial C.int, errOut **C.char) *C.char {
+	password, err := passgen.GeneratePassword(int(length), includeSpecial != 0)
+	if err != nil {
+		setError(errOut, err)
+		return nil
+	}
+	return C.CString(password)
+}
+
+// passgen_check evaluates newline-separated rule expressions against a
+// password. It returns 1 if all rules pass, 0 if any fails and -1 with *err
+// set if the rules are invalid.
+//
+//export passgen_check
+func passgen_check(password *C.char, rules *C.char, errOut **C.char) C.int {
+	compiled, err := passgen.CompileRules(C.GoString(rules))
+	if err != nil {
+		setError(errOut, err)
+		return -1
+	}
+
+	p := C.GoString(password)
+	for _, rule := range compiled {
+		ok, err := rule.Eval(p)
+		if err != nil {
+			setError(errOut, err)
+			return -1
+		}
+		if !ok {
+			return 0
+		}
+	}
+	return 1
+}
+
+// passgen_entropy estimates the strength of a password in bits.
+//
+//export passgen_entropy
+func passgen_entropy(password *C.char) C.double {
+	return C.double(passgen.EstimateEntropy(C.GoString(password)))
+}
+
+// passgen_free releases a string returned by the library.
+//
+//export passgen_free
+func passgen_free(ptr *C.char) {
+	C.free(unsafe.Pointer(ptr))
+}
+
+func main() {}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printDeriveUsage(programName string) {
+	fmt.Printf("Usage: %s derive-child (-parent SECRET | -parent-file FILE) -info NAME [OPTIONS]\n", programName)
+	fmt.Println("Derive passwords from one master secret with HKDF-SHA256, so a family of")
+	fmt.Println("related credentials can be managed from a single stored value. The same")
+	fmt.Println("parent and -info always give the same password.")
+	fmt.Println("Options:")
+	fmt.Println("  -parent SECRET")
+	fmt.Println("               Master secret; visible to other users in the process list,")
+	fmt.Println("               prefer -parent-file")
+	fmt.Println("  -parent-file FILE")
+	fmt.Println("               Read the master secret from the first line of FILE, or stdin for -")
+	fmt.Println("  -info NAME   Name of the child, e.g. db/replica1 (repeatable)")
+	fmt.Println("  -l LENGTH    Password length (default: 12)")
+	fmt.Println("  -s           Include special characters")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s derive-child -parent-file master.key -info db/replica1 -info db/replica2\n", programName)
+}
+
+// runDeriveChild implements the derive-child subcommand.
+func runDeriveChild(programName string, args []string) error {
+	fs := flag.NewFlagSet("derive-child", flag.ContinueOnError)
+	parent := fs.String("parent", "", "Master secret")
+	parentFile := fs.String("parent-file", "", "Read the master secret from FILE, or stdin for -")
+	var infos stringList
+	fs.Var(&infos, "info", "Name of the child (repeatable)")
+	length := fs.Int("l", 12, "Password length")
+	includeSpecial := fs.Bool("s", false, "Include special characters")
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printDeriveUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printDeriveUsage(programName)
+		return nil
+	}
+	if (*parent == "") == (*parentFile == "") {
+		return fmt.Errorf("exactly one of -parent and -parent-file is required")
+	}
+	if len(infos) == 0 {
+		return fmt.Errorf("at least one -info is required")
+	}
+	if *length < minLength || *length > maxLength {
+		return fmt.Errorf("password length must be between %d and %d", minLength, maxLength)
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	secret := *parent
+	if *parentFile != "" {
+		var err error
+		if secret, err = readParent(*parentFile); err != nil {
+			return err
+		}
+	}
+
+	charsets := passgen.Options{IncludeSpecial: *includeSpecial}.Charsets()
+	// A child cannot hold more entropy than the secret it is derived from
+	parentBits := passgen.EstimateEntropy(secret)
+	results := make([]passwordOutput, 0, len(infos))
+	for _, info := range infos {
+		password, err := passgen.DeriveChild([]byte(secret), info, *length, charsets)
+		if err != nil {
+			return fmt.Errorf("deriving %s: %w", info, err)
+		}
+		bits := min(passgen.EstimateEntropyWith(password, charsets), parentBits)
+		results = append(results, passwordOutput{Label: info, Password: password, Entropy: bits})
+	}
+
+	if *format == "json" {
+		return writeJSON(os.Stdout, generateOutput{Schema: outputSchema, Length: *length, Charsets: charsets, Passwords: results})
+	}
+	plural := ""
+	if len(results) > 1 {
+		plural = "s"
+	}
+	fmt.Printf("Derived password%s:\n", plural)
+	fmt.Printf("Length: %d characters\n", *length)
+	fmt.Printf("Parent entropy: %.1f bits\n\n", parentBits)
+	for _, r := range results {
+		fmt.Printf("%s: %s\n", r.Label, r.Password)
+	}
+	return nil
+}
+
+// readParent reads a master secret from the first line of a file, or of
+// stdin when path is "-".
+func readParent(path string) (string, error) {
+	if path == "-" {
+		return readPassword(os.Stdin)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" {
+		return "", fmt.Errorf("%s: no secret on the first line", path)
+	}
+	return line, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReadParent tests reading the master secret from the first line of a file
+func TestReadParent(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"single line", "s3cret master key\n", "s3cret master key", false},
+		{"crlf", "s3cret\r\nignored\r\n", "s3cret", false},
+		{"no newline", "s3cret", "s3cret", false},
+		{"empty", "", "", true},
+		{"blank first line", "\ns3cret\n", "", true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readParent(path)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("readParent = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := readParent(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// maxDiceBits is the most entropy -dice collects: the ChaCha8 seed the
+// rolls are hashed into holds 256 bits.
+const maxDiceBits = 256
+
+// readDiceEntropy asks for dice rolls and coin flips until they debias to
+// need bits, asking again for lines that are not rolls or flips.
+func readDiceEntropy(in io.Reader, prompt io.Writer, need int) (*passgen.DiceEntropy, error) {
+	e := &passgen.DiceEntropy{}
+	fmt.Fprintf(prompt, "Roll a die (1-6) or flip a coin (H/T) and type the results, as many per line\n")
+	fmt.Fprintf(prompt, "as you like. %d unbiased bits are needed: about %d rolls or %d flips.\n", need, diceRollsFor(need), 4*need)
+	scanner := bufio.NewScanner(in)
+	for e.Bits() < need {
+		fmt.Fprintf(prompt, "%d of %d bits: ", e.Bits(), need)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("input ended with %d of %d bits; about %d more rolls are needed", e.Bits(), need, diceRollsFor(need-e.Bits()))
+		}
+		if err := e.Add(scanner.Text()); err != nil {
+			fmt.Fprintf(prompt, "%v, try again\n", err)
+		}
+	}
+	return e, nil
+}
+
+// diceRollsFor returns how many rolls of a fair die give bits unbiased
+// bits on average, at 5/12 of a bit per roll.
+func diceRollsFor(bits int) int {
+	return int(math.Ceil(float64(bits) * 12 / 5))
+}
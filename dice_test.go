@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReadDiceEntropy tests reading rolls until enough bits are collected
+func TestReadDiceEntropy(t *testing.T) {
+	var prompt strings.Builder
+	e, err := readDiceEntropy(strings.NewReader("HT\n1 2 x\nTH 1 2\nHT\n"), &prompt, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Bits() != 3 || e.Rolls != 2 || e.Flips != 4 {
+		t.Errorf("Got %d bits from %d rolls and %d flips", e.Bits(), e.Rolls, e.Flips)
+	}
+	if !strings.Contains(prompt.String(), "try again") || !strings.Contains(prompt.String(), "about 8 rolls or 12 flips") {
+		t.Errorf("Unexpected prompts %q", prompt.String())
+	}
+
+	if _, err := readDiceEntropy(strings.NewReader("1 2\n"), &prompt, 3); err == nil || !strings.Contains(err.Error(), "1 of 3 bits") {
+		t.Errorf("Expected an error for too few rolls, got %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// drandURL is the HTTP API of the League of Entropy's drand mainnet, which
+// -beacon drand stands for.
+const drandURL = "https://api.drand.sh"
+
+// drawLocalFlags are the generate flags left out of a draw's transcript:
+// the draw's own, whose beacon round the commitment fixes separately, and
+// those that only show, deliver or record the
+// passwords. Only these can be added to a replay, which draws the same
+// passwords.
+var drawLocalFlags = map[string]bool{
+	"beacon": true, "beacon-round": true, "transcript": true, "replay": true,
+	"o": true, "format": true, "q": true, "quiet": true, "template": true, "color": true, "mask": true, "header": true, "label": true, "note": true, "stream": true,
+	"histogram": true, "filter-stats": true, "show-entropy": true, "strength": true, "min-entropy": true, "target": true,
+	"preview": true, "homoglyph-report": true, "fingerprint": true, "fingerprint-only": true, "hash-format": true, "hash-config": true, "with-metadata": true,
+	"expires": true, "rng-timeout": true, "out": true, "custodian-out": true, "vault-path": true, "copy": true, "plugin": true,
+	"sink-retries": true, "sink-backoff": true, "verify-sink": true, "continue-on-error": true,
+	"audit-log": true, "audit-sink": true, "metrics-out": true, "sign-key": true,
+}
+
+// beaconRound is a round of a drand beacon. Its randomness is the SHA-256
+// hash of its signature, which the beacon's threshold of nodes made
+// together and nobody could predict.
+type beaconRound struct {
+	URL       string `json:"url"`
+	ChainHash string `json:"chainHash"`
+	Round     uint64 `json:"round"`
+	// Time is when the beacon published the round, in RFC 3339.
+	Time       string `json:"time"`
+	Randomness string `json:"randomness"`
+	Signature  string `json:"signature"`
+}
+
+// check verifies that the round's randomness is derived from its
+// signature. Checking the signature itself takes the beacon's BLS public
+// key, so the round is compared with the beacon instead.
+func (b *beaconRound) check() error {
+	sig, err := hex.DecodeString(b.Signature)
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("beacon round %d has no valid signature", b.Round)
+	}
+	sum := sha256.Sum256(sig)
+	if hex.EncodeToString(sum[:]) != b.Randomness {
+		return fmt.Errorf("the randomness of beacon round %d is not the hash of its signature", b.Round)
+	}
+	return nil
+}
+
+// drawTranscript is the proof of a -beacon draw, or the seal of commit:
+// everything needed to generate its passwords again, and to see that they
+// were fixed in advance. Publishing it publishes the passwords.
+type drawTranscript struct {
+	Schema  string `json:"schema"`
+	Version string `json:"version"`
+	// Committed is when the local seed was drawn, in RFC 3339; it must
+	// be before the beacon round.
+	Committed string `json:"committed"`
+	// Args are the generate options of the draw.
+	Args []string `json:"args"`
+	// BeaconChain and BeaconRound are the beacon round a -beacon draw
+	// will use, fixed before the local seed is drawn.
+	BeaconChain string `json:"beaconChain,omitempty"`
+	BeaconRound uint64 `json:"beaconRound,omitempty"`
+	// LocalSeed is the local randomness. Commitment is the hash of it,
+	// Args and the beacon round, which can be published before the beacon
+	// round or the reveal.
+	LocalSeed  string       `json:"localSeed"`
+	Commitment string       `json:"commitment"`
+	Beacon     *beaconRound `json:"beacon,omitempty"`
+	// Seed is the ChaCha8 seed the passwords are drawn from, the hash of
+	// the local seed and the beacon round.
+	Seed string `json:"seed"`
+	// Fingerprints identify the passwords of a -beacon draw; a seal is
+	// made before there are any.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+}
+
+// drawRun makes generate draw from a seed instead of the system RNG.
+type drawRun struct {
+	// transcript is the draw to generate again, for -replay and reveal.
+	transcript *drawTranscript
+	// sealPath is where commit writes the seal, instead of generating.
+	sealPath string
+}
+
+// drawArgs returns the options of a generate run for its transcript, one
+// argument per flag so each value is kept as it was.
+func drawArgs(fs *flag.FlagSet) []string {
+	var args []string
+	fs.Visit(func(f *flag.Flag) {
+		if drawLocalFlags[f.Name] {
+			return
+		}
+		if list, ok := f.Value.(*stringList); ok {
+			for _, v := range *list {
+				args = append(args, "-"+f.Name+"="+v)
+			}
+			return
+		}
+		args = append(args, "-"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// newSeal draws a local seed for a draw with args and commits to both and
+// to the beacon round target, if there is one.
+func newSeal(args []string, target *beaconRound) (*drawTranscript, error) {
+	local := make([]byte, 32)
+	if _, err := rand.Read(local); err != nil {
+		return nil, err
+	}
+	t := &drawTranscript{
+		Schema:    outputSchema,
+		Version:   version,
+		Committed: time.Now().UTC().Format(time.RFC3339),
+		Args:      args,
+		LocalSeed: hex.EncodeToString(local),
+	}
+	if target != nil {
+		t.BeaconChain, t.BeaconRound = target.ChainHash, target.Round
+	}
+	t.Commitment = commitmentOf(local, args, t.BeaconChain, t.BeaconRound)
+	seed, err := drawSeed(local, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.Seed = hex.EncodeToString(seed[:])
+	return t, nil
+}
+
+// newDraw seals a local seed and takes the beacon round from source, drand
+// or a drand API URL. Without a round it waits for the next one. The
+// commitment printed to status fixes the round and its chain as well as
+// the seed, before the beacon's randomness exists, so the operator cannot
+// look for a round that suits them afterwards.
+func newDraw(source string, round uint64, args []string, status io.Writer) (*drawTranscript, error) {
+	if source == "drand" {
+		source = drandURL
+	}
+	target, err := planBeaconRound(source, round)
+	if err != nil {
+		return nil, err
+	}
+	t, err := newSeal(args, target)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(status, "Commitment to the local seed and beacon round %d: %s\n", target.Round, t.Commitment)
+	if err := fetchBeaconRound(target, status); err != nil {
+		return nil, err
+	}
+	t.Beacon = target
+	local, _ := hex.DecodeString(t.LocalSeed)
+	seed, err := drawSeed(local, t.Beacon)
+	if err != nil {
+		return nil, err
+	}
+	t.Seed = hex.EncodeToString(seed[:])
+	// A round published before the commitment makes the draw unauditable
+	if _, err := t.seed(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// commitmentOf hashes the local seed, the options of a draw and the beacon
+// round it will use, so a published commitment fixes its passwords without
+// revealing them. A seal has no round, and round is 0.
+func commitmentOf(local []byte, args []string, chain string, round uint64) string {
+	h := sha256.New()
+	io.WriteString(h, "passgen commit\x00")
+	h.Write(local)
+	for _, arg := range args {
+		io.WriteString(h, arg+"\x00")
+	}
+	if round != 0 {
+		io.WriteString(h, "beacon\x00"+chain+"\x00")
+		h.Write(binary.BigEndian.AppendUint64(nil, round))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// drawSeed hashes the local seed, and the beacon round if there is one,
+// into a ChaCha8 seed.
+func drawSeed(local []byte, b *beaconRound) ([32]byte, error) {
+	h := sha256.New()
+	if b == nil {
+		io.WriteString(h, "passgen seal\x00")
+		h.Write(local)
+	} else {
+		randomness, err := hex.DecodeString(b.Randomness)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("beacon round %d: invalid randomness: %v", b.Round, err)
+		}
+		io.WriteString(h, "passgen beacon\x00")
+		h.Write(local)
+		h.Write(binary.BigEndian.AppendUint64(nil, b.Round))
+		h.Write(randomness)
+	}
+	var seed [32]byte
+	h.Sum(seed[:0])
+	return seed, nil
+}
+
+// seed verifies that the transcript holds together and returns the seed of
+// its passwords. A local seed drawn after the beacon round, or a round
+// other than the committed one, could have been chosen to suit the
+// outcome, which is reported as an error.
+func (t *drawTranscript) seed() ([32]byte, error) {
+	local, err := hex.DecodeString(t.LocalSeed)
+	if err != nil || len(local) != 32 {
+		return [32]byte{}, fmt.Errorf("the transcript has no valid local seed")
+	}
+	switch b := t.Beacon; {
+	case b == nil && t.BeaconRound != 0:
+		return [32]byte{}, fmt.Errorf("the transcript commits to beacon round %d but records no beacon round", t.BeaconRound)
+	case b != nil && (b.Round != t.BeaconRound || b.ChainHash != t.BeaconChain):
+		return [32]byte{}, fmt.Errorf("the transcript draws with round %d of beacon chain %s, but the commitment fixes round %d of chain %q", b.Round, b.ChainHash, t.BeaconRound, t.BeaconChain)
+	}
+	if commitmentOf(local, t.Args, t.BeaconChain, t.BeaconRound) != t.Commitment {
+		return [32]byte{}, fmt.Errorf("the local seed, options and beacon round do not match the commitment %s", t.Commitment)
+	}
+	if b := t.Beacon; b != nil {
+		if err := b.check(); err != nil {
+			return [32]byte{}, err
+		}
+		committed, err := time.Parse(time.RFC3339, t.Committed)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("the transcript has no valid commitment time")
+		}
+		published, err := time.Parse(time.RFC3339, b.Time)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("the transcript has no valid beacon round time")
+		}
+		if !committed.Before(published) {
+			return [32]byte{}, fmt.Errorf("the local seed was committed at %s, after beacon round %d was published at %s", t.Committed, b.Round, b.Time)
+		}
+	}
+	seed, err := drawSeed(local, t.Beacon)
+	if err != nil {
+		return seed, err
+	}
+	if hex.EncodeToString(seed[:]) != t.Seed {
+		return seed, fmt.Errorf("the seed is not derived from the local seed")
+	}
+	return seed, nil
+}
+
+// match compares the fingerprints of a replay with the transcript's.
+func (t *drawTranscript) match(fingerprints []string) error {
+	if len(fingerprints) != len(t.Fingerprints) {
+		return fmt.Errorf("the replay drew %d passwords, the transcript lists %d", len(fingerprints), len(t.Fingerprints))
+	}
+	for i, fp := range fingerprints {
+		if fp != t.Fingerprints[i] {
+			return fmt.Errorf("password %d has fingerprint %s, the transcript lists %s", i+1, fp, t.Fingerprints[i])
+		}
+	}
+	return nil
+}
+
+// loadDrawTranscript reads a transcript written by -transcript.
+func loadDrawTranscript(path string) (*drawTranscript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &drawTranscript{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if t.Schema != outputSchema {
+		return nil, fmt.Errorf("%s: unknown schema %q", path, t.Schema)
+	}
+	return t, nil
+}
+
+// writeDrawTranscript writes the transcript to a new file, readable only by
+// its owner since it reproduces the passwords. An existing transcript may
+// be the only proof of an earlier draw, so it is never replaced.
+func writeDrawTranscript(path string, t *drawTranscript) error {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, t); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replayDraw generates the passwords of a transcript or seal again. extra
+// may only hold options that deliver or record them.
+func replayDraw(programName string, t *drawTranscript, extra []string) error {
+	if t.Beacon != nil {
+		if err := checkBeaconRound(t.Beacon); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: beacon round %d not compared with the beacon: %v\n", t.Beacon.Round, err)
+		}
+	}
+	return generate(programName, append(slices.Clone(t.Args), extra...), &drawRun{transcript: t})
+}
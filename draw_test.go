@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// testDraw returns a consistent transcript of a draw with args.
+func testDraw(t *testing.T, args []string) *drawTranscript {
+	t.Helper()
+	local := make([]byte, 32)
+	local[0] = 1
+	sig := []byte("signature of round 42")
+	sigSum := sha256.Sum256(sig)
+	d := &drawTranscript{
+		Schema:      outputSchema,
+		Version:     version,
+		Committed:   "2026-10-16T09:00:00Z",
+		Args:        args,
+		BeaconChain: "abcd",
+		BeaconRound: 42,
+		LocalSeed:   hex.EncodeToString(local),
+		Commitment:  commitmentOf(local, args, "abcd", 42),
+		Beacon: &beaconRound{
+			URL:        "https://beacon.invalid",
+			ChainHash:  "abcd",
+			Round:      42,
+			Time:       "2026-10-16T09:00:30Z",
+			Randomness: hex.EncodeToString(sigSum[:]),
+			Signature:  hex.EncodeToString(sig),
+		},
+	}
+	seed, err := drawSeed(local, d.Beacon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Seed = hex.EncodeToString(seed[:])
+	return d
+}
+
+// TestDrawArgs tests which options a transcript records
+func TestDrawArgs(t *testing.T) {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.Int("l", 12, "")
+	fs.Bool("s", false, "")
+	fs.String("beacon", "", "")
+	fs.String("out", "", "")
+	var avoid stringList
+	fs.Var(&avoid, "avoid", "")
+	if err := fs.Parse([]string{"-l", "16", "-s", "-avoid", "a,b", "-avoid", "c", "-beacon", "drand", "-out", "x.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-avoid=a,b", "-avoid=c", "-l=16", "-s=true"}
+	if got := drawArgs(fs); !slices.Equal(got, want) {
+		t.Errorf("drawArgs() = %q, want %q", got, want)
+	}
+}
+
+// TestDrawTranscriptSeed tests the consistency checks of a transcript
+func TestDrawTranscriptSeed(t *testing.T) {
+	if _, err := testDraw(t, nil).seed(); err != nil {
+		t.Fatalf("seed() of a consistent transcript: %v", err)
+	}
+	tests := []struct {
+		name    string
+		tamper  func(d *drawTranscript)
+		wantErr string
+	}{
+		{"local seed", func(d *drawTranscript) { d.LocalSeed = strings.Repeat("00", 32) }, "commitment"},
+		{"options", func(d *drawTranscript) { d.Args = []string{"-l=20"} }, "commitment"},
+		{"randomness", func(d *drawTranscript) { d.Beacon.Randomness = strings.Repeat("00", 32) }, "hash of its signature"},
+		{"late commitment", func(d *drawTranscript) { d.Committed = "2026-10-16T09:00:30Z" }, "after beacon round 42"},
+		{"round", func(d *drawTranscript) { d.Beacon.Round = 43 }, "commitment fixes round 42"},
+		{"chain", func(d *drawTranscript) { d.Beacon.ChainHash = "ef01" }, "commitment fixes round 42"},
+		{"committed round", func(d *drawTranscript) { d.BeaconRound, d.Beacon.Round = 43, 43 }, "do not match the commitment"},
+		{"no beacon", func(d *drawTranscript) { d.Beacon = nil }, "records no beacon round"},
+		{"seed", func(d *drawTranscript) { d.Seed = strings.Repeat("00", 32) }, "not derived"},
+	}
+	for _, tt := range tests {
+		d := testDraw(t, nil)
+		tt.tamper(d)
+		if _, err := d.seed(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: seed() error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestGenerateReplay tests drawing the passwords of a transcript again
+func TestGenerateReplay(t *testing.T) {
+	d := testDraw(t, []string{"-c=3", "-l=16"})
+	seed, err := d.seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := passgen.NewGenerator(passgen.WithLength(16), passgen.WithRandom(rand.NewChaCha8(seed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Fingerprints = append(d.Fingerprints, passgen.Fingerprint(password))
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "draw.json")
+	if err := writeDrawTranscript(path, d); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Transcript mode = %v, %v", info.Mode(), err)
+	}
+	if err := runGenerate("passgen", []string{"-replay", path}); err != nil {
+		t.Errorf("-replay: %v", err)
+	}
+
+	if err := runGenerate("passgen", []string{"-replay", path, "-o", "csv"}); err != nil {
+		t.Errorf("-replay -o csv: %v", err)
+	}
+	if err := writeDrawTranscript(path, d); err == nil {
+		t.Error("writeDrawTranscript() replaced an existing transcript")
+	}
+
+	d.Fingerprints[1] = "00000000"
+	path = filepath.Join(dir, "tampered.json")
+	if err := writeDrawTranscript(path, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := runGenerate("passgen", []string{"-replay", path}); err == nil || !strings.Contains(err.Error(), "password 2") {
+		t.Errorf("-replay of a tampered transcript error = %v", err)
+	}
+	if err := runGenerate("passgen", []string{"-replay", path, "-l", "20"}); err == nil || !strings.Contains(err.Error(), "-c=3 -l=16") {
+		t.Errorf("-replay with options error = %v", err)
+	}
+	if err := runGenerate("passgen", []string{"-beacon", "drand"}); err == nil || !strings.Contains(err.Error(), "-transcript") {
+		t.Errorf("-beacon without -transcript error = %v", err)
+	}
+	if err := runGenerate("passgen", []string{"-beacon", "drand", "-transcript", path, "-dice"}); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("-beacon -dice error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// halfSink delivers one half of each dual control password to a custodian,
+// who never sees the other half.
+type halfSink struct {
+	sink
+	// half is 0 for the first half, 1 for the second.
+	half int
+}
+
+// newCustodianSinks returns a sink for each -custodian-out file, the first
+// receiving the first halves.
+func newCustodianSinks(paths []string) ([]sink, error) {
+	if len(paths) != 2 {
+		return nil, fmt.Errorf("-custodian-out must be given twice, once for each half")
+	}
+	var sinks []sink
+	for i, path := range paths {
+		out, err := newCSVSink(path)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, &halfSink{sink: out, half: i})
+	}
+	return sinks, nil
+}
+
+func (h *halfSink) write(creds []credential) error {
+	halves := make([]credential, len(creds))
+	for i, c := range creds {
+		halves[i] = c
+		halves[i].Password = dualControlHalf(c.Password, h.half)
+	}
+	return h.sink.write(halves)
+}
+
+func (h *halfSink) undo() error {
+	r, ok := h.sink.(reversibleSink)
+	if !ok {
+		return fmt.Errorf("%s cannot be rolled back", sinkName(h.sink))
+	}
+	return r.undo()
+}
+
+func (h *halfSink) canUndo() bool {
+	return reversible(h.sink)
+}
+
+func (h *halfSink) String() string {
+	return fmt.Sprintf("%s (half %d)", sinkName(h.sink), h.half+1)
+}
+
+// dualControlHalf returns the first or second half of password.
+func dualControlHalf(password string, half int) string {
+	first, second := passgen.DualControlHalves(password)
+	if half == 0 {
+		return first
+	}
+	return second
+}
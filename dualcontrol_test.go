@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCustodianSinks tests that each custodian file receives its half
+func TestCustodianSinks(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")}
+	sinks, err := newCustodianSinks(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := []credential{{Label: "root", Password: "Ab3kQ7x9Zr2m"}, {Label: "root", Password: "Ww4eRt5"}}
+	if err := writeSinks(sinks, creds, false); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"label,password,note\nroot,Ab3kQ7,\nroot,Ww4e,\n",
+		"label,password,note\nroot,x9Zr2m,\nroot,Rt5,\n",
+	}
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want[i] {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), data, want[i])
+		}
+	}
+	if got := sinkName(sinks[1]); !strings.HasSuffix(got, "b.csv (half 2)") {
+		t.Errorf("sinkName = %q", got)
+	}
+
+	if _, err := newCustodianSinks(paths[:1]); err == nil {
+		t.Error("a single custodian file was accepted")
+	}
+}
+
+// TestGenerateDualControlFlags tests what -dual-control rules out
+func TestGenerateDualControlFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-custodian-out", "a.csv", "-custodian-out", "b.csv"}, "requires -dual-control"},
+		{[]string{"-dual-control", "-pronounceable"}, "-dual-control cannot be combined"},
+		{[]string{"-dual-control", "-q"}, "show the whole password"},
+		{[]string{"-dual-control", "-o", "csv"}, "requires -o text, json or ndjson"},
+		{[]string{"-dual-control", "-o", "json", "-custodian-out", "a.csv", "-custodian-out", "b.csv"}, "would show the whole password"},
+		{[]string{"-dual-control", "-l", "7", "-s"}, "at least 8"},
+		{[]string{"-dual-control", "-out", "w.csv", "-custodian-out", "a.csv", "-custodian-out", "b.csv"}, "receive the whole password"},
+		{[]string{"-dual-control", "-vault-path", "secret/root", "-custodian-out", "a.csv", "-custodian-out", "b.csv"}, "receive the whole password"},
+		{[]string{"-dual-control", "-copy", "-custodian-out", "a.csv", "-custodian-out", "b.csv"}, "receive the whole password"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
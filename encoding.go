@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// encodingOptions turns -encoding and -encoding-action into generator
+// options. With regenerate, passwords that would not survive every encoding
+// verbatim are discarded. With escape, the returned encoding is the one
+// output is escaped for, and only passwords it cannot represent at all are
+// regenerated.
+func encodingOptions(names []string, action string) (*passgen.Encoding, []passgen.GeneratorOption, error) {
+	var encs []*passgen.Encoding
+	for _, name := range names {
+		e, err := passgen.LookupEncoding(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		encs = append(encs, e)
+	}
+
+	switch action {
+	case "regenerate":
+		if len(encs) == 0 {
+			return nil, nil, nil
+		}
+		return nil, []passgen.GeneratorOption{passgen.WithEncoding(encs...)}, nil
+	case "escape":
+		if len(encs) == 0 {
+			return nil, nil, nil
+		}
+		if len(encs) > 1 {
+			return nil, nil, fmt.Errorf("passwords can only be escaped for one -encoding; use -encoding-action regenerate for several")
+		}
+		e := encs[0]
+		escapable := passgen.WithPostGenerate(func(password string) error {
+			if _, err := e.Escape(password); err != nil {
+				return passgen.Reject("encoding "+e.Name, "%v", err)
+			}
+			return nil
+		})
+		return e, []passgen.GeneratorOption{escapable}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown encoding action %q (use escape or regenerate)", action)
+}
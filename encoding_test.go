@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestEncodingOptions tests the -encoding and -encoding-action combinations
+func TestEncodingOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		encodings  []string
+		action     string
+		wantEscape string
+		wantErr    string
+	}{
+		{"none", nil, "escape", "", ""},
+		{"escape", []string{"shell"}, "escape", "shell", ""},
+		{"regenerate several", []string{"yaml", "env"}, "regenerate", "", ""},
+		{"escape several", []string{"yaml", "env"}, "escape", "", "only be escaped for one"},
+		{"unknown encoding", []string{"toml"}, "escape", "", "unknown encoding"},
+		{"unknown action", []string{"yaml"}, "ignore", "", "unknown encoding action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escapeFor, opts, err := encodingOptions(tt.encodings, tt.action)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if escapeFor != nil {
+				got = escapeFor.Name
+			}
+			if got != tt.wantEscape {
+				t.Errorf("Escaping for %q, want %q", got, tt.wantEscape)
+			}
+			if _, err := passgen.NewGenerator(opts...); err != nil {
+				t.Errorf("Options rejected: %v", err)
+			}
+		})
+	}
+}
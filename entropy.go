@@ -0,0 +1,68 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// entropyBucket is one bar of an entropy histogram covering [low, low+width).
+type entropyBucket struct {
+	low   int
+	count int
+}
+
+// entropyHistogram groups entropy values into buckets of the given width in bits.
+func entropyHistogram(values []float64, width int) []entropyBucket {
+	counts := make(map[int]int)
+	for _, v := range values {
+		low := int(math.Floor(v/float64(width))) * width
+		counts[low]++
+	}
+
+	buckets := make([]entropyBucket, 0, len(counts))
+	for low, count := range counts {
+		buckets = append(buckets, entropyBucket{low: low, count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].low < buckets[j].low
+	})
+	return buckets
+}
+
+// printEntropyHistogram writes a text histogram of entropy values to w.
+func printEntropyHistogram(w io.Writer, values []float64, width int) {
+	fmt.Fprintln(w, "Entropy histogram (bits):")
+	for _, b := range entropyHistogram(values, width) {
+		fmt.Fprintf(w, "  %4d-%-4d | %s %d\n", b.low, b.low+width-1, strings.Repeat("#", b.count), b.count)
+	}
+}
+
+// printFilterStats writes how many candidates the filters rejected, most
+// frequent reason first, and what that cost in entropy and time.
+func printFilterStats(w io.Writer, s passgen.RejectStats) {
+	rejected := s.TotalRejected()
+	percent := 0.0
+	if s.Candidates > 0 {
+		percent = 100 * float64(rejected) / float64(s.Candidates)
+	}
+	fmt.Fprintf(w, "Filters rejected %d of %d candidates (%.1f%%) in %s\n", rejected, s.Candidates, percent, s.Elapsed.Round(time.Millisecond))
+	if rejected == 0 {
+		return
+	}
+	filters := slices.SortedFunc(maps.Keys(s.Rejected), func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.Rejected[b], s.Rejected[a]), strings.Compare(a, b))
+	})
+	for _, filter := range filters {
+		fmt.Fprintf(w, "  %6d  %s\n", s.Rejected[filter], filter)
+	}
+	fmt.Fprintf(w, "Entropy lost to the filters: about %.2f bits per password\n", s.EntropyLoss())
+}
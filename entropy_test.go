@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestEntropyHistogram tests bucketing of entropy values
+func TestEntropyHistogram(t *testing.T) {
+	buckets := entropyHistogram([]float64{60.5, 62, 71.9, 72, 90}, 8)
+
+	want := []entropyBucket{{56, 2}, {64, 1}, {72, 1}, {88, 1}}
+	if len(buckets) != len(want) {
+		t.Fatalf("Expected %d buckets, got %d: %v", len(want), len(buckets), buckets)
+	}
+	for i := range want {
+		if buckets[i] != want[i] {
+			t.Errorf("Bucket %d: expected %v, got %v", i, want[i], buckets[i])
+		}
+	}
+}
+
+// TestPrintEntropyHistogram tests the histogram text output
+func TestPrintEntropyHistogram(t *testing.T) {
+	var buf bytes.Buffer
+	printEntropyHistogram(&buf, []float64{65, 66, 70}, 8)
+
+	if !strings.Contains(buf.String(), "64-71   | ### 3") {
+		t.Errorf("Unexpected histogram output:\n%s", buf.String())
+	}
+}
+
+// TestPrintFilterStats tests the -filter-stats report
+func TestPrintFilterStats(t *testing.T) {
+	var buf bytes.Buffer
+	printFilterStats(&buf, passgen.RejectStats{
+		Candidates: 8,
+		Rejected:   map[string]int{"blocklist": 1, "keyboard walk": 3},
+		Elapsed:    1500 * time.Microsecond,
+	})
+	want := "Filters rejected 4 of 8 candidates (50.0%) in 2ms\n" +
+		"       3  keyboard walk\n" +
+		"       1  blocklist\n" +
+		"Entropy lost to the filters: about 1.00 bits per password\n"
+	if buf.String() != want {
+		t.Errorf("printFilterStats() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	printFilterStats(&buf, passgen.RejectStats{Candidates: 3})
+	if buf.String() != "Filters rejected 0 of 3 candidates (0.0%) in 0s\n" {
+		t.Errorf("printFilterStats() without rejections = %q", buf.String())
+	}
+}
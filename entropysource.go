@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// hwrngDevice is the Linux hardware random number generator, the source
+// named hwrng.
+const hwrngDevice = "/dev/hwrng"
+
+// openEntropySources mixes the named sources into the system random number
+// generator: hwrng, system (always included) or a file or device path. The
+// returned function closes them.
+func openEntropySources(names []string) (*passgen.MixedRandom, func(), error) {
+	m := passgen.NewMixedRandom()
+	var files []*os.File
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	for _, name := range names {
+		path := name
+		switch name {
+		case "system":
+			continue
+		case "hwrng":
+			path = hwrngDevice
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("entropy source %s: %w", name, err)
+		}
+		files = append(files, f)
+		m.Add(path, f)
+	}
+	return m, closeAll, nil
+}
+
+// printEntropyHealth reports how much each source gave and whether it
+// passed its health tests.
+func printEntropyHealth(w io.Writer, m *passgen.MixedRandom) {
+	for _, h := range m.Health() {
+		status := "healthy"
+		if h.Failure != "" {
+			status = "failed: " + h.Failure
+		}
+		fmt.Fprintf(w, "Entropy source %s: %d bytes, %s\n", h.Name, h.Bytes, status)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOpenEntropySources tests mixing files into the system RNG
+func TestOpenEntropySources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pad.bin")
+	if err := os.WriteFile(path, []byte("0123456789abcdef"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, closeSources, err := openEntropySources([]string{"system", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeSources()
+	if _, err := io.ReadFull(m, make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	// The file has run out
+	if _, err := m.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected an error once the file ran out")
+	}
+	var out strings.Builder
+	printEntropyHealth(&out, m)
+	// The system RNG was read before the file failed
+	want := "Entropy source system: 17 bytes, healthy\nEntropy source " + path + ": 16 bytes, failed: ran out of data\n"
+	if out.String() != want {
+		t.Errorf("Got %q, want %q", out.String(), want)
+	}
+
+	if _, _, err := openEntropySources([]string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("Expected an error for a missing source")
+	}
+}
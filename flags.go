@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// envPrefix starts the names of the environment variables that configure
+// flags, e.g. PASSGEN_LENGTH for --length.
+const envPrefix = "PASSGEN_"
+
+// stringList is a flag.Value collecting the values of a repeated flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// aliasFlag registers alias as another name for an already defined flag, so
+// `-l 16` and `--length 16` set the same value.
+func aliasFlag(fs *flag.FlagSet, name, alias string) {
+	f := fs.Lookup(name)
+	fs.Var(f.Value, alias, f.Usage)
+}
+
+// isFlagSet reports whether the named flag or one of its aliases was given
+// on the command line or in the environment, so defaults from elsewhere do
+// not override it.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	value := fs.Lookup(name).Value
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Value == value {
+			set = true
+		}
+	})
+	return set
+}
+
+// isBoolFlag reports whether f can be given without a value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// expandShortFlags splits GNU-style clusters of one-letter flags, so that
+// `-sc5` and `-sl 16` parse as `-s -c=5` and `-s -l 16`. Only arguments that
+// are not flags themselves or their values and consist entirely of known
+// one-letter flags are split; anything else, including `--` flags, is left
+// for the flag package to report.
+func expandShortFlags(fs *flag.FlagSet, args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			out = append(out, arg)
+			continue
+		}
+		body := arg[1:]
+		long := body[0] == '-'
+		if long {
+			body = body[1:]
+		}
+		name, _, hasValue := strings.Cut(body, "=")
+		if f := fs.Lookup(name); f != nil {
+			// A flag's value may itself start with a dash
+			out = append(out, arg)
+			if !hasValue && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				out = append(out, args[i])
+			}
+			continue
+		}
+		if cluster, ok := splitCluster(fs, body); !long && ok {
+			out = append(out, cluster...)
+		} else {
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+// splitCluster expands the letters of a short flag cluster. A letter taking a
+// value ends the cluster and receives the rest of it, if any.
+func splitCluster(fs *flag.FlagSet, cluster string) ([]string, bool) {
+	var out []string
+	for i, c := range cluster {
+		f := fs.Lookup(string(c))
+		if f == nil {
+			return nil, false
+		}
+		if isBoolFlag(f) {
+			out = append(out, "-"+string(c))
+			continue
+		}
+		if rest := cluster[i+1:]; rest != "" {
+			return append(out, "-"+string(c)+"="+rest), true
+		}
+		return append(out, "-"+string(c)), true
+	}
+	return out, true
+}
+
+// envName returns the environment variable for a flag: its name upper-cased
+// with dashes turned into underscores.
+func envName(flagName string) string {
+	return envPrefix + passgen.ToUpperASCII(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets flags from their PASSGEN_* environment variables, so CI
+// pipelines and containers can configure passgen without writing files.
+// Call it after parsing the command line: flags given there, under any
+// alias, keep their value, so a repeatable flag is replaced rather than
+// added to. One-letter flags are only reachable through their long alias,
+// and empty variables are ignored.
+func applyEnv(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || len(f.Name) == 1 || f.Name == "help" || isFlagSet(fs, f.Name) {
+			return
+		}
+		name := envName(f.Name)
+		value := os.Getenv(name)
+		if value == "" {
+			return
+		}
+		if e := fs.Set(f.Name, value); e != nil {
+			err = fmt.Errorf("%s: invalid value %q: %v", name, value, e)
+		}
+	})
+	return err
+}
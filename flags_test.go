@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestStringList tests collecting repeated flag values
+func TestStringList(t *testing.T) {
+	var s stringList
+	s.Set("a")
+	s.Set("b,c")
+	if len(s) != 2 || s[1] != "b,c" {
+		t.Errorf("Expected [a b,c], got %v", s)
+	}
+}
+
+// TestExpandShortFlags tests splitting clusters of one-letter flags
+func TestExpandShortFlags(t *testing.T) {
+	newFlags := func() *flag.FlagSet {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Int("l", 12, "")
+		fs.Bool("s", false, "")
+		fs.Int("c", 1, "")
+		fs.Bool("h", false, "")
+		fs.Bool("allow-similar", false, "")
+		fs.String("note", "", "")
+		fs.String("exclude", "", "")
+		return fs
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-sc5"}, []string{"-s", "-c=5"}},
+		{[]string{"-sl", "16"}, []string{"-s", "-l", "16"}},
+		{[]string{"-sh"}, []string{"-s", "-h"}},
+		{[]string{"-l", "16", "-s"}, []string{"-l", "16", "-s"}},
+		{[]string{"-allow-similar", "--length=16"}, []string{"-allow-similar", "--length=16"}},
+		{[]string{"-note", "-sc5"}, []string{"-note", "-sc5"}},
+		// The value of a -- flag is not a cluster either
+		{[]string{"--exclude", "-lc"}, []string{"--exclude", "-lc"}},
+		{[]string{"--exclude", "-sc", "-s"}, []string{"--exclude", "-sc", "-s"}},
+		{[]string{"--exclude=-lc", "-sc5"}, []string{"--exclude=-lc", "-s", "-c=5"}},
+		{[]string{"--sc5"}, []string{"--sc5"}},
+		{[]string{"-sx"}, []string{"-sx"}},
+		{[]string{"--", "-sc5"}, []string{"--", "-sc5"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			got := expandShortFlags(newFlags(), tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandShortFlags(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAliasFlag tests that long and short names set the same value
+func TestAliasFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	length := fs.Int("l", 12, "Password length")
+	special := fs.Bool("s", false, "Include special characters")
+	aliasFlag(fs, "l", "length")
+	aliasFlag(fs, "s", "special")
+
+	if err := fs.Parse([]string{"--length", "20", "--special"}); err != nil {
+		t.Fatal(err)
+	}
+	if *length != 20 || !*special {
+		t.Errorf("Expected length 20 and special, got %d and %v", *length, *special)
+	}
+}
+
+// TestIsFlagSet tests recognizing flags given under any of their names
+func TestIsFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("l", 12, "")
+	fs.Bool("s", false, "")
+	aliasFlag(fs, "l", "length")
+	if err := fs.Parse([]string{"--length", "20"}); err != nil {
+		t.Fatal(err)
+	}
+	if !isFlagSet(fs, "l") || !isFlagSet(fs, "length") {
+		t.Error("Expected -l to be set through its alias")
+	}
+	if isFlagSet(fs, "s") {
+		t.Error("Expected -s not to be set")
+	}
+}
+
+// TestApplyEnv tests that environment variables set defaults the command line overrides
+func TestApplyEnv(t *testing.T) {
+	newFlags := func() (*flag.FlagSet, *int, *bool, *string, *stringList) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		length := fs.Int("l", 12, "")
+		special := fs.Bool("s", false, "")
+		note := fs.String("min-note", "", "")
+		var rules stringList
+		fs.Var(&rules, "rule", "")
+		aliasFlag(fs, "l", "length")
+		aliasFlag(fs, "s", "special")
+		return fs, length, special, note, &rules
+	}
+
+	t.Setenv("PASSGEN_LENGTH", "20")
+	t.Setenv("PASSGEN_SPECIAL", "true")
+	t.Setenv("PASSGEN_MIN_NOTE", "from env")
+	t.Setenv("PASSGEN_RULE", "from env")
+
+	fs, length, special, note, rules := newFlags()
+	if err := fs.Parse([]string{"-l", "16", "-rule", "a", "-rule", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyEnv(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *length != 16 || !*special || *note != "from env" {
+		t.Errorf("Got length %d, special %v, note %q", *length, *special, *note)
+	}
+	// A repeatable flag on the command line replaces the variable
+	if !reflect.DeepEqual(*rules, stringList{"a", "b"}) {
+		t.Errorf("Got rules %q, want only those of the command line", *rules)
+	}
+
+	fs, _, _, _, rules = newFlags()
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyEnv(fs); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*rules, stringList{"from env"}) {
+		t.Errorf("Got rules %q, want the variable's", *rules)
+	}
+
+	t.Setenv("PASSGEN_LENGTH", "long")
+	fs, _, _, _, _ = newFlags()
+	if err := applyEnv(fs); err == nil || !strings.Contains(err.Error(), "PASSGEN_LENGTH") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
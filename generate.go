@@ -0,0 +1,1522 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// runGenerate implements the generate subcommand, which is also what runs
+// when passgen is invoked without a command.
+func runGenerate(programName string, args []string) error {
+	return generate(programName, args, nil)
+}
+
+// generate runs generate with args. With run, the passwords are drawn from
+// a seed: again from a transcript, or for commit not at all.
+func generate(programName string, args []string, run *drawRun) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	length := fs.Int("l", 12, "Password length")
+	includeSpecial := fs.Bool("s", false, "Include special characters")
+	count := fs.Int("c", 1, "Number of passwords to generate")
+	choices := fs.Int("choices", 0, "Show N candidates and output the one picked by number")
+	stream := fs.Bool("stream", false, "Deliver passwords in batches as they are generated, for any -c")
+	noUpper := fs.Bool("no-upper", false, "Leave out uppercase letters")
+	noLower := fs.Bool("no-lower", false, "Leave out lowercase letters")
+	noDigits := fs.Bool("no-digits", false, "Leave out digits")
+	digitsOnly := fs.Bool("digits-only", false, "Only use digits, like -no-upper -no-lower")
+	minUpper := fs.Int("min-upper", 0, "Minimum number of uppercase letters")
+	minLower := fs.Int("min-lower", 0, "Minimum number of lowercase letters")
+	minDigits := fs.Int("min-digits", 0, "Minimum number of digits")
+	minSpecial := fs.Int("min-special", 0, "Minimum number of special characters (implies -s)")
+	exclude := fs.String("exclude", "", "Never use any of these characters")
+	allowSimilar := fs.Bool("allow-similar", false, "Include similar-looking characters (0, O, I, l, 1)")
+	var charsets stringList
+	fs.Var(&charsets, "charset", "Also draw at least one character from these characters (repeatable)")
+	var filter unicodeFilter
+	fs.Var((*stringList)(&filter.IncludeCategories), "include-category", "Only use characters of this Unicode category (repeatable)")
+	fs.Var((*stringList)(&filter.ExcludeCategories), "exclude-category", "Never use characters of this Unicode category (repeatable)")
+	fs.Var((*stringList)(&filter.IncludeScripts), "include-script", "Only use characters of this Unicode script (repeatable)")
+	fs.Var((*stringList)(&filter.ExcludeScripts), "exclude-script", "Never use characters of this Unicode script (repeatable)")
+	ambiguityLevel := fs.String("ambiguity-level", "standard", "Look-alike characters to exclude: none, standard or extended")
+	histogram := fs.Bool("histogram", false, "Print a histogram of password entropy")
+	filterStats := fs.Bool("filter-stats", false, "Report how many candidates the filters rejected and why")
+	showEntropy := fs.Bool("show-entropy", false, "Show the theoretical entropy of each password")
+	minEntropy := fs.Float64("min-entropy", 0, "Flag passwords with less entropy than this many bits")
+	showStrength := fs.Bool("strength", false, "Show the estimated strength of each password")
+	minScore := fs.Int("min-score", 0, "Regenerate until the strength score is at least N (0-4)")
+	entropyTarget := fs.Float64("entropy", 0, "Use the shortest length that reaches this many bits of entropy")
+	markovCorpus := fs.String("markov", "", "Train a Markov model on FILE and generate from it")
+	markovOrder := fs.Int("markov-order", 2, "Characters of context used by the Markov model")
+	pronounceable := fs.Bool("pronounceable", false, "Alternate consonants and vowels so passwords can be spoken")
+	group := fs.Int("group", 0, "Split passwords into groups of N characters")
+	groupSep := fs.String("group-sep", "-", "Separator between groups")
+	groupInLength := fs.Bool("group-in-length", false, "Count the group separators in the length")
+	appleStyle := fs.Bool("apple-style", false, "Generate xxxxxx-xxxxxx-xxxxxx passwords like iCloud Keychain")
+	dualControl := fs.Bool("dual-control", false, "Generate each password as two halves for two people to enter")
+	var custodianOut stringList
+	fs.Var(&custodianOut, "custodian-out", "Append one half of each -dual-control password to a CSV file; give it twice")
+	anchorList := fs.String("anchor-word", "", "Embed one word of LIST (eff-long, eff-short or a wordlist file) among random characters")
+	patternSrc := fs.String("pattern", "", "Generate passwords from a KeePass-style pattern such as A{4}-d{4}-s{2}")
+	canary := fs.Bool("canary", false, "Generate canary credentials and record their hashes")
+	canaryDir := fs.String("canary-dir", defaultCanaryDir(), "Canary key and registry directory")
+	canaryCheck := fs.String("canary-check", "", "Scan FILE (or - for stdin) for canary credentials")
+	preview := fs.Bool("preview", false, "Show each password spaced out, bracketed and with its character codes")
+	homoglyphReport := fs.Bool("homoglyph-report", false, "Report look-alike characters and sequences in each password")
+	fingerprint := fs.String("fingerprint", "", "Also show a fingerprint of each password: hex or emoji")
+	fingerprintOnly := fs.Bool("fingerprint-only", false, "Show fingerprints instead of the passwords, which only go to the sinks")
+	hashFormat := fs.String("hash-format", "", "Also show a hash of each password: "+strings.Join(passgen.HashFormats(), ", "))
+	hashConfig := fs.String("hash-config", "", "Argon2id parameters saved by hash-tune -config FILE (default: hash-tune's file)")
+	withMetadata := fs.Bool("with-metadata", false, "Show when each -hash-format hash was created, so audits can see its age")
+	noConfusables := fs.Bool("no-confusables", false, "Reject passwords with look-alike sequences such as rn (m) or vv (w)")
+	hibp := fs.Bool("hibp", false, "Regenerate passwords found in Have I Been Pwned's Pwned Passwords")
+	hibpOffline := fs.String("hibp-offline", "", "Regenerate passwords in the Pwned Passwords bloom filter FILE")
+	offline := fs.Bool("offline", false, "Skip checks that need the network, such as -hibp")
+	noWalks := fs.Bool("no-keyboard-walks", false, "Reject passwords containing keyboard walks such as qwer or 1qaz")
+	noSequences := fs.Bool("no-sequences", false, "Reject passwords containing sequences such as abcd or 9876")
+	noRepeats := fs.Bool("no-repeats", false, "Reject passwords repeating a character three times in a row")
+	var avoid stringList
+	fs.Var(&avoid, "avoid", "Reject passwords containing any of these comma-separated words, ignoring case and l33t (repeatable)")
+	noWords := fs.Bool("no-words", false, "Reject passwords containing a word of four or more letters from the embedded wordlists")
+	maxAttempts := fs.Int("max-attempts", passgen.DefaultMaxAttempts, "Candidates to try for each password before giving up")
+	noBlocklist := fs.Bool("no-blocklist", false, "Allow passwords on the embedded list of common passwords")
+	var rules stringList
+	fs.Var(&rules, "rule", "Only accept passwords matching the expression (repeatable)")
+	presetName := fs.String("preset", "", "Defaults for a kind of secret: "+strings.Join(presetNames(), ", "))
+	temp := fs.Bool("temp", false, "Generate temporary passwords that must be changed at first login, with -preset temporary")
+	expires := fs.Duration("expires", 24*time.Hour, "How long -temp passwords are valid")
+	policyName := fs.String("policy", "", "Satisfy the password policy of a standard: "+strings.Join(standardNames(), ", "))
+	policyFile := fs.String("policy-file", "", "Satisfy the password policy defined in FILE")
+	site := fs.String("site", "", "Satisfy the known password rules of a site such as example.com")
+	var servers stringList
+	fs.Var(&servers, "server", "Device address of each secret for -o cisco or -o junos (repeatable)")
+	var targets stringList
+	fs.Var(&targets, "target", "Warn when the length does not suit the named service (repeatable)")
+	var encodingNames stringList
+	fs.Var(&encodingNames, "encoding", "Make passwords safe for a destination format (repeatable)")
+	encodingAction := fs.String("encoding-action", "escape", "How to make passwords safe: escape or regenerate")
+	rngTimeout := fs.Duration("rng-timeout", 0, "Fail if the system RNG does not respond within this duration")
+	dice := fs.Bool("dice", false, "Draw passwords from physical dice rolls or coin flips typed in instead of the system RNG")
+	var entropySources stringList
+	fs.Var(&entropySources, "entropy-source", "XOR the system RNG with hwrng or a file or device (repeatable)")
+	beacon := fs.String("beacon", "", "Mix a public drand beacon round into the passwords: drand or a drand API URL")
+	beaconRound := fs.Uint64("beacon-round", 0, "Beacon round to draw with (default: the next one)")
+	transcript := fs.String("transcript", "", "Write the proof of a -beacon draw to FILE")
+	replayFile := fs.String("replay", "", "Draw the passwords of a -beacon transcript or commit seal again")
+	var pluginNames stringList
+	fs.Var(&pluginNames, "plugin", "Enable the passgen-NAME plugin (repeatable)")
+	sinkRetries := fs.Int("sink-retries", defaultRetryPolicy.Retries, "Retries for failed sink plugin writes")
+	verifySink := fs.Bool("verify-sink", false, "Read every batch back from the sinks and compare it")
+	continueOnError := fs.Bool("continue-on-error", false, "Keep writing to the other sinks when one fails")
+	sinkBackoff := fs.Duration("sink-backoff", defaultRetryPolicy.Backoff, "Delay before the first sink retry")
+	listPlugins := fs.Bool("list-plugins", false, "List plugins found on PATH")
+	format := fs.String("o", "text", "Output format: text, json, csv, tsv, cisco or junos")
+	header := fs.Bool("header", false, "Start -o csv and tsv with a row of column names")
+	signKeyFile := fs.String("sign-key", "", "Sign JSON output with the HMAC secret or Ed25519 private key in FILE")
+	auditLog := fs.String("audit-log", "", "Append who generated what, with fingerprints instead of passwords, to FILE")
+	var auditSinks stringList
+	fs.Var(&auditSinks, "audit-sink", "Also send audit records to syslog or journald (repeatable)")
+	metricsOut := fs.String("metrics-out", "", "Append anonymized policy and strength metrics to FILE")
+	outFile := fs.String("out", "", "Also append the passwords to a CSV file")
+	vaultPath := fs.String("vault-path", "", "Also store the passwords at this Vault KV path")
+	copyOut := fs.Bool("copy", false, "Also copy the passwords to the clipboard")
+	label := fs.String("label", "", "Label stored with every password in JSON output and sinks")
+	note := fs.String("note", "", "Comment stored with every password in JSON output and sinks")
+	quiet := fs.Bool("q", false, "Print only the passwords, one per line")
+	mask := fs.Bool("mask", false, "Show passwords as bullets and reveal them one at a time on request")
+	colorMode := fs.String("color", "never", "Color digits and symbols apart from letters: auto, always or never")
+	templateSrc := fs.String("template", "", "Format each password with a Go text/template, e.g. '{{.Index}}: {{.Password}}'")
+	help := fs.Bool("h", false, "Show help message")
+	aliasFlag(fs, "q", "quiet")
+	aliasFlag(fs, "l", "length")
+	aliasFlag(fs, "s", "special")
+	aliasFlag(fs, "c", "count")
+	aliasFlag(fs, "entropy", "e")
+	aliasFlag(fs, "o", "format")
+	aliasFlag(fs, "h", "help")
+
+	fs.Usage = func() { printUsage(programName) }
+
+	if err := fs.Parse(expandShortFlags(fs, args)); err != nil {
+		return err
+	}
+	if err := applyEnv(fs); err != nil {
+		return err
+	}
+	if *help {
+		printUsage(programName)
+		return nil
+	}
+	if *listPlugins {
+		printPlugins()
+		return nil
+	}
+	if *replayFile != "" && run == nil {
+		t, err := loadDrawTranscript(*replayFile)
+		if err != nil {
+			return err
+		}
+		return replayDraw(programName, t, args)
+	}
+	var replay *drawTranscript
+	if run != nil {
+		replay = run.transcript
+		if *beacon != "" || *beaconRound != 0 || *transcript != "" {
+			return fmt.Errorf("-beacon, -beacon-round and -transcript cannot be combined with commit, reveal or -replay")
+		}
+	}
+	if replay != nil && !slices.Equal(drawArgs(fs), replay.Args) {
+		return fmt.Errorf("only options that deliver the passwords can be added to a replay; the draw was made with %s (check PASSGEN_ variables too)", strings.Join(replay.Args, " "))
+	}
+	if run != nil && run.sealPath != "" {
+		var local []string
+		fs.Visit(func(f *flag.Flag) {
+			if drawLocalFlags[f.Name] {
+				local = append(local, "-"+f.Name)
+			}
+		})
+		if len(local) > 0 {
+			return fmt.Errorf("commit only seals the options that shape the passwords; give %s to reveal", strings.Join(local, ", "))
+		}
+	}
+	if *canaryCheck != "" {
+		store, err := openCanaryStore(*canaryDir)
+		if err != nil {
+			return err
+		}
+		return runCanaryCheck(store, *canaryCheck)
+	}
+
+	// A temporary password is marked as one wherever it is delivered
+	var expiresAt time.Time
+	if *temp {
+		if *presetName != "" && !passgen.EqualFoldASCII(*presetName, tempPreset) {
+			return fmt.Errorf("-temp uses -preset %s and cannot be combined with -preset %s", tempPreset, *presetName)
+		}
+		if *expires <= 0 {
+			return fmt.Errorf("-expires must be positive")
+		}
+		*presetName = tempPreset
+		expiresAt = time.Now().Add(*expires).UTC().Truncate(time.Second)
+	} else if isFlagSet(fs, "expires") {
+		return fmt.Errorf("-expires requires -temp")
+	}
+	// A preset fills in what the command line leaves open
+	var devicePreset preset
+	if *presetName != "" {
+		p, err := lookupPreset(*presetName)
+		if err != nil {
+			return err
+		}
+		if !isFlagSet(fs, "l") {
+			*length = p.length
+		}
+		if !isFlagSet(fs, "s") {
+			*includeSpecial = p.special
+		}
+		*exclude += p.exclude
+		if p.target != "" {
+			targets = append(targets, p.target)
+		}
+		devicePreset = p
+	}
+	// A standard policy or a site's rules set minimums, which the final
+	// settings are checked against once the length is known
+	var standard *standardPolicy
+	if *policyName != "" || *policyFile != "" || *site != "" {
+		var s standardPolicy
+		var err error
+		switch {
+		case *policyName != "" && *policyFile != "", *site != "" && (*policyName != "" || *policyFile != ""):
+			return fmt.Errorf("only one of -policy, -policy-file and -site can be given")
+		case *policyFile != "":
+			s, err = loadPolicyFile(*policyFile)
+		case *site != "":
+			s, err = loadSite(*site)
+		default:
+			s, err = lookupStandard(*policyName)
+		}
+		if errors.Is(err, errNoSiteRules) {
+			// Most sites take what passgen generates by default
+			fmt.Fprintf(os.Stderr, "Warning: %v; using the defaults\n", err)
+		} else if err != nil {
+			return err
+		} else {
+			standard = &s
+		}
+	}
+	if standard != nil {
+		s := standard
+		if *markovCorpus != "" || *pronounceable || *patternSrc != "" || *appleStyle {
+			return fmt.Errorf("-policy, -policy-file and -site cannot be combined with -markov, -pronounceable, -pattern or -apple-style")
+		}
+		if !isFlagSet(fs, "l") && *presetName == "" {
+			*length = s.length
+		}
+		if !isFlagSet(fs, "s") {
+			*includeSpecial = *includeSpecial || s.special
+		}
+		*minUpper = max(*minUpper, s.policy.MinUpper)
+		*minLower = max(*minLower, s.policy.MinLower)
+		*minDigits = max(*minDigits, s.policy.MinDigits)
+		*minSpecial = max(*minSpecial, s.policy.MinSpecial)
+		*exclude += s.policy.Exclude
+	}
+	// Device snippets hold one secret per server
+	writeDevice := deviceFormats[*format]
+	if writeDevice != nil {
+		if devicePreset.protocol == "" {
+			return fmt.Errorf("-o %s needs a device preset such as -preset radius or -preset tacacs", *format)
+		}
+		if len(servers) == 0 {
+			return fmt.Errorf("-o %s needs the -server address of every device", *format)
+		}
+		if !isFlagSet(fs, "c") {
+			*count = len(servers)
+		} else if *count != len(servers) {
+			return fmt.Errorf("-c %d does not match the %d -server addresses", *count, len(servers))
+		}
+	} else if len(servers) > 0 {
+		return fmt.Errorf("-server only applies to -o cisco and -o junos")
+	}
+	serverIPs := make([]net.IP, len(servers))
+	for i, server := range servers {
+		if serverIPs[i] = net.ParseIP(server); serverIPs[i] == nil {
+			return fmt.Errorf("-server %q is not an IP address", server)
+		}
+	}
+
+	// A pattern fixes the length and the class of every position
+	var pattern *passgen.Pattern
+	if *patternSrc != "" {
+		if *markovCorpus != "" || *pronounceable || *canary {
+			return fmt.Errorf("-pattern cannot be combined with -markov, -pronounceable or -canary")
+		}
+		if *minUpper > 0 || *minLower > 0 || *minDigits > 0 || *minSpecial > 0 {
+			return fmt.Errorf("-pattern cannot be combined with --min-upper, --min-lower, --min-digits or --min-special")
+		}
+		p, err := passgen.CompilePattern(*patternSrc)
+		if err != nil {
+			return err
+		}
+		pattern = p
+		*length = pattern.Len()
+	}
+	if *appleStyle {
+		if *markovCorpus != "" || *pronounceable || *canary || pattern != nil {
+			return fmt.Errorf("-apple-style cannot be combined with -markov, -pronounceable, -canary or -pattern")
+		}
+		if *minUpper > 0 || *minLower > 0 || *minDigits > 0 || *minSpecial > 0 {
+			return fmt.Errorf("-apple-style cannot be combined with --min-upper, --min-lower, --min-digits or --min-special")
+		}
+		*length = passgen.AppleStyleLength
+	}
+	if *dualControl {
+		switch {
+		case *markovCorpus != "" || *pronounceable || *canary || pattern != nil || *appleStyle || *anchorList != "":
+			return fmt.Errorf("-dual-control cannot be combined with -markov, -pronounceable, -canary, -pattern, -apple-style or -anchor-word")
+		case *group > 0 || *quiet || *templateSrc != "" || *choices > 0 || *preview:
+			return fmt.Errorf("-dual-control cannot be combined with -group, -q, -template, -choices or -preview, which show the whole password")
+		case *format != "text" && *format != "json" && *format != "ndjson":
+			return fmt.Errorf("-dual-control requires -o text, json or ndjson, which can show the halves")
+		}
+		// Custodians get their halves only, so nobody sees the whole
+		if len(custodianOut) > 0 {
+			if *format != "text" {
+				return fmt.Errorf("-custodian-out cannot be combined with -o %s, which would show the whole password", *format)
+			}
+			*fingerprintOnly = true
+		}
+	} else if len(custodianOut) > 0 {
+		return fmt.Errorf("-custodian-out requires -dual-control")
+	}
+	if *anchorList != "" {
+		if *markovCorpus != "" || *pronounceable || *canary || pattern != nil || *appleStyle {
+			return fmt.Errorf("-anchor-word cannot be combined with -markov, -pronounceable, -canary, -pattern or -apple-style")
+		}
+		if *noWords {
+			return fmt.Errorf("-anchor-word cannot be combined with -no-words, which rejects the word")
+		}
+	}
+	// A target entropy sets the length once the character sets are known
+	if *entropyTarget < 0 {
+		return fmt.Errorf("target entropy cannot be negative")
+	}
+	if *entropyTarget > 0 {
+		if *markovCorpus != "" || *canary || pattern != nil || *appleStyle || *groupInLength {
+			return fmt.Errorf("-entropy cannot be combined with -markov, -canary, -pattern, -apple-style or -group-in-length")
+		}
+	}
+
+	// Grouping works on the generated characters; counting the separators
+	// in the length leaves fewer of them
+	if *group < 0 {
+		return fmt.Errorf("group size cannot be negative")
+	}
+	if *group > 0 {
+		if *canary {
+			return fmt.Errorf("-group cannot be combined with -canary")
+		}
+		if *groupSep == "" {
+			return fmt.Errorf("-group-sep cannot be empty")
+		}
+		if *groupInLength {
+			if pattern != nil || *appleStyle {
+				return fmt.Errorf("-group-in-length cannot be combined with -pattern or -apple-style, which fix the length")
+			}
+			n, err := passgen.UngroupedLength(*length, *group, *groupSep)
+			if err != nil {
+				return err
+			}
+			*length = n
+		}
+	}
+
+	// Validate input
+	if *length < minLength {
+		return fmt.Errorf("password length must be at least %d", minLength)
+	}
+	if *length > maxLength {
+		return fmt.Errorf("password length cannot exceed %d", maxLength)
+	}
+	if *count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+	if *count > maxCount && !*stream {
+		return fmt.Errorf("count cannot exceed %d without -stream", maxCount)
+	}
+	if *sinkRetries < 0 || *sinkBackoff < 0 {
+		return fmt.Errorf("sink retries and backoff cannot be negative")
+	}
+	if *minEntropy < 0 {
+		return fmt.Errorf("minimum entropy cannot be negative")
+	}
+	if *digitsOnly {
+		if *includeSpecial || *noDigits {
+			return fmt.Errorf("-digits-only cannot be combined with -s or -no-digits")
+		}
+		*noUpper, *noLower = true, true
+	}
+	if *minSpecial > 0 {
+		*includeSpecial = true
+	}
+	// A separator inside a group would blur where groups end
+	if *group > 0 && (*includeSpecial || len(charsets) > 0 || pattern != nil) {
+		*exclude += *groupSep
+	}
+	if *appleStyle && *includeSpecial {
+		return fmt.Errorf("-apple-style cannot be combined with -s")
+	}
+	if *includeSpecial && *length < 4 && pattern == nil {
+		return fmt.Errorf("password length must be at least 4 when using special characters")
+	}
+	if *canary && *length < 8 {
+		return fmt.Errorf("password length must be at least 8 for canary credentials")
+	}
+	// Formats that write each password as it is generated
+	lineFormat := tableFormats[*format] || *format == "ndjson"
+	if writeDevice == nil && !lineFormat && checkOutputFormat(*format) != nil {
+		return fmt.Errorf("unknown output format %q (use text, json, ndjson, csv, tsv, cisco or junos)", *format)
+	}
+	if *header && !tableFormats[*format] {
+		return fmt.Errorf("-header requires -o csv or tsv")
+	}
+	if *fingerprintOnly && *fingerprint == "" {
+		*fingerprint = "hex"
+	}
+	fingerprintOf, err := fingerprinter(*fingerprint)
+	if err != nil {
+		return err
+	}
+	var hasher *passgen.Hasher
+	if *hashFormat != "" {
+		if writeDevice != nil {
+			return fmt.Errorf("-hash-format cannot be combined with -o cisco or junos, which hash for the device")
+		}
+		if hasher, err = newHasher(*hashFormat, *hashConfig); err != nil {
+			return err
+		}
+	}
+	if *withMetadata && hasher == nil {
+		return fmt.Errorf("-with-metadata requires -hash-format")
+	}
+	if *fingerprintOnly && (*format != "text" || *preview || *homoglyphReport) {
+		return fmt.Errorf("-fingerprint-only cannot be combined with -o json, ndjson, csv, tsv, cisco or junos, -preview or -homoglyph-report")
+	}
+	if *quiet {
+		switch {
+		case *format != "text":
+			return fmt.Errorf("-q only applies to text output; -o %s prints no banner", *format)
+		case *fingerprint != "" || *hashFormat != "" || *preview || *homoglyphReport || *showStrength || *showEntropy:
+			return fmt.Errorf("-q prints only the passwords and cannot be combined with -fingerprint, -fingerprint-only, -hash-format, -preview, -homoglyph-report, -strength or -show-entropy")
+		}
+	}
+	color, err := useColor(*colorMode, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if *mask {
+		switch {
+		case *format != "text":
+			return fmt.Errorf("-mask only applies to text output on a terminal")
+		case *quiet || *templateSrc != "" || *fingerprintOnly || *stream || *preview || *homoglyphReport || *dualControl || *choices > 0:
+			return fmt.Errorf("-mask cannot be combined with -q, -template, -fingerprint-only, -stream, -preview, -homoglyph-report, -dual-control or -choices")
+		case !isTerminal(os.Stdin) || !isTerminal(os.Stdout):
+			return fmt.Errorf("-mask needs a terminal to reveal the passwords on")
+		}
+	}
+	var tmpl *template.Template
+	if *templateSrc != "" {
+		switch {
+		case *format != "text":
+			return fmt.Errorf("-template replaces the text output and cannot be combined with -o %s", *format)
+		case *quiet || *fingerprintOnly || *preview || *homoglyphReport || *showStrength:
+			return fmt.Errorf("-template cannot be combined with -q, -fingerprint-only, -preview, -homoglyph-report or -strength")
+		}
+		if tmpl, err = parseLineTemplate(*templateSrc); err != nil {
+			return err
+		}
+	}
+	// Streaming keeps no more than a batch, and these need the whole run
+	if *stream && (*format != "text" && !lineFormat || *histogram || *metricsOut != "" || *vaultPath != "" || *copyOut) {
+		return fmt.Errorf("-stream cannot be combined with -o json, cisco or junos, -histogram, -metrics-out, -vault-path or -copy, which need every password at once")
+	}
+	ambiguity, err := parseAmbiguity(*ambiguityLevel, *allowSimilar)
+	if err != nil {
+		return err
+	}
+	jsonOutput := *format == "json"
+	// Structured output replaces the human-readable listing on stdout
+	structured := *format != "text"
+	var table *tableWriter
+	if tableFormats[*format] {
+		table = newTableWriter(os.Stdout, *format)
+	}
+	var lines *lineWriter
+	if *format == "ndjson" {
+		lines = newLineWriter(os.Stdout)
+	}
+	var signKey *signingKey
+	if *signKeyFile != "" {
+		if !jsonOutput {
+			return fmt.Errorf("-sign-key requires -o json")
+		}
+		if signKey, err = loadSigningKey(*signKeyFile); err != nil {
+			return err
+		}
+		if _, err := signKey.sign(nil); err != nil {
+			return fmt.Errorf("%s: %w", *signKeyFile, err)
+		}
+	}
+
+	// Make sure entropy is available before doing any real work
+	if *rngTimeout > 0 {
+		if err := passgen.CheckRandom(*rngTimeout); err != nil {
+			return err
+		}
+	}
+
+	// Compile rules and load plugins before printing anything so mistakes fail cleanly
+	genOpts := []passgen.GeneratorOption{
+		passgen.WithLength(*length),
+		passgen.WithSpecial(*includeSpecial),
+		passgen.WithSimilar(*allowSimilar),
+		passgen.WithAmbiguity(ambiguity),
+		passgen.WithExclude(*exclude),
+		passgen.WithMaxAttempts(*maxAttempts),
+	}
+	genOpts = append(genOpts, minCountOptions(*minUpper, *minLower, *minDigits, *minSpecial)...)
+	genOpts = append(genOpts, disableOptions(*noUpper, *noLower, *noDigits)...)
+	for _, charset := range charsets {
+		genOpts = append(genOpts, passgen.WithCharset(charset))
+	}
+	filterOpts, err := filter.options()
+	if err != nil {
+		return err
+	}
+	genOpts = append(genOpts, filterOpts...)
+	if *pronounceable {
+		if *markovCorpus != "" {
+			return fmt.Errorf("-pronounceable cannot be combined with -markov")
+		}
+		genOpts = append(genOpts, passgen.WithPronounceable())
+	}
+	if pattern != nil {
+		genOpts = append(genOpts, passgen.WithPattern(pattern))
+	}
+	if *appleStyle {
+		genOpts = append(genOpts, passgen.WithAppleStyle())
+	}
+	if *dualControl {
+		genOpts = append(genOpts, passgen.WithDualControl())
+	}
+	var anchorWords []string
+	if *anchorList != "" {
+		if anchorWords, err = anchorWordlist(*anchorList); err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithAnchorWord(anchorWords))
+	}
+	if *markovCorpus != "" {
+		model, err := loadMarkovModel(*markovCorpus, *markovOrder)
+		if err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithMarkov(model))
+	}
+	for _, expr := range rules {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithRules(rule))
+	}
+	if *noWalks {
+		genOpts = append(genOpts, passgen.WithoutKeyboardWalks(passgen.DefaultMaxKeyboardWalk))
+	}
+	if *noSequences {
+		genOpts = append(genOpts, passgen.WithoutSequences(passgen.DefaultMaxSequence))
+	}
+	if *noRepeats {
+		genOpts = append(genOpts, passgen.WithoutRepeats(passgen.DefaultMaxRun))
+	}
+	if standard != nil && standard.policy.MaxRun > 0 {
+		genOpts = append(genOpts, passgen.WithoutRepeats(standard.policy.MaxRun))
+	}
+	if standard != nil && standard.policy.Blocklist != nil {
+		genOpts = append(genOpts, passgen.WithBlocklist(standard.policy.Blocklist))
+	}
+	if *noWords {
+		genOpts = append(genOpts, passgen.WithoutDictionaryWords(embeddedDictionary()))
+	}
+	if words := avoidWords(avoid); len(words) > 0 {
+		genOpts = append(genOpts, passgen.WithAvoid(words...))
+	}
+	if *minScore != 0 {
+		genOpts = append(genOpts, passgen.WithMinScore(*minScore))
+	}
+	if !*noBlocklist {
+		genOpts = append(genOpts, passgen.WithBlocklist(commonBlocklist()))
+	}
+	breached, err := breachOption(*hibp, *hibpOffline, *offline)
+	if err != nil {
+		return err
+	}
+	if breached != nil {
+		genOpts = append(genOpts, passgen.WithPostGenerate(rejectBreached(breached)))
+	}
+	if *noConfusables {
+		genOpts = append(genOpts, passgen.WithoutConfusables())
+	}
+	escapeFor, encOpts, err := encodingOptions(encodingNames, *encodingAction)
+	if err != nil {
+		return err
+	}
+	if *dualControl && escapeFor != nil {
+		return fmt.Errorf("-dual-control cannot escape passwords for an -encoding, since each half is entered as it is")
+	}
+	genOpts = append(genOpts, encOpts...)
+
+	if *entropyTarget > 0 {
+		n, err := entropyLength(*entropyTarget, genOpts)
+		if err != nil {
+			return err
+		}
+		*length = n
+		genOpts = append(genOpts, passgen.WithLength(n))
+	}
+	if standard != nil {
+		classes := 0
+		for _, used := range []bool{!*noUpper, !*noLower, !*noDigits, *includeSpecial} {
+			if used {
+				classes++
+			}
+		}
+		if err := standard.check(*length, classes, !*noBlocklist); err != nil {
+			return err
+		}
+	}
+
+	if *choices != 0 {
+		switch {
+		case *choices < 2 || *choices > maxChoices:
+			return fmt.Errorf("-choices must be between 2 and %d", maxChoices)
+		case *count != 1:
+			return fmt.Errorf("-choices picks a single password and cannot be combined with -c")
+		case *beacon != "" || run != nil || *dice:
+			return fmt.Errorf("-choices cannot be combined with -beacon, -dice, commit or reveal, whose passwords are not picked")
+		case *fingerprintOnly:
+			return fmt.Errorf("-choices cannot be combined with -fingerprint-only, which never shows the password")
+		}
+	}
+	if (*beacon != "" || run != nil) && (*dice || len(entropySources) > 0 || *markovCorpus != "" || *pronounceable || *appleStyle || *canary) {
+		return fmt.Errorf("-beacon cannot be combined with -dice, -entropy-source, -markov, -pronounceable, -apple-style or -canary")
+	}
+	// Dice replace the system RNG, so they have to carry all the entropy of
+	// the passwords drawn from them
+	var diceEntropy *passgen.DiceEntropy
+	if *dice {
+		if *markovCorpus != "" || *pronounceable || *appleStyle || *canary {
+			return fmt.Errorf("-dice cannot be combined with -markov, -pronounceable, -apple-style or -canary, which use the system RNG")
+		}
+		probe, err := passgen.NewGenerator(genOpts...)
+		if err != nil {
+			return err
+		}
+		bits, err := probe.KeyspaceEntropy()
+		if err != nil {
+			return err
+		}
+		need := min(int(math.Ceil(bits*float64(*count))), maxDiceBits)
+		if diceEntropy, err = readDiceEntropy(os.Stdin, os.Stderr, need); err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithRandom(diceEntropy.Reader()))
+	}
+	// Mixed sources only ever add to the system RNG, and are accounted for
+	// even when one fails
+	var mixed *passgen.MixedRandom
+	if len(entropySources) > 0 {
+		if *dice {
+			return fmt.Errorf("-entropy-source cannot be combined with -dice, which replaces the system RNG")
+		}
+		if *markovCorpus != "" || *pronounceable || *appleStyle {
+			return fmt.Errorf("-entropy-source cannot be combined with -markov, -pronounceable or -apple-style, which use the system RNG")
+		}
+		m, closeSources, err := openEntropySources(entropySources)
+		if err != nil {
+			return err
+		}
+		mixed = m
+		defer closeSources()
+		defer printEntropyHealth(os.Stderr, mixed)
+		genOpts = append(genOpts, passgen.WithRandom(mixed))
+	}
+	// A draw commits to local randomness before the beacon round exists,
+	// so neither the operator nor the beacon picks the passwords alone
+	draw := replay
+	switch {
+	case run != nil && run.sealPath != "":
+		if draw, err = newSeal(drawArgs(fs), nil); err != nil {
+			return err
+		}
+	case *beacon != "":
+		if *transcript == "" {
+			return fmt.Errorf("-beacon needs -transcript FILE to record the proof of the draw")
+		}
+		if _, err := os.Stat(*transcript); err == nil {
+			return fmt.Errorf("transcript %s already exists", *transcript)
+		}
+		if draw, err = newDraw(*beacon, *beaconRound, drawArgs(fs), os.Stderr); err != nil {
+			return err
+		}
+	case *transcript != "" || *beaconRound != 0:
+		return fmt.Errorf("-transcript and -beacon-round need -beacon")
+	}
+	if draw != nil {
+		seed, err := draw.seed()
+		if err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithRandom(rand.NewChaCha8(seed)))
+	}
+
+	// Services that truncate or reject long passwords cause silent lockouts
+	for _, name := range targets {
+		target, err := lookupTarget(name)
+		if err != nil {
+			return err
+		}
+		if warning := target.check(passgen.GroupedLength(*length, *group, *groupSep)); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	// Canaries reserve the end of the password for the marker
+	var canaries *canaryStore
+	if *canary {
+		store, err := openCanaryStore(*canaryDir)
+		if err != nil {
+			return err
+		}
+		canaries = store
+		genOpts = append(genOpts,
+			passgen.WithLength(*length-canaryTagLength),
+			passgen.WithPreOutput(func(password string) (string, error) { return canaries.mark(password), nil }))
+	}
+
+	gen, err := passgen.NewGenerator(genOpts...)
+	if err != nil {
+		return err
+	}
+	opts := gen.Options()
+	sinks, err := enablePlugins(pluginNames, gen.Pipeline())
+	if err != nil {
+		return err
+	}
+	// Custodians must be the only ones to receive anything of a password
+	if len(custodianOut) > 0 && (len(sinks) > 0 || *outFile != "" || *vaultPath != "" || *copyOut) {
+		return fmt.Errorf("-custodian-out cannot be combined with -out, -vault-path, -copy or a sink -plugin, which would receive the whole password")
+	}
+	sinks = withRetry(sinks, retryPolicy{Retries: *sinkRetries, Backoff: *sinkBackoff})
+	if *outFile != "" {
+		out, err := newCSVSink(*outFile)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, out)
+	}
+	if *vaultPath != "" {
+		vault, err := newVaultSink(*vaultPath)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, vault)
+	}
+	if len(custodianOut) > 0 {
+		custodians, err := newCustodianSinks(custodianOut)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, custodians...)
+	}
+	// The clipboard goes last: it cannot be rolled back, and it is the
+	// only sink that never has to be
+	if *copyOut {
+		clipboard, err := newClipboardSink()
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, clipboard)
+	}
+	sinks = orderSinks(sinks)
+	// Connect to the system logs up front so a missing daemon fails the
+	// run before any password is handed out
+	var audits []auditSink
+	for _, name := range auditSinks {
+		sink, err := openAuditSink(name)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+		audits = append(audits, sink)
+	}
+	var auditKey []byte
+	if *auditLog != "" || len(audits) > 0 {
+		if auditKey, err = loadAuditKey(auditKeyPath(*auditLog), true); err != nil {
+			return err
+		}
+	}
+	if *fingerprintOnly && len(sinks) == 0 {
+		return fmt.Errorf("-fingerprint-only needs -out, -vault-path, -copy or a sink -plugin")
+	}
+	if *verifySink {
+		if len(sinks) == 0 {
+			return fmt.Errorf("-verify-sink needs -out or a sink -plugin")
+		}
+		var unsupported []string
+		sinks, unsupported = withVerify(sinks)
+		for _, name := range unsupported {
+			fmt.Fprintf(os.Stderr, "Warning: %s cannot be read back and is not verified\n", name)
+		}
+	}
+
+	// commit seals the seed and options instead of generating
+	if run != nil && run.sealPath != "" {
+		if err := writeDrawTranscript(run.sealPath, draw); err != nil {
+			return fmt.Errorf("writing seal: %w", err)
+		}
+		fmt.Println(draw.Commitment)
+		return nil
+	}
+
+	// Generate passwords
+	if table != nil && *header {
+		columns := []string{"index", "label", "password", "entropy"}
+		if fingerprintOf != nil {
+			columns = append(columns, "fingerprint")
+		}
+		if hasher != nil {
+			columns = append(columns, "hash")
+		}
+		if *withMetadata {
+			columns = append(columns, "created")
+		}
+		if *temp {
+			columns = append(columns, "must_change", "expires_at")
+		}
+		if err := table.write(columns...); err != nil {
+			return err
+		}
+	}
+	if !structured && !*quiet && tmpl == nil {
+		plural := ""
+		if *count > 1 {
+			plural = "s"
+		}
+
+		fmt.Printf("Generated password%s:\n", plural)
+		fmt.Printf("Length: %d characters\n", *length)
+		if *presetName != "" {
+			fmt.Printf("Preset: %s, %s\n", devicePreset.name, devicePreset.summary)
+		}
+		if *temp {
+			fmt.Printf("Temporary: must be changed at first login, expires %s\n", expiresAt.Format(time.RFC3339))
+		}
+		if standard != nil {
+			fmt.Printf("Policy: %s\n", standard.title)
+			for _, note := range standard.policy.Notes {
+				fmt.Printf("Note: %s\n", note)
+			}
+		}
+		if *entropyTarget > 0 {
+			fmt.Printf("Target entropy: %g bits\n", *entropyTarget)
+		}
+		if diceEntropy != nil {
+			fmt.Printf("Randomness: %d dice rolls and %d coin flips, debiased to %d bits\n", diceEntropy.Rolls, diceEntropy.Flips, diceEntropy.Bits())
+		}
+		if mixed != nil {
+			var names []string
+			for _, h := range mixed.Health() {
+				names = append(names, h.Name)
+			}
+			fmt.Printf("Randomness: %s\n", strings.Join(names, " XOR "))
+		}
+		if draw != nil && draw.Beacon != nil {
+			fmt.Printf("Randomness: beacon round %d and a local seed with commitment %s\n", draw.Beacon.Round, draw.Commitment)
+		} else if draw != nil {
+			fmt.Printf("Randomness: sealed seed with commitment %s\n", draw.Commitment)
+		}
+		if opts.Model != nil {
+			fmt.Printf("Markov model: order %d, trained on %d words\n", opts.Model.Order(), opts.Model.Words())
+		} else if opts.Pronounceable {
+			fmt.Println("Pronounceable: alternating consonants and vowels")
+		} else if opts.AppleStyle {
+			fmt.Println("Apple style: three groups of six lowercase letters, one digit and one uppercase letter")
+		} else if opts.Pattern != nil {
+			fmt.Printf("Pattern: %s\n", opts.Pattern)
+			if similar := opts.ExcludedSimilar(); similar != "" {
+				fmt.Printf("Excluded similar characters: %s\n", strings.Join(strings.Split(similar, ""), ", "))
+			}
+			if *exclude != "" {
+				fmt.Printf("Excluded characters: %s\n", *exclude)
+			}
+		} else {
+			if *anchorList != "" {
+				fmt.Printf("Anchor word: one word of %s at a random position\n", *anchorList)
+			}
+			if opts.DualControl {
+				first, second := (*length+1)/2, *length/2
+				fmt.Printf("Dual control: halves of %d and %d characters, each with every character set\n", first, second)
+			}
+			fmt.Printf("Character sets: %s\n", strings.Join(charsetNames(opts, charsets), ", "))
+			if m := describeMinCounts(opts.MinCounts); m != "" {
+				fmt.Printf("Minimums: %s\n", m)
+			}
+			if similar := opts.ExcludedSimilar(); similar != "" {
+				fmt.Printf("Excluded similar characters: %s\n", strings.Join(strings.Split(similar, ""), ", "))
+			}
+			if *exclude != "" {
+				fmt.Printf("Excluded characters: %s\n", *exclude)
+			}
+			if f := filter.String(); f != "" {
+				fmt.Printf("Unicode: %s\n", f)
+			}
+		}
+		if len(pluginNames) > 0 {
+			fmt.Printf("Plugins: %s\n", pluginNames.String())
+		}
+		if len(encodingNames) > 0 {
+			fmt.Printf("Encoding: %s (%s)\n", encodingNames.String(), *encodingAction)
+		}
+		if canaries != nil {
+			fmt.Printf("Canary: marked and recorded in %s\n", canaries.dir)
+		}
+		if *group > 0 {
+			fmt.Printf("Groups: %d characters separated by %q, %d characters in all\n",
+				*group, *groupSep, passgen.GroupedLength(*length, *group, *groupSep))
+		}
+		if *label != "" {
+			fmt.Printf("Label: %s\n", *label)
+		}
+		if *note != "" {
+			fmt.Printf("Note: %s\n", *note)
+		}
+		fmt.Println()
+	}
+
+	// Every password of the options shares one keyspace, so it is only
+	// computed once; Markov output has none and shows its own entropy
+	var keyspace float64
+	if (*showEntropy || tmpl != nil) && opts.Model == nil {
+		if keyspace, err = gen.KeyspaceEntropy(); err != nil {
+			return err
+		}
+	}
+	charsetsOut := outputCharsets(opts)
+	mode := "random"
+	switch {
+	case canaries != nil:
+		mode = "canary"
+	case opts.Model != nil:
+		mode = "markov"
+	case opts.Pronounceable:
+		mode = "pronounceable"
+	case opts.Pattern != nil:
+		mode = "pattern"
+	case opts.AppleStyle:
+		mode = "apple-style"
+	case len(opts.AnchorWords) > 0:
+		mode = "anchor-word"
+	case opts.DualControl:
+		mode = "dual-control"
+	}
+	delivered := deliveredTo(sinks, !*fingerprintOnly)
+
+	// Without -stream the whole run is one batch. With it, every batch is
+	// delivered, recorded and dropped before the next is generated, so
+	// memory does not grow with -c
+	batchSize := *count
+	if *stream {
+		batchSize = min(*count, streamBatchSize)
+	}
+	passwords := make([]string, 0, batchSize)
+	entropies := make([]float64, 0, batchSize)
+	results := make([]passwordOutput, 0, batchSize)
+	var drawn []string
+	// hidden holds what -mask reveals on request
+	var hidden []string
+	generated := 0
+	flush := func() error {
+		first := generated - len(passwords) + 1
+		if table != nil {
+			if err := table.flush(); err != nil {
+				return err
+			}
+		}
+		if canaries != nil {
+			if err := canaries.record(passwords); err != nil {
+				return fmt.Errorf("recording canaries: %w", err)
+			}
+		}
+
+		// Files and vaults have no field for the expiry, so the note
+		// carries it
+		credNote := *note
+		if *temp && credNote == "" {
+			credNote = "temporary password, change at first login, expires " + expiresAt.Format(time.RFC3339)
+		}
+		creds := make([]credential, len(passwords))
+		for i, password := range passwords {
+			creds[i] = credential{Label: *label, Password: password, Note: credNote}
+		}
+		if err := writeSinks(sinks, creds, *continueOnError); err != nil {
+			if *stream && first > 1 {
+				return fmt.Errorf("passwords %d to %d: %w (the earlier passwords were delivered)", first, generated, err)
+			}
+			return err
+		}
+
+		if *auditLog != "" || len(audits) > 0 {
+			rec := auditRecord{
+				Command:     "generate",
+				Mode:        mode,
+				Label:       *label,
+				Note:        *note,
+				Count:       len(passwords),
+				Length:      *length,
+				DeliveredTo: delivered,
+			}
+			for _, password := range passwords {
+				rec.Fingerprints = append(rec.Fingerprints, auditFingerprint(auditKey, password))
+			}
+			now := time.Now()
+			if *auditLog != "" {
+				if err := appendAudit(*auditLog, rec, now); err != nil {
+					return fmt.Errorf("writing audit log: %w", err)
+				}
+			}
+			for i, sink := range audits {
+				if err := sink.write(stampAudit(rec, now)); err != nil {
+					return fmt.Errorf("writing audit record to %s: %w", auditSinks[i], err)
+				}
+			}
+		}
+
+		// Flag weak outliers so they are not handed out unnoticed
+		for i, bits := range entropies {
+			if bits < *minEntropy {
+				fmt.Fprintf(os.Stderr, "Warning: password %d has %.1f bits of entropy (below %.1f)\n", first+i, bits, *minEntropy)
+			}
+		}
+		return nil
+	}
+
+	emit := func(password string) error {
+		if len(passwords) == batchSize {
+			passwords, entropies, results = passwords[:0], entropies[:0], results[:0]
+		}
+		generated++
+		bits, err := gen.Entropy(password)
+		if err != nil {
+			return fmt.Errorf("generating password: %w", err)
+		}
+		// Picking one of N by its looks can leave out all but the Nth
+		// most likely, which costs up to log2 N bits
+		if *choices > 0 {
+			bits -= math.Log2(float64(*choices))
+		}
+		// Separators add no entropy, so they are added after estimating it
+		if *group > 0 {
+			password = passgen.Group(password, *group, *groupSep)
+		}
+		shown := password
+		if escapeFor != nil {
+			if shown, err = escapeFor.Escape(password); err != nil {
+				return fmt.Errorf("generating password: %w", err)
+			}
+		}
+		passwords = append(passwords, password)
+		entropies = append(entropies, bits)
+		if draw != nil {
+			drawn = append(drawn, passgen.Fingerprint(password))
+		}
+		result := passwordOutput{Label: *label, Password: password, Entropy: bits, KeyspaceEntropy: keyspace, Note: *note}
+		if shown != password {
+			result.Escaped = shown
+		}
+		var confusables []string
+		if *homoglyphReport {
+			for _, c := range passgen.FindConfusables(password) {
+				confusables = append(confusables, c.String())
+			}
+			result.Confusables = confusables
+		}
+		if fingerprintOf != nil {
+			result.Fingerprint = fingerprintOf(password)
+		}
+		if hasher != nil {
+			if result.Hash, err = hasher.Hash(password); err != nil {
+				return fmt.Errorf("hashing password %d: %w", generated, err)
+			}
+			if *withMetadata {
+				result.Created = time.Now().UTC().Format(time.RFC3339)
+			}
+		}
+		if *showStrength {
+			result.Strength = newStrengthOutput(passgen.EstimateStrength(password))
+		}
+		if opts.DualControl {
+			first, second := passgen.DualControlHalves(password)
+			result.Halves = []string{first, second}
+		}
+		if *temp {
+			result.Temporary, result.MustChange = true, true
+			result.ExpiresAt = expiresAt.Format(time.RFC3339)
+		}
+		results = append(results, result)
+
+		display := shown
+		if color {
+			display = colorize(shown)
+		}
+		if *mask {
+			hidden = append(hidden, display)
+			display = maskPassword(shown)
+		}
+		switch {
+		case table != nil:
+			row := []string{strconv.Itoa(generated), *label, shown, strconv.FormatFloat(bits, 'f', 1, 64)}
+			if fingerprintOf != nil {
+				row = append(row, result.Fingerprint)
+			}
+			if hasher != nil {
+				row = append(row, result.Hash)
+			}
+			if *withMetadata {
+				row = append(row, result.Created)
+			}
+			if *temp {
+				row = append(row, "true", result.ExpiresAt)
+			}
+			if err := table.write(row...); err != nil {
+				return err
+			}
+		case lines != nil:
+			if err := lines.write(generated, result); err != nil {
+				return err
+			}
+		case structured:
+		case *quiet:
+			fmt.Println(display)
+		case tmpl != nil:
+			line := templateLine{
+				Index: generated, Label: *label, Password: password, Escaped: shown,
+				Entropy: bits, KeyspaceEntropy: keyspace, Note: *note,
+				Fingerprint: result.Fingerprint, Hash: result.Hash,
+				Charset: strings.Join(charsetsOut, ""), Charsets: charsetsOut,
+				Timestamp: time.Now(), MustChange: *temp, Expires: expiresAt,
+			}
+			if err := writeTemplateLine(os.Stdout, tmpl, line); err != nil {
+				return err
+			}
+		case *fingerprintOnly:
+			fmt.Printf("%d: fingerprint %s\n", generated, result.Fingerprint)
+		case opts.DualControl:
+			first, second := result.Halves[0], result.Halves[1]
+			if color {
+				first, second = colorize(first), colorize(second)
+			}
+			fmt.Printf("%d: first half:  %s\n", generated, first)
+			fmt.Printf("%*s  second half: %s\n", len(strconv.Itoa(generated)), "", second)
+		case opts.Model != nil || opts.Pronounceable || opts.Pattern != nil || opts.AppleStyle || len(opts.AnchorWords) > 0:
+			// The entropy of model, pronounceable, pattern, Apple-style and
+			// anchored output is not what its length suggests, so always
+			// show it
+			fmt.Printf("%d: %s (%.1f bits)\n", generated, display, bits)
+		case *showEntropy:
+			fmt.Printf("%d: %s (%.1f bits)\n", generated, display, keyspace)
+		default:
+			fmt.Printf("%d: %s\n", generated, display)
+		}
+		if !structured && tmpl == nil && !*fingerprintOnly && fingerprintOf != nil {
+			fmt.Printf("   fingerprint: %s\n", result.Fingerprint)
+		}
+		if !structured && tmpl == nil && result.Hash != "" {
+			fmt.Printf("   hash: %s\n", result.Hash)
+			if result.Created != "" {
+				fmt.Printf("   created: %s\n", result.Created)
+			}
+		}
+		if !structured && len(confusables) > 0 {
+			fmt.Printf("   look-alikes: %s\n", strings.Join(confusables, ", "))
+		}
+		if !structured && result.Strength != nil {
+			printStrength(os.Stdout, result.Strength, "   ")
+		}
+		if !structured && *preview {
+			printPreview(os.Stdout, password, "   ")
+		}
+		if len(passwords) == batchSize {
+			return flush()
+		}
+		return nil
+	}
+	var emitErr error
+	if *choices > 0 {
+		show := func(password string) string {
+			if *group > 0 {
+				return passgen.Group(password, *group, *groupSep)
+			}
+			return password
+		}
+		chosen, err := chooseCandidate(gen.Generate, show, *choices, os.Stdin, os.Stderr)
+		if err != nil {
+			return err
+		}
+		if err := emit(chosen); err != nil {
+			return err
+		}
+	} else if err := gen.Stream(*count, func(password string) error {
+		emitErr = emit(password)
+		return emitErr
+	}); err != nil {
+		if err == emitErr {
+			return err
+		}
+		return fmt.Errorf("generating password: %w", err)
+	}
+	if len(passwords) < batchSize {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if !structured && !*quiet && tmpl == nil && len(sinks) > 0 {
+		fmt.Printf("\nDelivered to: %s\n", strings.Join(delivered, ", "))
+	}
+
+	if jsonOutput {
+		out := generateOutput{Schema: outputSchema, Length: *length, Passwords: results}
+		if len(sinks) > 0 {
+			out.DeliveredTo = delivered
+		}
+		out.Charsets = charsetsOut
+		if signKey != nil {
+			if err := signOutput(signKey, &out); err != nil {
+				return fmt.Errorf("signing output: %w", err)
+			}
+		}
+		if err := writeJSON(os.Stdout, out); err != nil {
+			return err
+		}
+	}
+	if writeDevice != nil {
+		for i, password := range passwords {
+			name := servers[i]
+			if *label != "" {
+				name = *label
+				if *count > 1 {
+					name = fmt.Sprintf("%s-%d", *label, i+1)
+				}
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := writeDevice(os.Stdout, devicePreset.protocol, name, serverIPs[i], password); err != nil {
+				return err
+			}
+		}
+	}
+
+	if replay != nil && replay.Fingerprints != nil {
+		if err := replay.match(drawn); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "All %d passwords match the transcript\n", len(drawn))
+	} else if draw != nil && replay == nil {
+		draw.Fingerprints = drawn
+		if err := writeDrawTranscript(*transcript, draw); err != nil {
+			return fmt.Errorf("writing transcript: %w", err)
+		}
+	}
+
+	if *metricsOut != "" {
+		rec := metricsRecord{
+			Mode:         mode,
+			Length:       *length,
+			Count:        *count,
+			Special:      *includeSpecial,
+			AllowSimilar: *allowSimilar,
+			Ambiguity:    opts.Ambiguity.String(),
+			Rules:        len(rules),
+			Encodings:    encodingNames,
+			Targets:      targets,
+			Sinks:        len(sinks),
+			Labeled:      *label != "",
+			Entropy:      summarizeEntropy(entropies),
+		}
+		if *minEntropy > 0 {
+			for _, bits := range entropies {
+				if bits < *minEntropy {
+					rec.BelowTarget++
+				}
+			}
+		}
+		if err := appendMetrics(*metricsOut, rec, time.Now()); err != nil {
+			return fmt.Errorf("writing metrics: %w", err)
+		}
+	}
+
+	// Keep stdout a single document in structured modes, and only the
+	// passwords with -q and -template
+	if *histogram {
+		w := os.Stdout
+		if structured || *quiet || tmpl != nil {
+			w = os.Stderr
+		}
+		fmt.Fprintln(w)
+		printEntropyHistogram(w, entropies, 8)
+	}
+	if *filterStats {
+		w := os.Stdout
+		if structured || *quiet || tmpl != nil {
+			w = os.Stderr
+		}
+		fmt.Fprintln(w)
+		printFilterStats(w, gen.Pipeline().Stats())
+	}
+	if *mask {
+		return revealOnTerminal(hidden)
+	}
+	return nil
+}
+
+// parseAmbiguity reads an -ambiguity-level, which -allow-similar may only
+// accompany when it agrees.
+func parseAmbiguity(name string, allowSimilar bool) (passgen.Ambiguity, error) {
+	level, err := passgen.ParseAmbiguity(name)
+	if err != nil {
+		return 0, err
+	}
+	if allowSimilar && level == passgen.AmbiguityExtended {
+		return 0, fmt.Errorf("-allow-similar contradicts -ambiguity-level %s", level)
+	}
+	return level, nil
+}
+
+// entropyLength returns the shortest password length whose entropy under
+// the generator options reaches bits. It is never shorter than the minimum
+// length or than the character set minimums need.
+func entropyLength(bits float64, genOpts []passgen.GeneratorOption) (int, error) {
+	probe, err := passgen.NewGenerator(append(slices.Clip(genOpts), passgen.WithLength(maxLength))...)
+	if err != nil {
+		return 0, err
+	}
+	opts := probe.Options()
+	n := 1
+	if opts.Pronounceable {
+		for n <= maxLength && passgen.PronounceableEntropy(n) < bits {
+			n++
+		}
+	} else if len(opts.AnchorWords) > 0 || opts.DualControl {
+		// The weakest anchored password, with the longest word, or both
+		// halves of dual control together have to reach it
+		for ; n <= maxLength; n++ {
+			g, err := passgen.NewGenerator(append(slices.Clip(genOpts), passgen.WithLength(n))...)
+			if err != nil {
+				continue
+			}
+			if keyspace, _ := g.KeyspaceEntropy(); keyspace >= bits {
+				break
+			}
+		}
+	} else {
+		charsets := opts.Charsets()
+		if n = passgen.LengthForEntropy(bits, charsets); n == 0 {
+			return 0, fmt.Errorf("the character sets are too small to reach %g bits of entropy", bits)
+		}
+		n = max(n, opts.MinLength())
+	}
+	n = max(n, minLength)
+	if n > maxLength {
+		return 0, fmt.Errorf("%g bits of entropy need more than %d characters with these character sets", bits, maxLength)
+	}
+	return n, nil
+}
+
+// minCountOptions requires at least the given number of characters of each
+// class.
+func minCountOptions(upper, lower, digits, special int) []passgen.GeneratorOption {
+	return []passgen.GeneratorOption{
+		passgen.WithMinCount(passgen.ClassUpper, upper),
+		passgen.WithMinCount(passgen.ClassLower, lower),
+		passgen.WithMinCount(passgen.ClassDigit, digits),
+		passgen.WithMinCount(passgen.ClassSpecial, special),
+	}
+}
+
+// describeMinCounts lists the class minimums above the default of one, e.g.
+// "2 digit, 3 special".
+func describeMinCounts(counts [passgen.ClassSpecial + 1]int) string {
+	var parts []string
+	for class, n := range counts {
+		if n > 1 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, passgen.CharClass(class)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// disableOptions removes the classes turned off by -no-upper, -no-lower and
+// -no-digits.
+func disableOptions(noUpper, noLower, noDigits bool) []passgen.GeneratorOption {
+	var opts []passgen.GeneratorOption
+	if noUpper {
+		opts = append(opts, passgen.WithoutClass(passgen.ClassUpper))
+	}
+	if noLower {
+		opts = append(opts, passgen.WithoutClass(passgen.ClassLower))
+	}
+	if noDigits {
+		opts = append(opts, passgen.WithoutClass(passgen.ClassDigit))
+	}
+	return opts
+}
+
+// charsetNames names the character sets of the text output header.
+func charsetNames(opts passgen.Options, extra []string) []string {
+	var names []string
+	for _, class := range []struct {
+		class passgen.CharClass
+		name  string
+		on    bool
+	}{
+		{passgen.ClassUpper, "Uppercase", true},
+		{passgen.ClassLower, "Lowercase", true},
+		{passgen.ClassDigit, "Numbers", true},
+		{passgen.ClassSpecial, "Special characters", opts.IncludeSpecial},
+	} {
+		if class.on && !opts.Disabled[class.class] {
+			names = append(names, class.name)
+		}
+	}
+	return append(names, extra...)
+}
+
+// deliveredTo lists where a batch went, in the order it was written:
+// standard output first, unless only fingerprints were printed, then every
+// sink.
+func deliveredTo(sinks []sink, stdout bool) []string {
+	var where []string
+	if stdout {
+		where = append(where, "stdout")
+	}
+	for _, s := range sinks {
+		where = append(where, sinkName(s))
+	}
+	return where
+}
+
+// avoidWords splits the comma-separated values of -avoid into words.
+func avoidWords(values []string) []string {
+	var words []string
+	for _, v := range values {
+		for _, w := range strings.Split(v, ",") {
+			if w = strings.TrimSpace(w); w != "" {
+				words = append(words, w)
+			}
+		}
+	}
+	return words
+}
+
+// fingerprinter returns the function computing fingerprints in the named
+// format, or nil when format is empty.
+func fingerprinter(format string) (func(string) string, error) {
+	switch format {
+	case "":
+		return nil, nil
+	case "hex":
+		return passgen.Fingerprint, nil
+	case "emoji":
+		return passgen.EmojiFingerprint, nil
+	}
+	return nil, fmt.Errorf("unknown fingerprint format %q (use hex or emoji)", format)
+}
+
+// outputCharsets returns the character sets passwords of opts are drawn
+// from, for structured output. Patterns and Markov models have none.
+func outputCharsets(opts passgen.Options) []string {
+	switch {
+	case opts.Pronounceable:
+		return []string{passgen.PronounceableConsonants, passgen.PronounceableVowels}
+	case opts.AppleStyle:
+		return []string{passgen.Lowercase, passgen.Uppercase, passgen.Numbers}
+	case opts.Pattern != nil:
+		// Every position has its own set; the pattern says which
+		return nil
+	case opts.Model == nil:
+		return opts.Charsets()
+	}
+	return nil
+}
+
+// anchorWordlist returns the words of -anchor-word: an embedded wordlist
+// by name, or a wordlist file or URL.
+func anchorWordlist(source string) ([]string, error) {
+	if slices.Contains(passgen.Wordlists(), passgen.ToLowerASCII(source)) {
+		return passgen.Wordlist(source)
+	}
+	return loadWordlist(source, false)
+}
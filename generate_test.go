@@ -0,0 +1,241 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestParseAmbiguityFlag tests -ambiguity-level together with -allow-similar
+func TestParseAmbiguityFlag(t *testing.T) {
+	tests := []struct {
+		name         string
+		level        string
+		allowSimilar bool
+		want         passgen.Ambiguity
+		wantErr      bool
+	}{
+		{"default", "standard", false, passgen.AmbiguityStandard, false},
+		{"extended", "extended", false, passgen.AmbiguityExtended, false},
+		{"allow similar", "standard", true, passgen.AmbiguityStandard, false},
+		{"allow similar agrees", "none", true, passgen.AmbiguityNone, false},
+		{"allow similar contradicts", "extended", true, 0, true},
+		{"unknown", "paranoid", false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAmbiguity(tt.level, tt.allowSimilar)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAmbiguity error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAmbiguity = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEntropyLength tests the length -entropy picks for the options
+func TestEntropyLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		bits    float64
+		opts    []passgen.GeneratorOption
+		want    int
+		wantErr bool
+	}{
+		{"default sets", 80, nil, 14, false},
+		{"digits only", 60, disableOptions(true, true, false), 20, false},
+		{"pronounceable", 64, []passgen.GeneratorOption{passgen.WithPronounceable()}, 21, false},
+		{"anchor word", 40, []passgen.GeneratorOption{passgen.WithAnchorWord([]string{"cat", "dig"})}, 10, false},
+		{"minimum length", 10, nil, minLength, false},
+		{"class minimums", 20, minCountOptions(0, 0, 6, 0), 8, false},
+		{"too long", 1000, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := entropyLength(tt.bits, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("entropyLength error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("entropyLength = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDescribeMinCounts tests the minimums line of the text output
+func TestDescribeMinCounts(t *testing.T) {
+	tests := []struct {
+		counts [passgen.ClassSpecial + 1]int
+		want   string
+	}{
+		{[passgen.ClassSpecial + 1]int{}, ""},
+		{[passgen.ClassSpecial + 1]int{1, 1, 1, 1}, ""},
+		{[passgen.ClassSpecial + 1]int{0, 0, 2, 3}, "2 digit, 3 special"},
+		{[passgen.ClassSpecial + 1]int{4, 0, 0, 0}, "4 uppercase"},
+	}
+	for _, tt := range tests {
+		if got := describeMinCounts(tt.counts); got != tt.want {
+			t.Errorf("describeMinCounts(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
+
+// TestCharsetNames tests the character sets line of the text output
+func TestCharsetNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []passgen.GeneratorOption
+		extra []string
+		want  string
+	}{
+		{"default", nil, nil, "Uppercase, Lowercase, Numbers"},
+		{"special and extra", []passgen.GeneratorOption{passgen.WithSpecial(true)}, []string{"~"}, "Uppercase, Lowercase, Numbers, Special characters, ~"},
+		{"digits only", disableOptions(true, true, false), nil, "Numbers"},
+		{"no digits", disableOptions(false, false, true), nil, "Uppercase, Lowercase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := passgen.NewGenerator(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(charsetNames(gen.Options(), tt.extra), ", "); got != tt.want {
+				t.Errorf("charsetNames = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDeliveredTo tests the summary of where a batch went
+func TestDeliveredTo(t *testing.T) {
+	out, err := newCSVSink("creds.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(deliveredTo([]sink{out, &memorySink{}}, true), ", ")
+	if want := "stdout, output file creds.csv, *main.memorySink"; got != want {
+		t.Errorf("deliveredTo = %q, want %q", got, want)
+	}
+	got = strings.Join(deliveredTo([]sink{out}, false), ", ")
+	if want := "output file creds.csv"; got != want {
+		t.Errorf("deliveredTo without stdout = %q, want %q", got, want)
+	}
+}
+
+// TestFingerprinter tests fingerprint format names
+func TestFingerprinter(t *testing.T) {
+	for format, want := range map[string]string{"hex": "5e884898", "emoji": passgen.EmojiFingerprint("password")} {
+		f, err := fingerprinter(format)
+		if err != nil || f("password") != want {
+			t.Errorf("fingerprinter(%q) = %v", format, err)
+		}
+	}
+	if f, err := fingerprinter(""); f != nil || err != nil {
+		t.Errorf("fingerprinter(\"\") = %p, %v; want nil", f, err)
+	}
+	if _, err := fingerprinter("base64"); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
+
+// TestAvoidWords tests splitting the values of -avoid
+func TestAvoidWords(t *testing.T) {
+	got := avoidWords([]string{"alice, acme,,", "1987"})
+	if want := []string{"alice", "acme", "1987"}; !slices.Equal(got, want) {
+		t.Errorf("avoidWords = %q, want %q", got, want)
+	}
+	if err := runGenerate("passgen", []string{"-avoid", "al"}); err == nil || !strings.Contains(err.Error(), "at least 3") {
+		t.Errorf("-avoid al error = %v", err)
+	}
+}
+
+// TestGenerateStreamFlags tests the count limit and what -stream rules out
+func TestGenerateStreamFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-c", "101"}, "without -stream"},
+		{[]string{"-stream", "-c", "101", "-o", "json"}, "-stream cannot be combined"},
+		{[]string{"-stream", "-histogram"}, "-stream cannot be combined"},
+		{[]string{"-stream", "-copy"}, "-stream cannot be combined"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// TestGenerateQuietFlags tests what -q rules out
+func TestGenerateQuietFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-q", "-o", "json"}, "-q only applies to text output"},
+		{[]string{"--quiet", "-o", "csv"}, "-q only applies to text output"},
+		{[]string{"-q", "-strength"}, "-q prints only the passwords"},
+		{[]string{"-q", "-fingerprint-only", "-copy"}, "-q prints only the passwords"},
+		{[]string{"-q", "-hash-format", "django"}, "-q prints only the passwords"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// TestGenerateAnchorWordFlags tests what -anchor-word rules out
+func TestGenerateAnchorWordFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-anchor-word", "eff-long", "-pronounceable"}, "-anchor-word cannot be combined"},
+		{[]string{"-anchor-word", "eff-long", "-apple-style"}, "-anchor-word cannot be combined"},
+		{[]string{"-anchor-word", "eff-long", "-no-words"}, "-no-words"},
+		{[]string{"-anchor-word", "eff-long", "-l", "5"}, "no anchor word fits"},
+		{[]string{"-anchor-word", "missing.txt"}, "missing.txt"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// TestGenerateTempFlags tests what -temp and -expires rule out
+func TestGenerateTempFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-expires", "1h"}, "-expires requires -temp"},
+		{[]string{"-temp", "-expires", "-1h"}, "-expires must be positive"},
+		{[]string{"-temp", "-preset", "radius"}, "cannot be combined with -preset radius"},
+		{[]string{"-temp", "-o", "cisco", "-server", "10.0.0.1"}, "needs a device preset"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// TestGenerateWithMetadataFlags tests that -with-metadata needs hashes
+func TestGenerateWithMetadataFlags(t *testing.T) {
+	err := runGenerate("passgen", []string{"-with-metadata"})
+	if err == nil || !strings.Contains(err.Error(), "-with-metadata requires -hash-format") {
+		t.Errorf("runGenerate(-with-metadata) error = %v", err)
+	}
+}
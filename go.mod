@@ -1,3 +1,7 @@
 module github.com/junedkhatri31/passgen
 
 go 1.25.1
+
+require golang.org/x/crypto v0.54.0
+
+require golang.org/x/sys v0.47.0 // indirect
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printHashTuneUsage(programName string) {
+	fmt.Printf("Usage: %s hash-tune [OPTIONS]\n", programName)
+	fmt.Println("Benchmark Argon2id on this machine and recommend the memory and passes that")
+	fmt.Println("take about -target per hash, then save them for hashing passwords.")
+	fmt.Println("Options:")
+	fmt.Println("  -target DURATION")
+	fmt.Println("               Time one hash may take (default: 250ms)")
+	fmt.Println("  -max-memory MIB")
+	fmt.Println("               Most memory one hash may use, in MiB (default: 1024)")
+	fmt.Println("  -threads N   Lanes hashed in parallel (default: the number of CPUs, at most 4)")
+	fmt.Println("  -config FILE Where to save the parameters (default: hash.json in the user")
+	fmt.Println("               config dir); give generate and rpc the same -hash-config FILE")
+	fmt.Println("  -dry-run     Only print the recommendation")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s hash-tune -target 250ms -max-memory 512\n", programName)
+}
+
+// Argon2id memory tried first and the least recommended, in KiB.
+const minTuneMemory = 8 * 1024
+
+// tuneTrial is one benchmarked parameter set.
+type tuneTrial struct {
+	Params   passgen.Argon2Params `json:"params"`
+	Duration time.Duration        `json:"duration"`
+}
+
+// tuneArgon2 finds the Argon2id parameters that use the most memory, up to
+// maxMemory KiB, and then the most passes that measure within target, as
+// RFC 9106 advises: memory first, since it is what makes attacks costly.
+// Trials are reported to progress as they finish.
+func tuneArgon2(target time.Duration, threads uint8, maxMemory uint32, measure func(passgen.Argon2Params) time.Duration, progress func(tuneTrial)) (tuneTrial, error) {
+	if maxMemory < minTuneMemory {
+		return tuneTrial{}, fmt.Errorf("-max-memory must be at least %d MiB", minTuneMemory/1024)
+	}
+	run := func(p passgen.Argon2Params) tuneTrial {
+		trial := tuneTrial{Params: p, Duration: measure(p)}
+		progress(trial)
+		return trial
+	}
+	best := run(passgen.Argon2Params{Memory: minTuneMemory, Time: 1, Threads: threads})
+	if best.Duration > target {
+		return best, fmt.Errorf("even %d MiB and 1 pass take %v, more than %v", minTuneMemory/1024, best.Duration.Round(time.Millisecond), target)
+	}
+	// Double the memory while the time, which grows with it, allows
+	for best.Params.Memory*2 <= maxMemory && best.Duration*2 <= target*11/10 {
+		next := run(passgen.Argon2Params{Memory: best.Params.Memory * 2, Time: 1, Threads: threads})
+		if next.Duration > target {
+			break
+		}
+		best = next
+	}
+	// Then spend what is left on passes, checking the estimate
+	for passes := uint32(target / best.Duration); passes > best.Params.Time; passes-- {
+		p := best.Params
+		p.Time = passes
+		if next := run(p); next.Duration <= target {
+			best = next
+			break
+		}
+	}
+	return best, nil
+}
+
+// measureArgon2 times one Argon2id hash.
+func measureArgon2(p passgen.Argon2Params) time.Duration {
+	start := time.Now()
+	passgen.Argon2id([]byte("passgen hash-tune"), []byte("passgen-salt-16b"), p, 32)
+	return time.Since(start)
+}
+
+// hashConfig holds the saved parameters for hashing passwords.
+type hashConfig struct {
+	Argon2id *tunedArgon2 `json:"argon2id,omitempty"`
+}
+
+// tunedArgon2 records Argon2id parameters and how they were chosen.
+type tunedArgon2 struct {
+	passgen.Argon2Params
+	Target   string `json:"target"`
+	Duration string `json:"duration"`
+	Host     string `json:"host,omitempty"`
+	Tuned    string `json:"tuned"`
+}
+
+// defaultHashConfigPath returns the file hash-tune writes when -config is
+// not set.
+func defaultHashConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".passgen-hash.json"
+	}
+	return filepath.Join(dir, "passgen", "hash.json")
+}
+
+// readHashConfig reads a hash configuration; a missing file is an empty one.
+func readHashConfig(path string) (*hashConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &hashConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg hashConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if cfg.Argon2id != nil {
+		if err := cfg.Argon2id.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// writeHashConfig replaces the hash configuration at path.
+func writeHashConfig(path string, cfg *hashConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newHasher returns the hasher for -hash-format, with the Argon2id
+// parameters hash-tune saved in configPath, or in its default file when
+// configPath is empty, if there are any.
+func newHasher(format, configPath string) (*passgen.Hasher, error) {
+	h, err := passgen.NewHasher(format)
+	if err != nil {
+		return nil, err
+	}
+	if configPath == "" {
+		configPath = defaultHashConfigPath()
+	}
+	if h.Format() == passgen.HashArgon2id {
+		cfg, err := readHashConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Argon2id != nil {
+			h.Argon2 = cfg.Argon2id.Argon2Params
+		}
+	}
+	return h, nil
+}
+
+func printTuneResult(w io.Writer, best tuneTrial) {
+	p := best.Params
+	fmt.Fprintf(w, "Recommended: Argon2id m=%d (%d MiB), t=%d, p=%d, %v per hash\n",
+		p.Memory, p.Memory/1024, p.Time, p.Threads, best.Duration.Round(time.Millisecond))
+}
+
+// runHashTune implements the hash-tune subcommand.
+func runHashTune(programName string, args []string) error {
+	fs := flag.NewFlagSet("hash-tune", flag.ContinueOnError)
+	target := fs.Duration("target", 250*time.Millisecond, "Time one hash may take")
+	maxMemory := fs.Int("max-memory", 1024, "Most memory one hash may use, in MiB")
+	threads := fs.Int("threads", min(runtime.NumCPU(), 4), "Lanes hashed in parallel")
+	configPath := fs.String("config", defaultHashConfigPath(), "Where to save the parameters")
+	dryRun := fs.Bool("dry-run", false, "Only print the recommendation")
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printHashTuneUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printHashTuneUsage(programName)
+		return nil
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if *target <= 0 {
+		return fmt.Errorf("-target must be positive")
+	}
+	if *threads < 1 || *threads > 255 {
+		return fmt.Errorf("-threads must be between 1 and 255")
+	}
+	if *maxMemory < 1 || *maxMemory > 1<<22 {
+		return fmt.Errorf("-max-memory must be between 1 and %d MiB", 1<<22)
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	// Read the configuration first so a broken file fails before the
+	// benchmark
+	cfg, err := readHashConfig(*configPath)
+	if err != nil && !*dryRun {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Benchmarking Argon2id for %v per hash, p=%d, up to %d MiB...\n", *target, *threads, *maxMemory)
+	best, err := tuneArgon2(*target, uint8(*threads), uint32(*maxMemory)*1024, measureArgon2, func(t tuneTrial) {
+		fmt.Fprintf(os.Stderr, "  %s: %v\n", t.Params, t.Duration.Round(time.Millisecond))
+	})
+	if err != nil {
+		return err
+	}
+
+	tuned := &tunedArgon2{
+		Argon2Params: best.Params,
+		Target:       target.String(),
+		Duration:     best.Duration.Round(time.Millisecond).String(),
+		Tuned:        time.Now().UTC().Format(time.RFC3339),
+	}
+	tuned.Host, _ = os.Hostname()
+	if !*dryRun {
+		cfg.Argon2id = tuned
+		if err := writeHashConfig(*configPath, cfg); err != nil {
+			return err
+		}
+	}
+	if *format == "json" {
+		return writeJSON(os.Stdout, tuned)
+	}
+	printTuneResult(os.Stdout, best)
+	if !*dryRun {
+		fmt.Printf("Saved to %s\n", *configPath)
+		if *configPath != defaultHashConfigPath() {
+			fmt.Printf("Use them with -hash-config %s\n", *configPath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestTuneArgon2 tests picking memory first, then passes
+func TestTuneArgon2(t *testing.T) {
+	// A machine that hashes 1 MiB per pass in a millisecond
+	measure := func(p passgen.Argon2Params) time.Duration {
+		return time.Duration(p.Memory/1024*p.Time) * time.Millisecond
+	}
+	tests := []struct {
+		name      string
+		target    time.Duration
+		maxMemory uint32
+		want      passgen.Argon2Params
+		wantErr   string
+	}{
+		{"memory bound", 250 * time.Millisecond, 1024 * 1024, passgen.Argon2Params{Memory: 128 * 1024, Time: 1, Threads: 4}, ""},
+		{"capped memory", 250 * time.Millisecond, 64 * 1024, passgen.Argon2Params{Memory: 64 * 1024, Time: 3, Threads: 4}, ""},
+		{"exact fit", 32 * time.Millisecond, 16 * 1024, passgen.Argon2Params{Memory: 16 * 1024, Time: 2, Threads: 4}, ""},
+		{"too slow", 5 * time.Millisecond, 1024 * 1024, passgen.Argon2Params{}, "more than 5ms"},
+		{"too little memory", time.Second, 1024, passgen.Argon2Params{}, "at least 8 MiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trials := 0
+			best, err := tuneArgon2(tt.target, 4, tt.maxMemory, measure, func(tuneTrial) { trials++ })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("tuneArgon2 error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if best.Params != tt.want {
+				t.Errorf("tuneArgon2 = %s, want %s", best.Params, tt.want)
+			}
+			if best.Duration > tt.target {
+				t.Errorf("Recommended %s takes %v, more than %v", best.Params, best.Duration, tt.target)
+			}
+			if trials == 0 {
+				t.Error("No trials were reported")
+			}
+		})
+	}
+}
+
+// TestNewHasher tests that argon2id hashes use the tuned parameters
+func TestNewHasher(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	h, err := newHasher("argon2id", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Argon2 != passgen.DefaultArgon2Params {
+		t.Errorf("Untuned parameters = %s, want %s", h.Argon2, passgen.DefaultArgon2Params)
+	}
+
+	tuned := passgen.Argon2Params{Memory: 32 * 1024, Time: 2, Threads: 1}
+	if err := writeHashConfig(defaultHashConfigPath(), &hashConfig{Argon2id: &tunedArgon2{Argon2Params: tuned}}); err != nil {
+		t.Fatal(err)
+	}
+	if h, err = newHasher("argon2id", ""); err != nil {
+		t.Fatal(err)
+	}
+	if h.Argon2 != tuned {
+		t.Errorf("Tuned parameters = %s, want %s", h.Argon2, tuned)
+	}
+	// Parameters saved with hash-tune -config elsewhere
+	other := filepath.Join(dir, "other.json")
+	elsewhere := passgen.Argon2Params{Memory: 16 * 1024, Time: 4, Threads: 2}
+	if err := writeHashConfig(other, &hashConfig{Argon2id: &tunedArgon2{Argon2Params: elsewhere}}); err != nil {
+		t.Fatal(err)
+	}
+	if h, err = newHasher("argon2id", other); err != nil {
+		t.Fatal(err)
+	}
+	if h.Argon2 != elsewhere {
+		t.Errorf("Parameters from %s = %s, want %s", other, h.Argon2, elsewhere)
+	}
+	if _, err := newHasher("md5", ""); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+// TestHashConfig tests saving and reading tuned parameters
+func TestHashConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passgen", "hash.json")
+	cfg, err := readHashConfig(path)
+	if err != nil || cfg.Argon2id != nil {
+		t.Fatalf("readHashConfig of a missing file = %+v, %v", cfg, err)
+	}
+	cfg.Argon2id = &tunedArgon2{Argon2Params: passgen.Argon2Params{Memory: 65536, Time: 2, Threads: 4}, Target: "250ms", Duration: "231ms", Tuned: "2026-01-02T03:04:05Z"}
+	if err := writeHashConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readHashConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got.Argon2id != *cfg.Argon2id {
+		t.Errorf("readHashConfig = %+v, want %+v", got.Argon2id, cfg.Argon2id)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"argon2id":{"memory":65536,"time":0,"threads":4}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readHashConfig(path); err == nil || !strings.Contains(err.Error(), "pass") {
+		t.Errorf("readHashConfig of 0 passes error = %v", err)
+	}
+}
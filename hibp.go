@@ -0,0 +1,196 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	features = append(features, "hibp")
+}
+
+// hibpRangeURL is the Pwned Passwords range API, which takes the first five
+// hex digits of a SHA-1 hash.
+const hibpRangeURL = "https://api.pwnedpasswords.com/range/"
+
+// hibpPrefixes is the number of ranges the corpus is split into, one for
+// every five hex digits.
+const hibpPrefixes = 1 << 20
+
+// hibpClient looks passwords up in Pwned Passwords with the k-anonymity
+// range protocol: only the first five hex digits of the password's SHA-1
+// are sent, and the matching suffixes are compared locally.
+type hibpClient struct {
+	baseURL string
+	client  *http.Client
+	retry   retryPolicy
+}
+
+func newHIBPClient() *hibpClient {
+	return &hibpClient{baseURL: hibpRangeURL, client: &http.Client{Timeout: 15 * time.Second}, retry: defaultRetryPolicy}
+}
+
+// newHIBPCheck returns a breachCheck backed by the Pwned Passwords API.
+func newHIBPCheck() (breachCheck, error) {
+	return newHIBPClient().count, nil
+}
+
+// count returns how often the password appears in known breaches.
+func (c *hibpClient) count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	// Padding hides how many suffixes share the prefix from eavesdroppers
+	body, err := c.get(prefix, true)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	count := 0
+	err = readRange(body, func(got string, n int) {
+		// Padding entries have a count of zero
+		if strings.EqualFold(got, suffix) {
+			count = n
+		}
+	})
+	return count, err
+}
+
+// get requests one range.
+func (c *hibpClient) get(prefix string, padding bool) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "passgen/"+version)
+	if padding {
+		req.Header.Set("Add-Padding", "true")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("checking Pwned Passwords: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("checking Pwned Passwords: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// readRange calls fn with the suffix and count of every line of a range.
+func readRange(r io.Reader, fn func(suffix string, count int)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		suffix, count, err := parseCorpusLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("checking Pwned Passwords: %w", err)
+		}
+		if suffix != "" {
+			fn(suffix, count)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("checking Pwned Passwords: %w", err)
+	}
+	return nil
+}
+
+// corpus fetches the ranges of the first prefixes prefixes with workers
+// parallel requests, retrying failed ones, and calls emit with every hash
+// and its count. emit and progress are never called concurrently.
+func (c *hibpClient) corpus(prefixes, workers int, emit func(sum [sha1.Size]byte, count int), progress func(done int)) error {
+	jobs := make(chan int)
+	errs := make(chan error, workers)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				prefix := fmt.Sprintf("%05X", p)
+				data, err := c.fetchRange(prefix)
+				if err == nil {
+					mu.Lock()
+					err = readRange(bytes.NewReader(data), func(suffix string, count int) {
+						if sum, perr := parseSHA1(prefix + suffix); perr == nil {
+							emit(sum, count)
+						}
+					})
+					done++
+					progress(done)
+					mu.Unlock()
+				}
+				if err != nil {
+					errs <- fmt.Errorf("range %s: %w", prefix, err)
+					return
+				}
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for p := range prefixes {
+		select {
+		case jobs <- p:
+		case err = <-errs:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err == nil {
+		select {
+		case err = <-errs:
+		default:
+		}
+	}
+	return err
+}
+
+// fetchRange downloads one range, retrying as for remote sinks.
+func (c *hibpClient) fetchRange(prefix string) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= c.retry.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.retry.delay(attempt - 1))
+		}
+		var body io.ReadCloser
+		if body, err = c.get(prefix, false); err != nil {
+			continue
+		}
+		var data []byte
+		data, err = io.ReadAll(body)
+		body.Close()
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, err
+}
+
+// fetchHIBPCorpus downloads the whole Pwned Passwords corpus from the range
+// API, showing progress on stderr.
+func fetchHIBPCorpus(workers int, emit func(sum [sha1.Size]byte, count int)) error {
+	err := newHIBPClient().corpus(hibpPrefixes, workers, emit, func(done int) {
+		if done%1024 == 0 || done == hibpPrefixes {
+			fmt.Fprintf(os.Stderr, "\rFetched %d of %d ranges", done, hibpPrefixes)
+		}
+	})
+	fmt.Fprintln(os.Stderr)
+	return err
+}
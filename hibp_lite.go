@@ -0,0 +1,18 @@
+//go:build passgen_lite
+
+package main
+
+import (
+	"crypto/sha1"
+	"errors"
+)
+
+// newHIBPCheck fails, since lite builds do not talk to remote services.
+func newHIBPCheck() (breachCheck, error) {
+	return nil, errors.New("-hibp is not available in this build (built with passgen_lite); use -hibp-offline")
+}
+
+// fetchHIBPCorpus fails, since lite builds do not talk to remote services.
+func fetchHIBPCorpus(workers int, emit func(sum [sha1.Size]byte, count int)) error {
+	return errors.New("downloading is not available in this build (built with passgen_lite); use -in")
+}
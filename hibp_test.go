@@ -0,0 +1,105 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestHIBPClient tests the range protocol against a fake Pwned Passwords API
+func TestHIBPClient(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	var prefixes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix, ok := strings.CutPrefix(r.URL.Path, "/range/")
+		if !ok {
+			http.Error(w, "throttled", http.StatusTooManyRequests)
+			return
+		}
+		prefixes = append(prefixes, prefix)
+		switch prefix {
+		case "5BAA6":
+			fmt.Fprint(w, "003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n")
+		default:
+			// Padding only
+			fmt.Fprint(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n")
+		}
+	}))
+	defer srv.Close()
+	c := &hibpClient{baseURL: srv.URL + "/range/", client: srv.Client()}
+
+	if n, err := c.count("password"); err != nil || n != 9659365 {
+		t.Errorf("count(password) = %d, %v; want 9659365", n, err)
+	}
+	if n, err := c.count("xK9#mQ2$vL7p"); err != nil || n != 0 {
+		t.Errorf("count of a random password = %d, %v; want 0", n, err)
+	}
+	for _, prefix := range prefixes {
+		if len(prefix) != 5 {
+			t.Errorf("Sent %q, want only a five digit prefix", prefix)
+		}
+	}
+
+	c.baseURL = srv.URL + "/throttled/"
+	if _, err := c.count("password"); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Expected an HTTP error, got %v", err)
+	}
+}
+
+// TestHIBPCorpus tests fetching ranges in parallel, with a retried failure
+func TestHIBPCorpus(t *testing.T) {
+	var mu sync.Mutex
+	failed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix, ok := strings.CutPrefix(r.URL.Path, "/range/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Add-Padding") != "" {
+			t.Error("Corpus requests should not ask for padding")
+		}
+		mu.Lock()
+		first := prefix == "00002" && !failed
+		failed = failed || first
+		mu.Unlock()
+		if first {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		// Every range holds one suffix seen as often as its number
+		n, _ := strconv.ParseInt(prefix, 16, 64)
+		fmt.Fprintf(w, "%035X:%d\r\n", n, n+1)
+	}))
+	defer srv.Close()
+	c := &hibpClient{baseURL: srv.URL + "/range/", client: srv.Client(), retry: retryPolicy{Retries: 1}}
+
+	counts := make(map[[sha1.Size]byte]int)
+	done := 0
+	err := c.corpus(4, 2, func(sum [sha1.Size]byte, count int) {
+		counts[sum] = count
+	}, func(n int) { done = n })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 4 || done != 4 {
+		t.Fatalf("Got %d hashes and %d ranges, want 4", len(counts), done)
+	}
+	sum, _ := parseSHA1("00003" + fmt.Sprintf("%035X", 3))
+	if counts[sum] != 4 {
+		t.Errorf("Hash of range 00003 has count %d, want 4", counts[sum])
+	}
+
+	c.retry.Retries = 0
+	c.baseURL = srv.URL + "/missing/"
+	if err := c.corpus(4, 2, func([sha1.Size]byte, int) {}, func(int) {}); err == nil {
+		t.Error("Expected an error when ranges cannot be fetched")
+	}
+}
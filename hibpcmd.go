@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func printHIBPUsage(programName string) {
+	fmt.Printf("Usage: %s hibp download -out FILE [OPTIONS]\n", programName)
+	fmt.Println("Build a bloom filter of Have I Been Pwned's Pwned Passwords for -hibp-offline,")
+	fmt.Println("so machines without network access can screen passwords. The corpus is")
+	fmt.Println("fetched range by range from the API, or read from a downloaded copy.")
+	fmt.Println("Options:")
+	fmt.Println("  -out FILE    Where to write the filter")
+	fmt.Println("  -in FILE     Read the SHA-1 corpus, HASH:COUNT lines, from FILE (- for stdin)")
+	fmt.Println("               instead of fetching it")
+	fmt.Println("  -fp-rate P   False positive rate of the filter (default: 0.001)")
+	fmt.Println("  -min-count N Leave out hashes seen fewer than N times (default: 1)")
+	fmt.Println("  -capacity N  Hashes to size the filter for when they cannot be counted")
+	fmt.Println("               first, as when fetching or reading stdin (default: 1000000000)")
+	fmt.Println("  -workers N   Parallel requests when fetching (default: 16)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s hibp download -out pwned.bloom\n", programName)
+	fmt.Printf("  %s check -hibp-offline pwned.bloom\n", programName)
+}
+
+// defaultHIBPCapacity covers the Pwned Passwords corpus, which holds about
+// 930 million hashes.
+const defaultHIBPCapacity = 1_000_000_000
+
+// parseCorpusLine parses a HASH:COUNT line of the Pwned Passwords corpus or
+// of a range, where HASH is only the suffix. Blank lines give an empty hash.
+func parseCorpusLine(line string) (string, int, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", 0, nil
+	}
+	hash, n, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid line %q: expected HASH:COUNT", line)
+	}
+	count, err := strconv.Atoi(n)
+	if err != nil || count < 0 {
+		return "", 0, fmt.Errorf("invalid count %q", n)
+	}
+	return hash, count, nil
+}
+
+// readCorpus calls fn with every hash of r seen at least minCount times.
+func readCorpus(r io.Reader, minCount int, fn func(sum [sha1.Size]byte)) error {
+	scanner := bufio.NewScanner(r)
+	num := 0
+	for scanner.Scan() {
+		num++
+		hash, count, err := parseCorpusLine(scanner.Text())
+		if err == nil && hash != "" && count >= minCount {
+			var sum [sha1.Size]byte
+			if sum, err = parseSHA1(hash); err == nil {
+				fn(sum)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %v", num, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// buildCorpusFilter builds a filter from a corpus file. A regular file is
+// read twice, to size the filter exactly; stdin is sized for capacity.
+func buildCorpusFilter(path string, minCount int, rate float64, capacity uint64) (*bloomFilter, error) {
+	if path == "-" {
+		f, err := newBloomFilter(capacity, rate)
+		if err != nil {
+			return nil, err
+		}
+		return f, readCorpus(os.Stdin, minCount, f.add)
+	}
+
+	read := func(fn func(sum [sha1.Size]byte)) error {
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if err := readCorpus(in, minCount, fn); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+	var n uint64
+	if err := read(func([sha1.Size]byte) { n++ }); err != nil {
+		return nil, err
+	}
+	f, err := newBloomFilter(n, rate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, read(f.add)
+}
+
+// runHIBP implements the hibp subcommand.
+func runHIBP(programName string, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		printHIBPUsage(programName)
+		return nil
+	}
+	if args[0] != "download" {
+		return fmt.Errorf("unknown hibp command %q (use download)", args[0])
+	}
+
+	fs := flag.NewFlagSet("hibp download", flag.ContinueOnError)
+	out := fs.String("out", "", "Where to write the filter")
+	in := fs.String("in", "", "Read the SHA-1 corpus from FILE instead of fetching it")
+	rate := fs.Float64("fp-rate", 0.001, "False positive rate of the filter")
+	minCount := fs.Int("min-count", 1, "Leave out hashes seen fewer than N times")
+	capacity := fs.Uint64("capacity", defaultHIBPCapacity, "Hashes to size the filter for when they cannot be counted first")
+	workers := fs.Int("workers", 16, "Parallel requests when fetching")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printHIBPUsage(programName) }
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *help {
+		printHIBPUsage(programName)
+		return nil
+	}
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+	if *workers < 1 {
+		return fmt.Errorf("-workers must be at least 1")
+	}
+
+	var filter *bloomFilter
+	var err error
+	if *in != "" {
+		filter, err = buildCorpusFilter(*in, *minCount, *rate, *capacity)
+	} else {
+		if filter, err = newBloomFilter(*capacity, *rate); err != nil {
+			return err
+		}
+		err = fetchHIBPCorpus(*workers, func(sum [sha1.Size]byte, count int) {
+			if count >= *minCount {
+				filter.add(sum)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(*out), ".passgen-bloom-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	if _, err := filter.WriteTo(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), *out); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s: %d hashes, %.1f MB, %g false positive rate\n", *out, filter.entries, float64(filter.m/8)/(1<<20), *rate)
+	return nil
+}
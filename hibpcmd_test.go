@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha1"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseCorpusLine tests the lines of the corpus and of ranges
+func TestParseCorpusLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		hash    string
+		count   int
+		wantErr bool
+	}{
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", 9659365, false},
+		{"1E4C9B93F3F0682250B6CF8331B7EE68FD8:0", "1E4C9B93F3F0682250B6CF8331B7EE68FD8", 0, false},
+		{"", "", 0, false},
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", "", 0, true},
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:many", "", 0, true},
+	}
+	for _, tt := range tests {
+		hash, count, err := parseCorpusLine(tt.line)
+		if (err != nil) != tt.wantErr || hash != tt.hash || count != tt.count {
+			t.Errorf("parseCorpusLine(%q) = %q, %d, %v", tt.line, hash, count, err)
+		}
+	}
+}
+
+// TestBuildCorpusFilter tests building a filter from a corpus file
+func TestBuildCorpusFilter(t *testing.T) {
+	// SHA-1 of "password" and "letmein"
+	corpus := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\n" +
+		"B7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3:2\n"
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(corpus), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := buildCorpusFilter(path, 1, 0.001, defaultHIBPCapacity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.entries != 2 || !f.contains(sha1.Sum([]byte("password"))) || !f.contains(sha1.Sum([]byte("letmein"))) {
+		t.Errorf("Filter of %d entries misses a corpus hash", f.entries)
+	}
+	// Sized for the two hashes, not the capacity
+	if f.m > 64 {
+		t.Errorf("Filter has %d bits, want it sized for 2 entries", f.m)
+	}
+
+	if f, err = buildCorpusFilter(path, 3, 0.001, defaultHIBPCapacity); err != nil || f.entries != 1 || f.contains(sha1.Sum([]byte("letmein"))) {
+		t.Errorf("-min-count 3 kept %d entries, %v", f.entries, err)
+	}
+
+	if err := os.WriteFile(path, []byte(corpus+"not a hash:1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildCorpusFilter(path, 1, 0.001, defaultHIBPCapacity); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected an error on line 3, got %v", err)
+	}
+}
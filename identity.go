@@ -0,0 +1,286 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printServiceIdentityUsage(programName string) {
+	fmt.Printf("Usage: %s service-identity [OPTIONS] SERVICE...\n", programName)
+	fmt.Println("Generate a credential bundle for each service: a password and a TLS client")
+	fmt.Println("key with a self-signed certificate or a CSR, so services can move from")
+	fmt.Println("passwords to mTLS one at a time. Each bundle is written to DIR/SERVICE.")
+	fmt.Println("Options:")
+	fmt.Println("  -dir DIR     Directory for the bundles (default: .)")
+	fmt.Println("  -l LENGTH    Password length (default: 32)")
+	fmt.Println("  -s           Include special characters")
+	fmt.Println("  -key TYPE    Key type: ecdsa (P-256), ed25519 or rsa (3072 bits)")
+	fmt.Println("               (default: ecdsa)")
+	fmt.Println("  -csr         Write a certificate signing request for your CA instead of a")
+	fmt.Println("               self-signed certificate")
+	fmt.Println("  -days N      Validity of the self-signed certificate (default: 365)")
+	fmt.Println("  -force       Replace existing bundles")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s service-identity -dir /etc/passgen/identities billing-api orders-worker\n", programName)
+}
+
+// Files of a service identity bundle.
+const (
+	identityPasswordFile = "password"
+	identityKeyFile      = "client.key"
+	identityCertFile     = "client.crt"
+	identityCSRFile      = "client.csr"
+)
+
+// serviceNamePattern accepts names that are safe as a directory and a DNS
+// name label sequence, such as billing-api or orders.worker.
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$`)
+
+// identityOutput describes a written bundle, and is the JSON record of
+// service-identity.
+type identityOutput struct {
+	Service             string `json:"service"`
+	Dir                 string `json:"dir"`
+	PasswordFingerprint string `json:"passwordFingerprint"`
+	KeyType             string `json:"keyType"`
+	// Certificate is the file name of the self-signed certificate or of
+	// the CSR.
+	Certificate string `json:"certificate"`
+	CSR         bool   `json:"csr,omitempty"`
+	// SHA256 is the fingerprint of the certificate, for pinning it on the
+	// server, and NotAfter its expiry; a CSR has neither yet.
+	SHA256   string `json:"sha256,omitempty"`
+	NotAfter string `json:"notAfter,omitempty"`
+}
+
+type identitiesOutput struct {
+	Schema     string           `json:"schema"`
+	Identities []identityOutput `json:"identities"`
+}
+
+// newIdentityKey generates a client key of the named type.
+func newIdentityKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, 3072)
+	}
+	return nil, fmt.Errorf("unknown key type %q (use ecdsa, ed25519 or rsa)", keyType)
+}
+
+// identityCertificate returns a self-signed client certificate for service,
+// valid for TLS client authentication only, or with csr a request for a CA
+// to sign.
+func identityCertificate(service string, key crypto.Signer, csr bool, days int, now time.Time) (block *pem.Block, cert *x509.Certificate, err error) {
+	subject := pkix.Name{CommonName: service}
+	if csr {
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: subject, DNSNames: []string{service}}, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}, nil, nil
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	usage := x509.KeyUsageDigitalSignature
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		usage |= x509.KeyUsageKeyEncipherment
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		DNSNames:     []string{service},
+		// Allow for clocks running a little behind
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.AddDate(0, 0, days),
+		KeyUsage:              usage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		return nil, nil, err
+	}
+	return &pem.Block{Type: "CERTIFICATE", Bytes: der}, cert, nil
+}
+
+// writeIdentity generates the bundle of one service in dir/service. The
+// password and key are readable by the owner only.
+func writeIdentity(gen *passgen.Generator, dir, service, keyType string, csr bool, days int, force bool) (*identityOutput, error) {
+	bundle := filepath.Join(dir, service)
+	out := &identityOutput{Service: service, Dir: bundle, KeyType: keyType, Certificate: identityCertFile, CSR: csr}
+	if csr {
+		out.Certificate = identityCSRFile
+	}
+	if !force {
+		for _, name := range []string{identityPasswordFile, identityKeyFile, out.Certificate} {
+			if _, err := os.Stat(filepath.Join(bundle, name)); err == nil {
+				return nil, fmt.Errorf("%s already exists (use -force to replace it)", filepath.Join(bundle, name))
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("generating password: %w", err)
+	}
+	key, err := newIdentityKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	block, cert, err := identityCertificate(service, key, csr, days, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("creating certificate: %w", err)
+	}
+	if cert != nil {
+		sum := sha256.Sum256(cert.Raw)
+		out.SHA256 = hex.EncodeToString(sum[:])
+		out.NotAfter = cert.NotAfter.UTC().Format(time.RFC3339)
+	}
+	out.PasswordFingerprint = passgen.Fingerprint(password)
+
+	if err := os.MkdirAll(bundle, 0700); err != nil {
+		return nil, err
+	}
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{identityPasswordFile, []byte(password + "\n"), 0600},
+		{identityKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600},
+		{out.Certificate, pem.EncodeToMemory(block), 0644},
+	}
+	for _, f := range files {
+		path := filepath.Join(bundle, f.name)
+		// WriteFile keeps the mode of a file it replaces
+		os.Remove(path)
+		if err := os.WriteFile(path, f.data, f.perm); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func printIdentity(w io.Writer, id *identityOutput) {
+	fmt.Fprintf(w, "%s: %s\n", id.Service, id.Dir)
+	fmt.Fprintf(w, "  %-12s fingerprint %s\n", identityPasswordFile, id.PasswordFingerprint)
+	fmt.Fprintf(w, "  %-12s %s\n", identityKeyFile, id.KeyType)
+	if id.CSR {
+		fmt.Fprintf(w, "  %-12s CN=%s, to be signed by your CA\n", id.Certificate, id.Service)
+		return
+	}
+	fmt.Fprintf(w, "  %-12s self-signed, expires %s\n", id.Certificate, id.NotAfter)
+	fmt.Fprintf(w, "  %-12s %s\n", "sha256", id.SHA256)
+}
+
+// runServiceIdentity implements the service-identity subcommand.
+func runServiceIdentity(programName string, args []string) error {
+	fs := flag.NewFlagSet("service-identity", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "Directory for the bundles")
+	length := fs.Int("l", 32, "Password length")
+	includeSpecial := fs.Bool("s", false, "Include special characters")
+	keyType := fs.String("key", "ecdsa", "Key type: ecdsa, ed25519 or rsa")
+	csr := fs.Bool("csr", false, "Write a CSR instead of a self-signed certificate")
+	days := fs.Int("days", 365, "Validity of the self-signed certificate")
+	force := fs.Bool("force", false, "Replace existing bundles")
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printServiceIdentityUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printServiceIdentityUsage(programName)
+		return nil
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("give at least one service name, e.g. %s service-identity billing-api", programName)
+	}
+	for _, service := range fs.Args() {
+		if !serviceNamePattern.MatchString(service) {
+			return fmt.Errorf("invalid service name %q: use up to 63 letters, digits, ., - and _, starting with a letter or digit", service)
+		}
+	}
+	if *length < minLength || *length > maxLength {
+		return fmt.Errorf("password length must be between %d and %d", minLength, maxLength)
+	}
+	if *includeSpecial && *length < 4 {
+		return fmt.Errorf("password length must be at least 4 when using special characters")
+	}
+	*keyType = strings.ToLower(*keyType)
+	switch *keyType {
+	case "ecdsa", "ed25519", "rsa":
+	default:
+		return fmt.Errorf("unknown key type %q (use ecdsa, ed25519 or rsa)", *keyType)
+	}
+	if *days < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	gen, err := passgen.NewGenerator(passgen.WithLength(*length), passgen.WithSpecial(*includeSpecial), passgen.WithBlocklist(commonBlocklist()))
+	if err != nil {
+		return err
+	}
+	out := identitiesOutput{Schema: outputSchema}
+	for i, service := range fs.Args() {
+		id, err := writeIdentity(gen, *dir, service, *keyType, *csr, *days, *force)
+		if err != nil {
+			return fmt.Errorf("%s: %w", service, err)
+		}
+		out.Identities = append(out.Identities, *id)
+		if *format == "text" {
+			if i > 0 {
+				fmt.Println()
+			}
+			printIdentity(os.Stdout, id)
+		}
+	}
+	if *format == "json" {
+		return writeJSON(os.Stdout, out)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// readPEM reads the single PEM block of a file
+func readPEM(t *testing.T, path string) *pem.Block {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, rest := pem.Decode(data)
+	if block == nil || len(rest) != 0 {
+		t.Fatalf("%s is not a single PEM block", path)
+	}
+	return block
+}
+
+// TestWriteIdentity tests the files, modes and certificates of the bundles
+func TestWriteIdentity(t *testing.T) {
+	gen, err := passgen.NewGenerator(passgen.WithLength(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, keyType := range []string{"ecdsa", "ed25519", "rsa"} {
+		service := "svc-" + keyType
+		id, err := writeIdentity(gen, dir, service, keyType, false, 30, false)
+		if err != nil {
+			t.Fatalf("%s: %v", keyType, err)
+		}
+		bundle := filepath.Join(dir, service)
+		for name, mode := range map[string]os.FileMode{
+			identityPasswordFile: 0600,
+			identityKeyFile:      0600,
+			identityCertFile:     0644,
+		} {
+			info, err := os.Stat(filepath.Join(bundle, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != mode {
+				t.Errorf("%s: %s has mode %v, want %v", keyType, name, info.Mode().Perm(), mode)
+			}
+		}
+		password, err := os.ReadFile(filepath.Join(bundle, identityPasswordFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := passgen.Fingerprint(strings.TrimSuffix(string(password), "\n")); got != id.PasswordFingerprint {
+			t.Errorf("%s: fingerprint %s, want %s", keyType, id.PasswordFingerprint, got)
+		}
+
+		cert, err := x509.ParseCertificate(readPEM(t, filepath.Join(bundle, identityCertFile)).Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert.Subject.CommonName != service || !slices.Equal(cert.DNSNames, []string{service}) {
+			t.Errorf("%s: subject %v, SANs %v", keyType, cert.Subject, cert.DNSNames)
+		}
+		if !slices.Equal(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}) {
+			t.Errorf("%s: extended key usage %v, want client authentication only", keyType, cert.ExtKeyUsage)
+		}
+		if cert.IsCA {
+			t.Errorf("%s: certificate is a CA", keyType)
+		}
+		if d := time.Until(cert.NotAfter); d < 29*24*time.Hour || d > 30*24*time.Hour {
+			t.Errorf("%s: certificate expires in %v, want 30 days", keyType, d)
+		}
+		if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+			t.Errorf("%s: %v", keyType, err)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(readPEM(t, filepath.Join(bundle, identityKeyFile)).Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !key.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(cert.PublicKey) {
+			t.Errorf("%s: key does not match the certificate", keyType)
+		}
+	}
+}
+
+// TestWriteIdentityCSR tests the certificate signing request of a bundle
+func TestWriteIdentityCSR(t *testing.T) {
+	gen, err := passgen.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	id, err := writeIdentity(gen, dir, "orders.worker", "ecdsa", true, 365, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Certificate != identityCSRFile || id.SHA256 != "" {
+		t.Errorf("Unexpected output %+v", id)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "orders.worker", identityCertFile)); !os.IsNotExist(err) {
+		t.Errorf("A CSR bundle should have no certificate, stat error = %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(readPEM(t, filepath.Join(dir, "orders.worker", identityCSRFile)).Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Error(err)
+	}
+	if csr.Subject.CommonName != "orders.worker" || !slices.Equal(csr.DNSNames, []string{"orders.worker"}) {
+		t.Errorf("Unexpected subject %v, SANs %v", csr.Subject, csr.DNSNames)
+	}
+}
+
+// TestWriteIdentityExisting tests that bundles are only replaced with -force
+func TestWriteIdentityExisting(t *testing.T) {
+	gen, err := passgen.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	first, err := writeIdentity(gen, dir, "api", "ed25519", false, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeIdentity(gen, dir, "api", "ed25519", false, 1, false); err == nil || !strings.Contains(err.Error(), "-force") {
+		t.Errorf("Expected an error about -force, got %v", err)
+	}
+	second, err := writeIdentity(gen, dir, "api", "ed25519", false, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.SHA256 == first.SHA256 {
+		t.Error("Forced bundle kept the old certificate")
+	}
+}
+
+// TestRunServiceIdentityArgs tests the rejected arguments
+func TestRunServiceIdentityArgs(t *testing.T) {
+	dir := t.TempDir()
+	tests := [][]string{
+		{"-dir", dir},
+		{"-dir", dir, "../etc"},
+		{"-dir", dir, "--", "-api"},
+		{"-dir", dir, "a/b"},
+		{"-dir", dir, "-key", "dsa", "api"},
+		{"-dir", dir, "-days", "0", "api"},
+		{"-dir", dir, "-l", "2", "api"},
+		{"-dir", dir, "-o", "yaml", "api"},
+	}
+	for _, args := range tests {
+		if err := runServiceIdentity("passgen", args); err == nil {
+			t.Errorf("Expected error for %q", args)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Rejected arguments wrote %d bundles", len(entries))
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printLocalAdminUsage(programName string) {
+	fmt.Printf("Usage: %s local-admin [OPTIONS]\n", programName)
+	fmt.Println("Rotate the password of this machine's local administrator, LAPS style:")
+	fmt.Println("generate a new password, store it in the configured sinks and then set it")
+	fmt.Println("on the account before any sink that cannot be rolled back, such as Vault.")
+	fmt.Println("The password is never printed; if storing it fails, the account keeps")
+	fmt.Println("its old password.")
+	fmt.Println("Options:")
+	fmt.Printf("  -user NAME   Account to rotate (default: %s)\n", defaultAdminUser)
+	fmt.Println("  -l LENGTH    Password length (default: 20)")
+	fmt.Println("  -s           Include special characters (default: true; -s=false to omit)")
+	fmt.Println("  -out FILE    Store the password in a CSV file; FILE may contain")
+	fmt.Println("               {{date}}, {{time}}, {{timestamp}} and {{label}}")
+	fmt.Println("  -vault-path PATH")
+	fmt.Println("               Store the password at a Vault KV path with the vault command")
+	fmt.Println("  -plugin NAME Store the password with the passgen-NAME sink plugin (repeatable)")
+	fmt.Println("  -label TEXT  Label stored with the password (default: HOST/USER)")
+	fmt.Println("  -audit-log FILE")
+	fmt.Println("               Append the rotation, with a fingerprint of the password, to FILE")
+	fmt.Println("  -dry-run     Generate and describe the rotation without storing or setting it")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample, run as root or Administrator from a daily scheduled task:")
+	fmt.Printf("  %s local-admin -vault-path secret/laps/$(hostname)\n", programName)
+}
+
+// localAccountSink sets the password of a local account. It cannot be
+// rolled back, since the old password is unknown, so it goes after every
+// sink that can: if storing fails, the account is left alone. It goes
+// before the sinks that cannot, such as Vault, so none of them replaces a
+// working password with one the account never received.
+type localAccountSink struct {
+	user string
+}
+
+func (s *localAccountSink) write(creds []credential) error {
+	if len(creds) != 1 {
+		return fmt.Errorf("local account %s takes exactly one password", s.user)
+	}
+	return setLocalPassword(s.user, creds[0].Password)
+}
+
+func (s *localAccountSink) String() string {
+	return "local account " + s.user
+}
+
+// storeAndSet delivers creds to the sinks, ordered by orderSinks, and the
+// account. The sinks that can be rolled back and the account take them as
+// one all-or-nothing batch; if that fails, nothing is returned and nothing
+// has changed. The sinks that cannot be rolled back follow, and each still
+// gets the password when another fails. It returns where the password went.
+func storeAndSet(sinks []sink, account sink, creds []credential) ([]sink, error) {
+	n := 0
+	for n < len(sinks) && reversible(sinks[n]) {
+		n++
+	}
+	delivered := append(sinks[:n:n], account)
+	if err := writeSinks(delivered, creds, false); err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, s := range sinks[n:] {
+		if err := s.write(creds); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered = append(delivered, s)
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// runLocalAdmin implements the local-admin subcommand.
+func runLocalAdmin(programName string, args []string) error {
+	fs := flag.NewFlagSet("local-admin", flag.ContinueOnError)
+	user := fs.String("user", defaultAdminUser, "Account to rotate")
+	length := fs.Int("l", 20, "Password length")
+	includeSpecial := fs.Bool("s", true, "Include special characters")
+	outFile := fs.String("out", "", "Store the password in a CSV file")
+	vaultPath := fs.String("vault-path", "", "Store the password at this Vault KV path")
+	var pluginNames stringList
+	fs.Var(&pluginNames, "plugin", "Store the password with the passgen-NAME sink plugin (repeatable)")
+	label := fs.String("label", "", "Label stored with the password")
+	auditLog := fs.String("audit-log", "", "Append the rotation to FILE")
+	dryRun := fs.Bool("dry-run", false, "Describe the rotation without storing or setting it")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printLocalAdminUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printLocalAdminUsage(programName)
+		return nil
+	}
+	if *user == "" {
+		return fmt.Errorf("-user cannot be empty")
+	}
+	if *length < minLength || *length > maxLength {
+		return fmt.Errorf("password length must be between %d and %d", minLength, maxLength)
+	}
+	if *includeSpecial && *length < 4 {
+		return fmt.Errorf("password length must be at least 4 when using special characters")
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	if *label == "" {
+		*label = host + "/" + *user
+	}
+
+	gen, err := passgen.NewGenerator(passgen.WithLength(*length), passgen.WithSpecial(*includeSpecial))
+	if err != nil {
+		return err
+	}
+	sinks, err := enablePlugins(pluginNames, gen.Pipeline())
+	if err != nil {
+		return err
+	}
+	sinks = withRetry(sinks, defaultRetryPolicy)
+	if *outFile != "" {
+		out, err := newCSVSink(*outFile)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, out)
+	}
+	if *vaultPath != "" {
+		vault, err := newVaultSink(*vaultPath)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, vault)
+	}
+	// Without a copy elsewhere nobody could log in as the account again
+	if len(sinks) == 0 {
+		return fmt.Errorf("choose where to store the password with -out, -vault-path or a sink -plugin")
+	}
+	sinks = orderSinks(sinks)
+	stored := deliveredTo(sinks, false)
+	var auditKey []byte
+	if *auditLog != "" && !*dryRun {
+		if auditKey, err = loadAuditKey(auditKeyPath(*auditLog), true); err != nil {
+			return err
+		}
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		return fmt.Errorf("generating password: %w", err)
+	}
+	fingerprint := passgen.Fingerprint(password)
+	if *dryRun {
+		fmt.Printf("Would store a new password for %s (%s) in %s and set it on the account\n", *user, *label, strings.Join(stored, ", "))
+		return nil
+	}
+	cred := []credential{{Label: *label, Password: password, Note: "local administrator"}}
+	delivered, err := storeAndSet(sinks, &localAccountSink{user: *user}, cred)
+	if delivered == nil {
+		return err
+	}
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+		if len(delivered) == 1 {
+			fmt.Fprintf(os.Stderr, "The new password of %s could not be stored anywhere; keep it now: %s\n", *user, password)
+		}
+	}
+	if *auditLog != "" {
+		rec := auditRecord{
+			Command:      "local-admin",
+			Mode:         "random",
+			Label:        *label,
+			Count:        1,
+			Length:       *length,
+			Fingerprints: []string{auditFingerprint(auditKey, password)},
+			DeliveredTo:  deliveredTo(delivered, false),
+		}
+		if err := appendAudit(*auditLog, rec, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("writing audit log: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("the password of %s was changed but not stored everywhere: %w", *user, errors.Join(errs...))
+	}
+	fmt.Printf("Rotated the password of %s on %s\n", *user, host)
+	fmt.Printf("Stored in: %s\n", strings.Join(stored, ", "))
+	fmt.Printf("Fingerprint: %s\n", fingerprint)
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build passgen_lite
+
+package main
+
+import (
+	"errors"
+	"runtime"
+)
+
+// defaultAdminUser is the account local-admin rotates unless told otherwise.
+var defaultAdminUser = "root"
+
+func init() {
+	if runtime.GOOS == "windows" {
+		defaultAdminUser = "Administrator"
+	}
+}
+
+// setLocalPassword fails, since lite builds leave out the integrations
+// that change the system.
+func setLocalPassword(user, password string) error {
+	return errors.New("setting local passwords is not available in this build (built with passgen_lite)")
+}
@@ -0,0 +1,83 @@
+//go:build !passgen_lite && !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSetLocalPassword tests the input passed to chpasswd
+func TestSetLocalPassword(t *testing.T) {
+	got := filepath.Join(t.TempDir(), "chpasswd.in")
+	old := chpasswdCommand
+	defer func() { chpasswdCommand = old }()
+	chpasswdCommand = []string{"sh", "-c", "cat > " + got}
+
+	if err := setLocalPassword("root", "s3cr:et"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "root:s3cr:et\n" {
+		t.Errorf("chpasswd read %q", data)
+	}
+	for _, user := range []string{"ro:ot", "ro\not"} {
+		if err := setLocalPassword(user, "secret"); err == nil {
+			t.Errorf("Expected error for user %q", user)
+		}
+	}
+}
+
+// TestStoreAndSet tests that the account is set between the sinks that can
+// be rolled back and those that cannot
+func TestStoreAndSet(t *testing.T) {
+	creds := []credential{{Label: "host/root", Password: "secret"}}
+	undone, kept := &undoSink{}, &memorySink{}
+	account := &memorySink{}
+	delivered, err := storeAndSet(orderSinks([]sink{kept, failingSink{}, undone}), account, creds)
+	if err == nil {
+		t.Error("Expected the failing sink's error")
+	}
+	if len(delivered) != 3 || delivered[0] != undone || delivered[1] != account || delivered[2] != kept {
+		t.Errorf("Delivered to %v", deliveredTo(delivered, false))
+	}
+	if len(undone.creds) != 1 || len(kept.creds) != 1 || len(account.creds) != 1 {
+		t.Errorf("A failing irreversible sink kept the others from the password: %v %v %v", undone.creds, account.creds, kept.creds)
+	}
+
+	undone = &undoSink{}
+	vault := &memorySink{}
+	delivered, err = storeAndSet(orderSinks([]sink{vault, undone}), failingSink{}, creds)
+	if err == nil || delivered != nil {
+		t.Fatalf("storeAndSet() = %v, %v, want a failed account", delivered, err)
+	}
+	if len(undone.creds) != 0 || len(vault.creds) != 0 {
+		t.Errorf("A failed account left the password in %v and %v", undone.creds, vault.creds)
+	}
+}
+
+// TestLocalAccountSinkLast tests that a failed password change rolls back
+// the stored copy, so the sinks never hold a password the account lacks
+func TestLocalAccountSinkLast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "laps.csv")
+	old := chpasswdCommand
+	defer func() { chpasswdCommand = old }()
+	chpasswdCommand = []string{"sh", "-c", "echo 'user unknown' >&2; exit 1"}
+
+	out, err := newCSVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sinks := []sink{out, &localAccountSink{user: "nobody"}}
+	err = writeSinks(sinks, []credential{{Label: "host/nobody", Password: "secret"}}, false)
+	if err == nil {
+		t.Fatal("Expected the password change to fail")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("CSV file should have been rolled back, stat error = %v", err)
+	}
+}
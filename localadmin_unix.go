@@ -0,0 +1,46 @@
+//go:build !passgen_lite && !windows
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	features = append(features, "local-admin")
+}
+
+// defaultAdminUser is the account local-admin rotates unless told otherwise.
+const defaultAdminUser = "root"
+
+// chpasswdCommand sets passwords from "user:password" lines on stdin, so
+// the password never appears in a process list.
+var chpasswdCommand = []string{"chpasswd"}
+
+// setLocalPassword sets the password of a local account with chpasswd.
+func setLocalPassword(user, password string) error {
+	if strings.ContainsAny(user, ":\n") || strings.Contains(password, "\n") {
+		return fmt.Errorf("user %q or its password cannot be passed to chpasswd", user)
+	}
+	return runTool(chpasswdCommand, user+":"+password+"\n")
+}
+
+// runTool runs an account management command with the given input and
+// extra arguments, reporting what it printed on stderr if it fails.
+func runTool(command []string, stdin string, args ...string) error {
+	cmd := exec.Command(command[0], append(command[1:len(command):len(command)], args...)...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v: %s", filepath.Base(command[0]), err, msg)
+		}
+		return fmt.Errorf("%s: %v", filepath.Base(command[0]), err)
+	}
+	return nil
+}
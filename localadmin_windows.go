@@ -0,0 +1,48 @@
+//go:build windows && !passgen_lite
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+func init() {
+	features = append(features, "local-admin")
+}
+
+// defaultAdminUser is the account local-admin rotates unless told otherwise.
+const defaultAdminUser = "Administrator"
+
+var procNetUserSetInfo = syscall.NewLazyDLL("netapi32.dll").NewProc("NetUserSetInfo")
+
+// userInfo1003 is USER_INFO_1003, which holds only a new password.
+type userInfo1003 struct {
+	password *uint16
+}
+
+// nerrUserNotFound is the NET_API_STATUS for an unknown account.
+const nerrUserNotFound = 2221
+
+// setLocalPassword sets the password of a local account with
+// NetUserSetInfo, as net user does.
+func setLocalPassword(user, password string) error {
+	name, err := syscall.UTF16PtrFromString(user)
+	if err != nil {
+		return err
+	}
+	secret, err := syscall.UTF16PtrFromString(password)
+	if err != nil {
+		return err
+	}
+	info := userInfo1003{password: secret}
+	status, _, _ := procNetUserSetInfo.Call(0, uintptr(unsafe.Pointer(name)), 1003, uintptr(unsafe.Pointer(&info)), 0)
+	switch status {
+	case 0:
+		return nil
+	case nerrUserNotFound:
+		return fmt.Errorf("NetUserSetInfo: no local account %q", user)
+	}
+	return fmt.Errorf("NetUserSetInfo: %w", syscall.Errno(status))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// errLocked is returned when another process holds a lock.
+var errLocked = errors.New("locked by another process")
+
+// lockInfo is written into a lock file to identify its holder.
+type lockInfo struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Created time.Time `json:"created"`
+}
+
+// fileLock is an exclusive lock the operating system holds on an open
+// file: flock, or LockFileEx on Windows. The system releases it when its
+// holder exits, however it exits, so a crashed run never leaves a lock
+// behind and a slow one is never taken over. The file itself stays, and
+// only records the holder for messages.
+type fileLock struct {
+	f *os.File
+}
+
+// acquireLock locks the file at path, creating it if needed, waiting up to
+// wait for another holder to release it.
+func acquireLock(path string, wait time.Duration) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			if holder, err := readLock(path); err == nil && holder.PID != 0 {
+				return nil, fmt.Errorf("%s: %w (pid %d on %s since %s)", path, errLocked, holder.PID, holder.Host, holder.Created.Format(time.RFC3339))
+			}
+			return nil, fmt.Errorf("%s: %w", path, errLocked)
+		}
+		time.Sleep(min(200*time.Millisecond, time.Until(deadline)))
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.Marshal(lockInfo{PID: os.Getpid(), Host: host, Created: time.Now().UTC()})
+	if err == nil {
+		if err = f.Truncate(0); err == nil {
+			_, err = f.WriteAt(data, 0)
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// readLock returns the holder recorded in a lock file. A lock file that is
+// free, or whose holder has not written it yet, records none.
+func readLock(path string) (lockInfo, error) {
+	var info lockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	json.Unmarshal(data, &info)
+	return info, nil
+}
+
+// release clears the holder from the lock file and unlocks it.
+func (l *fileLock) release() error {
+	l.f.Truncate(0)
+	return l.f.Close()
+}
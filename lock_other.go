@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// tryLockFile fails, since there is no file locking to build on here.
+func tryLockFile(f *os.File) (bool, error) {
+	return false, fmt.Errorf("locking files is not supported on %s", runtime.GOOS)
+}
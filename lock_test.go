@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAcquireLock tests that a held lock excludes others until released
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json.lock")
+
+	first, err := acquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("First acquire failed: %v", err)
+	}
+	if _, err := acquireLock(path, 0); !errors.Is(err, errLocked) {
+		t.Fatalf("Second acquire error = %v, want errLocked", err)
+	}
+
+	if err := first.release(); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	second, err := acquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	second.release()
+}
+
+// TestAcquireLockWait tests waiting for the holder to release
+func TestAcquireLockWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	first, err := acquireLock(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		first.release()
+	}()
+	second, err := acquireLock(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Waiting acquire failed: %v", err)
+	}
+	second.release()
+}
+
+// TestAcquireLockLeftover tests that a lock file left by a crashed holder,
+// which no longer holds the lock, is acquired and records the new holder
+func TestAcquireLockLeftover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	if err := os.WriteFile(path, []byte(`{"pid":1,"host":"gone","created":"2020-01-01T00:00:00Z"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := acquireLock(path, 0)
+	if err != nil {
+		t.Fatalf("Leftover lock file should be acquired: %v", err)
+	}
+	if holder, _ := readLock(path); holder.PID != os.Getpid() {
+		t.Errorf("Lock holder = %d, want %d", holder.PID, os.Getpid())
+	}
+	l.release()
+	if holder, _ := readLock(path); holder.PID != 0 {
+		t.Errorf("Released lock still records pid %d", holder.PID)
+	}
+}
+
+// TestAcquireLockExclusive tests that waiting contenders hold the lock one
+// at a time
+func TestAcquireLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	var holders, overlaps atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l, err := acquireLock(path, 10*time.Second)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if holders.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			time.Sleep(10 * time.Millisecond)
+			holders.Add(-1)
+			l.release()
+		}()
+	}
+	wg.Wait()
+	if n := overlaps.Load(); n > 0 {
+		t.Errorf("The lock was held by two contenders at once %d times", n)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	// errorLockViolation is ERROR_LOCK_VIOLATION, returned when another
+	// handle holds the lock.
+	errorLockViolation syscall.Errno = 33
+)
+
+// tryLockFile takes an exclusive LockFileEx lock on f without waiting, and
+// reports whether it got it. Windows locks keep other handles from reading
+// the locked bytes, so a byte far past the holder record is locked instead
+// of the file's contents.
+func tryLockFile(f *os.File) (bool, error) {
+	ol := syscall.Overlapped{OffsetHigh: 0x7fffffff}
+	ok, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if ok != 0 {
+		return true, nil
+	}
+	if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
@@ -1,198 +1,317 @@
 package main
 
 import (
-	"crypto/rand"
+	"errors"
 	"flag"
 	"fmt"
-	"math/big"
 	"os"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
 )
 
-// Character sets excluding similar characters (0, O, I, l, 1)
+// version is set at build time with -ldflags "-X main.version=v2.1.0".
+var version = "dev"
+
+// Limits enforced on generation requests.
 const (
-	uppercase = "ABCDEFGHJKLMNPQRSTUVWXYZ"
-	lowercase = "abcdefghijkmnpqrstuvwxyz"
-	numbers   = "23456789"
-	special   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+	minLength = 3
+	maxLength = 128
+	maxCount  = 100
 )
 
-func printUsage(programName string) {
-	fmt.Printf("Usage: %s [OPTIONS]\n", programName)
-	fmt.Println("Options:")
-	fmt.Println("  -l LENGTH    Password length (default: 12)")
-	fmt.Println("  -s           Include special characters")
-	fmt.Println("  -c COUNT     Number of passwords to generate (default: 1)")
-	fmt.Println("  -h           Show this help message")
-	fmt.Println("\nExamples:")
-	fmt.Printf("  %s                    # Generate 12-character password\n", programName)
-	fmt.Printf("  %s -l 16 -s           # Generate 16-character password with special chars\n", programName)
-	fmt.Printf("  %s -l 10 -c 5         # Generate 5 passwords of 10 characters each\n", programName)
-}
+// streamBatchSize is how many passwords generate -stream delivers to the
+// sinks at a time.
+const streamBatchSize = 1000
 
-func getRandomChar(charset string) (byte, error) {
-	max := big.NewInt(int64(len(charset)))
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		return 0, err
-	}
-	return charset[n.Int64()], nil
+// command is a subcommand that parses its own flags.
+type command struct {
+	name    string
+	summary string
+	run     func(programName string, args []string) error
 }
 
-func shuffleString(str []byte) error {
-	length := len(str)
-	for i := length - 1; i > 0; i-- {
-		max := big.NewInt(int64(i + 1))
-		jBig, err := rand.Int(rand.Reader, max)
-		if err != nil {
-			return err
-		}
-		j := jBig.Int64()
-		str[i], str[j] = str[j], str[i]
-	}
-	return nil
-}
+// commands lists the subcommands in the order they appear in the help.
+var commands []command
 
-func generatePassword(length int, includeSpecial bool) (string, error) {
-	// Validate minimum length
-	minLength := 3
-	if includeSpecial {
-		minLength = 4
-	}
-	if length < minLength {
-		return "", fmt.Errorf("password length must be at least %d", minLength)
+func init() {
+	commands = []command{
+		{"generate", "Generate random passwords (the default command)", runGenerate},
+		{"passphrase", "Generate passphrases of random words from a wordlist", runPassphrase},
+		{"pin", "Generate numeric PINs that avoid easily guessed ones", runPIN},
+		{"derive-child", "Derive related passwords from one master secret with HKDF", runDeriveChild},
+		{"rotating", "Derive the password of the current time window from a shared secret", runRotating},
+		{"commit", "Seal a seed and options for a batch and print its commitment", runCommit},
+		{"reveal", "Generate the batch of a commit seal, checking its commitment", runReveal},
+		{"token", "Generate random identifiers as hex, base64url, proquints or Koremutake", runToken},
+		{"check", "Check an existing password against rules and an entropy target", runCheck},
+		{"pam", "Check new passwords for pam_exec during passwd", runPAM},
+		{"policy", "Check whether passgen satisfies a system password policy", runPolicy},
+		{"preset", "List and compare the standard policies of -policy", runPreset},
+		{"site", "Show or update the per-site password rules of -site", runSite},
+		{"version", "Print the version of this build", runVersion},
+		{"scrub", "Replace passwords in a CSV file with random equivalents", runScrub},
+		{"rpc", "Serve JSON-RPC on stdin/stdout for scripting languages", runRPC},
+		{"wizard", "Interactively label, generate and store credentials one by one", runWizard},
+		{"cron", "Rotate the secrets of a manifest whose age exceeds policy", runCron},
+		{"local-admin", "Rotate this machine's local administrator password into a sink", runLocalAdmin},
+		{"useradd", "Create a local user with a temporary password to change at first login", runUserAdd},
+		{"service-identity", "Generate a password and TLS client certificate bundle per service", runServiceIdentity},
+		{"audit", "Query the audit log of generated passwords", runAudit},
+		{"hibp", "Build an offline Pwned Passwords filter for -hibp-offline", runHIBP},
+		{"hash-tune", "Benchmark Argon2id and save the parameters for hashing passwords", runHashTune},
+		{"verify-manifest", "Check the signatures of JSON output written with -sign-key", runVerifyManifest},
+		{"capabilities", "Describe the modes, charsets, sinks and limits of this build", runCapabilities},
 	}
+}
 
-	password := make([]byte, length)
-	pos := 0
-
-	// Ensure at least one character from each required set
-	var err error
-	password[pos], err = getRandomChar(uppercase)
-	if err != nil {
-		return "", err
-	}
-	pos++
-
-	password[pos], err = getRandomChar(lowercase)
-	if err != nil {
-		return "", err
-	}
-	pos++
-
-	password[pos], err = getRandomChar(numbers)
-	if err != nil {
-		return "", err
-	}
-	pos++
-
-	if includeSpecial && length >= 4 {
-		password[pos], err = getRandomChar(special)
-		if err != nil {
-			return "", err
-		}
-		pos++
+func printUsage(programName string) {
+	fmt.Printf("Usage: %s [generate] [OPTIONS]\n", programName)
+	fmt.Printf("       %s COMMAND [OPTIONS]\n", programName)
+	fmt.Println("Commands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-12s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Printf("Run '%s COMMAND -h' for the options of a command.\n", programName)
+	fmt.Println("\nGenerate options:")
+	fmt.Println("  -l, --length LENGTH")
+	fmt.Println("               Password length (default: 12)")
+	fmt.Println("  -s, --special")
+	fmt.Println("               Include special characters")
+	fmt.Println("  -c, --count COUNT")
+	fmt.Println("               Number of passwords to generate (default: 1, at most 100 without")
+	fmt.Println("               -stream)")
+	fmt.Println("  -stream      Deliver passwords in batches of 1000 as they are generated, so any")
+	fmt.Println("               -c runs in constant memory (text output, -out and sink plugins)")
+	fmt.Println("  -choices N   Show N candidates on stderr and output the one picked by number")
+	fmt.Println("  --no-upper, --no-lower, --no-digits")
+	fmt.Println("               Leave out a class; it is then not required either")
+	fmt.Println("  --digits-only")
+	fmt.Println("               Only use digits, e.g. for PINs (same as --no-upper --no-lower)")
+	fmt.Println("  --min-upper N, --min-lower N, --min-digits N, --min-special N")
+	fmt.Println("               At least N characters of the class (default: 1 of each in use);")
+	fmt.Println("               --min-special implies -s")
+	fmt.Println("  -ambiguity-level LEVEL")
+	fmt.Println("               Look-alikes to exclude: none, standard (0 O I l 1) or")
+	fmt.Println("               extended (also S 5 B 8 Z 2) (default: standard)")
+	fmt.Println("  -exclude CHARS")
+	fmt.Println("               Never use any of CHARS, e.g. characters a site forbids")
+	fmt.Println("  -charset CHARS")
+	fmt.Println("               Also draw at least one character from CHARS (repeatable)")
+	fmt.Println("  -include-category NAME, -exclude-category NAME")
+	fmt.Println("               Only use, or never use, a Unicode category such as Punctuation or Lu")
+	fmt.Println("  -include-script NAME, -exclude-script NAME")
+	fmt.Println("               Only use, or never use, a Unicode script such as Latin or Greek")
+	fmt.Println("  -allow-similar")
+	fmt.Println("               Include similar-looking characters (0, O, I, l, 1)")
+	fmt.Println("  -histogram   Print a histogram of password entropy after generation")
+	fmt.Println("  -filter-stats")
+	fmt.Println("               Report how many candidates the filters rejected and why, and the")
+	fmt.Println("               entropy and time that cost")
+	fmt.Println("  -show-entropy")
+	fmt.Println("               Show the theoretical entropy of each password, from the character")
+	fmt.Println("               sets, the length and the guaranteed characters of each class")
+	fmt.Println("  -min-entropy BITS")
+	fmt.Println("               Flag passwords whose estimated entropy is below BITS")
+	fmt.Println("  -strength    Show a zxcvbn-style strength score (0-4) and the estimated")
+	fmt.Println("               guesses of each password")
+	fmt.Println("  -min-score N Regenerate until the strength score is at least N (0-4)")
+	fmt.Println("  -e, --entropy BITS")
+	fmt.Println("               Use the shortest length that reaches BITS of entropy with the")
+	fmt.Println("               selected character sets; overrides -l")
+	fmt.Println("  -markov FILE Generate word-like passwords from a Markov model of FILE")
+	fmt.Println("  -markov-order N")
+	fmt.Println("               Characters of context used by the Markov model (default: 2)")
+	fmt.Println("  -pronounceable")
+	fmt.Println("               Alternate consonants and vowels so passwords can be read out over")
+	fmt.Println("               the phone; weaker per character, so use -l 16 or more")
+	fmt.Println("  -group N     Split passwords into groups of N characters for transcription")
+	fmt.Println("  -group-sep SEP")
+	fmt.Println("               Separator between groups (default: -)")
+	fmt.Println("  -group-in-length")
+	fmt.Println("               Count the separators in -l instead of adding them to it")
+	fmt.Println("  -apple-style Generate passwords like hbtkqe-5wfzcn-xvPrje, as iCloud Keychain")
+	fmt.Println("               suggests: easy to type on phones, 20 characters, 87 bits")
+	fmt.Println("  -dual-control")
+	fmt.Println("               Generate each password as two halves, each with every character set,")
+	fmt.Println("               for two people to enter so neither knows the whole")
+	fmt.Println("  -custodian-out FILE")
+	fmt.Println("               Append one half of each password to a CSV file; give it twice, the")
+	fmt.Println("               first for the first halves, and only fingerprints are printed")
+	fmt.Println("  -anchor-word LIST")
+	fmt.Println("               Embed one word of LIST (eff-long, eff-short or a wordlist file) at")
+	fmt.Println("               a random position among random characters; use -l 16 or more")
+	fmt.Println("  -pattern MASK")
+	fmt.Println("               Draw each position from a class, KeePass style, e.g. uullddss or")
+	fmt.Println("               A{4}-d{4}-s{2}; the pattern sets the length (see README)")
+	fmt.Println("  -canary      Generate canary credentials with a hidden marker and record their hashes")
+	fmt.Println("  -canary-dir DIR")
+	fmt.Println("               Canary key and registry directory (default: user config dir)")
+	fmt.Println("  -canary-check FILE")
+	fmt.Println("               Scan FILE (or - for stdin) for canary credentials")
+	fmt.Println("  -rule EXPR   Only accept passwords matching the expression (repeatable)")
+	fmt.Println("  -hibp        Regenerate passwords found in Have I Been Pwned's Pwned Passwords;")
+	fmt.Println("               only the first 5 hex digits of each SHA-1 are sent")
+	fmt.Println("  -hibp-offline FILE")
+	fmt.Println("               Regenerate passwords in a bloom filter built by hibp download")
+	fmt.Println("  -offline     Skip checks that need the network, such as -hibp")
+	fmt.Println("  -no-blocklist")
+	fmt.Println("               Allow passwords on the embedded list of common passwords, which are")
+	fmt.Println("               otherwise regenerated")
+	fmt.Println("  -no-keyboard-walks")
+	fmt.Println("               Reject passwords with keyboard walks of 4+ keys, e.g. qwer, zxcv, 1qaz")
+	fmt.Println("  -no-sequences")
+	fmt.Println("               Reject passwords with sequences of 4+ characters, e.g. abcd, 9876")
+	fmt.Println("  -no-repeats  Reject passwords with a character 3+ times in a row, e.g. aaa")
+	fmt.Println("  -avoid WORDS Reject passwords containing any of the comma-separated words, such")
+	fmt.Println("               as a user name or birth year, ignoring case and l33t (repeatable)")
+	fmt.Println("  -no-words    Reject passwords containing a word of 4+ letters from the embedded")
+	fmt.Println("               wordlists, e.g. Xq7horse2k")
+	fmt.Println("  -max-attempts N")
+	fmt.Println("               Candidates to try for each password before giving up when filters")
+	fmt.Println("               and rules reject them (default: 100)")
+	fmt.Println("  -no-confusables")
+	fmt.Println("               Reject passwords with look-alike sequences such as rn (m) or vv (w)")
+	fmt.Println("  -preview     Also show each password spaced out, bracketed and with the hex")
+	fmt.Println("               code of every character, so no character can be misread")
+	fmt.Println("  -homoglyph-report")
+	fmt.Println("               List the look-alike characters and sequences in each password")
+	fmt.Println("  -fingerprint FORMAT")
+	fmt.Println("               Also show a short SHA-256 fingerprint of each password, as hex or")
+	fmt.Println("               emoji, so two parties can compare credentials without revealing them")
+	fmt.Println("  -fingerprint-only")
+	fmt.Println("               Show fingerprints instead of the passwords, which then only go to")
+	fmt.Println("               -out, -vault-path, -copy or a sink plugin")
+	fmt.Println("  -hash-format FORMAT")
+	fmt.Println("               Also show a salted hash of each password for a user table:")
+	fmt.Println("               argon2id, bcrypt, django or aspnet")
+	fmt.Println("  -hash-config FILE")
+	fmt.Println("               Argon2id parameters saved by hash-tune -config FILE (default:")
+	fmt.Println("               hash.json in the user config dir)")
+	fmt.Println("  -with-metadata")
+	fmt.Println("               Show when each hash was created, so audits can see its age")
+	fmt.Println("  -target NAME Warn when the length does not suit a service such as bcrypt or wpa2")
+	fmt.Println("  -preset NAME Defaults for a kind of secret: radius or tacacs shared secrets of")
+	fmt.Println("               32 characters with only device-safe special characters, or")
+	fmt.Println("               temporary passwords")
+	fmt.Println("  -temp        Generate temporary passwords that must be changed at first login,")
+	fmt.Println("               marked as such in structured output (-preset temporary)")
+	fmt.Println("  -expires DURATION")
+	fmt.Println("               How long -temp passwords are valid (default: 24h)")
+	fmt.Println("  -policy NAME Satisfy the password policy of a standard: nist (NIST SP 800-63B),")
+	fmt.Println("               pci (PCI DSS 4.0), ad (Active Directory complexity) or aws-iam")
+	fmt.Println("               (AWS IAM default); see preset list")
+	fmt.Println("  -policy-file FILE")
+	fmt.Println("               Satisfy the password policy defined in FILE: length, character")
+	fmt.Println("               classes, excluded characters and blocklists")
+	fmt.Println("  -site DOMAIN Satisfy the known password rules of a site, such as its maximum")
+	fmt.Println("               length or the special characters it accepts; see site show")
+	fmt.Println("  -server ADDR Device address of each secret for -o cisco or junos (repeatable)")
+	fmt.Println("  -encoding FORMAT")
+	fmt.Println("               Make passwords safe for yaml, env, shell, json, url or xml (repeatable)")
+	fmt.Println("  -encoding-action ACTION")
+	fmt.Println("               escape the output for -encoding or regenerate unsafe passwords (default: escape)")
+	fmt.Println("  -dice        Draw passwords from physical dice rolls or coin flips typed in,")
+	fmt.Println("               debiased, instead of the system random number generator")
+	fmt.Println("  -entropy-source SOURCE")
+	fmt.Println("               XOR the system random number generator with hwrng (/dev/hwrng) or")
+	fmt.Println("               a file or device, with health tests on each (repeatable)")
+	fmt.Println("  -beacon URL  Mix a public drand beacon round into the passwords: drand for the")
+	fmt.Println("               League of Entropy mainnet or a drand API URL; needs -transcript")
+	fmt.Println("  -beacon-round N")
+	fmt.Println("               Beacon round to draw with (default: the next one, waited for)")
+	fmt.Println("  -transcript FILE")
+	fmt.Println("               Write the proof of a -beacon draw, which reproduces its passwords")
+	fmt.Println("  -replay FILE Draw the passwords of a transcript or commit seal again and")
+	fmt.Println("               check them")
+	fmt.Println("  -rng-timeout DURATION")
+	fmt.Println("               Fail if the system RNG does not respond within DURATION, e.g. 2s")
+	fmt.Println("  -plugin NAME Enable the passgen-NAME plugin from PATH (repeatable)")
+	fmt.Println("  -sink-retries N")
+	fmt.Println("               Retries for failed sink plugin writes (default: 3)")
+	fmt.Println("  -sink-backoff DURATION")
+	fmt.Println("               Delay before the first retry, doubling each time (default: 1s)")
+	fmt.Println("  -verify-sink Read every batch back from the sinks that support it and compare")
+	fmt.Println("  -continue-on-error")
+	fmt.Println("               Keep writing to the other sinks when one fails instead of rolling back")
+	fmt.Println("  -list-plugins")
+	fmt.Println("               List plugins found on PATH")
+	fmt.Println("  -o, --format FORMAT")
+	fmt.Println("               Output format: text, json, ndjson, csv or tsv rows, or with -preset radius")
+	fmt.Println("               or tacacs, cisco or junos configuration (default: text)")
+	fmt.Println("  -header      Start -o csv and tsv with a row of column names")
+	fmt.Println("  -q, --quiet  Print only the passwords, one per line, without the banner or")
+	fmt.Println("               numbers, for scripts")
+	fmt.Println("  -template TEMPLATE")
+	fmt.Println("               Print each password as a Go text/template formats it, e.g.")
+	fmt.Println("               '{{.Index}}: {{.Password}} ({{.Entropy}} bits)'")
+	fmt.Println("  -mask        Show passwords as bullets on a terminal and reveal one at a time by")
+	fmt.Println("               number, until Enter hides it again")
+	fmt.Println("  -color WHEN  Color digits and symbols apart from letters: auto (on a terminal),")
+	fmt.Println("               always or never (default: never)")
+	fmt.Println("  -sign-key FILE")
+	fmt.Println("               Sign JSON output with the HMAC secret or Ed25519 private key in FILE")
+	fmt.Println("  -out FILE    Also append the passwords to a CSV file; FILE may contain")
+	fmt.Println("               {{date}}, {{time}}, {{timestamp}} and {{label}}")
+	fmt.Println("  -vault-path PATH")
+	fmt.Println("               Also store the passwords at a Vault KV path with the vault command")
+	fmt.Println("  -copy        Also copy the passwords to the clipboard")
+	fmt.Println("  -audit-log FILE")
+	fmt.Println("               Append who generated what and where it went to FILE, with")
+	fmt.Println("               keyed fingerprints instead of passwords (see audit query)")
+	fmt.Println("  -audit-sink NAME")
+	fmt.Println("               Also send the audit record to syslog or journald (repeatable)")
+	fmt.Println("  -metrics-out FILE")
+	fmt.Println("               Append anonymized policy and strength metrics, never secrets, to FILE")
+	fmt.Println("  -label TEXT  Label stored with every password in JSON output and sinks")
+	fmt.Println("  -note TEXT   Comment stored with every password in JSON output and sinks")
+	fmt.Println("  -h, --help   Show this help message")
+	fmt.Println("Any option may also be written with two dashes, and one-letter options")
+	fmt.Println("can be combined: -sc 5 is -s -c 5, -sl16 is -s -l 16.")
+	fmt.Println("Options with a long name can also be set in the environment as PASSGEN_NAME,")
+	fmt.Println("e.g. PASSGEN_LENGTH=16 or PASSGEN_MIN_ENTROPY=60; the command line wins.")
+	fmt.Println("\nExamples:")
+	fmt.Printf("  %s                    # Generate 12-character password\n", programName)
+	fmt.Printf("  %s -l 16 -s           # Generate 16-character password with special chars\n", programName)
+	fmt.Printf("  %s --length 16 --special  # The same with long options\n", programName)
+	fmt.Printf("  %s -l 10 -c 5         # Generate 5 passwords of 10 characters each\n", programName)
+	fmt.Printf("  %s -sc5               # Generate 5 passwords with special chars\n", programName)
+	fmt.Printf("  %s -c 50 -histogram   # Show the entropy spread of a batch\n", programName)
+	fmt.Printf("  %s -e 80 -s           # As long as 80 bits with special chars need\n", programName)
+	fmt.Printf("  %s -l 14 --min-digits 2 --min-special 2  # A typical corporate policy\n", programName)
+	fmt.Printf("  %s -rule 'maxRepeat <= 2'  # No character appears more than twice\n", programName)
+	fmt.Printf("  %s -label db -out 'creds-{{date}}-{{label}}.csv'\n", programName)
+}
 
-	// Fill remaining positions randomly
-	for i := pos; i < length; i++ {
-		var charsetChoice int
-		if includeSpecial {
-			max := big.NewInt(4)
-			n, err := rand.Int(rand.Reader, max)
-			if err != nil {
-				return "", err
-			}
-			charsetChoice = int(n.Int64())
-		} else {
-			max := big.NewInt(3)
-			n, err := rand.Int(rand.Reader, max)
-			if err != nil {
-				return "", err
+func main() {
+	// Every command parses its own flags. Without a command, generate
+	// runs so that `passgen -l 16` keeps working.
+	if len(os.Args) > 1 {
+		for _, cmd := range commands {
+			if os.Args[1] == cmd.name {
+				runCommand(cmd.run(os.Args[0], os.Args[2:]))
 			}
-			charsetChoice = int(n.Int64())
-		}
-
-		switch charsetChoice {
-		case 0:
-			password[i], err = getRandomChar(uppercase)
-		case 1:
-			password[i], err = getRandomChar(lowercase)
-		case 2:
-			password[i], err = getRandomChar(numbers)
-		case 3:
-			password[i], err = getRandomChar(special)
 		}
-
-		if err != nil {
-			return "", err
-		}
-	}
-
-	// Shuffle the password to randomize character positions
-	if err := shuffleString(password); err != nil {
-		return "", err
 	}
-
-	return string(password), nil
+	runCommand(runGenerate(os.Args[0], os.Args[1:]))
 }
 
-func main() {
-	length := flag.Int("l", 12, "Password length")
-	includeSpecial := flag.Bool("s", false, "Include special characters")
-	count := flag.Int("c", 1, "Number of passwords to generate")
-	help := flag.Bool("h", false, "Show help message")
-
-	flag.Parse()
-
-	if *help {
-		printUsage(os.Args[0])
+// runCommand exits with the outcome of a subcommand.
+func runCommand(err error) {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
 		os.Exit(0)
 	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
 
-	// Validate input
-	if *length < 3 {
-		fmt.Fprintln(os.Stderr, "Error: Password length must be at least 3")
-		os.Exit(1)
-	}
-	if *length > 128 {
-		fmt.Fprintln(os.Stderr, "Error: Password length cannot exceed 128")
-		os.Exit(1)
-	}
-	if *count < 1 {
-		fmt.Fprintln(os.Stderr, "Error: Count must be at least 1")
-		os.Exit(1)
-	}
-	if *count > 100 {
-		fmt.Fprintln(os.Stderr, "Error: Count cannot exceed 100")
-		os.Exit(1)
-	}
-	if *includeSpecial && *length < 4 {
-		fmt.Fprintln(os.Stderr, "Error: Password length must be at least 4 when using special characters")
-		os.Exit(1)
-	}
-
-	// Generate passwords
-	plural := ""
-	if *count > 1 {
-		plural = "s"
-	}
-
-	fmt.Printf("Generated password%s:\n", plural)
-	fmt.Printf("Length: %d characters\n", *length)
-	fmt.Print("Character sets: Uppercase, Lowercase, Numbers")
-	if *includeSpecial {
-		fmt.Print(", Special characters")
-	}
-	fmt.Println()
-	fmt.Println("Excluded similar characters: 0, O, I, l, 1")
-	fmt.Println()
-
-	for i := 0; i < *count; i++ {
-		password, err := generatePassword(*length, *includeSpecial)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error generating password: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("%d: %s\n", i+1, password)
+// loadMarkovModel trains a Markov model on a corpus file of whitespace
+// separated words or passwords.
+func loadMarkovModel(path string, order int) (*passgen.MarkovModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-}
\ No newline at end of file
+	words := strings.Fields(string(data))
+	return passgen.TrainMarkov(words, order)
+}
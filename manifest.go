@@ -0,0 +1,615 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// manifest describes the secrets a cron run keeps fresh. It is written in a
+// small subset of YAML:
+//
+//	state: rotate.state.json
+//	out: "creds-{{date}}-{{label}}.csv"
+//	plugins: [vault]
+//	sink-retries: 5
+//	secrets:
+//	  - label: db
+//	    max-age: 30d
+//	    length: 24
+//	    special: true
+//	    rules: ["maxRepeat <= 2"]
+type manifest struct {
+	// State is the file recording when each secret was last rotated.
+	// Relative State and Out paths are resolved against the manifest's
+	// directory.
+	State   string
+	Out     string
+	Plugins []string
+	// Retry applies to writes to sink plugins.
+	Retry retryPolicy
+	// ContinueOnError keeps writing a secret to the remaining sinks when
+	// one fails, instead of rolling back the others.
+	ContinueOnError bool
+	// VerifySink reads every secret back from the sinks that support it.
+	VerifySink bool
+	Secrets    []manifestSecret
+}
+
+// manifestSecret is one secret under rotation.
+type manifestSecret struct {
+	Label        string
+	MaxAge       time.Duration
+	Length       int
+	Special      bool
+	AllowSimilar bool
+	Ambiguity    passgen.Ambiguity
+	Exclude      string
+	NoUpper      bool
+	NoLower      bool
+	NoDigits     bool
+	MinUpper     int
+	MinLower     int
+	MinDigits    int
+	MinSpecial   int
+	NoWalks      bool
+	// NoSequences and NoRepeats reject values with sequences such as
+	// abcd and with a character three times in a row.
+	NoSequences bool
+	NoRepeats   bool
+	// NoConfusables rejects values with look-alike sequences such as rn.
+	NoConfusables bool
+	Rules         []string
+	// Encodings lists destination formats the secret must survive
+	// verbatim; unsafe values are regenerated.
+	Encodings []string
+	// Targets names services whose length limits the secret must fit.
+	Targets []string
+	// Unicode limits the secret to, or keeps it away from, Unicode
+	// categories and scripts.
+	Unicode unicodeFilter
+	Note    string
+}
+
+// loadManifest reads and validates a manifest file.
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseManifest(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// parseManifest decodes and validates manifest text.
+func parseManifest(text string) (*manifest, error) {
+	root, err := parseYAML(text)
+	if err != nil {
+		return nil, err
+	}
+	top, ok := root.(yamlMap)
+	if !ok {
+		return nil, fmt.Errorf("manifest must be a mapping of keys to values")
+	}
+
+	m := &manifest{Retry: defaultRetryPolicy}
+	for _, entry := range top {
+		var err error
+		switch entry.key {
+		case "state":
+			m.State, err = yamlString(entry)
+		case "out":
+			m.Out, err = yamlString(entry)
+		case "plugins":
+			m.Plugins, err = yamlStrings(entry)
+		case "sink-retries":
+			if m.Retry.Retries, err = yamlInt(entry); err == nil && m.Retry.Retries < 0 {
+				err = fmt.Errorf("sink-retries cannot be negative")
+			}
+		case "verify-sink":
+			m.VerifySink, err = yamlBool(entry)
+		case "continue-on-error":
+			m.ContinueOnError, err = yamlBool(entry)
+		case "sink-backoff":
+			var v string
+			if v, err = yamlString(entry); err == nil {
+				m.Retry.Backoff, err = parseAge(v)
+			}
+		case "secrets":
+			items, ok := entry.value.(yamlList)
+			if !ok {
+				err = fmt.Errorf("secrets must be a list")
+				break
+			}
+			for _, item := range items {
+				secret, err := parseManifestSecret(item, entry.line)
+				if err != nil {
+					return nil, err
+				}
+				m.Secrets = append(m.Secrets, secret)
+			}
+		default:
+			err = fmt.Errorf("unknown key %q", entry.key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", entry.line, err)
+		}
+	}
+
+	if len(m.Secrets) == 0 {
+		return nil, fmt.Errorf("manifest lists no secrets")
+	}
+	if m.Out == "" && len(m.Plugins) == 0 {
+		return nil, fmt.Errorf("manifest needs a sink: set out or plugins")
+	}
+	seen := make(map[string]bool)
+	for _, s := range m.Secrets {
+		if seen[s.Label] {
+			return nil, fmt.Errorf("secret %q is listed twice", s.Label)
+		}
+		seen[s.Label] = true
+	}
+	return m, nil
+}
+
+// parseManifestSecret decodes one item of the secrets list, which starts
+// after the given line.
+func parseManifestSecret(item yamlValue, line int) (manifestSecret, error) {
+	s := manifestSecret{Length: 12}
+	fields, ok := item.(yamlMap)
+	if !ok {
+		return s, fmt.Errorf("line %d: each secret must be a mapping with a label", line)
+	}
+
+	line = fields[0].line
+	for _, entry := range fields {
+		var err error
+		switch entry.key {
+		case "label":
+			s.Label, err = yamlString(entry)
+		case "max-age":
+			var v string
+			if v, err = yamlString(entry); err == nil {
+				s.MaxAge, err = parseAge(v)
+			}
+		case "length":
+			s.Length, err = yamlInt(entry)
+		case "special":
+			s.Special, err = yamlBool(entry)
+		case "allow-similar":
+			s.AllowSimilar, err = yamlBool(entry)
+		case "ambiguity-level":
+			var v string
+			if v, err = yamlString(entry); err == nil {
+				s.Ambiguity, err = passgen.ParseAmbiguity(v)
+			}
+		case "exclude":
+			s.Exclude, err = yamlString(entry)
+		case "include-category":
+			s.Unicode.IncludeCategories, err = yamlStrings(entry)
+		case "exclude-category":
+			s.Unicode.ExcludeCategories, err = yamlStrings(entry)
+		case "include-script":
+			s.Unicode.IncludeScripts, err = yamlStrings(entry)
+		case "exclude-script":
+			s.Unicode.ExcludeScripts, err = yamlStrings(entry)
+		case "no-upper":
+			s.NoUpper, err = yamlBool(entry)
+		case "no-lower":
+			s.NoLower, err = yamlBool(entry)
+		case "no-digits":
+			s.NoDigits, err = yamlBool(entry)
+		case "min-upper":
+			s.MinUpper, err = yamlInt(entry)
+		case "min-lower":
+			s.MinLower, err = yamlInt(entry)
+		case "min-digits":
+			s.MinDigits, err = yamlInt(entry)
+		case "min-special":
+			s.MinSpecial, err = yamlInt(entry)
+		case "no-keyboard-walks":
+			s.NoWalks, err = yamlBool(entry)
+		case "no-sequences":
+			s.NoSequences, err = yamlBool(entry)
+		case "no-repeats":
+			s.NoRepeats, err = yamlBool(entry)
+		case "no-confusables":
+			s.NoConfusables, err = yamlBool(entry)
+		case "rules":
+			s.Rules, err = yamlStrings(entry)
+		case "encoding":
+			if s.Encodings, err = yamlStrings(entry); err == nil {
+				for _, name := range s.Encodings {
+					if _, err = passgen.LookupEncoding(name); err != nil {
+						break
+					}
+				}
+			}
+		case "target":
+			if s.Targets, err = yamlStrings(entry); err == nil {
+				for _, name := range s.Targets {
+					if _, err = lookupTarget(name); err != nil {
+						break
+					}
+				}
+			}
+		case "note":
+			s.Note, err = yamlString(entry)
+		default:
+			err = fmt.Errorf("unknown key %q", entry.key)
+		}
+		if err != nil {
+			return s, fmt.Errorf("line %d: %v", entry.line, err)
+		}
+	}
+
+	if s.MinSpecial > 0 {
+		s.Special = true
+	}
+	required := 0
+	for _, class := range []struct {
+		off bool
+		min int
+	}{{s.NoUpper, s.MinUpper}, {s.NoLower, s.MinLower}, {s.NoDigits, s.MinDigits}} {
+		if !class.off {
+			required += max(class.min, 1)
+		}
+	}
+	if s.Special {
+		required += max(s.MinSpecial, 1)
+	}
+	switch {
+	case s.Label == "":
+		return s, fmt.Errorf("line %d: secret has no label", line)
+	case s.MaxAge <= 0:
+		return s, fmt.Errorf("line %d: secret %q needs a positive max-age", line, s.Label)
+	case s.Length < minLength || s.Length > maxLength:
+		return s, fmt.Errorf("line %d: secret %q length must be between %d and %d", line, s.Label, minLength, maxLength)
+	case s.Special && s.Length < 4:
+		return s, fmt.Errorf("line %d: secret %q length must be at least 4 when using special characters", line, s.Label)
+	case s.NoUpper && s.NoLower && s.NoDigits && !s.Special:
+		return s, fmt.Errorf("line %d: secret %q disables every character class", line, s.Label)
+	case s.NoUpper && s.MinUpper > 0, s.NoLower && s.MinLower > 0, s.NoDigits && s.MinDigits > 0:
+		return s, fmt.Errorf("line %d: secret %q sets a minimum for a disabled class", line, s.Label)
+	case s.MinUpper < 0 || s.MinLower < 0 || s.MinDigits < 0 || s.MinSpecial < 0:
+		return s, fmt.Errorf("line %d: secret %q minimums cannot be negative", line, s.Label)
+	case required > s.Length:
+		return s, fmt.Errorf("line %d: secret %q minimums add up to more than its length", line, s.Label)
+	}
+	if _, err := s.Unicode.options(); err != nil {
+		return s, fmt.Errorf("line %d: secret %q: %v", line, s.Label, err)
+	}
+	// An unattended rotation must not lock anyone out, so a mismatch is
+	// an error here rather than a warning
+	for _, name := range s.Targets {
+		target, _ := lookupTarget(name)
+		if problem := target.check(s.Length); problem != "" {
+			return s, fmt.Errorf("line %d: secret %q: %s", line, s.Label, problem)
+		}
+	}
+	return s, nil
+}
+
+// parseAge parses a duration, additionally accepting whole days such as 30d.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d or 12h)", s)
+	}
+	return d, nil
+}
+
+// The manifest parser understands the YAML needed for configuration files:
+// nested mappings and lists by indentation, plain, single and double quoted
+// scalars, inline [a, b] lists and # comments. Anchors, multi-line strings
+// and flow mappings are not supported.
+type yamlValue any
+
+// yamlMap keeps its entries in file order so errors can point at lines.
+type yamlMap []yamlEntry
+
+type yamlEntry struct {
+	key   string
+	value yamlValue
+	line  int
+}
+
+type yamlList []yamlValue
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML parses a document into nested yamlMap, yamlList and string values.
+func parseYAML(text string) (yamlValue, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimRight(raw, " \r")
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(raw) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return yamlMap{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	v, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.pos].num)
+	}
+	return v, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// block parses the mapping or list whose lines start at the given indent.
+func (p *yamlParser) block(indent int) (yamlValue, error) {
+	if strings.HasPrefix(p.lines[p.pos].text, "-") {
+		return p.list(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (yamlMap, error) {
+	var m yamlMap
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if strings.HasPrefix(line.text, "-") {
+			return nil, fmt.Errorf("line %d: unexpected list item", line.num)
+		}
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line.num)
+		}
+		p.pos++
+
+		entry := yamlEntry{key: key, line: line.num}
+		if rest != "" {
+			v, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.num, err)
+			}
+			entry.value = v
+		} else if p.pos < len(p.lines) {
+			// A nested block is indented further, except that lists
+			// may also start at the key's own indent
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && strings.HasPrefix(next.text, "-")) {
+				v, err := p.block(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				entry.value = v
+			}
+		}
+		if entry.value == nil {
+			entry.value = ""
+		}
+		m = append(m, entry)
+	}
+	return m, nil
+}
+
+func (p *yamlParser) list(indent int) (yamlList, error) {
+	var l yamlList
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "-") {
+		line := p.lines[p.pos]
+		item := strings.TrimLeft(line.text[1:], " ")
+		if line.text != "-" && line.text[1] != ' ' {
+			return nil, fmt.Errorf("line %d: expected a space after -", line.num)
+		}
+
+		if _, _, ok := splitYAMLKey(item); ok {
+			// The item is a mapping whose first entry shares the dash line;
+			// rewrite that line as if the dash were indentation
+			itemIndent := indent + len(line.text) - len(item)
+			p.lines[p.pos] = yamlLine{num: line.num, indent: itemIndent, text: item}
+			m, err := p.mapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, m)
+			continue
+		}
+
+		p.pos++
+		v, err := parseYAMLScalar(item)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line.num, err)
+		}
+		l = append(l, v)
+	}
+	return l, nil
+}
+
+// splitYAMLKey splits "key: value" and reports whether the text starts with
+// a plain key. Keys contain letters, digits, dashes and underscores.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			break
+		}
+		i++
+	}
+	if i == 0 || i == len(text) || text[i] != ':' || (i+1 < len(text) && text[i+1] != ' ') {
+		return "", "", false
+	}
+	return text[:i], strings.TrimSpace(text[i+1:]), true
+}
+
+// parseYAMLScalar parses a value after "key:" or "-": a quoted or plain
+// string, or an inline list of them, followed by an optional comment.
+func parseYAMLScalar(text string) (yamlValue, error) {
+	if strings.HasPrefix(text, "[") {
+		var l yamlList
+		rest := strings.TrimSpace(text[1:])
+		for !strings.HasPrefix(rest, "]") {
+			v, n, err := scanYAMLString(rest, ",]")
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+			rest = strings.TrimSpace(rest[n:])
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, fmt.Errorf("unterminated list")
+			}
+		}
+		if err := checkYAMLTrailer(rest[1:]); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	v, n, err := scanYAMLString(text, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkYAMLTrailer(text[n:]); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// scanYAMLString reads one string from the start of text and returns it with
+// the number of bytes consumed. Plain strings end at a comment or at any of
+// the stop characters.
+func scanYAMLString(text, stop string) (string, int, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\\' {
+				i++
+				continue
+			}
+			if text[i] == '"' {
+				s, err := strconv.Unquote(text[:i+1])
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid quoted string %s", text[:i+1])
+				}
+				return s, i + 1, nil
+			}
+		}
+		return "", 0, fmt.Errorf("unterminated quoted string")
+	case strings.HasPrefix(text, "'"):
+		var b strings.Builder
+		for i := 1; i < len(text); i++ {
+			if text[i] != '\'' {
+				b.WriteByte(text[i])
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), i + 1, nil
+		}
+		return "", 0, fmt.Errorf("unterminated quoted string")
+	}
+
+	end := len(text)
+	if i := strings.Index(text, " #"); i >= 0 {
+		end = i
+	}
+	if i := strings.IndexAny(text[:end], stop); stop != "" && i >= 0 {
+		end = i
+	}
+	return strings.TrimSpace(text[:end]), end, nil
+}
+
+// checkYAMLTrailer accepts whitespace and a comment after a value.
+func checkYAMLTrailer(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+	return fmt.Errorf("unexpected %q after value", text)
+}
+
+func yamlString(e yamlEntry) (string, error) {
+	s, ok := e.value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", e.key)
+	}
+	return s, nil
+}
+
+// yamlStrings accepts a list of strings, or a single string as a list of one.
+func yamlStrings(e yamlEntry) ([]string, error) {
+	switch v := e.value.(type) {
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case yamlList:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings", e.key)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s must be a list of strings", e.key)
+}
+
+func yamlInt(e yamlEntry) (int, error) {
+	s, err := yamlString(e)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a whole number, got %q", e.key, s)
+	}
+	return n, nil
+}
+
+func yamlBool(e yamlEntry) (bool, error) {
+	s, err := yamlString(e)
+	if err != nil {
+		return false, err
+	}
+	switch s {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s must be true or false, got %q", e.key, s)
+}
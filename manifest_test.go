@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestParseManifest tests decoding of a complete manifest
+func TestParseManifest(t *testing.T) {
+	text := `# Nightly rotation
+state: state.json
+out: "creds-{{date}}-{{label}}.csv"  # one file per secret
+plugins: [vault, 'audit']
+sink-retries: 5
+sink-backoff: 500ms
+continue-on-error: true
+verify-sink: true
+secrets:
+  - label: db
+    max-age: 30d
+    length: 24
+    special: true
+    exclude: "&'"
+    exclude-category: Punctuation
+    include-script: [Latin, Common]
+    ambiguity-level: extended
+    no-keyboard-walks: true
+    no-sequences: true
+    no-repeats: true
+    no-confusables: true
+    target: [bcrypt]
+    rules: ["maxRepeat <= 2", "contains(password, \"#\") || true"]
+  - label: web
+    max-age: 12h
+    min-digits: 2
+    min-special: 1
+    no-upper: true
+    note: rotated by cron
+    encoding: [yaml, env]
+    rules:
+      - length >= 12
+      - 'upper >= 2'
+`
+	m, err := parseManifest(text)
+	if err != nil {
+		t.Fatalf("parseManifest failed: %v", err)
+	}
+
+	want := &manifest{
+		State:           "state.json",
+		Out:             "creds-{{date}}-{{label}}.csv",
+		Plugins:         []string{"vault", "audit"},
+		Retry:           retryPolicy{Retries: 5, Backoff: 500 * time.Millisecond},
+		ContinueOnError: true,
+		VerifySink:      true,
+		Secrets: []manifestSecret{
+			{Label: "db", MaxAge: 30 * 24 * time.Hour, Length: 24, Special: true, Exclude: "&'", Ambiguity: passgen.AmbiguityExtended, NoWalks: true, NoSequences: true, NoRepeats: true, NoConfusables: true, Targets: []string{"bcrypt"},
+				Unicode: unicodeFilter{ExcludeCategories: []string{"Punctuation"}, IncludeScripts: []string{"Latin", "Common"}},
+				Rules:   []string{"maxRepeat <= 2", `contains(password, "#") || true`}},
+			{Label: "web", MaxAge: 12 * time.Hour, Length: 12, Note: "rotated by cron", MinDigits: 2, MinSpecial: 1, Special: true, NoUpper: true,
+				Rules: []string{"length >= 12", "upper >= 2"}, Encodings: []string{"yaml", "env"}},
+		},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("parseManifest =\n%+v\nwant\n%+v", m, want)
+	}
+}
+
+// TestParseManifestListAtKeyIndent tests lists that are not indented under their key
+func TestParseManifestListAtKeyIndent(t *testing.T) {
+	m, err := parseManifest("out: a.csv\nsecrets:\n- label: db\n  max-age: 1d\n- label: web\n  max-age: 2d\n")
+	if err != nil {
+		t.Fatalf("parseManifest failed: %v", err)
+	}
+	if len(m.Secrets) != 2 || m.Secrets[1].Label != "web" || m.Secrets[1].MaxAge != 48*time.Hour {
+		t.Errorf("Unexpected secrets: %+v", m.Secrets)
+	}
+}
+
+// TestParseManifestErrors tests that mistakes are reported with line numbers
+func TestParseManifestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{"no secrets", "out: a.csv\n", "no secrets"},
+		{"no sink", "secrets:\n  - label: db\n    max-age: 1d\n", "needs a sink"},
+		{"unknown key", "out: a.csv\nsink: x\n", "line 2: unknown key"},
+		{"unknown secret key", "out: a.csv\nsecrets:\n  - label: db\n    maxage: 1d\n", "line 4: unknown key"},
+		{"missing max-age", "out: a.csv\nsecrets:\n  - label: db\n", "line 3: secret \"db\" needs a positive max-age"},
+		{"missing label", "out: a.csv\nsecrets:\n  - max-age: 1d\n", "line 3: secret has no label"},
+		{"bad age", "out: a.csv\nsecrets:\n  - label: db\n    max-age: soon\n", "line 4: invalid age"},
+		{"bad bool", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    special: maybe\n", "line 5: special must be true or false"},
+		{"length out of range", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    length: 500\n", "length must be between"},
+		{"unknown encoding", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    encoding: [toml]\n", "line 5: unknown encoding \"toml\""},
+		{"unknown target", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    target: vax\n", "line 5: unknown target \"vax\""},
+		{"minimums too long", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    length: 6\n    min-digits: 3\n    min-special: 2\n", "line 3: secret \"db\" minimums add up to more than its length"},
+		{"every class disabled", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    no-upper: true\n    no-lower: true\n    no-digits: true\n", "line 3: secret \"db\" disables every character class"},
+		{"minimum of disabled class", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    no-digits: true\n    min-digits: 2\n", "minimum for a disabled class"},
+		{"unknown script", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n    include-script: Elvish\n", "line 3: secret \"db\": unknown Unicode script \"Elvish\""},
+		{"too long for target", "out: a.csv\nsecrets:\n  - label: wifi\n    max-age: 1d\n    length: 64\n    target: wpa2\n", "line 3: secret \"wifi\": wpa2 accepts at most 63"},
+		{"negative retries", "out: a.csv\nsink-retries: -1\n", "line 2: sink-retries cannot be negative"},
+		{"duplicate label", "out: a.csv\nsecrets:\n  - label: db\n    max-age: 1d\n  - label: db\n    max-age: 2d\n", "listed twice"},
+		{"scalar secret", "out: a.csv\nsecrets:\n  - db\n", "must be a mapping"},
+		{"bad indentation", "out: a.csv\n   state: x\n", "line 2: unexpected indentation"},
+		{"tabs", "out: a.csv\nsecrets:\n\t- label: db\n", "line 3: indent with spaces"},
+		{"unterminated quote", "out: \"a.csv\n", "line 1: unterminated"},
+		{"trailing garbage", "out: \"a.csv\" b\n", "line 1: unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseManifest(tt.text)
+			if err == nil {
+				t.Fatalf("Expected error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParseAge tests durations with day suffixes
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"d", 0, true},
+		{"1.5d", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAge(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAge(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
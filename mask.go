@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maskChar stands in for every character of a -mask password.
+const maskChar = "•"
+
+// maskPassword returns a bullet for each character of password.
+func maskPassword(password string) string {
+	return strings.Repeat(maskChar, utf8.RuneCountInString(password))
+}
+
+// revealPasswords asks on out which of passwords to show, by number on in,
+// and shows it until Enter is pressed again, when it is cleared from the
+// screen with the prompts around it. An empty answer or the end of input
+// finishes.
+func revealPasswords(passwords []string, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	numbers := "1"
+	if len(passwords) > 1 {
+		numbers = fmt.Sprintf("1-%d", len(passwords))
+	}
+	width := len(strconv.Itoa(len(passwords)))
+	for {
+		fmt.Fprintf(out, "Reveal %s [Enter to finish]: ", numbers)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return nil
+		}
+		i, err := strconv.Atoi(answer)
+		if err != nil || i < 1 || i > len(passwords) {
+			fmt.Fprintf(out, "%q is not a password\n", answer)
+			continue
+		}
+		fmt.Fprintf(out, "%*d: %s\n", width, i, passwords[i-1])
+		fmt.Fprint(out, "Press Enter to hide it")
+		if !scanner.Scan() {
+			// The cursor is still on the last line, without a newline
+			fmt.Fprint(out, "\x1b[2A\r\x1b[J")
+			return scanner.Err()
+		}
+		// Back up over the prompt, the password and the line after it
+		fmt.Fprint(out, "\x1b[3A\r\x1b[J")
+	}
+}
+
+// revealOnTerminal runs revealPasswords on the terminal. An interrupt
+// clears the screen first, so it cannot leave a password on display.
+func revealOnTerminal(passwords []string) error {
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupted:
+			fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J")
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	defer func() {
+		signal.Stop(interrupted)
+		close(done)
+	}()
+	fmt.Println()
+	return revealPasswords(passwords, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestMaskPassword tests that every character is masked
+func TestMaskPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"", ""},
+		{"a8L5", "••••"},
+		{"pässwörd", "••••••••"},
+	}
+	for _, tt := range tests {
+		if got := maskPassword(tt.password); got != tt.want {
+			t.Errorf("maskPassword(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+// TestRevealPasswords tests revealing, hiding and finishing
+func TestRevealPasswords(t *testing.T) {
+	tests := []struct {
+		input    string
+		revealed []string
+		hidden   []string
+	}{
+		{"\n", nil, []string{"p1", "p2"}},
+		{"", nil, []string{"p1", "p2"}},
+		{"2\n\n\n", []string{"2: p2\n"}, []string{"p1"}},
+		{"1\n\n2\n", []string{"1: p1\n", "2: p2\n"}, nil},
+		{"3\nx\n\n", []string{`"3" is not a password`, `"x" is not a password`}, []string{"p1", "p2"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := revealPasswords([]string{"p1", "p2"}, strings.NewReader(tt.input), &out); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.revealed {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("revealPasswords(%q) showed %q, want %q in it", tt.input, out.String(), want)
+			}
+		}
+		for _, p := range tt.hidden {
+			if strings.Contains(out.String(), p) {
+				t.Errorf("revealPasswords(%q) showed %s", tt.input, p)
+			}
+		}
+		if !strings.HasPrefix(out.String(), "Reveal 1-2 [Enter to finish]: ") {
+			t.Errorf("revealPasswords(%q) showed %q", tt.input, out.String())
+		}
+	}
+}
+
+// TestGenerateMaskFlags tests what -mask rules out
+func TestGenerateMaskFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-mask", "-o", "json"}, "-mask only applies to text output"},
+		{[]string{"-mask", "-q"}, "-mask cannot be combined"},
+		{[]string{"-mask", "-stream"}, "-mask cannot be combined"},
+		{[]string{"-mask", "-choices", "3"}, "-mask cannot be combined"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// metricsRecord describes one generate run for the usage dataset written by
+// -metrics-out. It holds the policy that was used and how strong the result
+// was, never passwords, labels, notes, rule text or file names, so the
+// dataset can be shared with a security team without exposing anything.
+type metricsRecord struct {
+	Schema string `json:"schema"`
+	// Date is only the day, so records cannot be matched to individual
+	// credentials by their creation time.
+	Date         string         `json:"date"`
+	Version      string         `json:"version"`
+	Mode         string         `json:"mode"`
+	Length       int            `json:"length"`
+	Count        int            `json:"count"`
+	Special      bool           `json:"special"`
+	AllowSimilar bool           `json:"allowSimilar"`
+	Ambiguity    string         `json:"ambiguity"`
+	Rules        int            `json:"rules"`
+	Encodings    []string       `json:"encodings,omitempty"`
+	Targets      []string       `json:"targets,omitempty"`
+	Sinks        int            `json:"sinks"`
+	Labeled      bool           `json:"labeled"`
+	Entropy      entropySummary `json:"entropy"`
+	// BelowTarget counts passwords under -min-entropy, when one was set.
+	BelowTarget int `json:"belowTarget,omitempty"`
+}
+
+// entropySummary is the spread of entropy in bits over a batch.
+type entropySummary struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	Max  float64 `json:"max"`
+}
+
+// summarizeEntropy returns the spread of a non-empty batch, rounded to a
+// tenth of a bit.
+func summarizeEntropy(entropies []float64) entropySummary {
+	s := entropySummary{Min: entropies[0], Max: entropies[0]}
+	var total float64
+	for _, bits := range entropies {
+		s.Min = min(s.Min, bits)
+		s.Max = max(s.Max, bits)
+		total += bits
+	}
+	s.Mean = total / float64(len(entropies))
+	round := func(f float64) float64 { return float64(int64(f*10+0.5)) / 10 }
+	s.Min, s.Mean, s.Max = round(s.Min), round(s.Mean), round(s.Max)
+	return s
+}
+
+// appendMetrics adds a record to the dataset at path, one JSON object per
+// line, creating the file if needed.
+func appendMetrics(path string, rec metricsRecord, now time.Time) error {
+	rec.Schema = outputSchema
+	rec.Date = now.UTC().Format("2006-01-02")
+	rec.Version = version
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
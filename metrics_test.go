@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSummarizeEntropy tests the rounded spread of a batch
+func TestSummarizeEntropy(t *testing.T) {
+	got := summarizeEntropy([]float64{70.04, 80, 90.26})
+	want := entropySummary{Min: 70, Mean: 80.1, Max: 90.3}
+	if got != want {
+		t.Errorf("summarizeEntropy = %+v, want %+v", got, want)
+	}
+}
+
+// TestAppendMetrics tests that records are appended as JSON lines with only the day
+func TestAppendMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+	now := time.Date(2025, 1, 31, 15, 30, 45, 0, time.UTC)
+	for _, length := range []int{12, 16} {
+		rec := metricsRecord{Mode: "random", Length: length, Count: 1, Entropy: entropySummary{Min: 70, Mean: 70, Max: 70}}
+		if err := appendMetrics(path, rec, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lengths []int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec metricsRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Invalid record %q: %v", scanner.Text(), err)
+		}
+		if rec.Schema != outputSchema || rec.Date != "2025-01-31" {
+			t.Errorf("Unexpected schema or date: %+v", rec)
+		}
+		if strings.Contains(scanner.Text(), "15:30") {
+			t.Errorf("Record should not carry the time of day: %s", scanner.Text())
+		}
+		lengths = append(lengths, rec.Length)
+	}
+	if len(lengths) != 2 || lengths[0] != 12 || lengths[1] != 16 {
+		t.Errorf("Expected records for lengths 12 and 16, got %v", lengths)
+	}
+}
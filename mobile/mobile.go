@@ -0,0 +1,62 @@
+// Package mobile exposes passgen's generation and checking logic to Android
+// and iOS apps. Its API only uses types gomobile can bind, so apps run
+// exactly the same code as the command-line tool:
+//
+//	gomobile bind -target=android -o passgen.aar ./mobile
+//	gomobile bind -target=ios -o Passgen.xcframework ./mobile
+package mobile
+
+import "github.com/junedkhatri31/passgen/pkg/passgen"
+
+// Options configures password generation. Create it with NewOptions so the
+// defaults match the command-line tool.
+type Options struct {
+	Length         int
+	IncludeSpecial bool
+	AllowSimilar   bool
+	// Rules holds acceptance rules, one expression per line. See
+	// passgen.Rule for the syntax.
+	Rules string
+}
+
+// NewOptions returns the default options: 12 characters, no special
+// characters and no rules.
+func NewOptions() *Options {
+	return &Options{Length: 12}
+}
+
+// Generate returns a password satisfying the options.
+func Generate(opts *Options) (string, error) {
+	if opts == nil {
+		opts = NewOptions()
+	}
+
+	rules, err := passgen.CompileRules(opts.Rules)
+	if err != nil {
+		return "", err
+	}
+	gen, err := passgen.NewGenerator(
+		passgen.WithLength(opts.Length),
+		passgen.WithSpecial(opts.IncludeSpecial),
+		passgen.WithSimilar(opts.AllowSimilar),
+		passgen.WithRules(rules...),
+	)
+	if err != nil {
+		return "", err
+	}
+	return gen.Generate()
+}
+
+// CheckRule reports whether password satisfies the rule expression.
+func CheckRule(rule, password string) (bool, error) {
+	r, err := passgen.CompileRule(rule)
+	if err != nil {
+		return false, err
+	}
+	return r.Eval(password)
+}
+
+// EstimateEntropy estimates the strength of a password in bits.
+func EstimateEntropy(password string) float64 {
+	return passgen.EstimateEntropy(password)
+}
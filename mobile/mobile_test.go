@@ -0,0 +1,53 @@
+package mobile
+
+import "testing"
+
+// TestGenerateDefaults tests generation with the default options
+func TestGenerateDefaults(t *testing.T) {
+	for _, opts := range []*Options{NewOptions(), nil} {
+		password, err := Generate(opts)
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		if len(password) != 12 {
+			t.Errorf("Expected password length 12, got %d", len(password))
+		}
+	}
+}
+
+// TestGenerateWithRules tests that newline-separated rules are applied
+func TestGenerateWithRules(t *testing.T) {
+	opts := NewOptions()
+	opts.Length = 16
+	opts.IncludeSpecial = true
+	opts.Rules = "digits >= 3\n\n maxRepeat <= 2 \n"
+
+	for i := 0; i < 10; i++ {
+		password, err := Generate(opts)
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		ok, err := CheckRule("digits >= 3 && maxRepeat <= 2", password)
+		if err != nil {
+			t.Fatalf("Failed to check rule: %v", err)
+		}
+		if !ok {
+			t.Errorf("Password %s violates the rules", password)
+		}
+	}
+
+	opts.Rules = "digits >"
+	if _, err := Generate(opts); err == nil {
+		t.Error("Expected error for invalid rule")
+	}
+}
+
+// TestEstimateEntropy tests the entropy binding
+func TestEstimateEntropy(t *testing.T) {
+	if EstimateEntropy("") != 0 {
+		t.Error("Expected 0 bits for empty password")
+	}
+	if EstimateEntropy("Ab3defgh") <= EstimateEntropy("abcdefgh") {
+		t.Error("Mixed classes should score higher than lowercase only")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// deviceFormats print shared secrets as configuration snippets for network
+// devices, for -o cisco and -o junos.
+var deviceFormats = map[string]func(w io.Writer, protocol, name string, server net.IP, secret string) error{
+	"cisco": writeCiscoSecret,
+	"junos": writeJunosSecret,
+}
+
+// writeCiscoSecret writes IOS and IOS XE configuration for a RADIUS or
+// TACACS+ server. An unencrypted (type 0) key cannot be quoted, so secrets
+// with spaces or ? are refused.
+func writeCiscoSecret(w io.Writer, protocol, name string, server net.IP, secret string) error {
+	if strings.ContainsAny(secret, " ?\t") {
+		return fmt.Errorf("secret for %s contains a space or ?, which IOS cannot take", server)
+	}
+	family := "ipv4"
+	if server.To4() == nil {
+		family = "ipv6"
+	}
+	switch protocol {
+	case "radius":
+		fmt.Fprintf(w, "radius server %s\n address %s %s auth-port 1812 acct-port 1813\n key 0 %s\n", name, family, server, secret)
+	case "tacacs":
+		fmt.Fprintf(w, "tacacs server %s\n address %s %s\n key 0 %s\n", name, family, server, secret)
+	default:
+		return fmt.Errorf("no Cisco configuration for %s", protocol)
+	}
+	return nil
+}
+
+// writeJunosSecret writes Junos set commands for a RADIUS or TACACS+
+// server. The secret is quoted, so only quotes and backslashes need
+// escaping.
+func writeJunosSecret(w io.Writer, protocol, name string, server net.IP, secret string) error {
+	statement := map[string]string{"radius": "radius-server", "tacacs": "tacplus-server"}[protocol]
+	if statement == "" {
+		return fmt.Errorf("no Junos configuration for %s", protocol)
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(secret)
+	fmt.Fprintf(w, "set system %s %s secret \"%s\"\n", statement, server, quoted)
+	return nil
+}
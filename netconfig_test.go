@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// TestDeviceFormats tests the configuration snippets for network devices
+func TestDeviceFormats(t *testing.T) {
+	tests := []struct {
+		format   string
+		protocol string
+		server   string
+		secret   string
+		want     string
+		wantErr  bool
+	}{
+		{"cisco", "radius", "10.0.0.5", "s3cr=t", "radius server core\n address ipv4 10.0.0.5 auth-port 1812 acct-port 1813\n key 0 s3cr=t\n", false},
+		{"cisco", "tacacs", "2001:db8::1", "s3cr=t", "tacacs server core\n address ipv6 2001:db8::1\n key 0 s3cr=t\n", false},
+		{"cisco", "radius", "10.0.0.5", "what?", "", true},
+		{"cisco", "ldap", "10.0.0.5", "s3cr=t", "", true},
+		{"junos", "radius", "10.0.0.5", "s3cr=t", "set system radius-server 10.0.0.5 secret \"s3cr=t\"\n", false},
+		{"junos", "tacacs", "10.0.0.9", `a"b\c`, `set system tacplus-server 10.0.0.9 secret "a\"b\\c"` + "\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.protocol, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := deviceFormats[tt.format](&buf, tt.protocol, "core", net.ParseIP(tt.server), tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
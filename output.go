@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// outputSchema identifies the layout of passgen's JSON documents.
+//
+// Within a major version the layout only grows: new fields may be added, but
+// existing fields keep their name, type and meaning and are never removed.
+// Parsers should therefore ignore fields they do not know. Any incompatible
+// change bumps the version (passgen/v2) instead.
+const outputSchema = "passgen/v1"
+
+// generateOutput is the JSON document printed by -o json.
+type generateOutput struct {
+	Schema    string           `json:"schema"`
+	Length    int              `json:"length"`
+	Charsets  []string         `json:"charsets,omitempty"`
+	Passwords []passwordOutput `json:"passwords"`
+	// DeliveredTo lists where the passwords were written, when that is
+	// more than stdout.
+	DeliveredTo []string `json:"deliveredTo,omitempty"`
+	// Signature signs the document with -sign-key.
+	Signature *signatureOutput `json:"signature,omitempty"`
+}
+
+// passwordOutput is one generated password in structured output.
+type passwordOutput struct {
+	Label    string `json:"label,omitempty"`
+	Password string `json:"password"`
+	// Escaped is the password escaped for -encoding, when that differs.
+	Escaped string  `json:"escaped,omitempty"`
+	Entropy float64 `json:"entropy"`
+	// KeyspaceEntropy is the theoretical entropy of every password the
+	// options can produce, with -show-entropy.
+	KeyspaceEntropy float64 `json:"keyspaceEntropy,omitempty"`
+	Note            string  `json:"note,omitempty"`
+	// Confusables lists look-alikes found by -homoglyph-report, e.g.
+	// "rn (m)".
+	Confusables []string `json:"confusables,omitempty"`
+	// Fingerprint identifies the password without revealing it, see
+	// -fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Hash is the password hashed in the -hash-format, for a user table.
+	Hash string `json:"hash,omitempty"`
+	// Created is when the hash was made, as an RFC 3339 timestamp, with
+	// -with-metadata.
+	Created string `json:"created,omitempty"`
+	// Strength is the estimated strength of the password, with -strength.
+	Strength *strengthOutput `json:"strength,omitempty"`
+	// Halves are the parts two people enter, with -dual-control.
+	Halves []string `json:"halves,omitempty"`
+	// Spoken is a PIN with its digits written as words, with pin -words.
+	Spoken string `json:"spoken,omitempty"`
+	// Temporary passwords of -temp must be changed at first login and
+	// stop working at ExpiresAt, an RFC 3339 timestamp.
+	Temporary  bool   `json:"temporary,omitempty"`
+	MustChange bool   `json:"mustChange,omitempty"`
+	ExpiresAt  string `json:"expiresAt,omitempty"`
+	// ValidFrom and ValidUntil bound the window of a rotating password,
+	// as RFC 3339 timestamps.
+	ValidFrom  string `json:"validFrom,omitempty"`
+	ValidUntil string `json:"validUntil,omitempty"`
+}
+
+// checkOutputFormat validates the value of an -o flag.
+func checkOutputFormat(format string) error {
+	switch format {
+	case "text", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (use text or json)", format)
+}
+
+// writeJSON writes v as an indented JSON document. Special characters such
+// as < and & are left unescaped so passwords stay readable.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// tableFormats are the -o formats of generate with a row per password, for
+// spreadsheets and bulk provisioning.
+var tableFormats = map[string]bool{"csv": true, "tsv": true}
+
+// tableWriter writes the rows of -o csv or tsv. TSV has no quoting, so
+// its fields cannot contain tabs or line breaks, but quotes and commas
+// are written as they are.
+type tableWriter struct {
+	w *bufio.Writer
+	// csv is nil for TSV.
+	csv *csv.Writer
+}
+
+func newTableWriter(w io.Writer, format string) *tableWriter {
+	t := &tableWriter{w: bufio.NewWriter(w)}
+	if format == "csv" {
+		t.csv = csv.NewWriter(t.w)
+	}
+	return t
+}
+
+// write writes one row.
+func (t *tableWriter) write(row ...string) error {
+	if t.csv != nil {
+		return t.csv.Write(row)
+	}
+	for _, field := range row {
+		if strings.ContainsAny(field, "\t\r\n") {
+			return fmt.Errorf("%q contains a tab or line break, which TSV cannot hold; use -o csv", field)
+		}
+	}
+	_, err := t.w.WriteString(strings.Join(row, "\t") + "\n")
+	return err
+}
+
+// flush writes out the buffered rows.
+func (t *tableWriter) flush() error {
+	if t.csv != nil {
+		t.csv.Flush()
+		if err := t.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return t.w.Flush()
+}
+
+// passwordLine is one line of -o ndjson: a password with the schema of the
+// stream and its position in the run, since there is no document to carry
+// them.
+type passwordLine struct {
+	Schema string `json:"schema"`
+	Index  int    `json:"index"`
+	passwordOutput
+}
+
+// lineWriter writes -o ndjson, a JSON object per line. Each line is
+// written as soon as its password is generated, so a consumer can read a
+// run of any size without either side holding it whole.
+type lineWriter struct {
+	enc *json.Encoder
+}
+
+func newLineWriter(w io.Writer) *lineWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &lineWriter{enc: enc}
+}
+
+// write writes the password at index as one line.
+func (l *lineWriter) write(index int, p passwordOutput) error {
+	return l.enc.Encode(passwordLine{Schema: outputSchema, Index: index, passwordOutput: p})
+}
+
+// templateLine is what -template formats for each password.
+type templateLine struct {
+	Index    int
+	Label    string
+	Password string
+	// Escaped is the password escaped for -encoding, or the password
+	// without one.
+	Escaped         string
+	Entropy         float64
+	KeyspaceEntropy float64
+	Note            string
+	Fingerprint     string
+	Hash            string
+	// Charset holds every character passwords are drawn from, and
+	// Charsets the sets themselves. Patterns and Markov models have none.
+	Charset  string
+	Charsets []string
+	// Timestamp is when the password was generated.
+	Timestamp time.Time
+	// MustChange is set by -temp, and Expires is when the temporary
+	// password stops working; it is zero otherwise.
+	MustChange bool
+	Expires    time.Time
+}
+
+// parseLineTemplate parses a -template and tries it on an empty line, so a
+// field that does not exist fails before any password is generated.
+func parseLineTemplate(src string) (*template.Template, error) {
+	tmpl, err := template.New("-template").Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, templateLine{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// writeTemplateLine formats line with tmpl, ending it with a newline unless
+// the template does.
+func writeTemplateLine(w io.Writer, tmpl *template.Template, line templateLine) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, line); err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
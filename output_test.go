@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckOutputFormat tests validation of the -o flag
+func TestCheckOutputFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{"text", false},
+		{"json", false},
+		{"yaml", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := checkOutputFormat(tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("checkOutputFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestGenerateOutputSchema tests the field names promised by passgen/v1
+func TestGenerateOutputSchema(t *testing.T) {
+	var buf bytes.Buffer
+	out := generateOutput{
+		Schema:    outputSchema,
+		Length:    12,
+		Passwords: []passwordOutput{{Password: "abcdefghijkm", Entropy: 56.4, Note: "until 2025-01"}},
+	}
+	if err := writeJSON(&buf, out); err != nil {
+		t.Fatalf("writeJSON failed: %v", err)
+	}
+
+	var decoded struct {
+		Schema    string `json:"schema"`
+		Length    int    `json:"length"`
+		Passwords []struct {
+			Password string  `json:"password"`
+			Entropy  float64 `json:"entropy"`
+			Note     string  `json:"note"`
+		} `json:"passwords"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded.Schema != "passgen/v1" {
+		t.Errorf("schema = %q, want passgen/v1", decoded.Schema)
+	}
+	if decoded.Length != 12 || len(decoded.Passwords) != 1 {
+		t.Fatalf("Decoded %+v", decoded)
+	}
+	if p := decoded.Passwords[0]; p.Password != "abcdefghijkm" || p.Entropy != 56.4 || p.Note != "until 2025-01" {
+		t.Errorf("Password entry = %+v", decoded.Passwords[0])
+	}
+}
+
+// TestTableWriter tests the rows of -o csv and tsv
+func TestTableWriter(t *testing.T) {
+	tests := []struct {
+		format  string
+		row     []string
+		want    string
+		wantErr bool
+	}{
+		{"csv", []string{"1", "db", "a,b\"c", "56.4"}, "1,db,\"a,b\"\"c\",56.4\n", false},
+		{"tsv", []string{"1", "db", "a,b\"c", "56.4"}, "1\tdb\ta,b\"c\t56.4\n", false},
+		{"csv", []string{"1", "a\tb"}, "1,a\tb\n", false},
+		{"tsv", []string{"1", "a\tb"}, "", true},
+		{"tsv", []string{"1", "a\nb"}, "", true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		tw := newTableWriter(&buf, tt.format)
+		err := tw.write(tt.row...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s write(%q) error = %v, wantErr %v", tt.format, tt.row, err, tt.wantErr)
+			continue
+		}
+		if err := tw.flush(); err != nil {
+			t.Fatalf("flush failed: %v", err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s write(%q) = %q, want %q", tt.format, tt.row, got, tt.want)
+		}
+	}
+}
+
+// TestGenerateTableFormats tests the flags of -o csv and tsv
+func TestGenerateTableFormats(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-o", "yaml"}, "use text, json, ndjson, csv, tsv"},
+		{[]string{"-header"}, "-header requires"},
+		{[]string{"-o", "csv", "-fingerprint-only", "-out", "x.csv"}, "-fingerprint-only cannot"},
+		{[]string{"-o", "tsv", "-sign-key", "key"}, "-sign-key requires -o json"},
+		{[]string{"-o", "ndjson", "-sign-key", "key"}, "-sign-key requires -o json"},
+		{[]string{"-o", "ndjson", "-header"}, "-header requires"},
+		{[]string{"-o", "ndjson", "-fingerprint-only"}, "-fingerprint-only cannot"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// TestLineWriter tests that -o ndjson writes a complete object per line.
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLineWriter(&buf)
+	for i, p := range []string{"a<b&c", "x\ny"} {
+		if err := l.write(i+1, passwordOutput{Label: "db", Password: p, Entropy: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `{"schema":"passgen/v1","index":1,"label":"db","password":"a<b&c","entropy":10}
+{"schema":"passgen/v1","index":2,"label":"db","password":"x\ny","entropy":10}
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// TestLineTemplate tests parsing and formatting -template lines
+func TestLineTemplate(t *testing.T) {
+	line := templateLine{
+		Index: 2, Label: "db", Password: "a<b", Escaped: "a&lt;b", Entropy: 70.25,
+		Charsets: []string{"ab", "<"}, Charset: "ab<",
+		Timestamp: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"{{.Index}}: {{.Password}} ({{.Entropy}} bits)", "2: a<b (70.25 bits)\n"},
+		{"{{.Label}},{{.Escaped}}\n", "db,a&lt;b\n"},
+		{`{{.Timestamp.Format "2006-01-02"}} {{len .Charsets}} {{.Charset}}`, "2026-10-16 2 ab<\n"},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseLineTemplate(tt.src)
+		if err != nil {
+			t.Fatalf("parseLineTemplate(%q): %v", tt.src, err)
+		}
+		var buf bytes.Buffer
+		if err := writeTemplateLine(&buf, tmpl, line); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("template %q = %q, want %q", tt.src, buf.String(), tt.want)
+		}
+	}
+
+	for _, src := range []string{"{{.Index}", "{{.Secret}}"} {
+		if _, err := parseLineTemplate(src); err == nil {
+			t.Errorf("parseLineTemplate(%q) succeeded", src)
+		}
+	}
+}
+
+// TestGenerateTemplateFlags tests what -template rules out
+func TestGenerateTemplateFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-template", "{{.Password}}", "-o", "json"}, "cannot be combined with -o json"},
+		{[]string{"-template", "{{.Password}}", "-q"}, "-template cannot be combined"},
+		{[]string{"-template", "{{.Password}}", "-strength"}, "-template cannot be combined"},
+		{[]string{"-template", "{{.Pasword}}"}, "can't evaluate field Pasword"},
+	}
+	for _, tt := range tests {
+		err := runGenerate("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runGenerate(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
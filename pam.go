@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func printPAMUsage(programName string) {
+	fmt.Printf("Usage: %s pam [OPTIONS]\n", programName)
+	fmt.Println("Check a new password for pam_exec during passwd. The password is read from")
+	fmt.Println("stdin as pam_exec expose_authtok writes it; the exit status accepts or")
+	fmt.Println("rejects it. Passwords containing the user name ($PAM_USER) are rejected.")
+	fmt.Println("Options:")
+	fmt.Println("  -rule EXPR   Require the password to match the expression (repeatable)")
+	fmt.Println("  -min-entropy BITS")
+	fmt.Println("               Require at least BITS of estimated entropy")
+	fmt.Println("  -min-score N Require a strength score of at least N (0-4)")
+	fmt.Println("  -policy FILE Require what a system policy such as pwquality.conf does")
+	fmt.Println("  -policy-format NAME")
+	fmt.Println("               Format of the policy (default: from the file name)")
+	fmt.Println("  -policy-file FILE")
+	fmt.Println("               Require what a passgen policy file, as used by generate, does")
+	fmt.Println("  -blocklist FILE")
+	fmt.Println("               Reject the passwords listed in FILE, one per line (repeatable)")
+	fmt.Println("  -no-blocklist")
+	fmt.Println("               Allow passwords on the embedded list of common passwords")
+	fmt.Println("  -hibp        Reject passwords found in Have I Been Pwned's Pwned Passwords")
+	fmt.Println("  -hibp-offline FILE")
+	fmt.Println("               Reject passwords in a bloom filter built by hibp download")
+	fmt.Println("  -offline     Skip checks that need the network, such as -hibp")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nIn /etc/pam.d/common-password, before the module that stores the password:")
+	fmt.Printf("  password requisite pam_exec.so expose_authtok stdout quiet %s pam -min-entropy 50\n", programName)
+	fmt.Println("  password [success=1 default=ignore] pam_unix.so use_authtok sha512")
+}
+
+// maxAuthtok bounds the password read from pam_exec, which itself passes
+// at most PAM_MAX_RESP_SIZE bytes.
+const maxAuthtok = 512
+
+// readAuthtok reads the password pam_exec writes to stdin: the password
+// followed by a NUL byte, or a line when run by hand.
+func readAuthtok(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxAuthtok+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxAuthtok {
+		return "", fmt.Errorf("password is longer than %d bytes", maxAuthtok)
+	}
+	if i := bytes.IndexAny(data, "\x00\n"); i >= 0 {
+		data = data[:i]
+	}
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	if len(data) == 0 {
+		return "", fmt.Errorf("no password on stdin")
+	}
+	return string(data), nil
+}
+
+// runPAM implements the pam subcommand.
+func runPAM(programName string, args []string) error {
+	fs := flag.NewFlagSet("pam", flag.ContinueOnError)
+	checks := addCheckFlags(fs)
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printPAMUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printPAMUsage(programName)
+		return nil
+	}
+	check, err := checks.compile()
+	if err != nil {
+		return err
+	}
+
+	// Listed under auth or session by mistake, stay out of the way rather
+	// than lock everyone out
+	if t := os.Getenv("PAM_TYPE"); t != "" && t != "password" {
+		return nil
+	}
+	password, err := readAuthtok(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if user := os.Getenv("PAM_USER"); len(user) >= minLength {
+		check.User = user
+	}
+	results, err := check.results(password)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			// The wording of pam_pwquality, which users already know
+			fmt.Printf("BAD PASSWORD: requirement not met: %s\n", r.Text)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("password rejected")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReadAuthtok tests reading passwords as pam_exec and people write them
+func TestReadAuthtok(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"s3cret\x00", "s3cret", false},
+		{"s3cret\x00ignored", "s3cret", false},
+		{"s3cret\r\n", "s3cret", false},
+		{"s3cret", "s3cret", false},
+		{"has space\x00", "has space", false},
+		{"\x00", "", true},
+		{"", "", true},
+		{strings.Repeat("a", maxAuthtok+1), "", true},
+	}
+	for _, tt := range tests {
+		got, err := readAuthtok(strings.NewReader(tt.input))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("readAuthtok(%q) = %q, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("readAuthtok(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
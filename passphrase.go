@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printPassphraseUsage(programName string) {
+	fmt.Printf("Usage: %s passphrase [OPTIONS]\n", programName)
+	fmt.Println("Generate diceware-style passphrases of words chosen at random from a")
+	fmt.Println("wordlist, using the same crypto/rand sampling as passwords.")
+	fmt.Println("Options:")
+	fmt.Println("  -list NAME      Built-in wordlist: eff-long (7776 words) or eff-short")
+	fmt.Println("                  (1296 words) (default: eff-long)")
+	fmt.Println("  -wordlist FILE  Words to choose from instead, one per line; diceware lists")
+	fmt.Println("                  with dice numbers before each word are accepted. An https://")
+	fmt.Println("                  URL is downloaded once and cached")
+	fmt.Println("  -refresh-wordlist")
+	fmt.Println("                  Download a -wordlist URL again instead of using the cache")
+	fmt.Println("  -w WORDS        Number of words (default: 6)")
+	fmt.Println("  -e, -entropy BITS")
+	fmt.Println("                  Use the fewest words that reach BITS of entropy, counting")
+	fmt.Println("                  capitalization, padding and typos; overrides -w")
+	fmt.Println("  -sep TEXT       Separator between words (default: -)")
+	fmt.Println("  -caps MODE      Capitalize words: none, first (every word), random or all")
+	fmt.Println("                  (default: none)")
+	fmt.Println("  -digits N       Add N random digits to a word (default: 0)")
+	fmt.Println("  -symbols N      Add N random symbols after the digits (default: 0)")
+	fmt.Println("  -placement P    Word the digits and symbols go after: end (the last word)")
+	fmt.Println("                  or random (default: end)")
+	fmt.Println("  -c COUNT        Number of passphrases to generate (default: 1)")
+	fmt.Println("  -typo-level N   Misspell N of the words with a random typo (default: 0)")
+	fmt.Println("  -dice           Pick the words with physical dice: type the rolls for each")
+	fmt.Println("                  word instead of using the computer's random numbers")
+	fmt.Println("  -no-blocklist   Allow passphrases on the embedded list of common passwords,")
+	fmt.Println("                  which are otherwise chosen again")
+	fmt.Println("  -h              Show this help message")
+	fmt.Println("\nExamples:")
+	fmt.Printf("  %s passphrase -w 6\n", programName)
+	fmt.Printf("  %s passphrase -list eff-short -sep ' '\n", programName)
+	fmt.Printf("  %s passphrase -w 4 -caps first -digits 2 -symbols 1\n", programName)
+	fmt.Printf("  %s passphrase -e 80 -list eff-short\n", programName)
+}
+
+// runPassphrase implements the passphrase subcommand.
+func runPassphrase(programName string, args []string) error {
+	fs := flag.NewFlagSet("passphrase", flag.ContinueOnError)
+	listName := fs.String("list", passgen.WordlistEFFLong, "Built-in wordlist: eff-long or eff-short")
+	wordlist := fs.String("wordlist", "", "Words to choose from, one per line")
+	refresh := fs.Bool("refresh-wordlist", false, "Download a -wordlist URL again instead of using the cached copy")
+	words := fs.Int("w", 6, "Number of words")
+	entropyTarget := fs.Float64("entropy", 0, "Use the fewest words that reach this many bits of entropy")
+	sep := fs.String("sep", "-", "Separator between words")
+	capsName := fs.String("caps", "none", "Capitalize words: none, first, random or all")
+	digits := fs.Int("digits", 0, "Add this many random digits to a word")
+	symbols := fs.Int("symbols", 0, "Add this many random symbols to a word")
+	placementName := fs.String("placement", "end", "Word the digits and symbols go after: end or random")
+	count := fs.Int("c", 1, "Number of passphrases to generate")
+	typos := fs.Int("typo-level", 0, "Misspell this many of the words")
+	dice := fs.Bool("dice", false, "Pick the words with physical dice rolls typed in")
+	noBlocklist := fs.Bool("no-blocklist", false, "Allow passphrases on the embedded list of common passwords")
+	help := fs.Bool("h", false, "Show help message")
+	aliasFlag(fs, "entropy", "e")
+	fs.Usage = func() { printPassphraseUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printPassphraseUsage(programName)
+		return nil
+	}
+	if *words < 1 {
+		return fmt.Errorf("word count must be at least 1")
+	}
+	if *entropyTarget < 0 {
+		return fmt.Errorf("target entropy cannot be negative")
+	}
+	if *count < 1 || *count > maxCount {
+		return fmt.Errorf("count must be between 1 and %d", maxCount)
+	}
+	if *typos < 0 || *entropyTarget == 0 && *typos > *words {
+		return fmt.Errorf("typo level must be between 0 and the number of words")
+	}
+	caps, err := passgen.ParseCaps(*capsName)
+	if err != nil {
+		return err
+	}
+	placement, err := passgen.ParsePlacement(*placementName)
+	if err != nil {
+		return err
+	}
+	if *digits < 0 || *symbols < 0 || *digits+*symbols > maxLength {
+		return fmt.Errorf("digits and symbols must be between 0 and %d in total", maxLength)
+	}
+	// Dice are for users who distrust the machine's RNG, so nothing else
+	// may draw from it
+	if *dice && (*typos > 0 || caps == passgen.CapsRandom || *digits > 0 || *symbols > 0) {
+		return fmt.Errorf("-dice cannot be combined with -typo-level, -caps random, -digits or -symbols, which use the computer's random numbers")
+	}
+
+	var list []string
+	source := *listName
+	if *wordlist != "" {
+		source = *wordlist
+		list, err = loadWordlist(*wordlist, *refresh)
+	} else {
+		list, err = passgen.Wordlist(*listName)
+	}
+	if err != nil {
+		return err
+	}
+	strength := passphraseStrength{
+		listSize: len(list), shortest: shortestWord(list), typos: *typos,
+		caps: caps, digits: *digits, symbols: *symbols, placement: placement,
+	}
+	if *entropyTarget > 0 {
+		if *words, err = strength.wordsFor(*entropyTarget); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+	perWord := 0
+	if *dice {
+		if perWord, err = passgen.DicePerWord(len(list)); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	plural := ""
+	if *count > 1 {
+		plural = "s"
+	}
+	fmt.Printf("Generated passphrase%s:\n", plural)
+	fmt.Printf("Words: %d from %s, a list of %d\n", *words, source, len(list))
+	if *entropyTarget > 0 {
+		fmt.Printf("Target entropy: %g bits\n", *entropyTarget)
+	}
+	if *dice {
+		fmt.Printf("Dice: %d per word, rolled by hand\n", perWord)
+	}
+	if *typos > 0 {
+		fmt.Printf("Typos: %d\n", *typos)
+	}
+	if caps != passgen.CapsNone {
+		fmt.Printf("Capitalization: %s\n", caps)
+	}
+	if pad := describePadding(*digits, *symbols, placement); pad != "" {
+		fmt.Printf("Padding: %s\n", pad)
+	}
+	fmt.Printf("Entropy: %.1f bits\n\n", strength.entropy(*words))
+	var blocklist *passgen.Blocklist
+	if !*noBlocklist {
+		blocklist = commonBlocklist()
+	}
+	for i := 0; i < *count; i++ {
+		var passphrase string
+		for attempt := 0; ; attempt++ {
+			if attempt == passgen.DefaultMaxAttempts {
+				return fmt.Errorf("all %d candidate passphrases were on the blocklist", attempt)
+			}
+			var chosen []string
+			if *dice {
+				chosen, err = readDiceWords(os.Stdin, os.Stderr, list, *words)
+			} else {
+				chosen, err = passgen.ChooseWords(list, *words)
+			}
+			if err != nil {
+				return err
+			}
+			if chosen, err = passgen.Misspell(chosen, *typos); err != nil {
+				return err
+			}
+			if chosen, err = passgen.Capitalize(chosen, caps); err != nil {
+				return err
+			}
+			if chosen, err = passgen.Pad(chosen, *digits, *symbols, placement); err != nil {
+				return err
+			}
+			passphrase = strings.Join(chosen, *sep)
+			if !onBlocklist(blocklist, chosen, passphrase) {
+				break
+			}
+			if *dice {
+				fmt.Fprintln(os.Stderr, "That passphrase is a common password; roll again.")
+			}
+		}
+		fmt.Printf("%d: %s\n", i+1, passphrase)
+	}
+	return nil
+}
+
+// onBlocklist reports whether a passphrase is on the blocklist, with its
+// separators or without them, since "dragon" and "iloveyou" are common
+// passwords and so are the words of "i-love-you" run together.
+func onBlocklist(b *passgen.Blocklist, words []string, passphrase string) bool {
+	return b != nil && (b.Contains(passphrase) || b.Contains(strings.Join(words, "")))
+}
+
+// passphraseStrength holds what besides the word count adds to the entropy
+// of a passphrase.
+type passphraseStrength struct {
+	listSize  int
+	shortest  int
+	typos     int
+	caps      passgen.Caps
+	digits    int
+	symbols   int
+	placement passgen.Placement
+}
+
+// entropy returns the entropy in bits of a passphrase of n words.
+func (s passphraseStrength) entropy(n int) float64 {
+	return passgen.PassphraseEntropy(s.listSize, n) +
+		passgen.TypoEntropy(s.shortest, s.typos) +
+		passgen.CapsEntropy(s.caps, n) +
+		passgen.PadEntropy(s.digits, s.symbols, n, s.placement)
+}
+
+// wordsFor returns the fewest words whose passphrase reaches bits of
+// entropy. Every typo needs a word of its own, so there are never fewer
+// words than typos.
+func (s passphraseStrength) wordsFor(bits float64) (int, error) {
+	most := passgen.WordsForEntropy(bits, s.listSize)
+	if most == 0 {
+		return 0, fmt.Errorf("a list of %d words is too small to reach %g bits of entropy", s.listSize, bits)
+	}
+	if most > maxLength {
+		return 0, fmt.Errorf("%g bits of entropy need more than %d words", bits, maxLength)
+	}
+	// The extras only add entropy, so the words alone are an upper bound
+	for n := max(1, s.typos); n < most; n++ {
+		if s.entropy(n) >= bits {
+			return n, nil
+		}
+	}
+	return max(most, s.typos), nil
+}
+
+// readDiceWords asks for the rolls of n words on prompt and reads them from
+// in, one word per line, until every word is picked. Invalid rolls are
+// reported and asked for again.
+func readDiceWords(in io.Reader, prompt io.Writer, list []string, n int) ([]string, error) {
+	dice, err := passgen.DicePerWord(len(list))
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(in)
+	words := make([]string, 0, n)
+	for len(words) < n {
+		fmt.Fprintf(prompt, "Word %d of %d, roll %d dice: ", len(words)+1, n, dice)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("input ended after %d of %d words", len(words), n)
+		}
+		word, err := passgen.DiceWord(list, scanner.Text())
+		if err != nil {
+			fmt.Fprintf(prompt, "%v, try again\n", err)
+			continue
+		}
+		fmt.Fprintf(prompt, "  %s\n", word)
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+// describePadding summarizes the digits and symbols added to a word, e.g.
+// "2 digits and 1 symbol after the last word", or "" when there are none.
+func describePadding(digits, symbols int, placement passgen.Placement) string {
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		name string
+	}{{digits, "digit"}, {symbols, "symbol"}} {
+		switch {
+		case p.n == 1:
+			parts = append(parts, "1 "+p.name)
+		case p.n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", p.n, p.name))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	where := "the last word"
+	if placement == passgen.PlaceRandom {
+		where = "a random word"
+	}
+	return strings.Join(parts, " and ") + " after " + where
+}
+
+// shortestWord returns the number of letters in the shortest word.
+func shortestWord(words []string) int {
+	shortest := 0
+	for i, w := range words {
+		if n := utf8.RuneCountInString(w); i == 0 || n < shortest {
+			shortest = n
+		}
+	}
+	return shortest
+}
+
+// loadWordlist reads a wordlist from a file or an HTTPS URL and checks that
+// it is fit for passphrases.
+func loadWordlist(source string, refresh bool) ([]string, error) {
+	var words []string
+	var err error
+	if strings.Contains(source, "://") {
+		words, err = fetchWordlist(source, refresh)
+	} else {
+		words, err = readWordlistFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := passgen.CheckWordlist(words); err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return words, nil
+}
+
+func readWordlistFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return passgen.ReadWordlist(f)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestShortestWord tests counting letters rather than bytes
+func TestShortestWord(t *testing.T) {
+	if got := shortestWord([]string{"abacus", "éte", "zebra"}); got != 3 {
+		t.Errorf("shortestWord = %d, want 3", got)
+	}
+}
+
+// TestDescribePadding tests the summary of added digits and symbols
+func TestDescribePadding(t *testing.T) {
+	tests := []struct {
+		digits, symbols int
+		placement       passgen.Placement
+		want            string
+	}{
+		{0, 0, passgen.PlaceEnd, ""},
+		{2, 1, passgen.PlaceEnd, "2 digits and 1 symbol after the last word"},
+		{0, 3, passgen.PlaceRandom, "3 symbols after a random word"},
+	}
+	for _, tt := range tests {
+		if got := describePadding(tt.digits, tt.symbols, tt.placement); got != tt.want {
+			t.Errorf("describePadding(%d, %d, %s) = %q, want %q", tt.digits, tt.symbols, tt.placement, got, tt.want)
+		}
+	}
+}
+
+// TestPassphraseWordsFor tests the word count -entropy picks
+func TestPassphraseWordsFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		strength passphraseStrength
+		bits     float64
+		want     int
+		wantErr  bool
+	}{
+		{"words alone", passphraseStrength{listSize: 7776}, 80, 7, false},
+		{"exact", passphraseStrength{listSize: 1024}, 40, 4, false},
+		{"padding helps", passphraseStrength{listSize: 7776, caps: passgen.CapsRandom, digits: 2, symbols: 1}, 80, 6, false},
+		{"a word per typo", passphraseStrength{listSize: 7776, shortest: 3, typos: 4}, 20, 4, false},
+		{"list too small", passphraseStrength{listSize: 1}, 80, 0, true},
+		{"too many words", passphraseStrength{listSize: 2}, 1000, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.strength.wordsFor(tt.bits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wordsFor error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("wordsFor = %d, want %d", got, tt.want)
+			}
+			if !tt.wantErr && tt.strength.entropy(got) < tt.bits && got > tt.strength.typos {
+				t.Errorf("%d words only reach %.1f bits", got, tt.strength.entropy(got))
+			}
+		})
+	}
+}
+
+// TestReadDiceWords tests reading rolls and asking again for invalid ones
+func TestReadDiceWords(t *testing.T) {
+	list, err := passgen.Wordlist(passgen.WordlistEFFLong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prompt strings.Builder
+	words, err := readDiceWords(strings.NewReader("11111\n1234\n6 6 6 6 6\n"), &prompt, list, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(words, " ") != "abacus zoom" {
+		t.Errorf("Got words %q", words)
+	}
+	if !strings.Contains(prompt.String(), "need 5 dice rolls, got 4, try again") {
+		t.Errorf("Invalid rolls were not reported:\n%s", prompt.String())
+	}
+
+	if _, err := readDiceWords(strings.NewReader("11111\n"), &prompt, list, 2); err == nil {
+		t.Error("Expected error when input ends early")
+	}
+	if _, err := readDiceWords(strings.NewReader(""), &prompt, list[:1000], 1); err == nil {
+		t.Error("Expected error for a list that is not a power of 6")
+	}
+}
+
+// TestLoadWordlist tests that custom wordlists are checked before use
+func TestLoadWordlist(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := range 2000 {
+		lines = append(lines, fmt.Sprintf("word%d", i))
+	}
+	good := filepath.Join(dir, "good.txt")
+	if err := os.WriteFile(good, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	words, err := loadWordlist(good, false)
+	if err != nil || len(words) != 2000 {
+		t.Errorf("loadWordlist = %d words, %v", len(words), err)
+	}
+
+	small := filepath.Join(dir, "small.txt")
+	if err := os.WriteFile(small, []byte("one\ntwo\nthree\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadWordlist(small, false); err == nil || !strings.Contains(err.Error(), small) {
+		t.Errorf("Expected error naming %s, got %v", small, err)
+	}
+	if _, err := loadWordlist(filepath.Join(dir, "missing.txt"), false); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+// TestOnBlocklist tests matching passphrases with and without separators
+func TestOnBlocklist(t *testing.T) {
+	b := passgen.NewBlocklist("dragon", "iloveyou")
+	tests := []struct {
+		words      []string
+		passphrase string
+		want       bool
+	}{
+		{[]string{"Dragon"}, "Dragon", true},
+		{[]string{"i", "love", "you"}, "i-love-you", true},
+		{[]string{"i", "love", "you"}, "iloveyou", true},
+		{[]string{"dragon", "fly"}, "dragon-fly", false},
+	}
+	for _, tt := range tests {
+		if got := onBlocklist(b, tt.words, tt.passphrase); got != tt.want {
+			t.Errorf("onBlocklist(%q) = %v, want %v", tt.passphrase, got, tt.want)
+		}
+	}
+	if onBlocklist(nil, []string{"dragon"}, "dragon") {
+		t.Error("onBlocklist without a blocklist = true, want false")
+	}
+}
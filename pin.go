@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printPINUsage(programName string) {
+	fmt.Printf("Usage: %s pin [OPTIONS]\n", programName)
+	fmt.Println("Generate numeric PINs from all ten digits, including 0 and 1. PINs that")
+	fmt.Println("would be guessed first, such as 1234, 0000, 1212 or a year, are never")
+	fmt.Println("produced.")
+	fmt.Println("Options:")
+	fmt.Println("  -l LENGTH  Number of digits (default: 6)")
+	fmt.Println("  -c COUNT   Number of PINs to generate (default: 1)")
+	fmt.Println("  -groups NxM")
+	fmt.Println("             Split each PIN into N groups of M digits, e.g. 2x4 for a phone")
+	fmt.Println("             conference code of 8 digits")
+	fmt.Println("  -words     Also spell the digits as words, to read them out")
+	fmt.Println("  -o FORMAT  Output format: text or json (default: text)")
+	fmt.Println("  -h         Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s pin -l 6\n", programName)
+	fmt.Printf("  %s pin -groups 2x4 -words\n", programName)
+}
+
+// parsePINGroups reads a -groups value such as 2x4, for two groups of four
+// digits.
+func parsePINGroups(spec string) (groups, size int, err error) {
+	n, m, ok := strings.Cut(passgen.ToLowerASCII(spec), "x")
+	if ok {
+		groups, err = strconv.Atoi(n)
+		if err == nil {
+			size, err = strconv.Atoi(m)
+		}
+	}
+	if !ok || err != nil || groups < 1 || size < 1 {
+		return 0, 0, fmt.Errorf("invalid -groups %q (use NxM, e.g. 2x4 for two groups of four digits)", spec)
+	}
+	return groups, size, nil
+}
+
+// runPIN implements the pin subcommand.
+func runPIN(programName string, args []string) error {
+	fs := flag.NewFlagSet("pin", flag.ContinueOnError)
+	length := fs.Int("l", 6, "Number of digits")
+	count := fs.Int("c", 1, "Number of PINs to generate")
+	format := fs.String("o", "text", "Output format: text or json")
+	groupSpec := fs.String("groups", "", "Split each PIN into N groups of M digits, e.g. 2x4")
+	words := fs.Bool("words", false, "Also spell the digits as words, to read them out")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printPINUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printPINUsage(programName)
+		return nil
+	}
+	groupSize := 0
+	if *groupSpec != "" {
+		groups, size, err := parsePINGroups(*groupSpec)
+		if err != nil {
+			return err
+		}
+		if !isFlagSet(fs, "l") {
+			*length = groups * size
+		} else if *length != groups*size {
+			return fmt.Errorf("-groups %s makes %d digits, not -l %d", *groupSpec, groups*size, *length)
+		}
+		groupSize = size
+	}
+	if *length < passgen.MinPINLength || *length > maxLength {
+		return fmt.Errorf("PIN length must be between %d and %d", passgen.MinPINLength, maxLength)
+	}
+	if *count < 1 || *count > maxCount {
+		return fmt.Errorf("count must be between 1 and %d", maxCount)
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	gen, err := passgen.NewPINGenerator(*length)
+	if err != nil {
+		return err
+	}
+	results := make([]passwordOutput, 0, *count)
+	for i := 0; i < *count; i++ {
+		pin, err := gen.Generate()
+		if err != nil {
+			return fmt.Errorf("generating PIN: %w", err)
+		}
+		bits, err := gen.Entropy(pin)
+		if err != nil {
+			return fmt.Errorf("generating PIN: %w", err)
+		}
+		// Separators are added after estimating the entropy, which they
+		// do not change
+		if groupSize > 0 {
+			pin = passgen.Group(pin, groupSize, " ")
+		}
+		result := passwordOutput{Password: pin, Entropy: bits}
+		if *words {
+			result.Spoken = passgen.SpellDigits(pin)
+		}
+		results = append(results, result)
+	}
+
+	if *format == "json" {
+		return writeJSON(os.Stdout, generateOutput{Schema: outputSchema, Length: *length, Charsets: []string{passgen.Digits}, Passwords: results})
+	}
+	plural := ""
+	if *count > 1 {
+		plural = "s"
+	}
+	fmt.Printf("Generated PIN%s:\n", plural)
+	fmt.Printf("Length: %d digits\n", *length)
+	if groupSize > 0 {
+		fmt.Printf("Groups: %d of %d digits\n", *length/groupSize, groupSize)
+	}
+	fmt.Printf("Entropy: %.1f bits\n\n", results[0].Entropy)
+	for i, r := range results {
+		fmt.Printf("%d: %s\n", i+1, r.Password)
+		if r.Spoken != "" {
+			fmt.Printf("   %s\n", r.Spoken)
+		}
+	}
+	return nil
+}
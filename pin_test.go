@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParsePINGroups tests reading -groups
+func TestParsePINGroups(t *testing.T) {
+	tests := []struct {
+		spec              string
+		wantGroups, wantN int
+		wantErr           bool
+	}{
+		{"2x4", 2, 4, false},
+		{"3X3", 3, 3, false},
+		{"1x6", 1, 6, false},
+		{"4", 0, 0, true},
+		{"x4", 0, 0, true},
+		{"2x0", 0, 0, true},
+		{"-1x4", 0, 0, true},
+		{"2x4x1", 0, 0, true},
+	}
+	for _, tt := range tests {
+		groups, size, err := parsePINGroups(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePINGroups(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if groups != tt.wantGroups || size != tt.wantN {
+			t.Errorf("parsePINGroups(%q) = %d, %d, want %d, %d", tt.spec, groups, size, tt.wantGroups, tt.wantN)
+		}
+	}
+}
+
+// TestPINGroupsFlags tests -groups against the length
+func TestPINGroupsFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"-groups", "2x4", "-l", "6"}, "makes 8 digits, not -l 6"},
+		{[]string{"-groups", "1x3"}, "PIN length must be between"},
+		{[]string{"-groups", "two"}, "invalid -groups"},
+	}
+	for _, tt := range tests {
+		err := runPIN("passgen", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runPIN(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
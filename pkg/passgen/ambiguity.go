@@ -0,0 +1,88 @@
+package passgen
+
+import "fmt"
+
+// Ambiguity selects which look-alike characters are kept out of passwords.
+// The zero value is AmbiguityStandard, the long-standing default.
+type Ambiguity int
+
+const (
+	// AmbiguityStandard excludes 0, O, I, l and 1.
+	AmbiguityStandard Ambiguity = iota
+	// AmbiguityNone excludes nothing.
+	AmbiguityNone
+	// AmbiguityExtended additionally excludes the homoglyph pairs S/5,
+	// B/8 and Z/2, for passwords that are read aloud or typed from print.
+	AmbiguityExtended
+)
+
+// Look-alike characters excluded at each ambiguity level.
+const (
+	SimilarChars         = "0OIl1"
+	ExtendedSimilarChars = SimilarChars + "S5B8Z2"
+)
+
+var ambiguityNames = []string{"standard", "none", "extended"}
+
+// ParseAmbiguity parses an ambiguity level by name: none, standard or
+// extended.
+func ParseAmbiguity(name string) (Ambiguity, error) {
+	for i, n := range ambiguityNames {
+		if EqualFoldASCII(n, name) {
+			return Ambiguity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown ambiguity level %q (use none, standard or extended)", name)
+}
+
+func (a Ambiguity) String() string {
+	if a < 0 || int(a) >= len(ambiguityNames) {
+		return fmt.Sprintf("Ambiguity(%d)", int(a))
+	}
+	return ambiguityNames[a]
+}
+
+// Excluded returns the look-alike characters the level keeps out.
+func (a Ambiguity) Excluded() string {
+	switch a {
+	case AmbiguityNone:
+		return ""
+	case AmbiguityExtended:
+		return ExtendedSimilarChars
+	}
+	return SimilarChars
+}
+
+// WithAmbiguity selects which look-alike characters are excluded.
+// WithSimilar(true) is equivalent to WithAmbiguity(AmbiguityNone).
+func WithAmbiguity(level Ambiguity) GeneratorOption {
+	return func(g *Generator) error {
+		if level < AmbiguityStandard || level > AmbiguityExtended {
+			return fmt.Errorf("unknown ambiguity level %d", int(level))
+		}
+		g.opts.Ambiguity = level
+		return nil
+	}
+}
+
+// similarCharsets returns the letter and digit sets for the options.
+func (o Options) similarCharsets() []string {
+	if o.AllowSimilar || o.Ambiguity == AmbiguityNone {
+		return []string{allUppercase, allLowercase, allNumbers}
+	}
+	sets := []string{Uppercase, Lowercase, Numbers}
+	if o.Ambiguity == AmbiguityExtended {
+		for i, set := range sets {
+			sets[i] = stripChars(set, ExtendedSimilarChars)
+		}
+	}
+	return sets
+}
+
+// ExcludedSimilar returns the look-alike characters the options keep out.
+func (o Options) ExcludedSimilar() string {
+	if o.AllowSimilar {
+		return ""
+	}
+	return o.Ambiguity.Excluded()
+}
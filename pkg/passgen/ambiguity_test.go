@@ -0,0 +1,68 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAmbiguityLevels tests which look-alikes each level excludes
+func TestAmbiguityLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		excluded string
+		kept     string
+	}{
+		{"standard", Options{}, "0OIl1", "S5B8Z2"},
+		{"none", Options{Ambiguity: AmbiguityNone}, "", "0OIl1S5B8Z2"},
+		{"allow similar", Options{AllowSimilar: true, Ambiguity: AmbiguityExtended}, "", "0OIl1S5B8Z2"},
+		{"extended", Options{Ambiguity: AmbiguityExtended}, "0OIl1S5B8Z2", "sbz34"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all := strings.Join(tt.opts.Charsets(), "")
+			if strings.ContainsAny(all, tt.excluded) {
+				t.Errorf("Charsets %q contain one of %q", all, tt.excluded)
+			}
+			for _, c := range tt.kept {
+				if !strings.ContainsRune(all, c) {
+					t.Errorf("Charsets %q should contain %q", all, c)
+				}
+			}
+			if got := tt.opts.ExcludedSimilar(); got != tt.excluded {
+				t.Errorf("ExcludedSimilar = %q, want %q", got, tt.excluded)
+			}
+		})
+	}
+}
+
+// TestParseAmbiguity tests level names round-tripping through String
+func TestParseAmbiguity(t *testing.T) {
+	for _, level := range []Ambiguity{AmbiguityStandard, AmbiguityNone, AmbiguityExtended} {
+		got, err := ParseAmbiguity(ToUpperASCII(level.String()))
+		if err != nil || got != level {
+			t.Errorf("ParseAmbiguity(%q) = %v, %v", level, got, err)
+		}
+	}
+	if _, err := ParseAmbiguity("paranoid"); err == nil {
+		t.Error("Expected error for an unknown level")
+	}
+}
+
+// TestWithAmbiguity tests generation at the extended level
+func TestWithAmbiguity(t *testing.T) {
+	g, err := NewGenerator(WithLength(64), WithAmbiguity(AmbiguityExtended))
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(password, ExtendedSimilarChars) {
+		t.Errorf("Password contains an extended look-alike: %s", password)
+	}
+	if _, err := NewGenerator(WithAmbiguity(Ambiguity(7))); err == nil {
+		t.Error("Expected error for an unknown level")
+	}
+}
@@ -0,0 +1,175 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// anchorWords returns the distinct words of AnchorWords that leave room
+// for the minimums of the character sets and hold no excluded character.
+// Look-alikes are left to the words: within a word an l or an o reads one
+// way only. The sets are returned along with them.
+func (o Options) anchorWords() (words []string, charsets []string, minimums []int) {
+	charsets, minimums = o.requiredSets()
+	room := o.Length - o.MinLength()
+	seen := make(map[string]bool)
+	for _, word := range o.AnchorWords {
+		runes := []rune(word)
+		if len(runes) == 0 || len(runes) > room || seen[word] {
+			continue
+		}
+		if o.allowsAll(word) {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words, charsets, minimums
+}
+
+// generateAnchored draws a word of anchorWords, fills the rest of the
+// length from the character sets and puts the word at a random position
+// among them, such as "x8$horse!Km2".
+func (o Options) generateAnchored() (string, error) {
+	words, charsets, minimums := o.anchorWords()
+	if len(words) == 0 {
+		return "", fmt.Errorf("no anchor word fits in %d characters", o.Length)
+	}
+	random := o.random()
+	n, err := rand.Int(random, big.NewInt(int64(len(words))))
+	if err != nil {
+		return "", err
+	}
+	word := words[n.Int64()]
+	filler, err := generateFromCharsets(random, o.Length-len([]rune(word)), charsets, minimums)
+	if err != nil {
+		return "", err
+	}
+	rest := []rune(filler)
+	at, err := rand.Int(random, big.NewInt(int64(len(rest)+1)))
+	if err != nil {
+		return "", err
+	}
+	i := int(at.Int64())
+	return string(rest[:i]) + word + string(rest[i:]), nil
+}
+
+// anchoredEntropy returns the information content in bits of an anchored
+// password: the choice of word, of its position and of the characters
+// around it. A password that reads as several words at several positions
+// is more likely than any one of them, so every reading is summed.
+func (o Options) anchoredEntropy(password string) float64 {
+	words, charsets, minimums := o.anchorWords()
+	set := make(map[string]bool, len(words))
+	longest := 0
+	for _, word := range words {
+		set[word] = true
+		longest = max(longest, len([]rune(word)))
+	}
+	runes := []rune(password)
+	keyspace := make(map[int]float64)
+	// bits holds -log2 of the probability of every reading
+	var bits []float64
+	for i := range runes {
+		for k := 1; k <= longest && i+k <= len(runes); k++ {
+			if !set[string(runes[i:i+k])] {
+				continue
+			}
+			rest := append(append([]rune{}, runes[:i]...), runes[i+k:]...)
+			if !inKeyspace(rest, charsets, minimums) {
+				continue
+			}
+			f := len(rest)
+			if _, ok := keyspace[f]; !ok {
+				keyspace[f] = KeyspaceEntropy(f, charsets, minimums)
+			}
+			bits = append(bits, math.Log2(float64(len(words)))+math.Log2(float64(f+1))+keyspace[f])
+		}
+	}
+	if len(bits) == 0 {
+		return EstimateEntropyWith(password, charsets)
+	}
+	// -log2 of the sum of 2^-b, scaled by the likeliest reading so the
+	// terms do not underflow
+	least := bits[0]
+	for _, b := range bits {
+		least = min(least, b)
+	}
+	sum := 0.0
+	for _, b := range bits {
+		sum += math.Exp2(least - b)
+	}
+	return least - math.Log2(sum)
+}
+
+// anchoredKeyspace returns the entropy of the weakest anchored password,
+// the one with the longest word and so the fewest random characters.
+// Passwords that read as several words are left out, as they are rare.
+func (o Options) anchoredKeyspace() float64 {
+	words, charsets, minimums := o.anchorWords()
+	longest := 0
+	for _, word := range words {
+		longest = max(longest, len([]rune(word)))
+	}
+	if longest == 0 {
+		return 0
+	}
+	f := o.Length - longest
+	return math.Log2(float64(len(words))) + math.Log2(float64(f+1)) + KeyspaceEntropy(f, charsets, minimums)
+}
+
+// inKeyspace reports whether s could have been drawn from the sets with
+// their minimums, counting each character for the first set holding it as
+// KeyspaceEntropy does.
+func inKeyspace(s []rune, charsets []string, minimums []int) bool {
+	first := make(map[rune]int)
+	for i, charset := range charsets {
+		for _, r := range charset {
+			if _, ok := first[r]; !ok {
+				first[r] = i
+			}
+		}
+	}
+	counts := make([]int, len(charsets))
+	for _, r := range s {
+		i, ok := first[r]
+		if !ok {
+			return false
+		}
+		counts[i]++
+	}
+	for i, charset := range charsets {
+		least := 1
+		if minimums != nil {
+			least = minimums[i]
+		}
+		owns := false
+		for _, r := range charset {
+			if first[r] == i {
+				owns = true
+				break
+			}
+		}
+		if owns && counts[i] < least {
+			return false
+		}
+	}
+	return true
+}
+
+// WithAnchorWord embeds one of words, such as a wordlist, at a random
+// position among characters drawn from the sets, a middle ground between
+// random passwords and passphrases. Only words that fit the length with
+// the minimums of the sets and hold no excluded character are used.
+// Entropy accounts for the word, its position and the characters around
+// it, so it is far lower than for a random password of the same length.
+func WithAnchorWord(words []string) GeneratorOption {
+	return func(g *Generator) error {
+		if len(words) == 0 {
+			return fmt.Errorf("anchor words must not be empty")
+		}
+		g.opts.AnchorWords = words
+		return nil
+	}
+}
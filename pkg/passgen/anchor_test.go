@@ -0,0 +1,86 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestGenerateAnchored tests that passwords hold one fitting word among
+// characters of the sets
+func TestGenerateAnchored(t *testing.T) {
+	words := []string{"horse", "apple", "lamp", "overlong"}
+	g, err := NewGenerator(WithLength(10), WithExclude("m"), WithAnchorWord(words))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 10 {
+			t.Fatalf("Password %q has %d characters, want 10", password, len(password))
+		}
+		// lamp holds the excluded m and overlong leaves no room for the
+		// three required classes
+		if strings.Contains(password, "lamp") || strings.Contains(password, "overlong") {
+			t.Fatalf("Password %q holds a word that does not fit", password)
+		}
+		if !strings.Contains(password, "horse") && !strings.Contains(password, "apple") {
+			t.Fatalf("Password %q holds no anchor word", password)
+		}
+	}
+}
+
+// TestAnchoredEntropy tests the accounting of the word, its position and
+// the characters around it
+func TestAnchoredEntropy(t *testing.T) {
+	g, err := NewGenerator(WithLength(8), WithAnchorWord([]string{"cat", "dig"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	charsets := g.Options().Charsets()
+	single := 1 + math.Log2(6) + KeyspaceEntropy(5, charsets, nil)
+
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{"A2xcatBq", single},
+		// Two readings, cat or dig, are twice as likely as one
+		{"catA2dig", single - 1},
+		// The o around cat is not in the sets, so this is no anchored
+		// password and is estimated like any other
+		{"Xo2catBq", EstimateEntropyWith("Xo2catBq", charsets)},
+	}
+	for _, tt := range tests {
+		got, err := g.Entropy(tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Entropy(%q) = %.3f, want %.3f", tt.password, got, tt.want)
+		}
+	}
+	keyspace, err := g.KeyspaceEntropy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(keyspace-single) > 1e-9 {
+		t.Errorf("KeyspaceEntropy() = %.3f, want %.3f", keyspace, single)
+	}
+}
+
+// TestAnchorWordErrors tests options no word can satisfy
+func TestAnchorWordErrors(t *testing.T) {
+	if _, err := NewGenerator(WithAnchorWord(nil)); err == nil {
+		t.Error("WithAnchorWord(nil) succeeded")
+	}
+	if _, err := NewGenerator(WithLength(6), WithAnchorWord([]string{"horse"})); err == nil {
+		t.Error("a word without room for the required classes was accepted")
+	}
+	if _, err := NewGenerator(WithoutClass(ClassLower), WithAnchorWord([]string{"horse"})); err == nil {
+		t.Error("a word of a disabled class was accepted")
+	}
+}
@@ -0,0 +1,85 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+)
+
+// Layout of Apple-style passwords: three groups of six characters joined by
+// hyphens.
+const (
+	appleStyleGroups    = 3
+	appleStyleGroupSize = 6
+
+	// AppleStyleLength is the length of an Apple-style password, hyphens
+	// included.
+	AppleStyleLength = appleStyleGroups*appleStyleGroupSize + appleStyleGroups - 1
+)
+
+// GenerateAppleStyle returns a password in the format iCloud Keychain
+// suggests, such as "hbtkqe-5wfzcn-xvPrje": three hyphenated groups of six
+// lowercase letters with one digit at the start or end of a group and one
+// uppercase letter anywhere else. Mobile keyboards type it without
+// switching layouts more than twice. Look-alike characters are never used.
+func GenerateAppleStyle() (string, error) {
+	const letters = appleStyleGroups * appleStyleGroupSize
+	chars := make([]byte, letters)
+	for i := range chars {
+		c, err := getRandomChar(Lowercase)
+		if err != nil {
+			return "", err
+		}
+		chars[i] = c
+	}
+
+	// The digit sits at one of the group edges
+	edge, err := randomInt(2 * appleStyleGroups)
+	if err != nil {
+		return "", err
+	}
+	digitAt := edge / 2 * appleStyleGroupSize
+	if edge%2 == 1 {
+		digitAt += appleStyleGroupSize - 1
+	}
+	if chars[digitAt], err = getRandomChar(Numbers); err != nil {
+		return "", err
+	}
+
+	// The uppercase letter takes any other position
+	upperAt, err := randomInt(letters - 1)
+	if err != nil {
+		return "", err
+	}
+	if upperAt >= digitAt {
+		upperAt++
+	}
+	if chars[upperAt], err = getRandomChar(Uppercase); err != nil {
+		return "", err
+	}
+
+	groups := make([]string, appleStyleGroups)
+	for i := range groups {
+		groups[i] = string(chars[i*appleStyleGroupSize : (i+1)*appleStyleGroupSize])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// AppleStyleEntropy returns the entropy in bits of an Apple-style password:
+// the choice of digit and its edge, of the uppercase letter and its
+// position, and of the sixteen lowercase letters, about 87.6 bits.
+func AppleStyleEntropy() float64 {
+	const letters = appleStyleGroups * appleStyleGroupSize
+	return math.Log2(2*appleStyleGroups) + math.Log2(float64(len(Numbers))) +
+		math.Log2(letters-1) + math.Log2(float64(len(Uppercase))) +
+		(letters-2)*math.Log2(float64(len(Lowercase)))
+}
+
+// WithAppleStyle generates Apple-style passwords, see GenerateAppleStyle,
+// instead of drawing from the character sets. The length option does not
+// apply.
+func WithAppleStyle() GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.AppleStyle = true
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestGenerateAppleStyle tests the grouping and the placement of the digit
+// and the uppercase letter
+func TestGenerateAppleStyle(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		password, err := GenerateAppleStyle()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != AppleStyleLength {
+			t.Fatalf("Password %q has %d characters, want %d", password, len(password), AppleStyleLength)
+		}
+		groups := strings.Split(password, "-")
+		if len(groups) != 3 {
+			t.Fatalf("Password %q has %d groups, want 3", password, len(groups))
+		}
+		var digits, upper int
+		for _, group := range groups {
+			if len(group) != 6 {
+				t.Errorf("Password %q has a group of %d characters", password, len(group))
+			}
+			for j, c := range group {
+				switch {
+				case strings.ContainsRune(Numbers, c):
+					digits++
+					if j != 0 && j != 5 {
+						t.Errorf("Password %q has a digit inside a group", password)
+					}
+				case strings.ContainsRune(Uppercase, c):
+					upper++
+				case !strings.ContainsRune(Lowercase, c):
+					t.Errorf("Password %q has unexpected character %q", password, c)
+				}
+			}
+		}
+		if digits != 1 || upper != 1 {
+			t.Errorf("Password %q has %d digits and %d uppercase letters, want 1 each", password, digits, upper)
+		}
+	}
+}
+
+// TestAppleStyleEntropy tests the keyspace of Apple-style passwords
+func TestAppleStyleEntropy(t *testing.T) {
+	want := math.Log2(6*8*17*24) + 16*math.Log2(24)
+	if got := AppleStyleEntropy(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("AppleStyleEntropy() = %f, want %f", got, want)
+	}
+}
+
+// TestWithAppleStyle tests the generator option, which ignores the length
+func TestWithAppleStyle(t *testing.T) {
+	g, err := NewGenerator(WithLength(8), WithAppleStyle(), WithExclude("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != AppleStyleLength || strings.Contains(password, "a") {
+			t.Errorf("Unexpected password %q", password)
+		}
+		if bits, _ := g.Entropy(password); bits != AppleStyleEntropy() {
+			t.Errorf("Entropy = %f, want %f", bits, AppleStyleEntropy())
+		}
+	}
+}
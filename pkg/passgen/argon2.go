@@ -0,0 +1,44 @@
+package passgen
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2Params are the cost parameters of Argon2id: the memory in KiB, the
+// number of passes over it and the number of lanes filled in parallel.
+type Argon2Params struct {
+	Memory  uint32 `json:"memory"`
+	Time    uint32 `json:"time"`
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultArgon2Params are the second recommended option of RFC 9106, for
+// machines that cannot spare 2 GiB per hash: 64 MiB, 3 passes, 4 lanes.
+var DefaultArgon2Params = Argon2Params{Memory: 64 * 1024, Time: 3, Threads: 4}
+
+// Validate reports parameters Argon2id cannot run with. Memory below 8 KiB
+// per lane is raised to that minimum rather than rejected.
+func (p Argon2Params) Validate() error {
+	if p.Time < 1 {
+		return fmt.Errorf("argon2id needs at least 1 pass")
+	}
+	if p.Threads < 1 {
+		return fmt.Errorf("argon2id needs at least 1 thread")
+	}
+	return nil
+}
+
+func (p Argon2Params) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Time, p.Threads)
+}
+
+// Argon2id derives keyLen bytes from a password and salt with Argon2id
+// version 1.3, as defined in RFC 9106, using golang.org/x/crypto/argon2.
+func Argon2id(password, salt []byte, p Argon2Params, keyLen uint32) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, keyLen), nil
+}
@@ -0,0 +1,31 @@
+package passgen
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestArgon2id tests key lengths, salts and parameter checks
+func TestArgon2id(t *testing.T) {
+	p := Argon2Params{Memory: 64, Time: 1, Threads: 2}
+	a, err := Argon2id([]byte("password"), []byte("somesalt"), p, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Argon2id([]byte("password"), []byte("somesalt"), p, 32)
+	c, _ := Argon2id([]byte("password"), []byte("othersalt"), p, 32)
+	if !bytes.Equal(a, b) || bytes.Equal(a, c) {
+		t.Errorf("Argon2id is not a function of password and salt: %x %x %x", a, b, c)
+	}
+	for _, n := range []uint32{4, 64, 65, 100, 128} {
+		if key, _ := Argon2id([]byte("password"), []byte("somesalt"), p, n); len(key) != int(n) {
+			t.Errorf("Argon2id key length = %d, want %d", len(key), n)
+		}
+	}
+	if _, err := Argon2id(nil, nil, Argon2Params{Memory: 64, Threads: 1}, 32); err == nil {
+		t.Error("Expected an error for 0 passes")
+	}
+	if _, err := Argon2id(nil, nil, Argon2Params{Memory: 64, Time: 1}, 32); err == nil {
+		t.Error("Expected an error for 0 threads")
+	}
+}
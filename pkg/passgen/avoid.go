@@ -0,0 +1,60 @@
+package passgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MinAvoidLength is the shortest word WithAvoid accepts. Shorter words
+// turn up by chance in most passwords; leave out single characters with
+// WithExclude instead.
+const MinAvoidLength = 3
+
+// foldAvoid lowercases s and undoes the l33t substitutions of
+// EstimateStrength, also folding l into i, since 1 stands for both, so
+// "Alice", "a1ice" and "4L1CE" compare equal.
+func foldAvoid(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if plain, ok := l33tTable[r]; ok {
+			r = plain
+		}
+		if r == 'l' {
+			r = 'i'
+		}
+		return r
+	}, s)
+}
+
+// FindAvoided returns the first of words found in password, ignoring case
+// and simple l33t substitutions such as 4 for a or 0 for o, or "" if none
+// is.
+func FindAvoided(password string, words []string) string {
+	folded := foldAvoid(password)
+	for _, w := range words {
+		if strings.Contains(folded, foldAvoid(w)) {
+			return w
+		}
+	}
+	return ""
+}
+
+// WithAvoid rejects passwords containing any of words, such as a user
+// name, company name or birth year, see FindAvoided.
+func WithAvoid(words ...string) GeneratorOption {
+	return func(g *Generator) error {
+		for _, w := range words {
+			if n := len([]rune(w)); n < MinAvoidLength {
+				return fmt.Errorf("words to avoid must be at least %d characters, not %q", MinAvoidLength, w)
+			}
+		}
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if w := FindAvoided(password, words); w != "" {
+				return Reject("avoided word", "contains %q", w)
+			}
+			return nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package passgen
+
+import "testing"
+
+// TestFindAvoided tests matching words regardless of case and l33t
+func TestFindAvoided(t *testing.T) {
+	words := []string{"alice", "Acme", "1987"}
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"xALICEx", "alice"},
+		{"x4l1c3x", "alice"},
+		{"a|ice99", "alice"},
+		{"q@cMEq", "Acme"},
+		{"born1987", "1987"},
+		{"bornI98T", "1987"},
+		{"al-ice", ""},
+		{"acm", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := FindAvoided(tt.password, words); got != tt.want {
+			t.Errorf("FindAvoided(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+// TestWithAvoid tests regenerating passwords containing avoided words
+func TestWithAvoid(t *testing.T) {
+	g, err := NewGenerator(WithLength(3), WithoutClass(ClassUpper), WithoutClass(ClassLower), WithAvoid("z22", "2e4"), WithMaxAttempts(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 500; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := FindAvoided(password, []string{"z22", "2e4"}); w != "" {
+			t.Fatalf("Generated %q containing %q", password, w)
+		}
+	}
+
+	// With only the digit 2 every candidate is 222, which reads z22
+	g, err = NewGenerator(WithLength(3), WithoutClass(ClassUpper), WithoutClass(ClassLower), WithExclude("3456789"), WithAvoid("z22"), WithMaxAttempts(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Generate(); err == nil {
+		t.Error("Generate() succeeded although every candidate is avoided")
+	}
+
+	if _, err := NewGenerator(WithAvoid("jo")); err == nil {
+		t.Error("Expected an error for a two-letter word")
+	}
+}
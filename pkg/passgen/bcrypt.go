@@ -0,0 +1,35 @@
+package passgen
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt costs, the base 2 logarithm of the key expansion rounds.
+const (
+	MinBcryptCost     = bcrypt.MinCost
+	MaxBcryptCost     = bcrypt.MaxCost
+	DefaultBcryptCost = 12
+)
+
+// MaxBcryptPassword is the longest password bcrypt hashes, in bytes. It
+// ignores everything after the first 72 bytes, so longer passwords are
+// rejected instead of silently truncated.
+const MaxBcryptPassword = 72
+
+// bcryptHash returns the $2a$ hash of a password with 2^cost rounds of key
+// expansion, from x/crypto/bcrypt, which draws its own salt.
+func bcryptHash(password string, cost int) (string, error) {
+	if cost < MinBcryptCost || cost > MaxBcryptCost {
+		return "", fmt.Errorf("bcrypt cost must be between %d and %d", MinBcryptCost, MaxBcryptCost)
+	}
+	if len(password) > MaxBcryptPassword {
+		return "", fmt.Errorf("bcrypt only hashes passwords of up to %d bytes, not %d", MaxBcryptPassword, len(password))
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,37 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestBcryptHash tests that hashes verify, and against hashes computed by
+// libxcrypt's crypt(3)
+func TestBcryptHash(t *testing.T) {
+	for _, password := range []string{"password", ""} {
+		got, err := bcryptHash(password, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(got, "$2a$04$") || bcrypt.CompareHashAndPassword([]byte(got), []byte(password)) != nil {
+			t.Errorf("bcryptHash(%q, 4) = %s, which does not verify", password, got)
+		}
+	}
+	for password, hash := range map[string]string{
+		"password": "$2b$04$KBCwKxOzLha2MUDgW0PjXehyC7kcbJmICs4eWpZZOlh/QJzfSPPHe",
+		"":         "$2b$05$KBCwKxOzLha2MUDgW0PjXe5A819FM2u.ZNmtjRaeljSIQmQ.kt98C",
+	} {
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			t.Errorf("libxcrypt hash of %q does not verify: %v", password, err)
+		}
+	}
+
+	if _, err := bcryptHash(strings.Repeat("x", MaxBcryptPassword+1), 4); err == nil {
+		t.Error("Expected an error for a password bcrypt would truncate")
+	}
+	if _, err := bcryptHash("password", 3); err == nil {
+		t.Error("Expected an error for cost 3")
+	}
+}
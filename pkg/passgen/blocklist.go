@@ -0,0 +1,83 @@
+package passgen
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Blocklist is a set of passwords that must not be used, such as common or
+// breached passwords. Matching ignores case, since a blocklisted password
+// with different capitalisation is among an attacker's first guesses.
+type Blocklist struct {
+	words map[string]struct{}
+}
+
+// NewBlocklist returns a blocklist of the given passwords.
+func NewBlocklist(words ...string) *Blocklist {
+	b := &Blocklist{words: make(map[string]struct{}, len(words))}
+	b.Add(words...)
+	return b
+}
+
+// Add adds passwords to the blocklist.
+func (b *Blocklist) Add(words ...string) {
+	for _, w := range words {
+		b.words[strings.ToLower(w)] = struct{}{}
+	}
+}
+
+// Read adds the passwords in r, one per line, to the blocklist. Blank lines
+// are skipped; anything else, including leading # or spaces, is part of a
+// password.
+func (b *Blocklist) Read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			b.Add(line)
+		}
+	}
+	return scanner.Err()
+}
+
+// Contains reports whether password is on the blocklist.
+func (b *Blocklist) Contains(password string) bool {
+	_, ok := b.words[strings.ToLower(password)]
+	return ok
+}
+
+// Len returns the number of passwords on the blocklist.
+func (b *Blocklist) Len() int {
+	return len(b.words)
+}
+
+// commonPasswords holds the embedded list of common passwords, most common
+// first, loaded on first use.
+var commonPasswords = sync.OnceValue(func() []string {
+	words, _ := readEmbeddedList("wordlists/common_passwords.txt")
+	return words
+})
+
+// CommonPasswords returns a blocklist of the embedded common passwords, the
+// most common passwords of public breach corpora. A random password rarely
+// collides with one, but short PINs, short passwords from few character
+// sets and passphrases of one or two words can.
+func CommonPasswords() *Blocklist {
+	return NewBlocklist(commonPasswords()...)
+}
+
+// WithBlocklist rejects passwords on any of the blocklists.
+func WithBlocklist(lists ...*Blocklist) GeneratorOption {
+	return func(g *Generator) error {
+		g.pipeline.UsePostGenerate(func(password string) error {
+			for _, b := range lists {
+				if b.Contains(password) {
+					return Reject("blocklist", "on the blocklist")
+				}
+			}
+			return nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBlocklist tests case-insensitive matching and reading lists
+func TestBlocklist(t *testing.T) {
+	b := NewBlocklist("password", "Summer2024")
+	if err := b.Read(strings.NewReader("letmein\r\n\n#hashtag\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", b.Len())
+	}
+	for _, password := range []string{"password", "PASSWORD", "summer2024", "LetMeIn", "#hashtag"} {
+		if !b.Contains(password) {
+			t.Errorf("Contains(%q) = false, want true", password)
+		}
+	}
+	for _, password := range []string{"", "password1", "letmein "} {
+		if b.Contains(password) {
+			t.Errorf("Contains(%q) = true, want false", password)
+		}
+	}
+}
+
+// TestCommonPasswords tests the embedded blocklist
+func TestCommonPasswords(t *testing.T) {
+	b := CommonPasswords()
+	if b.Len() < 100 {
+		t.Errorf("Len() = %d, want the embedded list", b.Len())
+	}
+	for _, password := range []string{"123456", "Password", "qwerty"} {
+		if !b.Contains(password) {
+			t.Errorf("Contains(%q) = false, want true", password)
+		}
+	}
+	// Each call returns a list of its own
+	b.Add("extra")
+	if CommonPasswords().Contains("extra") {
+		t.Error("Adding to one blocklist changed another")
+	}
+}
+
+// TestWithBlocklist tests regenerating blocklisted candidates
+func TestWithBlocklist(t *testing.T) {
+	common := CommonPasswords()
+	g, err := NewGenerator(WithLength(4), WithoutClass(ClassUpper), WithoutClass(ClassLower), WithBlocklist(NewBlocklist("0000"), common))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if password == "0000" || common.Contains(password) {
+			t.Fatalf("Generated blocklisted %q", password)
+		}
+	}
+
+	g, err = NewGenerator(WithLength(1), WithoutClass(ClassUpper), WithoutClass(ClassLower), WithBlocklist(NewBlocklist("0", "1", "2", "3", "4", "5", "6", "7", "8", "9")), WithMaxAttempts(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Generate(); err == nil {
+		t.Error("Expected an error when every password is blocklisted")
+	}
+}
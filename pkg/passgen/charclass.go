@@ -0,0 +1,99 @@
+package passgen
+
+import "unicode"
+
+// CharClass is the class a character counts towards in composition rules.
+//
+// Classes and case mappings in this package come from fixed tables: an
+// explicit ASCII table, and the Unicode tables for anything else. They never
+// depend on the process locale (LANG, LC_ALL, ...), so for example the
+// Turkish dotted and dotless i are classified and case-mapped the same way
+// on every machine.
+type CharClass int
+
+const (
+	ClassUpper CharClass = iota
+	ClassLower
+	ClassDigit
+	ClassSpecial
+)
+
+func (c CharClass) String() string {
+	switch c {
+	case ClassUpper:
+		return "uppercase"
+	case ClassLower:
+		return "lowercase"
+	case ClassDigit:
+		return "digit"
+	default:
+		return "special"
+	}
+}
+
+// asciiClasses is the class of every ASCII character.
+var asciiClasses = func() [128]CharClass {
+	var table [128]CharClass
+	for c := 0; c < 128; c++ {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			table[c] = ClassUpper
+		case c >= 'a' && c <= 'z':
+			table[c] = ClassLower
+		case c >= '0' && c <= '9':
+			table[c] = ClassDigit
+		default:
+			table[c] = ClassSpecial
+		}
+	}
+	return table
+}()
+
+// ClassOf returns the class of r. Non-ASCII letters count as uppercase or
+// lowercase according to Unicode; letters without case (e.g. CJK) count as
+// lowercase.
+func ClassOf(r rune) CharClass {
+	if r >= 0 && r < 128 {
+		return asciiClasses[r]
+	}
+	switch {
+	case unicode.IsUpper(r) || unicode.IsTitle(r):
+		return ClassUpper
+	case unicode.IsLetter(r):
+		return ClassLower
+	case unicode.IsDigit(r):
+		return ClassDigit
+	default:
+		return ClassSpecial
+	}
+}
+
+// ToUpperASCII upper-cases the ASCII letters in s and leaves every other
+// character untouched, so the result always has the same length as s.
+func ToUpperASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			b[i] = c - ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// ToLowerASCII lower-cases the ASCII letters in s and leaves every other
+// character untouched, so the result always has the same length as s.
+func ToLowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// EqualFoldASCII reports whether a and b are equal ignoring the case of ASCII
+// letters only.
+func EqualFoldASCII(a, b string) bool {
+	return len(a) == len(b) && ToLowerASCII(a) == ToLowerASCII(b)
+}
@@ -0,0 +1,95 @@
+package passgen
+
+import "testing"
+
+// TestClassOf tests character classification, including Turkish i variants
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want CharClass
+	}{
+		{'A', ClassUpper},
+		{'I', ClassUpper},
+		{'i', ClassLower},
+		{'z', ClassLower},
+		{'0', ClassDigit},
+		{'9', ClassDigit},
+		{'!', ClassSpecial},
+		{' ', ClassSpecial},
+		{'\x00', ClassSpecial},
+		{'İ', ClassUpper}, // U+0130 dotted capital I
+		{'ı', ClassLower}, // U+0131 dotless small i
+		{'ß', ClassLower},
+		{'ǅ', ClassUpper}, // title case counts as upper
+		{'字', ClassLower},
+		{'٣', ClassDigit}, // Arabic-Indic digit three
+		{'€', ClassSpecial},
+	}
+
+	for _, tt := range tests {
+		if got := ClassOf(tt.r); got != tt.want {
+			t.Errorf("ClassOf(%q) = %v, want %v", tt.r, got, tt.want)
+		}
+	}
+}
+
+// TestASCIICaseMapping tests that case mapping only touches ASCII letters,
+// so the Turkish i rules never apply
+func TestASCIICaseMapping(t *testing.T) {
+	if got := ToUpperASCII("title-İı-ß-9"); got != "TITLE-İı-ß-9" {
+		t.Errorf("ToUpperASCII = %q", got)
+	}
+	if got := ToLowerASCII("TITLE-İı-ß-9"); got != "title-İı-ß-9" {
+		t.Errorf("ToLowerASCII = %q", got)
+	}
+	if got := ToUpperASCII("i"); got != "I" {
+		t.Errorf("ToUpperASCII(i) = %q, want I even in Turkish locales", got)
+	}
+	if !EqualFoldASCII("PassWord", "password") {
+		t.Error("EqualFoldASCII should ignore ASCII case")
+	}
+	if EqualFoldASCII("İ", "i") || EqualFoldASCII("ı", "I") {
+		t.Error("EqualFoldASCII should not fold Turkish i variants")
+	}
+	// The Turkish rules would map I to ı and i to İ
+	if got := ToLowerASCII("I"); got != "i" {
+		t.Errorf("ToLowerASCII(I) = %q, want i", got)
+	}
+	if EqualFoldASCII("i", "İ") {
+		t.Error("EqualFoldASCII(i, İ) should be false")
+	}
+}
+
+// TestLocaleIndependentGeneration tests that generation and rules classify
+// the Turkish i variants by Unicode alone, which Go does whatever the
+// locale
+func TestLocaleIndependentGeneration(t *testing.T) {
+	rule, err := CompileRule("upper >= 1 && lower >= 1 && digits >= 1 && special == 0")
+	if err != nil {
+		t.Fatalf("Failed to compile rule: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		password, err := GeneratePassword(12, false)
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		if ok, _ := rule.Eval(password); !ok {
+			t.Errorf("Password %s misclassified", password)
+		}
+	}
+
+	// Dotted capital I is uppercase and dotless i is lowercase everywhere
+	env := newRuleEnv("İı")
+	if env["upper"] != int64(1) || env["lower"] != int64(1) {
+		t.Errorf("Expected 1 upper and 1 lower, got %v and %v", env["upper"], env["lower"])
+	}
+
+	replacement, err := Equivalent("İıI")
+	if err != nil {
+		t.Fatalf("Failed to create equivalent: %v", err)
+	}
+	if ClassOf(rune(replacement[0])) != ClassUpper || ClassOf(rune(replacement[1])) != ClassLower {
+		t.Errorf("Equivalent changed classes: %q", replacement)
+	}
+}
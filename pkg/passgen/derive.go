@@ -0,0 +1,83 @@
+package passgen
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// deriveSalt separates passgen's derivations from other uses of the same
+// parent secret. Changing it changes every derived password.
+const deriveSalt = "passgen derive-child v1"
+
+// DeriveChild returns a password of the given length derived from the
+// parent secret with HKDF-SHA256 (RFC 5869). info names the child, e.g.
+// "db/replica1": the same parent and info always give the same password, and
+// children with different info are independent of each other. Like
+// GenerateFromCharsets, the password contains at least one character from
+// each of the charsets.
+//
+// A child is never stronger than its parent, so the parent should itself be
+// a long random secret.
+func DeriveChild(parent []byte, info string, length int, charsets []string) (string, error) {
+	if len(parent) == 0 {
+		return "", fmt.Errorf("the parent secret must not be empty")
+	}
+	if len(charsets) == 0 {
+		return "", fmt.Errorf("at least one character set is required")
+	}
+	if length < len(charsets) {
+		return "", fmt.Errorf("password length must be at least %d", len(charsets))
+	}
+	var alphabet []rune
+	seen := make(map[rune]bool)
+	for _, charset := range charsets {
+		if charset == "" {
+			return "", fmt.Errorf("character sets must not be empty")
+		}
+		for _, r := range charset {
+			if !seen[r] {
+				seen[r] = true
+				alphabet = append(alphabet, r)
+			}
+		}
+	}
+
+	stream, err := hkdf.Key(sha256.New, parent, []byte(deriveSalt), info, 255*sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	// Draw 16-bit values and reject those above the largest multiple of
+	// the alphabet size, so every character is equally likely. Candidates
+	// missing a character set are discarded like in a Pipeline.
+	n := uint32(len(alphabet))
+	limit := 1 << 16 / n * n
+	password := make([]rune, 0, length)
+	for ; len(stream) >= 2; stream = stream[2:] {
+		v := uint32(binary.BigEndian.Uint16(stream))
+		if v >= limit {
+			continue
+		}
+		password = append(password, alphabet[v%n])
+		if len(password) < length {
+			continue
+		}
+		if hasEverySet(string(password), charsets) {
+			return string(password), nil
+		}
+		password = password[:0]
+	}
+	return "", fmt.Errorf("could not derive a password of %d characters containing every character set", length)
+}
+
+// hasEverySet reports whether password contains a character of every set.
+func hasEverySet(password string, charsets []string) bool {
+	for _, charset := range charsets {
+		if !strings.ContainsAny(password, charset) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDeriveChild tests that children are stable, distinct and well formed
+func TestDeriveChild(t *testing.T) {
+	parent := []byte("correct horse battery staple")
+	charsets := Options{}.Charsets()
+
+	// Pinned so the derivation never changes silently: HKDF-SHA256 with
+	// salt "passgen derive-child v1", checked against an independent
+	// implementation
+	got, err := DeriveChild(parent, "db/replica1", 16, charsets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "TeMA9bvExEiZd7L3" {
+		t.Errorf("DeriveChild = %q, want TeMA9bvExEiZd7L3", got)
+	}
+
+	other, err := DeriveChild(parent, "db/replica2", 16, charsets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == got {
+		t.Error("Children with different info should differ")
+	}
+
+	special := Options{IncludeSpecial: true}.Charsets()
+	for _, length := range []int{4, 12, 128} {
+		password, err := DeriveChild(parent, "web", length, special)
+		if err != nil {
+			t.Fatalf("Length %d: %v", length, err)
+		}
+		if len(password) != length {
+			t.Errorf("Got %d characters, want %d", len(password), length)
+		}
+		for _, charset := range special {
+			if !strings.ContainsAny(password, charset) {
+				t.Errorf("Password %q has no character of %q", password, charset)
+			}
+		}
+	}
+}
+
+// TestDeriveChildErrors tests invalid parents, lengths and charsets
+func TestDeriveChildErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		parent   string
+		length   int
+		charsets []string
+	}{
+		{"empty parent", "", 12, []string{Lowercase}},
+		{"no charsets", "secret", 12, nil},
+		{"empty charset", "secret", 12, []string{Lowercase, ""}},
+		{"too short", "secret", 2, Options{}.Charsets()},
+		{"stream exhausted", "secret", 5000, []string{Lowercase}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DeriveChild([]byte(tt.parent), "x", tt.length, tt.charsets); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package passgen
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+// DicePerWord returns how many six-sided dice pick one word from a list of
+// listSize words, which must be a power of six such as 1296 (4 dice) or
+// 7776 (5 dice) for every word to be equally likely.
+func DicePerWord(listSize int) (int, error) {
+	dice := 0
+	for n := listSize; n > 1 && n%6 == 0; n /= 6 {
+		dice++
+	}
+	if dice == 0 || pow6(dice) != listSize {
+		return 0, fmt.Errorf("a list of %d words cannot be used with dice; it needs a power of 6 such as 1296 or 7776", listSize)
+	}
+	return dice, nil
+}
+
+func pow6(k int) int {
+	n := 1
+	for ; k > 0; k-- {
+		n *= 6
+	}
+	return n
+}
+
+// DiceWord returns the word picked by physical dice rolls, given as the
+// digits 1 to 6 in the order the dice were read, e.g. "16655". Spaces
+// between the digits are ignored. words must be ordered like a diceware
+// list, so 11111 picks the first word and 66666 the last, which holds for
+// the embedded wordlists.
+func DiceWord(words []string, rolls string) (string, error) {
+	dice, err := DicePerWord(len(words))
+	if err != nil {
+		return "", err
+	}
+	rolls = strings.Join(strings.Fields(rolls), "")
+	if len(rolls) != dice {
+		return "", fmt.Errorf("need %d dice rolls, got %d", dice, len(rolls))
+	}
+	index := 0
+	for _, c := range rolls {
+		if c < '1' || c > '6' {
+			return "", fmt.Errorf("dice rolls must be the digits 1 to 6, got %q", c)
+		}
+		index = index*6 + int(c-'1')
+	}
+	return words[index], nil
+}
+
+// DiceEntropy collects physical dice rolls and coin flips as the only
+// source of randomness, for secrets that must not depend on the computer's
+// RNG. Real dice and coins are rarely fair, so they are debiased with von
+// Neumann's method: of two rolls of a die, or two flips of a coin, a
+// different pair yields one bit from their order, a to b or b to a, which
+// is equally likely however the die or coin is weighted, and an equal pair
+// yields nothing. A fair die gives 5/12 of a bit per roll this way and a
+// fair coin 1/4 of a bit per flip.
+type DiceEntropy struct {
+	bits []byte
+	n    int
+	// pendingDie and pendingCoin hold the first of a pair, 0 for none.
+	pendingDie, pendingCoin byte
+	// Rolls and Flips count the input so far.
+	Rolls, Flips int
+}
+
+// Add reads rolls and flips in any mix: the digits 1 to 6 are die rolls,
+// H and T coin flips, and spaces, commas and dashes are ignored. Input with
+// anything else is rejected as a whole.
+func (e *DiceEntropy) Add(input string) error {
+	input = strings.ToUpper(input)
+	for _, c := range input {
+		if !strings.ContainsRune("123456HT \t,-", c) {
+			return fmt.Errorf("rolls must be the digits 1 to 6 and flips H or T, got %q", c)
+		}
+	}
+	for _, c := range []byte(input) {
+		switch {
+		case c >= '1' && c <= '6':
+			e.Rolls++
+			e.pair(&e.pendingDie, c)
+		case c == 'H' || c == 'T':
+			e.Flips++
+			e.pair(&e.pendingCoin, c)
+		}
+	}
+	return nil
+}
+
+// pair completes or starts a pair of outcomes of one die or coin.
+func (e *DiceEntropy) pair(pending *byte, c byte) {
+	if *pending == 0 {
+		*pending = c
+		return
+	}
+	if *pending != c {
+		if e.n%8 == 0 {
+			e.bits = append(e.bits, 0)
+		}
+		if *pending < c {
+			e.bits[e.n/8] |= 1 << (e.n % 8)
+		}
+		e.n++
+	}
+	*pending = 0
+}
+
+// Bits returns the number of unbiased bits collected.
+func (e *DiceEntropy) Bits() int {
+	return e.n
+}
+
+// Reader returns a ChaCha8 stream seeded with the SHA-256 hash of the
+// collected bits, for WithRandom. Its output holds no more entropy than
+// the bits, at most 256, so collect at least as many as the secrets drawn
+// from it need.
+func (e *DiceEntropy) Reader() io.Reader {
+	h := sha256.New()
+	io.WriteString(h, "passgen dice\x00")
+	fmt.Fprintf(h, "%d\x00", e.n)
+	h.Write(e.bits)
+	var seed [32]byte
+	h.Sum(seed[:0])
+	return rand.NewChaCha8(seed)
+}
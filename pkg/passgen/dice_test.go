@@ -0,0 +1,162 @@
+package passgen
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestDicePerWord tests which list sizes work with dice
+func TestDicePerWord(t *testing.T) {
+	tests := []struct {
+		size    int
+		want    int
+		wantErr bool
+	}{
+		{7776, 5, false},
+		{1296, 4, false},
+		{6, 1, false},
+		{2048, 0, true},
+		{7777, 0, true},
+		{1, 0, true},
+		{0, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := DicePerWord(tt.size)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("DicePerWord(%d) = %d, %v; want %d", tt.size, got, err, tt.want)
+		}
+	}
+}
+
+// TestDiceWord tests mapping rolls to the embedded EFF wordlists
+func TestDiceWord(t *testing.T) {
+	long, err := Wordlist(WordlistEFFLong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := Wordlist(WordlistEFFShort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		words []string
+		rolls string
+		want  string
+	}{
+		// Entries of the published lists
+		{long, "11111", "abacus"},
+		{long, "1 1 1 1 2", "abdomen"},
+		{long, "66666", "zoom"},
+		{short, "1111", "aardvark"},
+		{short, "6666", "zucchini"},
+	}
+	for _, tt := range tests {
+		got, err := DiceWord(tt.words, tt.rolls)
+		if err != nil || got != tt.want {
+			t.Errorf("DiceWord(%q) = %q, %v; want %q", tt.rolls, got, err, tt.want)
+		}
+	}
+
+	for _, rolls := range []string{"1111", "111111", "11117", "1111a", ""} {
+		if _, err := DiceWord(long, rolls); err == nil {
+			t.Errorf("Expected error for rolls %q", rolls)
+		}
+	}
+}
+
+// TestDiceEntropy tests debiasing rolls and flips into bits
+func TestDiceEntropy(t *testing.T) {
+	tests := []struct {
+		input        string
+		bits         int
+		rolls, flips int
+	}{
+		{"HT TH", 2, 0, 4},
+		// Equal pairs yield nothing
+		{"hh tt HT", 1, 0, 6},
+		{"1 2 2 1 3 3", 2, 6, 0},
+		{"16-65-5", 2, 5, 0},
+		// Dice and coins pair separately
+		{"1 H 2 T", 2, 2, 2},
+	}
+	for _, tt := range tests {
+		var e DiceEntropy
+		if err := e.Add(tt.input); err != nil {
+			t.Fatal(err)
+		}
+		if e.Bits() != tt.bits || e.Rolls != tt.rolls || e.Flips != tt.flips {
+			t.Errorf("Add(%q) = %d bits from %d rolls and %d flips, want %d from %d and %d", tt.input, e.Bits(), e.Rolls, e.Flips, tt.bits, tt.rolls, tt.flips)
+		}
+	}
+
+	var e DiceEntropy
+	e.Add("1")
+	for _, input := range []string{"7", "1 2 x", "0"} {
+		if err := e.Add(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+	if e.Rolls != 1 || e.Bits() != 0 {
+		t.Errorf("Rejected input was counted: %d rolls, %d bits", e.Rolls, e.Bits())
+	}
+	// The pending roll pairs with the next input
+	e.Add("2")
+	if e.Bits() != 1 {
+		t.Errorf("Got %d bits, want 1", e.Bits())
+	}
+}
+
+// TestDiceEntropyBiased tests that a heavily weighted coin still yields
+// as many ones as zeros
+func TestDiceEntropyBiased(t *testing.T) {
+	var e DiceEntropy
+	// Nine heads to every tail, in every arrangement over 10 flips
+	var flips []byte
+	for tail := 0; tail < 10; tail++ {
+		for i := 0; i < 10; i++ {
+			flips = append(flips, "HT"[btoi(i == tail)])
+		}
+	}
+	if err := e.Add(string(flips)); err != nil {
+		t.Fatal(err)
+	}
+	ones := 0
+	for i := 0; i < e.Bits(); i++ {
+		ones += int(e.bits[i/8] >> (i % 8) & 1)
+	}
+	if e.Bits() == 0 || ones*2 != e.Bits() {
+		t.Errorf("Got %d ones in %d bits", ones, e.Bits())
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TestDiceEntropyReader tests that the stream depends only on the bits
+func TestDiceEntropyReader(t *testing.T) {
+	read := func(input string) []byte {
+		var e DiceEntropy
+		if err := e.Add(input); err != nil {
+			t.Fatal(err)
+		}
+		out := make([]byte, 32)
+		io.ReadFull(e.Reader(), out)
+		return out
+	}
+	if !bytes.Equal(read("HTTH12"), read("HT TH 1 2")) {
+		t.Error("The same bits gave different streams")
+	}
+	if !bytes.Equal(read("HT"), read("HTHH")) {
+		t.Error("An equal pair changed the stream")
+	}
+	// The stream also depends on the number of bits, which are packed
+	// into bytes with zeros after the last
+	if bytes.Equal(read("HTTH"), read("THHT")) || bytes.Equal(read("HT"), read("HTHT")) {
+		t.Error("Different bits gave the same stream")
+	}
+}
@@ -0,0 +1,88 @@
+package passgen
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultMinWordLength is the shortest word WithoutDictionaryWords looks for
+// by default. Shorter words such as "cat" turn up by chance in most random
+// passwords, while auditors who flag recognisable words rarely count them.
+const DefaultMinWordLength = 4
+
+// Dictionary finds words embedded in passwords, such as "Xq7horse2k".
+// Matching ignores case.
+type Dictionary struct {
+	words    map[string]struct{}
+	min, max int
+}
+
+// NewDictionary returns a dictionary of the given words that are at least
+// minLength bytes long; shorter words are left out.
+func NewDictionary(minLength int, words ...string) *Dictionary {
+	d := &Dictionary{words: make(map[string]struct{}, len(words)), min: max(minLength, 1)}
+	for _, w := range words {
+		if len(w) < d.min {
+			continue
+		}
+		d.words[strings.ToLower(w)] = struct{}{}
+		d.max = max(d.max, len(w))
+	}
+	return d
+}
+
+// Len returns the number of words in the dictionary.
+func (d *Dictionary) Len() int {
+	return len(d.words)
+}
+
+// Find returns the longest dictionary word in password, lower-cased and
+// leftmost if several are as long, or "" if it holds none.
+func (d *Dictionary) Find(password string) string {
+	lower := strings.ToLower(password)
+	found := ""
+	for i := range len(lower) {
+		for n := min(d.max, len(lower)-i); n >= d.min && n > len(found); n-- {
+			if _, ok := d.words[lower[i:i+n]]; ok {
+				found = lower[i : i+n]
+				break
+			}
+		}
+	}
+	return found
+}
+
+// embeddedWords holds the words of every embedded wordlist, loaded on first
+// use.
+var embeddedWords = sync.OnceValue(func() []string {
+	var words []string
+	for _, name := range Wordlists() {
+		list, _ := Wordlist(name)
+		words = append(words, list...)
+	}
+	return words
+})
+
+// EmbeddedDictionary returns a dictionary of the words of the embedded
+// wordlists that are at least minLength bytes long.
+func EmbeddedDictionary(minLength int) *Dictionary {
+	return NewDictionary(minLength, embeddedWords()...)
+}
+
+// WithoutDictionaryWords rejects passwords containing a word of the
+// dictionary, see Dictionary.Find.
+func WithoutDictionaryWords(d *Dictionary) GeneratorOption {
+	return func(g *Generator) error {
+		if d == nil || d.Len() == 0 {
+			return fmt.Errorf("dictionary must contain at least one word")
+		}
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if word := d.Find(password); word != "" {
+				return Reject("dictionary word", "contains the word %q", word)
+			}
+			return nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+package passgen
+
+import "testing"
+
+// TestDictionaryFind tests finding the longest embedded word
+func TestDictionaryFind(t *testing.T) {
+	d := NewDictionary(4, "cat", "horse", "HORSEBACK", "back", "planet")
+	if d.Len() != 4 {
+		t.Errorf("Len() = %d, want 4 without the short word", d.Len())
+	}
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"Xq7horse2k", "horse"},
+		{"xHoRsEbAcK9", "horseback"},
+		{"back7planet", "planet"},
+		{"bac7k", ""},
+		{"7cat7", ""},
+		{"hors", ""},
+		{"", ""},
+		{"€€planet€", "planet"},
+	}
+	for _, tt := range tests {
+		if got := d.Find(tt.password); got != tt.want {
+			t.Errorf("Find(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+// TestEmbeddedDictionary tests the dictionary of the embedded wordlists
+func TestEmbeddedDictionary(t *testing.T) {
+	d := EmbeddedDictionary(DefaultMinWordLength)
+	if d.Len() < 7000 {
+		t.Errorf("Len() = %d, want the embedded wordlists", d.Len())
+	}
+	if got := d.Find("Q9zebra2"); got != "zebra" {
+		t.Errorf("Find(%q) = %q, want %q", "Q9zebra2", got, "zebra")
+	}
+}
+
+// TestWithoutDictionaryWords tests regenerating passwords that contain words
+func TestWithoutDictionaryWords(t *testing.T) {
+	d := EmbeddedDictionary(DefaultMinWordLength)
+	g, err := NewGenerator(WithLength(24), WithoutClass(ClassUpper), WithoutClass(ClassDigit), WithoutDictionaryWords(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 500; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if word := d.Find(password); word != "" {
+			t.Fatalf("Generated %q containing %q", password, word)
+		}
+	}
+
+	// Drawing only a and A, every candidate is the word in some case
+	g, err = NewGenerator(WithLength(4), WithExclude("bcdefghijklmnpqrstuvwxyzBCDEFGHIJKLMNOPQRSTUVWXYZ23456789"), WithoutDictionaryWords(NewDictionary(4, "aaaa")), WithMaxAttempts(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Generate(); err == nil {
+		t.Error("Generate() succeeded although every candidate is a word")
+	}
+
+	if _, err := NewGenerator(WithoutDictionaryWords(NewDictionary(4, "cat"))); err == nil {
+		t.Error("NewGenerator accepted an empty dictionary")
+	}
+}
@@ -0,0 +1,55 @@
+package passgen
+
+import "fmt"
+
+// DualControlHalves splits a dual control password into the halves two
+// people enter one after the other: the first half, one character longer
+// for an odd length, and the rest.
+func DualControlHalves(password string) (first, second string) {
+	runes := []rune(password)
+	mid := (len(runes) + 1) / 2
+	return string(runes[:mid]), string(runes[mid:])
+}
+
+// generateDualControl draws each half of the length from the character
+// sets on its own, so each holds every required class and its minimums.
+func (o Options) generateDualControl() (string, error) {
+	charsets, minimums := o.requiredSets()
+	first, err := generateFromCharsets(o.random(), (o.Length+1)/2, charsets, minimums)
+	if err != nil {
+		return "", err
+	}
+	second, err := generateFromCharsets(o.random(), o.Length/2, charsets, minimums)
+	if err != nil {
+		return "", err
+	}
+	return first + second, nil
+}
+
+// dualControlKeyspace returns the entropy of a dual control password, the
+// keyspaces of its halves together. Each half repeating the minimums makes
+// it a little less than that of a password drawn whole.
+func (o Options) dualControlKeyspace() float64 {
+	charsets, minimums := o.requiredSets()
+	return KeyspaceEntropy((o.Length+1)/2, charsets, minimums) + KeyspaceEntropy(o.Length/2, charsets, minimums)
+}
+
+// WithDualControl generates passwords for dual control, whose halves, see
+// DualControlHalves, are handed to two people so neither knows the whole
+// password. Each half is drawn on its own and meets the character set
+// requirements, so the length must fit them twice.
+func WithDualControl() GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.DualControl = true
+		return nil
+	}
+}
+
+// checkDualControl reports a length that leaves a half too short for the
+// minimums of the sets.
+func (o Options) checkDualControl() error {
+	if need := o.MinLength(); o.Length/2 < need {
+		return fmt.Errorf("dual control needs a length of at least %d, for %d characters in each half", 2*need, need)
+	}
+	return nil
+}
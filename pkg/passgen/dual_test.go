@@ -0,0 +1,60 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestDualControlHalves tests splitting passwords of even and odd length
+func TestDualControlHalves(t *testing.T) {
+	tests := []struct {
+		password, first, second string
+	}{
+		{"abcdef", "abc", "def"},
+		{"abcdefg", "abcd", "efg"},
+		{"äöüß", "äö", "üß"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		first, second := DualControlHalves(tt.password)
+		if first != tt.first || second != tt.second {
+			t.Errorf("DualControlHalves(%q) = %q, %q, want %q, %q", tt.password, first, second, tt.first, tt.second)
+		}
+	}
+}
+
+// TestGenerateDualControl tests that each half holds every class
+func TestGenerateDualControl(t *testing.T) {
+	g, err := NewGenerator(WithLength(9), WithSpecial(true), WithDualControl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, second := DualControlHalves(password)
+		for _, half := range []string{first, second} {
+			for _, set := range []string{Uppercase, Lowercase, Numbers, Special} {
+				if !strings.ContainsAny(half, set) {
+					t.Fatalf("Half %q of %q lacks a character of %q", half, password, set)
+				}
+			}
+		}
+	}
+
+	keyspace, err := g.KeyspaceEntropy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	charsets := g.Options().Charsets()
+	if want := KeyspaceEntropy(5, charsets, nil) + KeyspaceEntropy(4, charsets, nil); math.Abs(keyspace-want) > 1e-9 {
+		t.Errorf("KeyspaceEntropy() = %.3f, want %.3f", keyspace, want)
+	}
+
+	if _, err := NewGenerator(WithLength(7), WithSpecial(true), WithDualControl()); err == nil {
+		t.Error("a half of 3 characters for 4 classes was accepted")
+	}
+}
@@ -0,0 +1,222 @@
+package passgen
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Encoding describes a destination format a secret is pasted into, such as
+// a YAML value or an environment file. A secret survives the encoding when
+// it can be inserted verbatim and still reads back as the same string.
+type Encoding struct {
+	// Name identifies the encoding, e.g. "yaml".
+	Name string
+	// Description says where the raw secret is assumed to be inserted.
+	Description string
+
+	check  func(password string) error
+	escape func(password string) (string, error)
+}
+
+// Check reports why the password would not survive being inserted verbatim,
+// or nil if it would.
+func (e *Encoding) Check(password string) error {
+	if err := e.check(password); err != nil {
+		return fmt.Errorf("not safe as %s: %v", e.Name, err)
+	}
+	return nil
+}
+
+// Escape returns the password in a form that survives the encoding, quoting
+// or escaping it as needed. Passwords that are already safe are returned
+// unchanged. It fails when the encoding has no way to represent the
+// password, in which case a new one has to be generated.
+func (e *Encoding) Escape(password string) (string, error) {
+	if e.check(password) == nil {
+		return password, nil
+	}
+	escaped, err := e.escape(password)
+	if err != nil {
+		return "", fmt.Errorf("cannot escape for %s: %v", e.Name, err)
+	}
+	return escaped, nil
+}
+
+var encodings = []*Encoding{
+	{
+		Name:        "yaml",
+		Description: "plain YAML scalar, key: VALUE",
+		check:       checkYAML,
+		escape:      func(p string) (string, error) { return quoteJSON(p), nil },
+	},
+	{
+		Name:        "env",
+		Description: "unquoted environment file value, KEY=VALUE",
+		check:       func(p string) error { return checkChars(p, " \t\r\n#\"'\\$`") },
+		escape:      quoteEnv,
+	},
+	{
+		Name:        "shell",
+		Description: "unquoted shell word",
+		check:       checkShell,
+		escape:      func(p string) (string, error) { return "'" + strings.ReplaceAll(p, "'", `'\''`) + "'", nil },
+	},
+	{
+		Name:        "json",
+		Description: "inside a JSON string literal",
+		check:       checkJSON,
+		escape:      func(p string) (string, error) { q := quoteJSON(p); return q[1 : len(q)-1], nil },
+	},
+	{
+		Name:        "url",
+		Description: "URL query value or userinfo",
+		check:       checkURL,
+		escape:      func(p string) (string, error) { return url.QueryEscape(p), nil },
+	},
+	{
+		Name:        "xml",
+		Description: "XML text or attribute value",
+		check:       func(p string) error { return checkChars(p, `<>&"'`) },
+		escape:      escapeXML,
+	},
+}
+
+// Encodings returns every known encoding.
+func Encodings() []*Encoding {
+	return append([]*Encoding(nil), encodings...)
+}
+
+// LookupEncoding returns the encoding with the given name.
+func LookupEncoding(name string) (*Encoding, error) {
+	for _, e := range encodings {
+		if EqualFoldASCII(e.Name, name) {
+			return e, nil
+		}
+	}
+	names := make([]string, len(encodings))
+	for i, e := range encodings {
+		names[i] = e.Name
+	}
+	return nil, fmt.Errorf("unknown encoding %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// WithEncoding only accepts passwords that survive every encoding verbatim,
+// regenerating the others, so they can be pasted without escaping.
+func WithEncoding(encs ...*Encoding) GeneratorOption {
+	return func(g *Generator) error {
+		for _, e := range encs {
+			g.pipeline.UsePostGenerate(func(password string) error {
+				if err := e.Check(password); err != nil {
+					return Reject("encoding "+e.Name, "%v", err)
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+}
+
+// checkChars rejects passwords containing any of the characters.
+func checkChars(password, unsafe string) error {
+	if i := strings.IndexAny(password, unsafe); i >= 0 {
+		return fmt.Errorf("contains %q", password[i:i+1])
+	}
+	return nil
+}
+
+// checkYAML rejects passwords a YAML parser would not read back as the same
+// plain string: those starting with an indicator character, containing a
+// mapping or comment marker, or resolving to a bool, null or number.
+func checkYAML(password string) error {
+	if password == "" {
+		return fmt.Errorf("is empty")
+	}
+	if strings.ContainsAny(password[:1], "-?:,[]{}#&*!|>'\"%@` \t") {
+		return fmt.Errorf("starts with %q", password[:1])
+	}
+	if err := checkChars(password, "\t\r\n"); err != nil {
+		return err
+	}
+	switch {
+	case strings.Contains(password, ": "), strings.HasSuffix(password, ":"):
+		return fmt.Errorf("contains a mapping indicator")
+	case strings.Contains(password, " #"):
+		return fmt.Errorf("contains a comment")
+	case strings.HasSuffix(password, " "):
+		return fmt.Errorf("ends with a space")
+	}
+	switch ToLowerASCII(password) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~", ".inf", "-.inf", ".nan":
+		return fmt.Errorf("reads as a bool or null")
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(password, "_", ""), 64); err == nil {
+		return fmt.Errorf("reads as a number")
+	}
+	if _, err := strconv.ParseInt(password, 0, 64); err == nil {
+		return fmt.Errorf("reads as a number")
+	}
+	return nil
+}
+
+// checkShell rejects passwords with characters the shell would interpret.
+func checkShell(password string) error {
+	for i := 0; i < len(password); i++ {
+		c := password[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_@%+=:,./-", c) >= 0 {
+			continue
+		}
+		return fmt.Errorf("contains %q", password[i:i+1])
+	}
+	return nil
+}
+
+// checkJSON rejects characters that must be escaped inside a JSON string.
+func checkJSON(password string) error {
+	for i := 0; i < len(password); i++ {
+		if c := password[i]; c == '"' || c == '\\' || c < 0x20 {
+			return fmt.Errorf("contains %q", password[i:i+1])
+		}
+	}
+	return nil
+}
+
+// checkURL rejects characters outside the unreserved set of RFC 3986.
+func checkURL(password string) error {
+	for i := 0; i < len(password); i++ {
+		if url.QueryEscape(password[i:i+1]) != password[i:i+1] {
+			return fmt.Errorf("contains %q", password[i:i+1])
+		}
+	}
+	return nil
+}
+
+// quoteJSON returns the password as a double-quoted JSON string, which is
+// also a valid YAML double-quoted scalar.
+func quoteJSON(password string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(password)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// quoteEnv single-quotes an environment file value. Single quotes cannot be
+// escaped inside single quotes in most dotenv dialects.
+func quoteEnv(password string) (string, error) {
+	if strings.ContainsAny(password, "'\r\n") {
+		return "", fmt.Errorf("contains a quote or line break")
+	}
+	return "'" + password + "'", nil
+}
+
+func escapeXML(password string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(password)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
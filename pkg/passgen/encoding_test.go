@@ -0,0 +1,111 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEncodingCheck tests which passwords survive each encoding verbatim
+func TestEncodingCheck(t *testing.T) {
+	tests := []struct {
+		encoding string
+		password string
+		safe     bool
+	}{
+		{"yaml", "aB3#x:y", true},
+		{"yaml", "-aB3", false},
+		{"yaml", "*alias", false},
+		{"yaml", "ab: c", false},
+		{"yaml", "abc:", false},
+		{"yaml", "yes", false},
+		{"yaml", "0x1F", false},
+		{"yaml", "1e10", false},
+		{"env", "aB3%^&*", true},
+		{"env", "aB3#x", false},
+		{"env", "a$HOME", false},
+		{"shell", "aB3_@%+=:,./-", true},
+		{"shell", "a;b", false},
+		{"shell", "a*b", false},
+		{"json", "a!@#$%^&*()", true},
+		{"json", `a"b`, false},
+		{"json", `a\b`, false},
+		{"url", "aB3-._~", true},
+		{"url", "a&b", false},
+		{"xml", "a!@#$", true},
+		{"xml", "a<b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding+" "+tt.password, func(t *testing.T) {
+			e, err := LookupEncoding(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Check(tt.password); (err == nil) != tt.safe {
+				t.Errorf("Check(%q) = %v, want safe %v", tt.password, err, tt.safe)
+			}
+		})
+	}
+}
+
+// TestEncodingEscape tests escaping and the cases that cannot be escaped
+func TestEncodingEscape(t *testing.T) {
+	tests := []struct {
+		encoding string
+		password string
+		want     string
+		wantErr  bool
+	}{
+		{"yaml", "plain", "plain", false},
+		{"yaml", `-a"b\`, `"-a\"b\\"`, false},
+		{"env", "a#b$c", "'a#b$c'", false},
+		{"env", "a'#", "", true},
+		{"shell", "it's;", `'it'\''s;'`, false},
+		{"json", `a"b<`, `a\"b<`, false},
+		{"url", "a&b=c", "a%26b%3Dc", false},
+		{"xml", "a<b&c", "a&lt;b&amp;c", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding+" "+tt.password, func(t *testing.T) {
+			e, err := LookupEncoding(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := e.Escape(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Escape(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Escape(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLookupEncoding tests case-insensitive lookup and the unknown-name error
+func TestLookupEncoding(t *testing.T) {
+	if e, err := LookupEncoding("YAML"); err != nil || e.Name != "yaml" {
+		t.Errorf("Expected yaml, got %v, %v", e, err)
+	}
+	if _, err := LookupEncoding("toml"); err == nil || !strings.Contains(err.Error(), "yaml, env") {
+		t.Errorf("Expected an error listing the encodings, got %v", err)
+	}
+}
+
+// TestWithEncoding tests that generated passwords survive the encodings
+func TestWithEncoding(t *testing.T) {
+	yaml, _ := LookupEncoding("yaml")
+	env, _ := LookupEncoding("env")
+	g, err := NewGenerator(WithSpecial(true), WithEncoding(yaml, env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if yaml.Check(password) != nil || env.Check(password) != nil {
+			t.Errorf("Password does not survive the encodings: %s", password)
+		}
+	}
+}
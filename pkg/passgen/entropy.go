@@ -0,0 +1,111 @@
+package passgen
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// EstimateEntropy estimates the strength of a password in bits, based on the
+// default character sets that actually appear in it and its length.
+func EstimateEntropy(password string) float64 {
+	return EstimateEntropyWith(password, []string{Uppercase, Lowercase, Numbers, Special})
+}
+
+// EstimateEntropyWith estimates the strength of a password in bits from the
+// combined size of the given character sets that appear in it. Pass the sets
+// the password was generated from, e.g. Options.Charsets, so the estimate
+// reflects the real pool.
+func EstimateEntropyWith(password string, charsets []string) float64 {
+	pool := 0
+	for _, charset := range charsets {
+		if strings.ContainsAny(password, charset) {
+			pool += utf8.RuneCountInString(charset)
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
+
+// LengthForEntropy returns the shortest length at which a password drawn
+// from the combined character sets reaches the given entropy in bits, by the
+// same measure as EstimateEntropyWith. It returns 0 when the sets hold fewer
+// than two characters, since no length then adds any entropy.
+func LengthForEntropy(bits float64, charsets []string) int {
+	pool := 0
+	for _, charset := range charsets {
+		pool += utf8.RuneCountInString(charset)
+	}
+	if pool < 2 {
+		return 0
+	}
+	if bits <= 0 {
+		return 1
+	}
+	// Rounding must not add a character when bits is an exact multiple
+	return int(math.Ceil(bits/math.Log2(float64(pool)) - 1e-9))
+}
+
+// KeyspaceEntropy returns the theoretical entropy in bits of passwords of
+// the given length that hold at least minimums[i] characters of
+// charsets[i]: log2 of how many such passwords there are. Unlike a plain
+// length × log2(pool), it accounts for the guaranteed characters, which
+// rule out for example all-lowercase passwords. A character in several sets
+// counts for the first only, and a nil minimums requires one of each.
+func KeyspaceEntropy(length int, charsets []string, minimums []int) float64 {
+	seen := make(map[rune]bool)
+	// ways[j] counts the strings filling j of the positions from the sets
+	// so far, including the choice of positions
+	ways := []*big.Int{big.NewInt(1)}
+	for i, charset := range charsets {
+		size := 0
+		for _, r := range charset {
+			if !seen[r] {
+				seen[r] = true
+				size++
+			}
+		}
+		least := 1
+		if minimums != nil {
+			least = minimums[i]
+		}
+		if size == 0 {
+			// Its characters are all in earlier sets, which then
+			// satisfy the minimum as well
+			continue
+		}
+		next := make([]*big.Int, length+1)
+		for j := range next {
+			next[j] = new(big.Int)
+		}
+		n := big.NewInt(int64(size))
+		for j, w := range ways {
+			if w.Sign() == 0 {
+				continue
+			}
+			power := new(big.Int).Exp(n, big.NewInt(int64(least)), nil)
+			for k := least; j+k <= length; k++ {
+				term := new(big.Int).Binomial(int64(j+k), int64(k))
+				term.Mul(term, power).Mul(term, w)
+				next[j+k].Add(next[j+k], term)
+				power.Mul(power, n)
+			}
+		}
+		ways = next
+	}
+	if length >= len(ways) || ways[length].Sign() == 0 {
+		return 0
+	}
+	return log2Big(ways[length])
+}
+
+// log2Big returns the base 2 logarithm of a positive integer too large
+// for a float64.
+func log2Big(x *big.Int) float64 {
+	shift := max(x.BitLen()-64, 0)
+	top, _ := new(big.Float).SetInt(new(big.Int).Rsh(x, uint(shift))).Float64()
+	return float64(shift) + math.Log2(top)
+}
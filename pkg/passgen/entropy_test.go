@@ -0,0 +1,113 @@
+package passgen
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEstimateEntropy tests entropy estimation from the character sets present
+func TestEstimateEntropy(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		pool     int
+	}{
+		{"Lowercase only", "abcdefgh", len(Lowercase)},
+		{"upper and lower", "ABCDefgh", len(Uppercase) + len(Lowercase)},
+		{"all sets", "AB2cd!", len(Uppercase) + len(Lowercase) + len(Numbers) + len(Special)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := float64(len(tt.password)) * math.Log2(float64(tt.pool))
+			if got := EstimateEntropy(tt.password); math.Abs(got-want) > 1e-9 {
+				t.Errorf("Expected %.2f bits, got %.2f", want, got)
+			}
+		})
+	}
+
+	if got := EstimateEntropy(""); got != 0 {
+		t.Errorf("Expected 0 bits for empty password, got %.2f", got)
+	}
+}
+
+// TestEstimateEntropyWith tests that the estimate follows the given sets
+func TestEstimateEntropyWith(t *testing.T) {
+	strict := Options{}.Charsets()
+	similar := Options{AllowSimilar: true}.Charsets()
+
+	password := "Abc23"
+	if EstimateEntropyWith(password, similar) <= EstimateEntropyWith(password, strict) {
+		t.Error("Larger character sets should give a higher estimate")
+	}
+	want := 5 * math.Log2(26+26+10)
+	if got := EstimateEntropyWith(password, similar); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected %.2f bits, got %.2f", want, got)
+	}
+
+	// Similar characters are only counted when their set is in use
+	if got := EstimateEntropyWith("O0l", strict); got != 0 {
+		t.Errorf("Expected 0 bits for characters outside the sets, got %.2f", got)
+	}
+}
+
+// TestLengthForEntropy tests the shortest length reaching an entropy target
+func TestLengthForEntropy(t *testing.T) {
+	tests := []struct {
+		name     string
+		bits     float64
+		charsets []string
+		want     int
+	}{
+		{"exact multiple", 64, []string{"0123456789abcdef"}, 16},
+		{"rounds up", 65, []string{"0123456789abcdef"}, 17},
+		{"sets combine", 80, []string{allUppercase, allLowercase, allNumbers}, 14},
+		{"no target", 0, []string{Lowercase}, 1},
+		{"single character", 80, []string{"x"}, 0},
+		{"no sets", 80, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LengthForEntropy(tt.bits, tt.charsets); got != tt.want {
+				t.Errorf("Expected length %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestKeyspaceEntropy tests counting the passwords that meet the minimums
+func TestKeyspaceEntropy(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		charsets []string
+		minimums []int
+		want     float64
+	}{
+		{"one of each", 2, []string{"ab", "cd"}, nil, 3},
+		{"excludes single-set passwords", 3, []string{"ab", "cd"}, nil, math.Log2(64 - 8 - 8)},
+		{"no minimums", 3, []string{"ab", "cd"}, []int{0, 0}, 6},
+		{"two of the first", 3, []string{"ab", "cd"}, []int{2, 1}, math.Log2(3 * 4 * 2)},
+		{"overlap counts once", 2, []string{"ab", "bc"}, nil, math.Log2(2 * 1 * 2)},
+		{"too short", 1, []string{"ab", "cd"}, nil, 0},
+		{"no sets", 8, nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeyspaceEntropy(tt.length, tt.charsets, tt.minimums); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %.4f bits, got %.4f", tt.want, got)
+			}
+		})
+	}
+
+	// Long passwords lose little to the guarantees, and the count must
+	// not overflow
+	charsets := []string{Uppercase, Lowercase, Numbers, Special}
+	plain := 128 * math.Log2(float64(len(Uppercase+Lowercase+Numbers+Special)))
+	got := KeyspaceEntropy(128, charsets, nil)
+	if got >= plain || plain-got > 0.01 {
+		t.Errorf("Expected just under %.2f bits for 128 characters, got %.2f", plain, got)
+	}
+}
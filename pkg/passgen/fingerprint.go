@@ -0,0 +1,37 @@
+package passgen
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+)
+
+// fingerprintEmoji are 64 easily told apart emoji, so each one encodes six
+// bits of a fingerprint.
+var fingerprintEmoji = []rune("🐶🐱🐭🐰🦊🐻🐼🐨🐯🦁🐮🐷🐸🐵🐔🐧" +
+	"🐦🦆🦉🐴🦄🐝🐛🦋🐌🐞🐢🐍🐙🦀🐬🐳" +
+	"🌵🌲🌻🌹🍄🌙⭐🔥🌈⛄💧🍎🍋🍌🍉🍇" +
+	"🍓🍒🍍🥕🌽🍕🍩🎂🎈🎁⚽🎸🚗🚀⚓🔑")
+
+// Fingerprint returns a short, non-reversible fingerprint of password: the
+// first 8 hex digits (32 bits) of its SHA-256 hash. Two parties can compare
+// fingerprints to confirm they hold the same credential without revealing
+// it. The hash is unsalted, so fingerprints of guessable passwords can be
+// looked up; only share them for generated ones.
+func Fingerprint(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:4])
+}
+
+// EmojiFingerprint is Fingerprint rendered as five emoji (30 bits), which
+// are easier to compare out loud or across a room.
+func EmojiFingerprint(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	n := binary.BigEndian.Uint32(sum[:4])
+	var b strings.Builder
+	for i := 0; i < 5; i++ {
+		b.WriteRune(fingerprintEmoji[n>>(26-6*i)&63])
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package passgen
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// TestFingerprint tests the hex fingerprint against a known SHA-256 hash
+func TestFingerprint(t *testing.T) {
+	// sha256("password") = 5e884898da28047151d0e56f8dc6292773603d0d...
+	if got := Fingerprint("password"); got != "5e884898" {
+		t.Errorf("Fingerprint = %q, want 5e884898", got)
+	}
+	if Fingerprint("password") == Fingerprint("Password") {
+		t.Error("Different passwords should have different fingerprints")
+	}
+}
+
+// TestEmojiFingerprint tests the length and stability of emoji fingerprints
+func TestEmojiFingerprint(t *testing.T) {
+	if len(fingerprintEmoji) != 64 {
+		t.Fatalf("Got %d fingerprint emoji, want 64", len(fingerprintEmoji))
+	}
+	seen := make(map[rune]bool)
+	for _, r := range fingerprintEmoji {
+		if seen[r] {
+			t.Errorf("Emoji %q appears twice", r)
+		}
+		seen[r] = true
+	}
+
+	got := EmojiFingerprint("password")
+	if n := utf8.RuneCountInString(got); n != 5 {
+		t.Errorf("EmojiFingerprint = %q, %d emoji, want 5", got, n)
+	}
+	if got != EmojiFingerprint("password") {
+		t.Error("EmojiFingerprint is not stable")
+	}
+	// 0x5e884898 starts with the six bits 010111
+	if first, _ := utf8.DecodeRuneInString(got); first != fingerprintEmoji[23] {
+		t.Errorf("EmojiFingerprint starts with %q, want %q", first, fingerprintEmoji[23])
+	}
+	if got == EmojiFingerprint("Password") {
+		t.Error("Different passwords should have different fingerprints")
+	}
+}
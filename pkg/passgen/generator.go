@@ -0,0 +1,349 @@
+package passgen
+
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultLength is the password length used when WithLength is not given.
+const DefaultLength = 12
+
+// Generator produces passwords with a fixed configuration. It bundles the
+// generation Options with a Pipeline of hooks so programs can embed
+// generation without assembling those themselves:
+//
+//	g, err := passgen.NewGenerator(passgen.WithLength(16), passgen.WithSpecial(true))
+//	if err != nil {
+//		return err
+//	}
+//	password, err := g.Generate()
+type Generator struct {
+	opts     Options
+	pipeline *Pipeline
+}
+
+// GeneratorOption configures a Generator.
+type GeneratorOption func(g *Generator) error
+
+// WithLength sets the password length.
+func WithLength(length int) GeneratorOption {
+	return func(g *Generator) error {
+		if length < 1 {
+			return fmt.Errorf("password length must be at least 1")
+		}
+		g.opts.Length = length
+		return nil
+	}
+}
+
+// WithSpecial controls whether passwords include special characters.
+func WithSpecial(include bool) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.IncludeSpecial = include
+		return nil
+	}
+}
+
+// WithSimilar controls whether the similar-looking characters
+// (0, O, I, l, 1) may appear.
+func WithSimilar(allow bool) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.AllowSimilar = allow
+		return nil
+	}
+}
+
+// WithCharset adds a character set every password must draw at least one
+// character from.
+func WithCharset(charset string) GeneratorOption {
+	return func(g *Generator) error {
+		if charset == "" {
+			return fmt.Errorf("character sets must not be empty")
+		}
+		g.opts.ExtraCharsets = append(g.opts.ExtraCharsets, charset)
+		return nil
+	}
+}
+
+// WithExclude keeps the characters in chars out of every password.
+func WithExclude(chars string) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.Exclude += chars
+		return nil
+	}
+}
+
+// WithRandom draws passwords with randomness from r instead of crypto/rand.
+// r must be a cryptographically secure stream, such as a DRBG seeded from
+// an independent entropy source; it is only used for character sets and
+// patterns.
+func WithRandom(r io.Reader) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.Random = r
+		return nil
+	}
+}
+
+// WithMinCount requires at least n characters of the class in every
+// password, e.g. WithMinCount(ClassDigit, 2). The special class needs
+// WithSpecial(true).
+func WithMinCount(class CharClass, n int) GeneratorOption {
+	return func(g *Generator) error {
+		if class < ClassUpper || class > ClassSpecial {
+			return fmt.Errorf("unknown character class %d", int(class))
+		}
+		if n < 0 {
+			return fmt.Errorf("minimum %s characters cannot be negative", class)
+		}
+		g.opts.MinCounts[class] = n
+		return nil
+	}
+}
+
+// WithoutClass removes a whole character class from passwords, e.g.
+// WithoutClass(ClassUpper) for lowercase-only systems. Characters of the
+// class are also removed from WithCharset sets.
+func WithoutClass(class CharClass) GeneratorOption {
+	return func(g *Generator) error {
+		if class < ClassUpper || class > ClassSpecial {
+			return fmt.Errorf("unknown character class %d", int(class))
+		}
+		g.opts.Disabled[class] = true
+		return nil
+	}
+}
+
+// WithMarkov samples word-like passwords from the model instead of drawing
+// from the character sets.
+func WithMarkov(model *MarkovModel) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.Model = model
+		return nil
+	}
+}
+
+// WithRules only accepts passwords that satisfy every rule.
+func WithRules(rules ...*Rule) GeneratorOption {
+	return func(g *Generator) error {
+		for _, rule := range rules {
+			g.pipeline.UsePostGenerate(rule.Check)
+		}
+		return nil
+	}
+}
+
+// WithMaxAttempts bounds how many rejected candidates are tolerated per
+// password. The default is DefaultMaxAttempts.
+func WithMaxAttempts(n int) GeneratorOption {
+	return func(g *Generator) error {
+		if n < 1 {
+			return fmt.Errorf("max attempts must be at least 1")
+		}
+		g.pipeline.MaxAttempts = n
+		return nil
+	}
+}
+
+// WithPreValidate adds hooks that adjust the options before each password.
+func WithPreValidate(hooks ...PreValidateFunc) GeneratorOption {
+	return func(g *Generator) error {
+		g.pipeline.UsePreValidate(hooks...)
+		return nil
+	}
+}
+
+// WithPostGenerate adds hooks that may reject candidate passwords.
+func WithPostGenerate(hooks ...PostGenerateFunc) GeneratorOption {
+	return func(g *Generator) error {
+		g.pipeline.UsePostGenerate(hooks...)
+		return nil
+	}
+}
+
+// WithPreOutput adds hooks that transform accepted passwords before they
+// are returned.
+func WithPreOutput(hooks ...PreOutputFunc) GeneratorOption {
+	return func(g *Generator) error {
+		g.pipeline.UsePreOutput(hooks...)
+		return nil
+	}
+}
+
+// NewGenerator returns a Generator configured by the options. Without
+// options it produces DefaultLength character passwords of letters and
+// numbers, like the command-line tool.
+func NewGenerator(options ...GeneratorOption) (*Generator, error) {
+	g := &Generator{opts: Options{Length: DefaultLength}, pipeline: &Pipeline{}}
+	for _, option := range options {
+		if err := option(g); err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.Random != nil && (g.opts.Model != nil || g.opts.Pronounceable || g.opts.AppleStyle) {
+		return nil, fmt.Errorf("a custom source of randomness only works with character sets and patterns")
+	}
+	switch {
+	case g.opts.Pattern != nil:
+		// Catch positions left empty by exclusions up front
+		if _, err := g.opts.Pattern.charsets(g.opts); err != nil {
+			return nil, err
+		}
+	case g.opts.Model == nil && !g.opts.Pronounceable && !g.opts.AppleStyle:
+		if err := g.opts.checkMinimums(); err != nil {
+			return nil, err
+		}
+		if len(g.opts.AnchorWords) > 0 {
+			if words, _, _ := g.opts.anchorWords(); len(words) == 0 {
+				return nil, fmt.Errorf("no anchor word fits in %d characters with the required characters and without excluded ones", g.opts.Length)
+			}
+		} else if g.opts.DualControl {
+			if err := g.opts.checkDualControl(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// Options returns the generation options.
+func (g *Generator) Options() Options {
+	return g.opts
+}
+
+// Pipeline returns the generator's hook pipeline, so hooks can be added
+// after construction.
+func (g *Generator) Pipeline() *Pipeline {
+	return g.pipeline
+}
+
+// Generate returns a new password that passed every hook.
+func (g *Generator) Generate() (string, error) {
+	password, err := g.pipeline.Generate(g.opts)
+	if err != nil {
+		return "", err
+	}
+	return g.pipeline.Output(password)
+}
+
+// GenerateN returns n new passwords.
+func (g *Generator) GenerateN(n int) ([]string, error) {
+	passwords := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			return nil, err
+		}
+		passwords = append(passwords, password)
+	}
+	return passwords, nil
+}
+
+// Stream generates n passwords and hands each to yield as soon as it is
+// accepted, so unlike GenerateN its memory use does not grow with n.
+//
+// Stream applies backpressure: it generates the next password only after
+// yield returns, so a consumer writing to a slow sink slows generation to
+// its pace instead of letting passwords queue up. Nothing is buffered or
+// generated ahead. An error from yield stops the stream and is returned
+// unchanged, as is an error generating a password, which is never yielded.
+func (g *Generator) Stream(n int, yield func(password string) error) error {
+	for i := 0; i < n; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			return err
+		}
+		if err := yield(password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entropy estimates the strength in bits of a password from this
+// generator: its information content under the Markov model when one is
+// configured, the keyspace of a pattern, of Apple-style or of pronounceable
+// passwords, the word, position and surrounding characters of an anchored
+// password, otherwise the size of the character sets it draws from.
+func (g *Generator) Entropy(password string) (float64, error) {
+	if g.opts.Model != nil {
+		return g.opts.Model.Entropy(password)
+	}
+	if g.opts.Pattern != nil {
+		return g.opts.Pattern.entropy(g.opts)
+	}
+	if g.opts.AppleStyle {
+		return AppleStyleEntropy(), nil
+	}
+	if g.opts.Pronounceable {
+		return PronounceableEntropy(len([]rune(password))), nil
+	}
+	if len(g.opts.AnchorWords) > 0 {
+		return g.opts.anchoredEntropy(password), nil
+	}
+	return EstimateEntropyWith(password, g.opts.Charsets()), nil
+}
+
+// KeyspaceEntropy returns the theoretical entropy in bits of every password
+// from this generator: the keyspace of a pattern, of Apple-style or of
+// pronounceable passwords, that of the weakest anchored password, with the
+// longest word, that of both halves of a dual control password, otherwise
+// that of the character sets at the configured length with their minimums,
+// see KeyspaceEntropy. Rules and filters that reject candidates are not
+// accounted for. Passwords from a
+// Markov model differ in strength, so it is an error to ask for theirs.
+func (g *Generator) KeyspaceEntropy() (float64, error) {
+	switch {
+	case g.opts.Model != nil:
+		return 0, fmt.Errorf("passwords from a Markov model have no fixed keyspace")
+	case g.opts.Pattern != nil:
+		return g.opts.Pattern.entropy(g.opts)
+	case g.opts.AppleStyle:
+		return AppleStyleEntropy(), nil
+	case g.opts.Pronounceable:
+		return PronounceableEntropy(g.opts.Length), nil
+	case len(g.opts.AnchorWords) > 0:
+		return g.opts.anchoredKeyspace(), nil
+	case g.opts.DualControl:
+		return g.opts.dualControlKeyspace(), nil
+	}
+	charsets, minimums := g.opts.requiredSets()
+	return KeyspaceEntropy(g.opts.Length, charsets, minimums), nil
+}
+
+// checkMinimums reports character set options that can never be satisfied:
+// no characters left to draw from, a class minimum without characters of
+// that class, or minimums that add up to more than the length.
+func (o Options) checkMinimums() error {
+	for class, n := range o.MinCounts {
+		if n > 0 && o.Disabled[class] {
+			return fmt.Errorf("a minimum of %s characters contradicts disabling them", CharClass(class))
+		}
+	}
+	charsets, minimums := o.requiredSets()
+	if len(charsets) == 0 {
+		if o.Disabled[ClassUpper] && o.Disabled[ClassLower] && o.Disabled[ClassDigit] && (!o.IncludeSpecial || o.Disabled[ClassSpecial]) {
+			return fmt.Errorf("every character class is disabled")
+		}
+		return fmt.Errorf("every character is excluded")
+	}
+	if o.MinCounts[ClassSpecial] > 0 && !o.IncludeSpecial {
+		return fmt.Errorf("a minimum of special characters requires special characters")
+	}
+
+	var placed [ClassSpecial + 1]int
+	required := 0
+	for i, charset := range charsets {
+		class := ClassOf([]rune(charset)[0])
+		placed[class] += minimums[i]
+		required += minimums[i]
+	}
+	for class, n := range o.MinCounts {
+		if placed[class] < n {
+			return fmt.Errorf("no %s characters are left for the minimum of %d", CharClass(class), n)
+		}
+	}
+	if o.Length < required {
+		return fmt.Errorf("password length must be at least %d", required)
+	}
+	return nil
+}
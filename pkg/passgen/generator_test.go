@@ -0,0 +1,339 @@
+package passgen
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestNewGeneratorDefaults tests that the defaults match the command-line tool
+func TestNewGeneratorDefaults(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	password, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(password) != DefaultLength {
+		t.Errorf("Expected length %d, got %d", DefaultLength, len(password))
+	}
+	if strings.ContainsAny(password, Special+"0OIl1") {
+		t.Errorf("Default password should only use unambiguous letters and numbers: %s", password)
+	}
+}
+
+// TestGeneratorOptions tests that functional options configure generation
+func TestGeneratorOptions(t *testing.T) {
+	rule, err := CompileRule("special >= 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGenerator(
+		WithLength(20),
+		WithSpecial(true),
+		WithSimilar(true),
+		WithCharset("~"),
+		WithRules(rule),
+		WithPreOutput(func(p string) (string, error) { return "<" + p + ">", nil }),
+	)
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+
+	opts := g.Options()
+	if opts.Length != 20 || !opts.IncludeSpecial || !opts.AllowSimilar || len(opts.ExtraCharsets) != 1 {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	passwords, err := g.GenerateN(5)
+	if err != nil {
+		t.Fatalf("GenerateN failed: %v", err)
+	}
+	for _, p := range passwords {
+		inner := strings.TrimSuffix(strings.TrimPrefix(p, "<"), ">")
+		if len(inner) != 20 || inner == p {
+			t.Errorf("Expected a decorated 20-character password, got %q", p)
+		}
+		if !strings.Contains(inner, "~") {
+			t.Errorf("Password should contain the extra charset: %s", p)
+		}
+	}
+}
+
+// TestGeneratorStream tests yielding passwords one at a time and stopping early
+func TestGeneratorStream(t *testing.T) {
+	g, err := NewGenerator(WithLength(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if err := g.Stream(50, func(password string) error {
+		if len(password) != 10 {
+			t.Errorf("Password %q has length %d", password, len(password))
+		}
+		got = append(got, password)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 50 {
+		t.Errorf("Stream yielded %d passwords, want 50", len(got))
+	}
+
+	stop := errors.New("stop")
+	yielded := 0
+	err = g.Stream(50, func(string) error {
+		yielded++
+		if yielded == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || yielded != 3 {
+		t.Errorf("Stream returned %v after %d passwords, want stop after 3", err, yielded)
+	}
+
+	g, err = NewGenerator(WithLength(4), WithMinScore(MaxScore), WithMaxAttempts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Stream(5, func(string) error {
+		t.Error("Rejected password was yielded")
+		return nil
+	}); err == nil {
+		t.Error("Expected the generator error")
+	}
+}
+
+// TestNewGeneratorErrors tests that invalid configurations are rejected
+func TestNewGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []GeneratorOption
+	}{
+		{"zero length", []GeneratorOption{WithLength(0)}},
+		{"too short for charsets", []GeneratorOption{WithLength(3), WithSpecial(true)}},
+		{"empty charset", []GeneratorOption{WithCharset("")}},
+		{"zero attempts", []GeneratorOption{WithMaxAttempts(0)}},
+		{"everything excluded", []GeneratorOption{WithCharset("xy"), WithExclude(Uppercase + Lowercase + Numbers + "xy")}},
+		{"minimums exceed length", []GeneratorOption{WithLength(8), WithSpecial(true), WithMinCount(ClassDigit, 3), WithMinCount(ClassSpecial, 4)}},
+		{"negative minimum", []GeneratorOption{WithMinCount(ClassUpper, -1)}},
+		{"unknown class", []GeneratorOption{WithMinCount(CharClass(9), 1)}},
+		{"special minimum without special", []GeneratorOption{WithMinCount(ClassSpecial, 2)}},
+		{"every class disabled", []GeneratorOption{WithoutClass(ClassUpper), WithoutClass(ClassLower), WithoutClass(ClassDigit)}},
+		{"minimum of disabled class", []GeneratorOption{WithoutClass(ClassDigit), WithMinCount(ClassDigit, 2)}},
+		{"disable unknown class", []GeneratorOption{WithoutClass(CharClass(-1))}},
+		{"minimum of excluded class", []GeneratorOption{WithExclude(Numbers), WithMinCount(ClassDigit, 2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewGenerator(tt.options...); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+// TestWithExclude tests that excluded characters never appear and emptied classes are dropped
+func TestWithExclude(t *testing.T) {
+	g, err := NewGenerator(WithLength(30), WithSpecial(true), WithExclude(`&'\`+Numbers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(g.Options().Charsets()); got != 3 {
+		t.Errorf("Expected the emptied digit class to be dropped, got %d charsets", got)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.ContainsAny(password, `&'\`+Numbers) {
+			t.Errorf("Password contains an excluded character: %s", password)
+		}
+	}
+
+	model, err := TrainMarkov([]string{"abab", "abba", "baba"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = NewGenerator(WithLength(4), WithMarkov(model), WithExclude("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Generate(); err != nil {
+		t.Errorf("Markov generation with an unused exclusion failed: %v", err)
+	}
+}
+
+// TestWithMinCount tests that every password has the minimum of each class
+func TestWithMinCount(t *testing.T) {
+	g, err := NewGenerator(WithLength(8), WithSpecial(true), WithMinCount(ClassDigit, 2), WithMinCount(ClassSpecial, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var counts [ClassSpecial + 1]int
+		for _, r := range password {
+			counts[ClassOf(r)]++
+		}
+		if counts[ClassDigit] < 2 || counts[ClassSpecial] < 3 || counts[ClassUpper] < 1 || counts[ClassLower] < 1 {
+			t.Errorf("Password %s has class counts %v", password, counts)
+		}
+	}
+
+	// Exactly the minimums leaves no room for anything else
+	g, err = NewGenerator(WithLength(6), WithMinCount(ClassUpper, 2), WithMinCount(ClassLower, 2), WithMinCount(ClassDigit, 2))
+	if err != nil {
+		t.Fatalf("Minimums equal to the length should be accepted: %v", err)
+	}
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWithoutClass tests that disabled classes are removed and no longer required
+func TestWithoutClass(t *testing.T) {
+	g, err := NewGenerator(WithLength(6), WithoutClass(ClassUpper), WithoutClass(ClassLower), WithCharset("A1b!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Options().Charsets(); len(got) != 2 || got[0] != Numbers || got[1] != "1!" {
+		t.Errorf("Charsets = %q, want the digits and the digits and symbols of the extra set", got)
+	}
+	password, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(password, Uppercase+Lowercase+"Ab") {
+		t.Errorf("Password contains a disabled class: %s", password)
+	}
+
+	if _, err := NewGenerator(WithoutClass(ClassUpper), WithoutClass(ClassLower), WithoutClass(ClassDigit)); err == nil ||
+		!strings.Contains(err.Error(), "every character class is disabled") {
+		t.Errorf("Expected every class to be reported disabled, got %v", err)
+	}
+}
+
+// TestGeneratorEntropy tests entropy for charset and Markov generators
+func TestGeneratorEntropy(t *testing.T) {
+	g, err := NewGenerator(WithLength(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bits, err := g.Entropy("abcdefghABCDEF23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := EstimateEntropyWith("abcdefghABCDEF23", g.Options().Charsets()); bits != want {
+		t.Errorf("Entropy = %f, want %f", bits, want)
+	}
+
+	model, err := TrainMarkov([]string{"aaaa"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = NewGenerator(WithLength(4), WithMarkov(model))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bits, err := g.Entropy("aaaa"); err != nil || bits != 0 {
+		t.Errorf("Entropy of the only possible output = %f, %v; want 0", bits, err)
+	}
+}
+
+// TestGeneratorKeyspaceEntropy tests the theoretical entropy of each mode
+func TestGeneratorKeyspaceEntropy(t *testing.T) {
+	pattern, err := CompilePattern("dddd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digits := len(Numbers)
+	tests := []struct {
+		name string
+		opts []GeneratorOption
+		want float64
+	}{
+		{"digits only", []GeneratorOption{WithLength(6), WithoutClass(ClassUpper), WithoutClass(ClassLower)}, 6 * math.Log2(float64(digits))},
+		{"pattern", []GeneratorOption{WithPattern(pattern)}, 4 * math.Log2(float64(digits))},
+		{"pronounceable", []GeneratorOption{WithLength(10), WithPronounceable()}, PronounceableEntropy(10)},
+		{"apple style", []GeneratorOption{WithAppleStyle()}, AppleStyleEntropy()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGenerator(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := g.KeyspaceEntropy()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("KeyspaceEntropy = %f, want %f", got, tt.want)
+			}
+		})
+	}
+
+	// The guaranteed classes make the keyspace smaller than the pool suggests
+	g, err := NewGenerator(WithLength(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.KeyspaceEntropy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain := EstimateEntropyWith("aB3aB3aB", g.Options().Charsets()); got >= plain {
+		t.Errorf("KeyspaceEntropy = %f, want less than %f", got, plain)
+	}
+
+	model, err := TrainMarkov([]string{"abcd"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, err = NewGenerator(WithMarkov(model)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.KeyspaceEntropy(); err == nil {
+		t.Error("Expected an error for a Markov model")
+	}
+}
+
+// TestWithRandom tests drawing passwords from a given source of randomness
+func TestWithRandom(t *testing.T) {
+	generate := func(options ...GeneratorOption) string {
+		var e DiceEntropy
+		e.Add("1 2 3 4 5 6 H T")
+		g, err := NewGenerator(append(options, WithRandom(e.Reader()))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return password
+	}
+	if a, b := generate(WithLength(20), WithSpecial(true)), generate(WithLength(20), WithSpecial(true)); a != b {
+		t.Errorf("The same stream gave %q and %q", a, b)
+	}
+	pattern, err := CompilePattern("u{4}d{4}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := generate(WithPattern(pattern)), generate(WithPattern(pattern)); a != b {
+		t.Errorf("The same stream gave patterns %q and %q", a, b)
+	}
+	if _, err := NewGenerator(WithPronounceable(), WithRandom(strings.NewReader(""))); err == nil {
+		t.Error("Expected an error for WithRandom with WithPronounceable")
+	}
+}
@@ -0,0 +1,58 @@
+package passgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Group splits password into groups of size characters joined by sep, such
+// as "k7Qm-x2Pf-9wRt", so it can be read out or typed in chunks. The last
+// group may be shorter. A size below 1 returns the password unchanged.
+func Group(password string, size int, sep string) string {
+	runes := []rune(password)
+	if size < 1 || len(runes) <= size {
+		return password
+	}
+	var b strings.Builder
+	for i := 0; i < len(runes); i += size {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(string(runes[i:min(i+size, len(runes))]))
+	}
+	return b.String()
+}
+
+// GroupedLength returns the length in characters of a password of length
+// characters after Group.
+func GroupedLength(length, size int, sep string) int {
+	if size < 1 || length <= size {
+		return length
+	}
+	groups := (length + size - 1) / size
+	return length + (groups-1)*utf8.RuneCountInString(sep)
+}
+
+// UngroupedLength returns how many characters to generate so that, grouped,
+// the password is exactly total characters long including separators. It
+// fails when no length gives exactly total, naming the nearest that do.
+func UngroupedLength(total, size int, sep string) (int, error) {
+	below, above := 0, 0
+	for n := 1; ; n++ {
+		grouped := GroupedLength(n, size, sep)
+		if grouped == total {
+			return n, nil
+		}
+		if grouped < total {
+			below = grouped
+			continue
+		}
+		above = grouped
+		break
+	}
+	if below == 0 {
+		return 0, fmt.Errorf("length %d is too short for groups of %d", total, size)
+	}
+	return 0, fmt.Errorf("a length of %d cannot be split into groups of %d separated by %q; use %d or %d", total, size, sep, below, above)
+}
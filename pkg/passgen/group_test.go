@@ -0,0 +1,60 @@
+package passgen
+
+import "testing"
+
+// TestGroup tests splitting passwords into groups
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		password string
+		size     int
+		sep      string
+		want     string
+	}{
+		{"abcdefghijkl", 4, "-", "abcd-efgh-ijkl"},
+		{"abcdefghij", 4, "-", "abcd-efgh-ij"},
+		{"abcdef", 3, " ", "abc def"},
+		{"abcdef", 2, "--", "ab--cd--ef"},
+		{"äöüß", 2, "·", "äö·üß"},
+		{"abcd", 4, "-", "abcd"},
+		{"abcd", 0, "-", "abcd"},
+	}
+	for _, tt := range tests {
+		got := Group(tt.password, tt.size, tt.sep)
+		if got != tt.want {
+			t.Errorf("Group(%q, %d, %q) = %q, want %q", tt.password, tt.size, tt.sep, got, tt.want)
+		}
+		if n := GroupedLength(len([]rune(tt.password)), tt.size, tt.sep); n != len([]rune(got)) {
+			t.Errorf("GroupedLength = %d, want %d", n, len([]rune(got)))
+		}
+	}
+}
+
+// TestUngroupedLength tests fitting grouped passwords into a total length
+func TestUngroupedLength(t *testing.T) {
+	tests := []struct {
+		total, size int
+		sep         string
+		want        int
+		wantErr     bool
+	}{
+		{14, 4, "-", 12, false},
+		{11, 4, "-", 9, false},
+		{9, 4, "-", 8, false},
+		{3, 4, "-", 3, false},
+		{10, 4, "-", 0, true},
+		{10, 3, "--", 0, true},
+		{12, 3, "--", 8, false},
+	}
+	for _, tt := range tests {
+		got, err := UngroupedLength(tt.total, tt.size, tt.sep)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("UngroupedLength(%d, %d, %q) = %d, want error", tt.total, tt.size, tt.sep, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("UngroupedLength(%d, %d, %q) = %d, %v, want %d", tt.total, tt.size, tt.sep, got, err, tt.want)
+		}
+	}
+}
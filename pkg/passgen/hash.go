@@ -0,0 +1,148 @@
+package passgen
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Names of the password hash formats.
+const (
+	// HashArgon2id is the PHC string of Argon2id, $argon2id$v=19$..., as
+	// written by the argon2 CLI, libsodium and PHP's password_hash.
+	HashArgon2id = "argon2id"
+	// HashBcrypt is the $2a$ crypt(3) hash of bcrypt.
+	HashBcrypt = "bcrypt"
+	// HashDjango is the pbkdf2_sha256 format of Django's default hasher.
+	HashDjango = "django"
+	// HashASPNet is the version 3 format of ASP.NET Core Identity's
+	// PasswordHasher: PBKDF2 with HMAC-SHA512.
+	HashASPNet = "aspnet"
+)
+
+// The crypt(3) formats of /etc/shadow, yescrypt and SHA-crypt, are left out:
+// golang.org/x/crypto does not implement them, and passgen does not ship
+// password hashing of its own.
+var hashFormats = []string{HashArgon2id, HashBcrypt, HashDjango, HashASPNet}
+
+// PBKDF2 iterations of the framework formats, their defaults as of Django
+// 5.2 and .NET 7.
+const (
+	DefaultDjangoIterations = 1_000_000
+	DefaultASPNetIterations = 100_000
+)
+
+// HashFormats returns the names of the password hash formats.
+func HashFormats() []string {
+	return append([]string(nil), hashFormats...)
+}
+
+// Hasher hashes passwords for a user table or /etc/shadow in one of the
+// hash formats, with a fresh random salt for every hash. The costs are
+// those the frameworks default to; Argon2 can be tuned.
+type Hasher struct {
+	format string
+	// Argon2 are the parameters of HashArgon2id, DefaultArgon2Params
+	// unless changed.
+	Argon2 Argon2Params
+}
+
+// NewHasher returns a Hasher for one of HashFormats.
+func NewHasher(format string) (*Hasher, error) {
+	format = ToLowerASCII(format)
+	for _, f := range hashFormats {
+		if f == format {
+			return &Hasher{format: format, Argon2: DefaultArgon2Params}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown hash format %q (want one of %s)", format, strings.Join(hashFormats, ", "))
+}
+
+// Format returns the name of the hash format.
+func (h *Hasher) Format() string {
+	return h.format
+}
+
+// Hash hashes a password.
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, 16)
+	if h.format == HashDjango {
+		// Django's salts are 22 characters, each holding 6 bits here
+		salt = make([]byte, 22)
+	}
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return h.hash(password, salt)
+}
+
+// hash hashes a password with random bytes of salt.
+func (h *Hasher) hash(password string, salt []byte) (string, error) {
+	switch h.format {
+	case HashArgon2id:
+		key, err := Argon2id([]byte(password), salt, h.Argon2, 32)
+		if err != nil {
+			return "", err
+		}
+		enc := base64.RawStdEncoding
+		return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, h.Argon2, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+	case HashBcrypt:
+		// x/crypto/bcrypt draws a salt of its own
+		return bcryptHash(password, DefaultBcryptCost)
+	case HashDjango:
+		return djangoHash(password, cryptSalt(salt), DefaultDjangoIterations)
+	case HashASPNet:
+		return aspnetHash(password, salt, DefaultASPNetIterations)
+	}
+	return "", fmt.Errorf("unknown hash format %q", h.format)
+}
+
+// cryptAlphabet is the base64 alphabet of crypt(3) salts.
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// cryptSalt maps random bytes onto the characters of a crypt(3) salt, 6
+// bits each, which Django accepts.
+func cryptSalt(salt []byte) string {
+	out := make([]byte, len(salt))
+	for i, b := range salt {
+		out[i] = cryptAlphabet[b&0x3f]
+	}
+	return string(out)
+}
+
+// djangoHash returns the hash of Django's PBKDF2PasswordHasher.
+func djangoHash(password, salt string, iterations int) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, password, []byte(salt), iterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2_sha256$%d$%s$%s", iterations, salt, base64.StdEncoding.EncodeToString(key)), nil
+}
+
+// aspnetHash returns the version 3 hash of ASP.NET Core Identity: a format
+// marker, the PRF, the iterations and the salt length in big-endian order,
+// then the salt and a 32-byte key, all in base64.
+func aspnetHash(password string, salt []byte, iterations int) (string, error) {
+	const (
+		formatV3   = 0x01
+		hmacSHA512 = 2
+	)
+	key, err := pbkdf2.Key(sha512.New, password, salt, iterations, 32)
+	if err != nil {
+		return "", err
+	}
+	out := []byte{formatV3}
+	out = binary.BigEndian.AppendUint32(out, hmacSHA512)
+	out = binary.BigEndian.AppendUint32(out, uint32(iterations))
+	out = binary.BigEndian.AppendUint32(out, uint32(len(salt)))
+	out = append(out, salt...)
+	out = append(out, key...)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
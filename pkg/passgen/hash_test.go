@@ -0,0 +1,76 @@
+package passgen
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestHasherVectors tests each format with a fixed salt against hashes from
+// x/crypto/argon2 and Python's hashlib
+func TestHasherVectors(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	tests := []struct {
+		format string
+		hash   func(h *Hasher) (string, error)
+		want   string
+	}{
+		{HashArgon2id, func(h *Hasher) (string, error) {
+			h.Argon2 = Argon2Params{Memory: 64, Time: 1, Threads: 1}
+			return h.hash("password", salt)
+		}, "$argon2id$v=19$m=64,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$c8unoZL1fMiTW9VzsIzI4XiLIjeYmhsk2dCIUg21RpY"},
+		{HashDjango, func(*Hasher) (string, error) {
+			return djangoHash("pw", "saltsalt", 1000)
+		}, "pbkdf2_sha256$1000$saltsalt$A+Pkljr7A4W/zbzUWSStAfjTCX2GWVTHwva2WigH9ow="},
+		{HashASPNet, func(*Hasher) (string, error) {
+			return aspnetHash("pw", salt, 1000)
+		}, "AQAAAAIAAAPoAAAAEDAxMjM0NTY3ODlhYmNkZWZuToDMM0+5/Ctob9WOYCX7rjYni6DRnX5o2uUrNfxofA=="},
+	}
+	for _, tt := range tests {
+		h, err := NewHasher(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tt.hash(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s hash = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+}
+
+// TestHasher tests the layout of every format and that each hash is salted
+func TestHasher(t *testing.T) {
+	patterns := map[string]string{
+		HashArgon2id: `^\$argon2id\$v=19\$m=65536,t=3,p=4\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`,
+		HashBcrypt:   `^\$2a\$12\$[./A-Za-z0-9]{53}$`,
+		HashDjango:   `^pbkdf2_sha256\$1000000\$[./A-Za-z0-9]{22}\$[A-Za-z0-9+/]{43}=$`,
+		HashASPNet:   `^AQAAAAIAAYagAAAAE[A-Za-z0-9+/]{65}==$`,
+	}
+	for _, format := range HashFormats() {
+		t.Run(format, func(t *testing.T) {
+			h, err := NewHasher(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := h.Hash("Xq7-horse2k")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !regexp.MustCompile(patterns[format]).MatchString(a) {
+				t.Errorf("Hash = %s, want %s", a, patterns[format])
+			}
+			if b, _ := h.Hash("Xq7-horse2k"); a == b {
+				t.Errorf("Two hashes of one password are both %s", a)
+			}
+		})
+	}
+
+	if h, err := NewHasher("BCrypt"); err != nil || h.Format() != HashBcrypt {
+		t.Errorf("NewHasher(BCrypt) = %v, %v", h, err)
+	}
+	if _, err := NewHasher("yescrypt"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
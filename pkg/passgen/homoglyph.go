@@ -0,0 +1,78 @@
+package passgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Confusable is a character, or a run of characters, in a password that a
+// reader can easily mistake for something else.
+type Confusable struct {
+	// Text is the confusable part of the password.
+	Text string
+	// LooksLike is what Text may be read as.
+	LooksLike string
+	// Offset is the position of Text in the password, in characters.
+	Offset int
+}
+
+func (c Confusable) String() string {
+	return fmt.Sprintf("%s (%s)", c.Text, c.LooksLike)
+}
+
+// confusableSequences are runs of characters that merge into a different
+// character in many fonts, especially at small sizes.
+var confusableSequences = []struct{ text, looksLike string }{
+	{"rn", "m"},
+	{"vv", "w"},
+	{"VV", "W"},
+	{"cl", "d"},
+	{"cI", "d"},
+}
+
+// confusableChars maps single characters to the ones they are mistaken
+// for. They include everything AmbiguityExtended excludes.
+var confusableChars = map[rune]string{
+	'0': "O", 'O': "0",
+	'1': "l", 'l': "1", 'I': "l", '|': "l",
+	'5': "S", 'S': "5",
+	'8': "B", 'B': "8",
+	'2': "Z", 'Z': "2",
+}
+
+// FindConfusables returns every confusable sequence and single character
+// in password, in order of position. Sequences such as "rn", which reads as
+// "m", are not caught by excluding single characters.
+func FindConfusables(password string) []Confusable {
+	var found []Confusable
+	offset := 0
+	for i, r := range password {
+		for _, seq := range confusableSequences {
+			if strings.HasPrefix(password[i:], seq.text) {
+				found = append(found, Confusable{Text: seq.text, LooksLike: seq.looksLike, Offset: offset})
+			}
+		}
+		if looksLike, ok := confusableChars[r]; ok {
+			found = append(found, Confusable{Text: string(r), LooksLike: looksLike, Offset: offset})
+		}
+		offset++
+	}
+	return found
+}
+
+// WithoutConfusables rejects passwords containing a confusable sequence of
+// several characters, see FindConfusables. Single look-alike characters are
+// left to WithAmbiguity.
+func WithoutConfusables() GeneratorOption {
+	return func(g *Generator) error {
+		g.pipeline.UsePostGenerate(func(password string) error {
+			for _, c := range FindConfusables(password) {
+				if len(c.Text) > 1 {
+					return Reject("look-alike", "%q reads as %q", c.Text, c.LooksLike)
+				}
+			}
+			return nil
+		})
+		return nil
+	}
+}
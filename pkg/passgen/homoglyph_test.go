@@ -0,0 +1,47 @@
+package passgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFindConfusables tests finding look-alike sequences and characters
+func TestFindConfusables(t *testing.T) {
+	tests := []struct {
+		password string
+		want     []Confusable
+	}{
+		{"abcdef", nil},
+		{"corner", []Confusable{{"rn", "m", 2}}},
+		{"VVavv", []Confusable{{"VV", "W", 0}, {"vv", "w", 3}}},
+		{"éclat", []Confusable{{"cl", "d", 1}, {"l", "1", 2}}},
+		{"S0", []Confusable{{"S", "5", 0}, {"0", "O", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := FindConfusables(tt.password); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindConfusables(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithoutConfusables tests that confusable sequences are regenerated
+func TestWithoutConfusables(t *testing.T) {
+	g, err := NewGenerator(WithLength(40), WithCharset("rnvcl"), WithoutConfusables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, seq := range []string{"rn", "vv", "cl"} {
+			if strings.Contains(password, seq) {
+				t.Errorf("Password %s contains %q", password, seq)
+			}
+		}
+	}
+}
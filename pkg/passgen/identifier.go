@@ -0,0 +1,58 @@
+package passgen
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// proquintVowels are the vowels of proquints; the consonants are those of
+// pronounceable passwords.
+const proquintVowels = "aiou"
+
+// EncodeProquint encodes b as proquints ("PRO-nouncable QUINTuplets"), one
+// five-letter consonant-vowel word per 16 bits joined by dashes, e.g.
+// 127.0.0.1 becomes "lusab-babad". b must have an even number of bytes.
+func EncodeProquint(b []byte) (string, error) {
+	if len(b) == 0 || len(b)%2 != 0 {
+		return "", fmt.Errorf("proquints encode an even number of bytes, got %d", len(b))
+	}
+	words := make([]string, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		n := uint16(b[i])<<8 | uint16(b[i+1])
+		words = append(words, string([]byte{
+			PronounceableConsonants[n>>12&0xf],
+			proquintVowels[n>>10&0x3],
+			PronounceableConsonants[n>>6&0xf],
+			proquintVowels[n>>4&0x3],
+			PronounceableConsonants[n&0xf],
+		}))
+	}
+	return strings.Join(words, "-"), nil
+}
+
+// koremutakeSyllables are the 128 syllables of Koremutake, each worth 7
+// bits.
+var koremutakeSyllables = strings.Fields(`
+	ba be bi bo bu by da de di do du dy fa fe fi fo fu fy ga ge gi go gu gy
+	ha he hi ho hu hy ja je ji jo ju jy ka ke ki ko ku ky la le li lo lu ly
+	ma me mi mo mu my na ne ni no nu ny pa pe pi po pu py ra re ri ro ru ry
+	sa se si so su sy ta te ti to tu ty va ve vi vo vu vy bra bre bri bro bru
+	bry dra dre dri dro dru dry fra fre fri fro fru fry gra gre gri gro gru
+	gry pra pre pri pro pru pry sta ste sti sto stu sty tra tre`)
+
+// EncodeKoremutake encodes b, read as a big-endian number, as Koremutake
+// syllables: 10610353957 becomes "koremutake". The result always has
+// ceil(8*len(b)/7) syllables, padded with "ba" (zero) in front, so every
+// identifier of the same size has the same shape.
+func EncodeKoremutake(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	size := (8*len(b) + 6) / 7
+	syllables := make([]string, size)
+	mod, digit := big.NewInt(128), new(big.Int)
+	for i := size - 1; i >= 0; i-- {
+		n.DivMod(n, mod, digit)
+		syllables[i] = koremutakeSyllables[digit.Int64()]
+	}
+	return strings.Join(syllables, "")
+}
@@ -0,0 +1,50 @@
+package passgen
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestEncodeProquint tests the examples of the proquint specification
+func TestEncodeProquint(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte{127, 0, 0, 1}, "lusab-babad"},
+		{[]byte{63, 84, 220, 193}, "gutih-tugad"},
+		{[]byte{192, 168, 1, 1}, "safom-bahad"},
+		{[]byte{0xff, 0xff}, "zuzuz"},
+	}
+	for _, tt := range tests {
+		got, err := EncodeProquint(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("EncodeProquint(%v) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range [][]byte{nil, {1, 2, 3}} {
+		if _, err := EncodeProquint(in); err == nil {
+			t.Errorf("Expected error for %d bytes", len(in))
+		}
+	}
+}
+
+// TestEncodeKoremutake tests the canonical example and padding
+func TestEncodeKoremutake(t *testing.T) {
+	if len(koremutakeSyllables) != 128 {
+		t.Fatalf("Got %d syllables, want 128", len(koremutakeSyllables))
+	}
+	// 10610353957 is the number behind the name, as 5 bytes it takes 6
+	// syllables, the first of them padding
+	b := make([]byte, 5)
+	big.NewInt(10610353957).FillBytes(b)
+	if got := EncodeKoremutake(b); got != "bakoremutake" {
+		t.Errorf("EncodeKoremutake = %q, want bakoremutake", got)
+	}
+	if got := EncodeKoremutake([]byte{0, 0}); got != "bababa" {
+		t.Errorf("EncodeKoremutake(0) = %q, want bababa", got)
+	}
+	if got := EncodeKoremutake([]byte{0xff}); got != "betre" {
+		t.Errorf("EncodeKoremutake(255) = %q, want betre", got)
+	}
+}
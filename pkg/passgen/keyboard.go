@@ -0,0 +1,104 @@
+package passgen
+
+import "fmt"
+
+// qwertyRows is the US QWERTY layout, unshifted. Each row is offset by half
+// a key from the one above, so key i of a row sits below keys i and i+1 of
+// the row above.
+var qwertyRows = []string{
+	"1234567890-=",
+	"qwertyuiop[]",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+// qwertyShifted maps shifted symbols to the key that produces them.
+const (
+	qwertyShifted   = "!@#$%^&*()_+{}:\"<>?"
+	qwertyUnshifted = "1234567890-=[];',./"
+)
+
+type keyPos struct{ row, col int }
+
+var qwertyKeys = func() map[rune]keyPos {
+	keys := make(map[rune]keyPos)
+	for row, keysInRow := range qwertyRows {
+		for col, r := range keysInRow {
+			keys[r] = keyPos{row, col}
+		}
+	}
+	for i, r := range qwertyShifted {
+		keys[r] = keys[rune(qwertyUnshifted[i])]
+	}
+	for r := 'a'; r <= 'z'; r++ {
+		keys[r-'a'+'A'] = keys[r]
+	}
+	return keys
+}()
+
+// keyStep returns the direction from one key to a neighbouring key, and
+// false if the keys are not neighbours.
+func keyStep(from, to keyPos) (keyPos, bool) {
+	step := keyPos{to.row - from.row, to.col - from.col}
+	switch step {
+	case keyPos{0, 1}, keyPos{0, -1}, keyPos{1, 0}, keyPos{1, -1}, keyPos{-1, 0}, keyPos{-1, 1}:
+		return step, true
+	}
+	return keyPos{}, false
+}
+
+// KeyboardWalk returns the length of the longest keyboard walk in password:
+// a run of keys that follow each other in a straight line on a QWERTY
+// keyboard, such as "qwerty", "zxcv" or "1qaz". Case and shift are ignored,
+// so "!QAZ" counts like "1qaz". A password without neighbouring keys in a
+// row returns 1, an empty one 0.
+func KeyboardWalk(password string) int {
+	longest, run := 0, 0
+	var prev keyPos
+	var dir keyPos
+	havePrev := false
+	for _, r := range password {
+		pos, ok := qwertyKeys[r]
+		if !ok {
+			havePrev, run = false, 0
+			continue
+		}
+		run++
+		if havePrev {
+			step, adjacent := keyStep(prev, pos)
+			switch {
+			case !adjacent:
+				run = 1
+			case run > 2 && step != dir:
+				// A turn starts a new walk from the previous key
+				run = 2
+			}
+			dir = step
+		}
+		longest = max(longest, run)
+		prev, havePrev = pos, true
+	}
+	return longest
+}
+
+// DefaultMaxKeyboardWalk is the longest keyboard walk WithoutKeyboardWalks
+// tolerates by default. Walks of three keys occur by chance too often to
+// reject.
+const DefaultMaxKeyboardWalk = 3
+
+// WithoutKeyboardWalks rejects passwords containing a keyboard walk longer
+// than maxWalk keys, see KeyboardWalk.
+func WithoutKeyboardWalks(maxWalk int) GeneratorOption {
+	return func(g *Generator) error {
+		if maxWalk < 1 {
+			return fmt.Errorf("longest keyboard walk must be at least 1")
+		}
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if walk := KeyboardWalk(password); walk > maxWalk {
+				return Reject("keyboard walk", "contains a keyboard walk of %d keys", walk)
+			}
+			return nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestKeyboardWalk tests straight walks in every direction and turns
+func TestKeyboardWalk(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"x9Kp", 1},
+		{"aa", 1},
+		{"qwerty", 6},
+		{"ytrewq", 6},
+		{"Hzxcv!", 4},
+		{"1qaz", 4},
+		{"zaq1", 4},
+		{"!QAZ", 4},
+		{"3edc", 4},
+		{"qwsx", 3},
+		{"a-qwer-b", 4},
+		{"asdfghjkl;'", 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := KeyboardWalk(tt.password); got != tt.want {
+				t.Errorf("KeyboardWalk(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithoutKeyboardWalks tests that generated passwords avoid long walks
+func TestWithoutKeyboardWalks(t *testing.T) {
+	g, err := NewGenerator(WithLength(64), WithSimilar(true), WithoutKeyboardWalks(DefaultMaxKeyboardWalk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if walk := KeyboardWalk(password); walk > DefaultMaxKeyboardWalk {
+			t.Errorf("Password has a walk of %d keys: %s", walk, password)
+		}
+	}
+
+	rule, err := CompileRule("keyboardWalk <= 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rule.Check("xx1qaz"); err == nil || !strings.Contains(err.Error(), "keyboardWalk") {
+		t.Errorf("Expected the rule to reject a walk, got %v", err)
+	}
+}
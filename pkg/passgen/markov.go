@@ -0,0 +1,150 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// MarkovModel is a character-level Markov chain trained on a corpus. It
+// produces word-like strings that resemble the training data, which is
+// useful for test data that should look like human-chosen passwords.
+type MarkovModel struct {
+	order int
+	words int
+	// transitions maps the previous order runes to the weighted choices
+	// for the next rune.
+	transitions map[string]*markovChoices
+}
+
+// markovChoices holds the candidate next runes for a state with their counts,
+// kept in a stable order so sampling is reproducible for a given RNG stream.
+type markovChoices struct {
+	runes  []rune
+	counts []int64
+	total  int64
+}
+
+// markovStart pads the state at the beginning of a word.
+const markovStart = '\x00'
+
+// TrainMarkov builds a model of the given order (number of preceding
+// characters considered) from the corpus words.
+func TrainMarkov(words []string, order int) (*MarkovModel, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("markov order must be at least 1")
+	}
+
+	counts := make(map[string]map[rune]int64)
+	m := &MarkovModel{order: order}
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) == 0 {
+			continue
+		}
+		m.words++
+
+		state := m.startState()
+		for _, r := range runes {
+			if counts[string(state)] == nil {
+				counts[string(state)] = make(map[rune]int64)
+			}
+			counts[string(state)][r]++
+			state = append(state[1:], r)
+		}
+	}
+	if m.words == 0 {
+		return nil, fmt.Errorf("markov corpus contains no words")
+	}
+
+	m.transitions = make(map[string]*markovChoices, len(counts))
+	for state, next := range counts {
+		c := &markovChoices{}
+		for r := range next {
+			c.runes = append(c.runes, r)
+		}
+		sort.Slice(c.runes, func(i, j int) bool { return c.runes[i] < c.runes[j] })
+		for _, r := range c.runes {
+			c.counts = append(c.counts, next[r])
+			c.total += next[r]
+		}
+		m.transitions[state] = c
+	}
+	return m, nil
+}
+
+// Order returns the number of preceding characters the model conditions on.
+func (m *MarkovModel) Order() int {
+	return m.order
+}
+
+// Words returns the number of corpus words the model was trained on.
+func (m *MarkovModel) Words() int {
+	return m.words
+}
+
+func (m *MarkovModel) startState() []rune {
+	state := make([]rune, m.order)
+	for i := range state {
+		state[i] = markovStart
+	}
+	return state
+}
+
+// Generate samples a string of the given length from the model. When the
+// chain reaches a state with no continuation it starts a new word.
+func (m *MarkovModel) Generate(length int) (string, error) {
+	out := make([]rune, 0, length)
+	state := m.startState()
+	for len(out) < length {
+		choices, ok := m.transitions[string(state)]
+		if !ok {
+			state = m.startState()
+			continue
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(choices.total))
+		if err != nil {
+			return "", err
+		}
+		pick := n.Int64()
+		var r rune
+		for i, count := range choices.counts {
+			if pick < count {
+				r = choices.runes[i]
+				break
+			}
+			pick -= count
+		}
+
+		out = append(out, r)
+		state = append(state[1:], r)
+	}
+	return string(out), nil
+}
+
+// Entropy returns the information content in bits of s under the model,
+// i.e. the sum of -log2(p) over every character choice made to produce it.
+// This is the honest strength of a generated string, and is usually far
+// lower than a uniform charset of the same length would suggest.
+func (m *MarkovModel) Entropy(s string) (float64, error) {
+	bits := 0.0
+	state := m.startState()
+	for _, r := range s {
+		choices, ok := m.transitions[string(state)]
+		if !ok {
+			state = m.startState()
+			choices = m.transitions[string(state)]
+		}
+
+		i := sort.Search(len(choices.runes), func(i int) bool { return choices.runes[i] >= r })
+		if i == len(choices.runes) || choices.runes[i] != r {
+			return 0, fmt.Errorf("%q cannot be produced by the model", s)
+		}
+		bits += math.Log2(float64(choices.total) / float64(choices.counts[i]))
+		state = append(state[1:], r)
+	}
+	return bits, nil
+}
@@ -0,0 +1,84 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestTrainMarkovErrors tests invalid training input
+func TestTrainMarkovErrors(t *testing.T) {
+	if _, err := TrainMarkov([]string{"word"}, 0); err == nil {
+		t.Error("Expected error for order 0")
+	}
+	if _, err := TrainMarkov([]string{"", ""}, 2); err == nil {
+		t.Error("Expected error for empty corpus")
+	}
+}
+
+// TestMarkovGenerate tests that output only uses corpus transitions
+func TestMarkovGenerate(t *testing.T) {
+	m, err := TrainMarkov([]string{"banana", "bandana"}, 2)
+	if err != nil {
+		t.Fatalf("Failed to train model: %v", err)
+	}
+	if m.Order() != 2 || m.Words() != 2 {
+		t.Errorf("Expected order 2 and 2 words, got %d and %d", m.Order(), m.Words())
+	}
+
+	for i := 0; i < 20; i++ {
+		word, err := m.Generate(12)
+		if err != nil {
+			t.Fatalf("Failed to generate: %v", err)
+		}
+		if len([]rune(word)) != 12 {
+			t.Errorf("Expected 12 characters, got %q", word)
+		}
+		if strings.Trim(word, "band") != "" {
+			t.Errorf("Generated characters outside the corpus: %q", word)
+		}
+		if _, err := m.Entropy(word); err != nil {
+			t.Errorf("Generated word should be scorable: %v", err)
+		}
+	}
+}
+
+// TestMarkovEntropy tests that entropy counts only real choices
+func TestMarkovEntropy(t *testing.T) {
+	// A single word leaves no choice at all
+	m, err := TrainMarkov([]string{"abc"}, 1)
+	if err != nil {
+		t.Fatalf("Failed to train model: %v", err)
+	}
+	bits, err := m.Entropy("abcabc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if bits != 0 {
+		t.Errorf("Expected 0 bits for a deterministic model, got %.2f", bits)
+	}
+
+	// Two equally likely first letters give exactly one bit
+	m, _ = TrainMarkov([]string{"ax", "bx"}, 1)
+	bits, _ = m.Entropy("ax")
+	if math.Abs(bits-1) > 1e-9 {
+		t.Errorf("Expected 1 bit, got %.2f", bits)
+	}
+
+	if _, err := m.Entropy("zz"); err == nil {
+		t.Error("Expected error for a string the model cannot produce")
+	}
+}
+
+// TestPipelineWithModel tests generating through a pipeline with a Markov model
+func TestPipelineWithModel(t *testing.T) {
+	m, _ := TrainMarkov([]string{"hello", "world"}, 2)
+	p := &Pipeline{}
+	password, err := p.Generate(Options{Length: 10, Model: m})
+	if err != nil {
+		t.Fatalf("Failed to generate password: %v", err)
+	}
+	if len(password) != 10 || strings.Trim(password, "helowrd") != "" {
+		t.Errorf("Unexpected model output: %q", password)
+	}
+}
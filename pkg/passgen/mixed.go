@@ -0,0 +1,170 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// Continuous health tests of SP 800-90B, section 4.4, run on every byte a
+// MixedRandom reads from its sources. They assume at least
+// healthEntropyBits of entropy per byte, low enough for real hardware RNGs,
+// and a false alarm rate of 2^-healthAlpha.
+const (
+	healthEntropyBits = 2
+	healthAlpha       = 20
+	// aptWindow is the window of the adaptive proportion test.
+	aptWindow = 512
+)
+
+// rctCutoff fails a source repeating one byte this many times in a row.
+const rctCutoff = 1 + (healthAlpha+healthEntropyBits-1)/healthEntropyBits
+
+// aptCutoff fails a source with this many copies of the first byte of a
+// window, for bytes that take the first value with probability
+// 2^-healthEntropyBits.
+var aptCutoff = func() int {
+	p := math.Exp2(-healthEntropyBits)
+	n := aptWindow - 1
+	lg := func(x int) float64 {
+		v, _ := math.Lgamma(float64(x + 1))
+		return v
+	}
+	// P(X >= c) for X ~ Binomial(n, p), summed down from the tail
+	tail := 0.0
+	for c := n; c >= 0; c-- {
+		tail += math.Exp(lg(n) - lg(c) - lg(n-c) + float64(c)*math.Log(p) + float64(n-c)*math.Log1p(-p))
+		if tail > math.Exp2(-healthAlpha) {
+			// The first byte itself counts too
+			return c + 2
+		}
+	}
+	return 1
+}()
+
+// SourceHealth is the accounting of one source of a MixedRandom.
+type SourceHealth struct {
+	Name string
+	// Bytes is how much was read from the source.
+	Bytes int64
+	// Failure describes the first failed health test or read error, and
+	// is empty while the source is healthy.
+	Failure string
+}
+
+// mixedSource is a source with the state of its health tests.
+type mixedSource struct {
+	r io.Reader
+	SourceHealth
+	// Repetition count test
+	last byte
+	run  int
+	// Adaptive proportion test
+	sample      byte
+	seen, count int
+}
+
+// MixedRandom XORs several sources of randomness byte by byte, so its
+// output is as unpredictable as the best of them: a source that is
+// compromised, broken or predictable cannot weaken it while one other
+// source is good. It always includes the system random number generator.
+//
+// Every byte of every source goes through the repetition count and
+// adaptive proportion tests of SP 800-90B. A source failing one, or failing
+// to read, fails all further reads, since an RNG that was stuck once is no
+// longer trusted; Health reports which source it was.
+type MixedRandom struct {
+	mu      sync.Mutex
+	sources []*mixedSource
+	buf     []byte
+}
+
+// NewMixedRandom returns a MixedRandom of the system random number
+// generator.
+func NewMixedRandom() *MixedRandom {
+	m := &MixedRandom{}
+	m.Add("system", rand.Reader)
+	return m
+}
+
+// Add mixes in another source, e.g. a hardware RNG device or a file of
+// random bytes.
+func (m *MixedRandom) Add(name string, r io.Reader) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sources = append(m.sources, &mixedSource{r: r, SourceHealth: SourceHealth{Name: name}})
+}
+
+// Read fills p with the XOR of the same number of bytes from every source.
+func (m *MixedRandom) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(p)
+	for _, s := range m.sources {
+		if s.Failure != "" {
+			return 0, fmt.Errorf("entropy source %s failed: %s", s.Name, s.Failure)
+		}
+	}
+	for _, s := range m.sources {
+		if cap(m.buf) < len(p) {
+			m.buf = make([]byte, len(p))
+		}
+		buf := m.buf[:len(p)]
+		n, err := io.ReadFull(s.r, buf)
+		s.Bytes += int64(n)
+		if err != nil {
+			s.Failure = err.Error()
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				s.Failure = "ran out of data"
+			}
+			return 0, fmt.Errorf("entropy source %s failed: %s", s.Name, s.Failure)
+		}
+		for i, b := range buf {
+			if s.Failure == "" {
+				s.test(b)
+			}
+			p[i] ^= b
+		}
+		if s.Failure != "" {
+			return 0, fmt.Errorf("entropy source %s failed: %s", s.Name, s.Failure)
+		}
+	}
+	return len(p), nil
+}
+
+// test runs the health tests on the next byte of the source.
+func (s *mixedSource) test(b byte) {
+	if s.run > 0 && b == s.last {
+		s.run++
+		if s.run >= rctCutoff {
+			s.Failure = fmt.Sprintf("repetition count test: byte %#02x %d times in a row", b, s.run)
+		}
+	} else {
+		s.last, s.run = b, 1
+	}
+
+	if s.seen == 0 {
+		s.sample, s.count = b, 0
+	}
+	if b == s.sample {
+		s.count++
+		if s.count >= aptCutoff {
+			s.Failure = fmt.Sprintf("adaptive proportion test: byte %#02x %d times in %d", b, s.count, aptWindow)
+		}
+	}
+	s.seen = (s.seen + 1) % aptWindow
+}
+
+// Health returns the accounting of every source, the system random number
+// generator first.
+func (m *MixedRandom) Health() []SourceHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health := make([]SourceHealth, len(m.sources))
+	for i, s := range m.sources {
+		health[i] = s.SourceHealth
+	}
+	return health
+}
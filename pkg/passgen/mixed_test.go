@@ -0,0 +1,86 @@
+package passgen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestMixedRandom tests XOR mixing and the accounting of each source
+func TestMixedRandom(t *testing.T) {
+	pad := make([]byte, 64)
+	rand.Read(pad)
+	m := &MixedRandom{}
+	m.Add("a", bytes.NewReader(pad))
+	m.Add("b", bytes.NewReader(pad))
+	out := make([]byte, 64)
+	if _, err := io.ReadFull(m, out); err != nil {
+		t.Fatal(err)
+	}
+	// Identical sources cancel out, which shows every byte is XORed
+	if !bytes.Equal(out, make([]byte, 64)) {
+		t.Errorf("Got %x, want zeros", out)
+	}
+	if h := m.Health(); len(h) != 2 || h[0].Bytes != 64 || h[1].Name != "b" || h[1].Failure != "" {
+		t.Errorf("Unexpected health %+v", h)
+	}
+
+	// A source running dry fails the mix
+	if _, err := m.Read(out[:1]); err == nil || !strings.Contains(err.Error(), "entropy source a failed: ran out of data") {
+		t.Errorf("Expected source a to run out, got %v", err)
+	}
+	if h := m.Health(); h[0].Failure == "" {
+		t.Error("The failure was not recorded")
+	}
+
+	m = NewMixedRandom()
+	m.Add("file", bytes.NewReader(pad))
+	if _, err := io.ReadFull(m, out); err != nil {
+		t.Fatal(err)
+	}
+	if h := m.Health(); h[0].Name != "system" || h[0].Bytes != 64 || h[1].Bytes != 64 {
+		t.Errorf("Unexpected health %+v", h)
+	}
+}
+
+// TestMixedRandomHealthTests tests that stuck and biased sources fail
+func TestMixedRandomHealthTests(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		fails string
+	}{
+		{"stuck", bytes.Repeat([]byte{0xaa}, 64), "repetition count test"},
+		// Every other byte the same never repeats, but is far too common
+		{"biased", bytes.Repeat([]byte{0, 1}, aptWindow), "adaptive proportion test"},
+		{"random", nil, ""},
+	}
+	for _, tt := range tests {
+		if tt.data == nil {
+			tt.data = make([]byte, 4*aptWindow)
+			rand.Read(tt.data)
+		}
+		m := NewMixedRandom()
+		m.Add(tt.name, bytes.NewReader(tt.data))
+		_, err := io.ReadFull(m, make([]byte, len(tt.data)))
+		health := m.Health()[1]
+		if tt.fails == "" {
+			if err != nil || health.Failure != "" {
+				t.Errorf("%s: %v, %+v", tt.name, err, health)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(health.Failure, tt.fails) {
+			t.Errorf("%s: expected the %s to fail, got %v, %+v", tt.name, tt.fails, err, health)
+		}
+		// A failed source stays failed
+		if _, err := m.Read(make([]byte, 1)); err == nil {
+			t.Errorf("%s: read after a failure succeeded", tt.name)
+		}
+	}
+	if rctCutoff != 11 || aptCutoff < 129 || aptCutoff > aptWindow/2 {
+		t.Errorf("Unexpected cutoffs %d and %d", rctCutoff, aptCutoff)
+	}
+}
@@ -0,0 +1,177 @@
+// Package passgen generates cryptographically secure passwords that avoid
+// visually similar characters.
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Character sets excluding similar characters (0, O, I, l, 1)
+const (
+	Uppercase = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	Lowercase = "abcdefghijkmnpqrstuvwxyz"
+	Numbers   = "23456789"
+	Special   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+)
+
+func getRandomChar(charset string) (byte, error) {
+	max := big.NewInt(int64(len(charset)))
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
+
+func getRandomRune(random io.Reader, charset []rune) (rune, error) {
+	max := big.NewInt(int64(len(charset)))
+	n, err := rand.Int(random, max)
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
+
+func shuffleString[T byte | rune](random io.Reader, str []T) error {
+	length := len(str)
+	for i := length - 1; i > 0; i-- {
+		max := big.NewInt(int64(i + 1))
+		jBig, err := rand.Int(random, max)
+		if err != nil {
+			return err
+		}
+		j := jBig.Int64()
+		str[i], str[j] = str[j], str[i]
+	}
+	return nil
+}
+
+// GeneratePassword returns a random password of the given length containing
+// at least one uppercase letter, lowercase letter and number, plus a special
+// character when includeSpecial is set.
+func GeneratePassword(length int, includeSpecial bool) (string, error) {
+	return GenerateFromCharsets(length, Options{IncludeSpecial: includeSpecial}.Charsets())
+}
+
+// GenerateFromCharsets returns a random password of the given length that
+// contains at least one character from each of the charsets. Sets may
+// contain any Unicode characters; length counts characters, not bytes.
+func GenerateFromCharsets(length int, charsets []string) (string, error) {
+	return generateFromCharsets(rand.Reader, length, charsets, nil)
+}
+
+// generateFromCharsets is GenerateFromCharsets with at least minimums[i]
+// characters from charsets[i], drawn with randomness from random. A nil
+// minimums requires one from each.
+func generateFromCharsets(random io.Reader, length int, charsets []string, minimums []int) (string, error) {
+	if len(charsets) == 0 {
+		return "", fmt.Errorf("at least one character set is required")
+	}
+	sets := make([][]rune, len(charsets))
+	for i, charset := range charsets {
+		if charset == "" {
+			return "", fmt.Errorf("character sets must not be empty")
+		}
+		sets[i] = []rune(charset)
+	}
+
+	// Validate minimum length
+	minLength := len(charsets)
+	if minimums != nil {
+		minLength = 0
+		for _, n := range minimums {
+			minLength += n
+		}
+	}
+	if length < minLength {
+		return "", fmt.Errorf("password length must be at least %d", minLength)
+	}
+
+	password := make([]rune, length)
+	pos := 0
+
+	// Ensure the minimum number of characters from each required set
+	var err error
+	for i, charset := range sets {
+		n := 1
+		if minimums != nil {
+			n = minimums[i]
+		}
+		for ; n > 0; n-- {
+			password[pos], err = getRandomRune(random, charset)
+			if err != nil {
+				return "", err
+			}
+			pos++
+		}
+	}
+
+	// Fill remaining positions randomly
+	max := big.NewInt(int64(len(charsets)))
+	for i := pos; i < length; i++ {
+		n, err := rand.Int(random, max)
+		if err != nil {
+			return "", err
+		}
+
+		password[i], err = getRandomRune(random, sets[n.Int64()])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Shuffle the password to randomize character positions
+	if err := shuffleString(random, password); err != nil {
+		return "", err
+	}
+
+	return string(password), nil
+}
+
+// Full character classes, including the similar-looking characters. They are
+// used when similar characters are explicitly allowed and when mimicking an
+// existing password, where the replacement should keep exactly the same
+// structure as the original.
+const (
+	allUppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	allLowercase = "abcdefghijklmnopqrstuvwxyz"
+	allNumbers   = "0123456789"
+	allSpecial   = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// Equivalent returns a random password with the same length as password and
+// the same character class (uppercase, lowercase, digit or symbol) at every
+// position. It is meant for anonymizing datasets: the replacement satisfies
+// the same composition policies as the original but reveals nothing else.
+func Equivalent(password string) (string, error) {
+	runes := []rune(password)
+	out := make([]byte, len(runes))
+	for i, r := range runes {
+		if r == ' ' {
+			out[i] = ' '
+			continue
+		}
+
+		var charset string
+		switch ClassOf(r) {
+		case ClassUpper:
+			charset = allUppercase
+		case ClassLower:
+			charset = allLowercase
+		case ClassDigit:
+			charset = allNumbers
+		default:
+			charset = allSpecial
+		}
+
+		c, err := getRandomChar(charset)
+		if err != nil {
+			return "", err
+		}
+		out[i] = c
+	}
+	return string(out), nil
+}
@@ -1,6 +1,7 @@
-package main
+package passgen
 
 import (
+	"crypto/rand"
 	"regexp"
 	"strings"
 	"testing"
@@ -8,7 +9,7 @@ import (
 
 // TestDefaultPasswordGeneration tests basic password generation with defaults
 func TestDefaultPasswordGeneration(t *testing.T) {
-	password, err := generatePassword(12, false)
+	password, err := GeneratePassword(12, false)
 	if err != nil {
 		t.Fatalf("Failed to generate password: %v", err)
 	}
@@ -35,7 +36,7 @@ func TestCustomLength(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			password, err := generatePassword(tt.length, false)
+			password, err := GeneratePassword(tt.length, false)
 			if err != nil {
 				t.Fatalf("Failed to generate password: %v", err)
 			}
@@ -63,7 +64,7 @@ func TestWithSpecialCharacters(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			password, err := generatePassword(tt.length, true)
+			password, err := GeneratePassword(tt.length, true)
 			if err != nil {
 				t.Fatalf("Failed to generate password: %v", err)
 			}
@@ -96,7 +97,7 @@ func TestInvalidLength(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			password, err := generatePassword(tt.length, tt.includeSpecial)
+			password, err := GeneratePassword(tt.length, tt.includeSpecial)
 
 			if tt.shouldFail {
 				if err == nil {
@@ -120,7 +121,7 @@ func TestMultiplePasswordsUniqueness(t *testing.T) {
 	count := 100
 
 	for i := 0; i < count; i++ {
-		password, err := generatePassword(12, false)
+		password, err := GeneratePassword(12, false)
 		if err != nil {
 			t.Fatalf("Failed to generate password: %v", err)
 		}
@@ -137,7 +138,7 @@ func TestMultiplePasswordsUniqueness(t *testing.T) {
 func TestExcludedCharactersNeverAppear(t *testing.T) {
 	// Generate a large sample of passwords
 	for i := 0; i < 50; i++ {
-		password, err := generatePassword(20, true)
+		password, err := GeneratePassword(20, true)
 		if err != nil {
 			t.Fatalf("Failed to generate password: %v", err)
 		}
@@ -148,9 +149,9 @@ func TestExcludedCharactersNeverAppear(t *testing.T) {
 // TestPasswordRandomness tests that consecutive password generations are different
 func TestPasswordRandomness(t *testing.T) {
 	passwords := make([]string, 10)
-	
+
 	for i := 0; i < 10; i++ {
-		password, err := generatePassword(12, false)
+		password, err := GeneratePassword(12, false)
 		if err != nil {
 			t.Fatalf("Failed to generate password: %v", err)
 		}
@@ -203,7 +204,7 @@ func TestShuffleString(t *testing.T) {
 	shuffled := make([]byte, len(original))
 	copy(shuffled, original)
 
-	err := shuffleString(shuffled)
+	err := shuffleString(rand.Reader, shuffled)
 	if err != nil {
 		t.Fatalf("Failed to shuffle string: %v", err)
 	}
@@ -216,7 +217,7 @@ func TestShuffleString(t *testing.T) {
 	// Verify all characters are still present
 	originalStr := string(original)
 	shuffledStr := string(shuffled)
-	
+
 	for _, char := range originalStr {
 		if !strings.ContainsRune(shuffledStr, char) {
 			t.Errorf("Character '%c' from original not found in shuffled string", char)
@@ -230,7 +231,7 @@ func TestShuffleString(t *testing.T) {
 // TestPasswordWithoutSpecialCharactersHasNoSpecial tests that passwords without special flag don't have special chars
 func TestPasswordWithoutSpecialCharactersHasNoSpecial(t *testing.T) {
 	for i := 0; i < 20; i++ {
-		password, err := generatePassword(12, false)
+		password, err := GeneratePassword(12, false)
 		if err != nil {
 			t.Fatalf("Failed to generate password: %v", err)
 		}
@@ -247,9 +248,9 @@ func TestPasswordWithSpecialCharactersHasSpecial(t *testing.T) {
 	// Due to randomness, a single password might not have special chars even with the flag
 	// So we test multiple times and expect at least one to have them
 	foundWithSpecial := false
-	
+
 	for i := 0; i < 20; i++ {
-		password, err := generatePassword(12, true)
+		password, err := GeneratePassword(12, true)
 		if err != nil {
 			t.Fatalf("Failed to generate password: %v", err)
 		}
@@ -303,7 +304,7 @@ func validateNoExcludedCharacters(t *testing.T, password string) {
 // Benchmark password generation
 func BenchmarkGeneratePassword(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := generatePassword(12, false)
+		_, err := GeneratePassword(12, false)
 		if err != nil {
 			b.Fatalf("Failed to generate password: %v", err)
 		}
@@ -312,7 +313,7 @@ func BenchmarkGeneratePassword(b *testing.B) {
 
 func BenchmarkGeneratePasswordWithSpecial(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := generatePassword(16, true)
+		_, err := GeneratePassword(16, true)
 		if err != nil {
 			b.Fatalf("Failed to generate password: %v", err)
 		}
@@ -321,9 +322,51 @@ func BenchmarkGeneratePasswordWithSpecial(b *testing.B) {
 
 func BenchmarkGeneratePasswordLong(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, err := generatePassword(128, true)
+		_, err := GeneratePassword(128, true)
 		if err != nil {
 			b.Fatalf("Failed to generate password: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// TestEquivalent tests that replacements keep length and per-position classes
+func TestEquivalent(t *testing.T) {
+	classOf := func(r rune) string {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return "upper"
+		case r >= 'a' && r <= 'z':
+			return "lower"
+		case r >= '0' && r <= '9':
+			return "digit"
+		case r == ' ':
+			return "space"
+		default:
+			return "special"
+		}
+	}
+
+	for _, original := range []string{"Hunter2!", "correct horse", "P@55w0rd", ""} {
+		replacement, err := Equivalent(original)
+		if err != nil {
+			t.Fatalf("Failed to create equivalent: %v", err)
+		}
+		if len(replacement) != len(original) {
+			t.Fatalf("Expected length %d, got %d (%q)", len(original), len(replacement), replacement)
+		}
+		for i, r := range replacement {
+			if classOf(r) != classOf(rune(original[i])) {
+				t.Errorf("Position %d of %q: %q is not the same class as %q", i, replacement, r, original[i])
+			}
+		}
+	}
+
+	// Non-ASCII characters are mapped to an ASCII character of the same kind
+	replacement, err := Equivalent("Ünï")
+	if err != nil {
+		t.Fatalf("Failed to create equivalent: %v", err)
+	}
+	if len(replacement) != 3 || !regexp.MustCompile(`^[A-Z][a-z]{2}$`).MatchString(replacement) {
+		t.Errorf("Unexpected replacement for non-ASCII input: %q", replacement)
+	}
+}
@@ -0,0 +1,149 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// GeneratePassphrase returns count words chosen uniformly at random from
+// words, joined by sep. Words may repeat, as in diceware, so every word adds
+// the same amount of entropy.
+func GeneratePassphrase(words []string, count int, sep string) (string, error) {
+	chosen, err := ChooseWords(words, count)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(chosen, sep), nil
+}
+
+// ChooseWords returns count words chosen uniformly at random from words,
+// for callers that transform the words before joining them.
+func ChooseWords(words []string, count int) ([]string, error) {
+	if len(words) < 2 {
+		return nil, fmt.Errorf("wordlist must contain at least 2 words")
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("passphrase must have at least 1 word")
+	}
+
+	max := big.NewInt(int64(len(words)))
+	chosen := make([]string, count)
+	for i := range chosen {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return nil, err
+		}
+		chosen[i] = words[n.Int64()]
+	}
+	return chosen, nil
+}
+
+// typoLetters are the replacements a substitution typo draws from.
+const typoLetters = "abcdefghijklmnopqrstuvwxyz"
+
+// Misspell introduces one random typo into each of n distinct words chosen
+// at random: two neighbouring letters swapped, a letter left out, a letter
+// doubled or a letter replaced. The phrase stays readable while no longer
+// being made of dictionary words.
+func Misspell(words []string, n int) ([]string, error) {
+	if n < 0 || n > len(words) {
+		return nil, fmt.Errorf("typo count must be between 0 and %d", len(words))
+	}
+	out := append([]string(nil), words...)
+	picked := make([]int, len(words))
+	for i := range picked {
+		picked[i] = i
+	}
+	// Partial Fisher-Yates shuffle: the first n entries are distinct words
+	for i := 0; i < n; i++ {
+		j, err := randomInt(len(picked) - i)
+		if err != nil {
+			return nil, err
+		}
+		picked[i], picked[i+j] = picked[i+j], picked[i]
+	}
+	for _, i := range picked[:n] {
+		if out[i] == "" {
+			return nil, fmt.Errorf("cannot misspell an empty word")
+		}
+		word, err := misspellWord([]rune(out[i]))
+		if err != nil {
+			return nil, err
+		}
+		out[i] = word
+	}
+	return out, nil
+}
+
+// misspellWord applies a single random typo that always changes the word.
+func misspellWord(word []rune) (string, error) {
+	op, err := randomInt(4)
+	if err != nil {
+		return "", err
+	}
+	pos, err := randomInt(len(word))
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case op == 0 && pos+1 < len(word) && word[pos] != word[pos+1]:
+		word[pos], word[pos+1] = word[pos+1], word[pos]
+		return string(word), nil
+	case op == 1 && len(word) > 1:
+		return string(word[:pos]) + string(word[pos+1:]), nil
+	case op == 2:
+		c, err := getRandomChar(strings.ReplaceAll(typoLetters, string(word[pos]), ""))
+		if err != nil {
+			return "", err
+		}
+		word[pos] = rune(c)
+		return string(word), nil
+	}
+	// Doubling is always possible and always changes the word
+	return string(word[:pos+1]) + string(word[pos:]), nil
+}
+
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// TypoEntropy returns the entropy in bits credited for n typos in words of
+// at least minLength letters. It is deliberately conservative: an attacker
+// is assumed to know which words were misspelled and which kind of typo was
+// applied, and is only credited with the position, minus one because
+// different typos can produce the same result.
+func TypoEntropy(minLength, n int) float64 {
+	if minLength < 3 || n < 1 {
+		return 0
+	}
+	return float64(n) * math.Log2(float64(minLength-1))
+}
+
+// PassphraseEntropy returns the entropy in bits of a passphrase of count
+// words drawn uniformly from a list of listSize words.
+func PassphraseEntropy(listSize, count int) float64 {
+	if listSize < 2 || count < 1 {
+		return 0
+	}
+	return float64(count) * math.Log2(float64(listSize))
+}
+
+// WordsForEntropy returns the fewest words drawn from a list of listSize
+// words that reach the given entropy in bits, or 0 when the list is too
+// short to add any.
+func WordsForEntropy(bits float64, listSize int) int {
+	if listSize < 2 {
+		return 0
+	}
+	if bits <= 0 {
+		return 1
+	}
+	return int(math.Ceil(bits/math.Log2(float64(listSize)) - 1e-9))
+}
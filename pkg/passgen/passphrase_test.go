@@ -0,0 +1,119 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestGeneratePassphrase tests word count, separator and word choice
+func TestGeneratePassphrase(t *testing.T) {
+	words := []string{"apple", "berry", "cherry", "damson"}
+	phrase, err := GeneratePassphrase(words, 5, "-")
+	if err != nil {
+		t.Fatalf("GeneratePassphrase failed: %v", err)
+	}
+
+	parts := strings.Split(phrase, "-")
+	if len(parts) != 5 {
+		t.Fatalf("Expected 5 words, got %q", phrase)
+	}
+	for _, part := range parts {
+		found := false
+		for _, w := range words {
+			found = found || part == w
+		}
+		if !found {
+			t.Errorf("Word %q is not from the list", part)
+		}
+	}
+}
+
+// TestGeneratePassphraseErrors tests invalid arguments
+func TestGeneratePassphraseErrors(t *testing.T) {
+	if _, err := GeneratePassphrase([]string{"only"}, 4, " "); err == nil {
+		t.Error("Expected error for a single-word list")
+	}
+	if _, err := GeneratePassphrase([]string{"a", "b"}, 0, " "); err == nil {
+		t.Error("Expected error for zero words")
+	}
+}
+
+// TestPassphraseEntropy tests the entropy of uniform word choices
+func TestPassphraseEntropy(t *testing.T) {
+	tests := []struct {
+		listSize int
+		count    int
+		want     float64
+	}{
+		{7776, 6, 6 * math.Log2(7776)},
+		{1024, 4, 40},
+		{1, 6, 0},
+		{1024, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := PassphraseEntropy(tt.listSize, tt.count); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("PassphraseEntropy(%d, %d) = %f, want %f", tt.listSize, tt.count, got, tt.want)
+		}
+	}
+}
+
+// TestWordsForEntropy tests the fewest words reaching an entropy target
+func TestWordsForEntropy(t *testing.T) {
+	tests := []struct {
+		bits     float64
+		listSize int
+		want     int
+	}{
+		{80, 7776, 7},
+		{77, 7776, 6},
+		{40, 1024, 4},
+		{41, 1024, 5},
+		{0, 7776, 1},
+		{80, 1, 0},
+	}
+
+	for _, tt := range tests {
+		if got := WordsForEntropy(tt.bits, tt.listSize); got != tt.want {
+			t.Errorf("WordsForEntropy(%g, %d) = %d, want %d", tt.bits, tt.listSize, got, tt.want)
+		}
+	}
+}
+
+// TestMisspell tests that exactly n distinct words change and the rest stay
+func TestMisspell(t *testing.T) {
+	words := []string{"correct", "horse", "battery", "staple", "aa"}
+	for i := 0; i < 50; i++ {
+		out, err := Misspell(words, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := 0
+		for j := range words {
+			if out[j] != words[j] {
+				changed++
+				if d := len(out[j]) - len(words[j]); d < -1 || d > 1 {
+					t.Errorf("Typo changed the length of %q too much: %q", words[j], out[j])
+				}
+			}
+		}
+		if changed != 3 {
+			t.Errorf("Expected 3 misspelled words, got %d: %v", changed, out)
+		}
+	}
+
+	if _, err := Misspell(words, 6); err == nil {
+		t.Error("Expected error for more typos than words")
+	}
+}
+
+// TestTypoEntropy tests the conservative credit for misspellings
+func TestTypoEntropy(t *testing.T) {
+	if got := TypoEntropy(3, 2); got != 2 {
+		t.Errorf("TypoEntropy(3, 2) = %f, want 2", got)
+	}
+	if got := TypoEntropy(2, 4); got != 0 {
+		t.Errorf("Words of 2 letters should earn no credit, got %f", got)
+	}
+}
@@ -0,0 +1,240 @@
+package passgen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// patternPlaceholders are the character classes of KeePass password
+// patterns. Sets drawn from the letters and digits lose their look-alike
+// characters like the built-in sets do.
+var patternPlaceholders = map[rune]string{
+	'a': allLowercase + allNumbers,
+	'A': allUppercase + allLowercase + allNumbers,
+	'U': allUppercase + allNumbers,
+	'd': allNumbers,
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	'l': allLowercase,
+	'L': allUppercase + allLowercase,
+	'u': allUppercase,
+	'v': "aeiou",
+	'V': "aeiouAEIOU",
+	'Z': "AEIOU",
+	'c': "bcdfghjklmnpqrstvwxyz",
+	'C': "bcdfghjklmnpqrstvwxyzBCDFGHJKLMNPQRSTVWXYZ",
+	'z': "BCDFGHJKLMNPQRSTVWXYZ",
+	'p': ",.;:",
+	'b': "()[]{}<>",
+	's': allSpecial,
+	'S': allUppercase + allLowercase + allNumbers + allSpecial,
+}
+
+// maxPatternRepeat bounds {n} so a typo cannot ask for a huge password.
+const maxPatternRepeat = 1024
+
+// patternSlot is one position of a pattern: a set of characters to draw
+// from, or a single literal character.
+type patternSlot struct {
+	charset string
+	// placeholder is set for slots from placeholders, whose look-alike
+	// characters are removed according to the ambiguity level.
+	placeholder bool
+	literal     bool
+}
+
+// Pattern is a compiled password template in the syntax of KeePass
+// password patterns, e.g. "uullddss" or "A{4}-d{4}-s{2}". Every placeholder
+// draws one character from its class:
+//
+//	a  lowercase letter or digit     A  letter or digit
+//	U  uppercase letter or digit     d  digit
+//	h  lowercase hex digit           H  uppercase hex digit
+//	l  lowercase letter              L  letter
+//	u  uppercase letter              v, V, Z  vowel: lower, mixed, upper
+//	c, C, z  consonant: lower, mixed, upper
+//	p  punctuation ,.;:              b  bracket ()[]{}<>
+//	s  special character             S  any printable ASCII character
+//
+// {n} repeats the previous placeholder, set or literal n times. [...] is a
+// custom set of the characters and placeholders it contains, \ makes the
+// next character literal, and characters other than letters are literal.
+type Pattern struct {
+	src   string
+	slots []patternSlot
+}
+
+// CompilePattern parses a KeePass-style password pattern.
+func CompilePattern(src string) (*Pattern, error) {
+	p := &Pattern{src: src}
+	runes := []rune(src)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("pattern %q ends with an unfinished escape", src)
+			}
+			i++
+			p.slots = append(p.slots, patternSlot{charset: string(runes[i]), literal: true})
+		case r == '[':
+			end, slot, err := parseCustomSet(runes, i)
+			if err != nil {
+				return nil, fmt.Errorf("pattern %q: %w", src, err)
+			}
+			i = end
+			p.slots = append(p.slots, slot)
+		case r == '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("pattern %q has an unclosed {", src)
+			}
+			n, err := strconv.Atoi(string(runes[i+1 : end]))
+			if err != nil || n < 1 || n > maxPatternRepeat {
+				return nil, fmt.Errorf("pattern %q: repeat count {%s} must be between 1 and %d", src, string(runes[i+1:end]), maxPatternRepeat)
+			}
+			if len(p.slots) == 0 {
+				return nil, fmt.Errorf("pattern %q: {%d} does not follow anything to repeat", src, n)
+			}
+			last := p.slots[len(p.slots)-1]
+			for ; n > 1; n-- {
+				p.slots = append(p.slots, last)
+			}
+			i = end
+		case patternPlaceholders[r] != "":
+			p.slots = append(p.slots, patternSlot{charset: patternPlaceholders[r], placeholder: true})
+		case isASCIILetter(r):
+			return nil, fmt.Errorf("pattern %q: unknown placeholder %q (write \\%c for a literal %c)", src, r, r, r)
+		default:
+			p.slots = append(p.slots, patternSlot{charset: string(r), literal: true})
+		}
+	}
+	if len(p.slots) == 0 {
+		return nil, fmt.Errorf("pattern must not be empty")
+	}
+	return p, nil
+}
+
+// parseCustomSet parses the [...] set starting at runes[start] and returns
+// the index of its closing bracket.
+func parseCustomSet(runes []rune, start int) (int, patternSlot, error) {
+	var set strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ']':
+			if set.Len() == 0 {
+				return 0, patternSlot{}, fmt.Errorf("empty character set []")
+			}
+			return i, patternSlot{charset: dedupe(set.String())}, nil
+		case r == '\\' && i+1 < len(runes):
+			i++
+			set.WriteRune(runes[i])
+		case patternPlaceholders[r] != "":
+			set.WriteString(patternPlaceholders[r])
+		case isASCIILetter(r):
+			return 0, patternSlot{}, fmt.Errorf("unknown placeholder %q in a character set", r)
+		default:
+			set.WriteRune(r)
+		}
+	}
+	return 0, patternSlot{}, fmt.Errorf("unclosed [")
+}
+
+func isASCIILetter(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+}
+
+// dedupe removes repeated characters from s, so every character of a
+// custom set is equally likely.
+func dedupe(s string) string {
+	seen := make(map[rune]bool)
+	return strings.Map(func(r rune) rune {
+		if seen[r] {
+			return -1
+		}
+		seen[r] = true
+		return r
+	}, s)
+}
+
+// String returns the source of the pattern.
+func (p *Pattern) String() string {
+	return p.src
+}
+
+// Len returns the length of the passwords the pattern produces.
+func (p *Pattern) Len() int {
+	return len(p.slots)
+}
+
+// charsets returns the characters each position draws from under the
+// options: look-alikes are removed from placeholder classes, and excluded
+// or filtered characters from every set. Literals are kept as written.
+func (p *Pattern) charsets(o Options) ([]string, error) {
+	similar := o.ExcludedSimilar()
+	sets := make([]string, len(p.slots))
+	for i, slot := range p.slots {
+		set := slot.charset
+		if !slot.literal {
+			if slot.placeholder {
+				set = stripChars(set, similar)
+			}
+			set = strings.Map(func(r rune) rune {
+				if !o.allows(r) {
+					return -1
+				}
+				return r
+			}, set)
+			if set == "" {
+				return nil, fmt.Errorf("position %d of pattern %q has no characters left", i+1, p.src)
+			}
+		}
+		sets[i] = set
+	}
+	return sets, nil
+}
+
+// generate draws one character per position.
+func (p *Pattern) generate(o Options) (string, error) {
+	sets, err := p.charsets(o)
+	if err != nil {
+		return "", err
+	}
+	password := make([]rune, len(sets))
+	for i, set := range sets {
+		if password[i], err = getRandomRune(o.random(), []rune(set)); err != nil {
+			return "", err
+		}
+	}
+	return string(password), nil
+}
+
+// entropy returns the exact entropy in bits of the pattern's passwords
+// under the options.
+func (p *Pattern) entropy(o Options) (float64, error) {
+	sets, err := p.charsets(o)
+	if err != nil {
+		return 0, err
+	}
+	var bits float64
+	for _, set := range sets {
+		bits += math.Log2(float64(len([]rune(set))))
+	}
+	return bits, nil
+}
+
+// WithPattern generates passwords from a pattern instead of the character
+// sets. The length, character set and minimum options do not apply, while
+// the ambiguity level, exclusions and filters still do.
+func WithPattern(p *Pattern) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.Pattern = p
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestCompilePattern tests parsing of placeholders, repeats, sets and literals
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		length  int
+		wantErr bool
+	}{
+		{"uullddss", 8, false},
+		{"A{4}-d{4}-s{2}", 12, false},
+		{"[ab]{3}", 3, false},
+		{`\d\u-d`, 4, false},
+		{"l[d_]", 2, false},
+		{"", 0, true},
+		{"x", 0, true},
+		{"{3}", 0, true},
+		{"d{0}", 0, true},
+		{"d{x}", 0, true},
+		{"d{2", 0, true},
+		{"[ab", 0, true},
+		{"[]", 0, true},
+		{"[q]", 0, true},
+		{`d\`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			p, err := CompilePattern(tt.pattern)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.pattern)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Len() != tt.length {
+				t.Errorf("Len() = %d, want %d", p.Len(), tt.length)
+			}
+			if p.String() != tt.pattern {
+				t.Errorf("String() = %q, want %q", p.String(), tt.pattern)
+			}
+		})
+	}
+}
+
+// TestWithPattern tests that every position is drawn from its class
+func TestWithPattern(t *testing.T) {
+	p, err := CompilePattern(`u{2}-d{3}[\x\y]\l`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGenerator(WithPattern(p), WithAmbiguity(AmbiguityNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 8 {
+			t.Fatalf("Password %q has %d characters, want 8", password, len(password))
+		}
+		want := []string{allUppercase, allUppercase, "-", allNumbers, allNumbers, allNumbers, "xy", "l"}
+		for j, set := range want {
+			if !strings.ContainsRune(set, rune(password[j])) {
+				t.Errorf("Password %q has %q at %d, want one of %q", password, password[j], j, set)
+			}
+		}
+	}
+}
+
+// TestPatternEntropy tests that entropy sums the size of every position
+func TestPatternEntropy(t *testing.T) {
+	p, err := CompilePattern("d{4}-h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGenerator(WithPattern(p), WithAmbiguity(AmbiguityNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bits, err := g.Entropy("1234-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 4*math.Log2(10) + 4; math.Abs(bits-want) > 1e-9 {
+		t.Errorf("Entropy = %f, want %f", bits, want)
+	}
+}
+
+// TestPatternExclusions tests that look-alikes and exclusions shrink the
+// placeholder classes and that an emptied position is an error
+func TestPatternExclusions(t *testing.T) {
+	p, err := CompilePattern("d{8}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGenerator(WithPattern(p), WithExclude("2345"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.ContainsAny(password, "0123452") {
+			t.Errorf("Password %q contains an excluded digit", password)
+		}
+	}
+
+	p, err = CompilePattern("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewGenerator(WithPattern(p), WithExclude(",.;:")); err == nil {
+		t.Error("Expected error for a position with no characters left")
+	}
+}
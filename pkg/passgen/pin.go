@@ -0,0 +1,123 @@
+package passgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Digits are the characters of a PIN. PINs are typed on keypads rather than
+// read from a screen, so 0 and 1 are kept.
+const Digits = allNumbers
+
+// MinPINLength is the shortest PIN GeneratePIN produces.
+const MinPINLength = 4
+
+// commonPINs are PINs that are far more frequent in real use than their
+// structure suggests, e.g. the middle column of a keypad.
+var commonPINs = map[string]bool{
+	"2580": true, "0852": true, "1004": true, "1379": true, "1397": true,
+}
+
+// WeakPIN returns why a PIN would be among the first ones guessed, or ""
+// if it is not: a single repeated digit or short repeating pattern (0000,
+// 1212), a run of consecutive digits (1234, 9876), a four-digit year
+// (1987) or another well-known PIN.
+func WeakPIN(pin string) string {
+	if commonPINs[pin] {
+		return "is a well-known PIN"
+	}
+	if len(pin) == 4 {
+		if year, err := strconv.Atoi(pin); err == nil && year >= 1900 && year <= 2099 {
+			return "looks like a year"
+		}
+	}
+	for period := 1; period <= len(pin)/2; period++ {
+		if repeatsWithPeriod(pin, period) {
+			if period == 1 {
+				return "repeats one digit"
+			}
+			return "repeats a short pattern"
+		}
+	}
+	if len(pin) > 1 {
+		step := int(pin[1]) - int(pin[0])
+		if step == 1 || step == -1 {
+			i := 2
+			for i < len(pin) && int(pin[i])-int(pin[i-1]) == step {
+				i++
+			}
+			if i == len(pin) {
+				return "is a run of consecutive digits"
+			}
+		}
+	}
+	return ""
+}
+
+// repeatsWithPeriod reports whether s consists of its first period bytes
+// repeated, the last repetition possibly cut short.
+func repeatsWithPeriod(s string, period int) bool {
+	for i := period; i < len(s); i++ {
+		if s[i] != s[i-period] {
+			return false
+		}
+	}
+	return true
+}
+
+// WithoutWeakPINs rejects the passwords WeakPIN reports.
+func WithoutWeakPINs() GeneratorOption {
+	return func(g *Generator) error {
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if reason := WeakPIN(password); reason != "" {
+				return Reject("weak PIN", "PIN %s", reason)
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// NewPINGenerator returns a Generator of numeric PINs of every digit,
+// including 0 and 1, that rejects the weak PINs WeakPIN reports.
+func NewPINGenerator(length int) (*Generator, error) {
+	if length < MinPINLength {
+		return nil, fmt.Errorf("PIN length must be at least %d", MinPINLength)
+	}
+	return NewGenerator(
+		WithLength(length),
+		WithAmbiguity(AmbiguityNone),
+		WithoutClass(ClassUpper),
+		WithoutClass(ClassLower),
+		WithoutWeakPINs(),
+	)
+}
+
+// digitWords are the spoken names of the digits.
+var digitWords = [10]string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine"}
+
+// SpellDigits writes the digits of s as words to read out over the phone,
+// such as "seven three nine two" for 7392. Other characters, such as the
+// separators of Group, mark a pause between the digits around them and are
+// written as a comma, so "7392 5184" becomes "seven three nine two, five
+// one eight four".
+func SpellDigits(s string) string {
+	var b strings.Builder
+	pause := false
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			pause = b.Len() > 0
+			continue
+		}
+		switch {
+		case pause:
+			b.WriteString(", ")
+		case b.Len() > 0:
+			b.WriteByte(' ')
+		}
+		pause = false
+		b.WriteString(digitWords[r-'0'])
+	}
+	return b.String()
+}
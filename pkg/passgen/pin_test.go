@@ -0,0 +1,83 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWeakPIN tests which PINs count as trivially guessable
+func TestWeakPIN(t *testing.T) {
+	tests := []struct {
+		pin  string
+		weak bool
+	}{
+		{"0000", true},
+		{"1234", true},
+		{"9876", true},
+		{"123456", true},
+		{"1212", true},
+		{"123123", true},
+		{"12121", true},
+		{"1987", true},
+		{"2024", true},
+		{"2580", true},
+		{"8206", false},
+		{"1235", false},
+		{"2100", false},
+		{"739154", false},
+		{"1122", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pin, func(t *testing.T) {
+			if reason := WeakPIN(tt.pin); (reason != "") != tt.weak {
+				t.Errorf("WeakPIN(%q) = %q, want weak %v", tt.pin, reason, tt.weak)
+			}
+		})
+	}
+}
+
+// TestNewPINGenerator tests that PINs use every digit and nothing else
+func TestNewPINGenerator(t *testing.T) {
+	g, err := NewPINGenerator(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Options().Charsets(); len(got) != 1 || got[0] != Digits {
+		t.Errorf("Charsets = %q, want only %q", got, Digits)
+	}
+	for i := 0; i < 50; i++ {
+		pin, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pin) != 6 || strings.Trim(pin, Digits) != "" {
+			t.Errorf("Invalid PIN %q", pin)
+		}
+		if reason := WeakPIN(pin); reason != "" {
+			t.Errorf("Generated weak PIN %q: %s", pin, reason)
+		}
+	}
+	if _, err := NewPINGenerator(3); err == nil {
+		t.Error("Expected error for a 3-digit PIN")
+	}
+}
+
+// TestSpellDigits tests reading digits and their groups as words
+func TestSpellDigits(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"7392", "seven three nine two"},
+		{"0815", "zero eight one five"},
+		{"7392 5184", "seven three nine two, five one eight four"},
+		{"12--34", "one two, three four"},
+		{"-12-", "one two"},
+	}
+	for _, tt := range tests {
+		if got := SpellDigits(tt.s); got != tt.want {
+			t.Errorf("SpellDigits(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrRejected is returned by a PostGenerateFunc to discard the candidate
+// password and have the pipeline generate a new one.
+var ErrRejected = errors.New("password rejected")
+
+// RejectError is an ErrRejected that names the filter which rejected the
+// candidate, so Pipeline.Stats can count rejections by filter.
+type RejectError struct {
+	// Filter names the filter, e.g. "blocklist" or "keyboard walk".
+	Filter string
+	// Reason says what was wrong with this candidate.
+	Reason string
+}
+
+// Reject returns a RejectError for filter, with a reason formatted as by
+// fmt.Sprintf.
+func Reject(filter, format string, args ...any) error {
+	return &RejectError{Filter: filter, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (e *RejectError) Error() string {
+	return ErrRejected.Error() + ": " + e.Reason
+}
+
+func (e *RejectError) Unwrap() error {
+	return ErrRejected
+}
+
+// Filters the pipeline applies itself, and the one counting rejections
+// that are not a RejectError.
+const (
+	FilterCharacters = "character filter"
+	FilterMinimums   = "class minimums"
+	FilterOther      = "other"
+)
+
+// RejectStats counts the candidates a Pipeline generated and why it
+// rejected them.
+type RejectStats struct {
+	Candidates int
+	// Rejected counts the rejected candidates by filter. A candidate is
+	// counted once, for the first filter rejecting it.
+	Rejected map[string]int
+	// Elapsed is the time spent generating and filtering candidates.
+	Elapsed time.Duration
+}
+
+// TotalRejected returns the number of rejected candidates.
+func (s RejectStats) TotalRejected() int {
+	n := 0
+	for _, count := range s.Rejected {
+		n += count
+	}
+	return n
+}
+
+// EntropyLoss estimates the bits of entropy the filters cost: rejecting a
+// fraction f of the candidates leaves a keyspace of 1-f the size, or
+// -log2(1-f) bits less.
+func (s RejectStats) EntropyLoss() float64 {
+	if s.Candidates == 0 {
+		return 0
+	}
+	accepted := s.Candidates - s.TotalRejected()
+	if accepted == 0 {
+		return math.Inf(1)
+	}
+	return -math.Log2(float64(accepted) / float64(s.Candidates))
+}
+
+// DefaultMaxAttempts is the number of candidates a Pipeline generates before
+// giving up when every one of them is rejected.
+const DefaultMaxAttempts = 100
+
+// Options describes the password a Pipeline should generate.
+type Options struct {
+	Length         int
+	IncludeSpecial bool
+
+	// AllowSimilar keeps the similar-looking characters (0, O, I, l, 1)
+	// that are excluded by default.
+	AllowSimilar bool
+
+	// Ambiguity selects which look-alikes are excluded when AllowSimilar
+	// is not set.
+	Ambiguity Ambiguity
+
+	// ExtraCharsets are additional character sets the password must draw
+	// at least one character from.
+	ExtraCharsets []string
+
+	// Exclude lists characters that must never appear, e.g. ones a site
+	// forbids. They are removed from every character set, and a set left
+	// empty is no longer required.
+	Exclude string
+
+	// IncludeTables, when set, limits passwords to characters in at least
+	// one of the tables, e.g. Unicode categories or scripts.
+	IncludeTables []*unicode.RangeTable
+
+	// ExcludeTables keeps the characters of every table out of passwords.
+	ExcludeTables []*unicode.RangeTable
+
+	// MinCounts is the minimum number of characters of each class, indexed
+	// by CharClass. Every character set in use contributes at least one
+	// character regardless.
+	MinCounts [ClassSpecial + 1]int
+
+	// Disabled removes whole character classes, indexed by CharClass, from
+	// every character set. A class set here is never required.
+	Disabled [ClassSpecial + 1]bool
+
+	// Model, when set, samples word-like passwords from a Markov chain
+	// instead of drawing from the character sets.
+	Model *MarkovModel
+
+	// Pronounceable, when set, alternates consonants and vowels instead
+	// of drawing from the character sets. It is ignored when Model is set.
+	Pronounceable bool
+
+	// AppleStyle generates passwords in the hyphenated format of
+	// GenerateAppleStyle instead of drawing from the character sets.
+	AppleStyle bool
+
+	// Pattern, when set, generates passwords from a template instead of
+	// the character sets. It is ignored when Model is set.
+	Pattern *Pattern
+
+	// AnchorWords, when set, puts one of the words at a random position
+	// among characters from the sets, see WithAnchorWord. It is ignored
+	// when Model, Pattern, AppleStyle or Pronounceable is set.
+	AnchorWords []string
+
+	// DualControl, when set, draws the two halves of the password from the
+	// sets on their own, see WithDualControl. It is ignored in the same
+	// cases as AnchorWords, and when AnchorWords is set.
+	DualControl bool
+
+	// Random, when set, replaces crypto/rand as the source of randomness
+	// for the character sets and patterns, e.g. a generator seeded from
+	// dice rolls. It cannot be combined with Model, Pronounceable or
+	// AppleStyle.
+	Random io.Reader
+}
+
+// random returns the source of randomness of the options.
+func (o Options) random() io.Reader {
+	if o.Random != nil {
+		return o.Random
+	}
+	return rand.Reader
+}
+
+// Charsets returns every character set the options require.
+func (o Options) Charsets() []string {
+	charsets, _ := o.requiredSets()
+	return charsets
+}
+
+// MinLength returns the shortest length that leaves room for the minimum
+// number of characters of every set the options require.
+func (o Options) MinLength() int {
+	_, minimums := o.requiredSets()
+	n := 0
+	for _, m := range minimums {
+		n += m
+	}
+	return n
+}
+
+// requiredSets returns the character sets the options require along with
+// how many characters must be drawn from each.
+func (o Options) requiredSets() ([]string, []int) {
+	charsets := o.similarCharsets()
+	minimums := []int{o.minCount(ClassUpper), o.minCount(ClassLower), o.minCount(ClassDigit)}
+	if o.IncludeSpecial {
+		charsets = append(charsets, Special)
+		minimums = append(minimums, o.minCount(ClassSpecial))
+	}
+	for _, charset := range o.ExtraCharsets {
+		charsets = append(charsets, charset)
+		minimums = append(minimums, 1)
+	}
+	if !o.filtered() {
+		return charsets, minimums
+	}
+
+	keptSets, keptMins := charsets[:0], minimums[:0]
+	for i, charset := range charsets {
+		charset = strings.Map(func(r rune) rune {
+			if !o.allows(r) {
+				return -1
+			}
+			return r
+		}, charset)
+		if charset != "" {
+			keptSets = append(keptSets, charset)
+			keptMins = append(keptMins, minimums[i])
+		}
+	}
+	return keptSets, keptMins
+}
+
+// minCount returns how many characters of the class the built-in set for
+// it contributes.
+func (o Options) minCount(class CharClass) int {
+	return max(o.MinCounts[class], 1)
+}
+
+// meetsMinimums reports whether password has at least MinCounts characters
+// of every class.
+func (o Options) meetsMinimums(password string) bool {
+	var counts [ClassSpecial + 1]int
+	for _, r := range password {
+		counts[ClassOf(r)]++
+	}
+	for class, n := range o.MinCounts {
+		if counts[class] < n {
+			return false
+		}
+	}
+	return true
+}
+
+// stripChars removes every character of chars from s.
+func stripChars(s, chars string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(chars, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// generate produces a single unchecked candidate for the options.
+func (o Options) generate() (string, error) {
+	if o.Model != nil {
+		if o.Length < 1 {
+			return "", fmt.Errorf("password length must be at least 1")
+		}
+		return o.Model.Generate(o.Length)
+	}
+	if o.Pattern != nil {
+		return o.Pattern.generate(o)
+	}
+	if o.AppleStyle {
+		return GenerateAppleStyle()
+	}
+	if o.Pronounceable {
+		return GeneratePronounceable(o.Length)
+	}
+	if len(o.AnchorWords) > 0 {
+		return o.generateAnchored()
+	}
+	if o.DualControl {
+		return o.generateDualControl()
+	}
+	charsets, minimums := o.requiredSets()
+	return generateFromCharsets(o.random(), o.Length, charsets, minimums)
+}
+
+// PreValidateFunc inspects or adjusts the options before they are validated.
+// Returning an error aborts generation.
+type PreValidateFunc func(opts *Options) error
+
+// PostGenerateFunc inspects a freshly generated password. Returning
+// ErrRejected (or an error wrapping it) discards the password and generates
+// another one; any other error aborts generation.
+type PostGenerateFunc func(password string) error
+
+// PreOutputFunc transforms a password right before it is handed to the
+// caller for output, e.g. to annotate or redact it.
+type PreOutputFunc func(password string) (string, error)
+
+// Pipeline runs password generation through a chain of hooks so embedders
+// can add their own checks without modifying the generator.
+type Pipeline struct {
+	// MaxAttempts bounds how many rejected candidates are tolerated per
+	// password. Zero means DefaultMaxAttempts.
+	MaxAttempts int
+
+	preValidate  []PreValidateFunc
+	postGenerate []PostGenerateFunc
+	preOutput    []PreOutputFunc
+
+	mu    sync.Mutex
+	stats RejectStats
+}
+
+// UsePreValidate appends hooks that run before the options are validated.
+func (p *Pipeline) UsePreValidate(hooks ...PreValidateFunc) {
+	p.preValidate = append(p.preValidate, hooks...)
+}
+
+// UsePostGenerate appends hooks that run on every generated candidate.
+func (p *Pipeline) UsePostGenerate(hooks ...PostGenerateFunc) {
+	p.postGenerate = append(p.postGenerate, hooks...)
+}
+
+// UsePreOutput appends hooks that run on each accepted password in Output.
+func (p *Pipeline) UsePreOutput(hooks ...PreOutputFunc) {
+	p.preOutput = append(p.preOutput, hooks...)
+}
+
+// Generate runs the pre-validate hooks, generates a password and passes it
+// through the post-generate hooks, regenerating rejected candidates.
+func (p *Pipeline) Generate(opts Options) (string, error) {
+	for _, hook := range p.preValidate {
+		if err := hook(&opts); err != nil {
+			return "", err
+		}
+	}
+
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	start := time.Now()
+	defer func() {
+		p.mu.Lock()
+		p.stats.Elapsed += time.Since(start)
+		p.mu.Unlock()
+	}()
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		password, err := opts.generate()
+		if err != nil {
+			return "", err
+		}
+		// Markov models are not limited to the character sets or
+		// their minimums
+		if opts.filtered() && !opts.allowsAll(password) {
+			p.count(FilterCharacters)
+			continue
+		}
+		if !opts.meetsMinimums(password) {
+			p.count(FilterMinimums)
+			continue
+		}
+		if err := p.runPostGenerate(password); err != nil {
+			var reject *RejectError
+			switch {
+			case errors.As(err, &reject):
+				p.count(reject.Filter)
+				continue
+			case errors.Is(err, ErrRejected):
+				p.count(FilterOther)
+				continue
+			}
+			return "", err
+		}
+		p.count("")
+		return password, nil
+	}
+	return "", fmt.Errorf("all %d candidate passwords were rejected", maxAttempts)
+}
+
+// count records a candidate, rejected by filter unless it is empty.
+func (p *Pipeline) count(filter string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Candidates++
+	if filter != "" {
+		if p.stats.Rejected == nil {
+			p.stats.Rejected = make(map[string]int)
+		}
+		p.stats.Rejected[filter]++
+	}
+}
+
+// Stats returns the candidates generated so far and why they were
+// rejected.
+func (p *Pipeline) Stats() RejectStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Rejected = make(map[string]int, len(p.stats.Rejected))
+	for filter, n := range p.stats.Rejected {
+		s.Rejected[filter] = n
+	}
+	return s
+}
+
+func (p *Pipeline) runPostGenerate(password string) error {
+	for _, hook := range p.postGenerate {
+		if err := hook(password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Output passes an accepted password through the pre-output hooks and
+// returns the text that should be written out.
+func (p *Pipeline) Output(password string) (string, error) {
+	var err error
+	for _, hook := range p.preOutput {
+		password, err = hook(password)
+		if err != nil {
+			return "", err
+		}
+	}
+	return password, nil
+}
@@ -0,0 +1,182 @@
+package passgen
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestPipelineWithoutHooks tests that an empty pipeline behaves like GeneratePassword
+func TestPipelineWithoutHooks(t *testing.T) {
+	p := &Pipeline{}
+	password, err := p.Generate(Options{Length: 16, IncludeSpecial: true})
+	if err != nil {
+		t.Fatalf("Failed to generate password: %v", err)
+	}
+	if len(password) != 16 {
+		t.Errorf("Expected password length 16, got %d", len(password))
+	}
+}
+
+// TestPipelinePreValidate tests that pre-validate hooks can adjust and veto options
+func TestPipelinePreValidate(t *testing.T) {
+	p := &Pipeline{}
+	p.UsePreValidate(func(opts *Options) error {
+		opts.Length = 20
+		return nil
+	})
+	password, err := p.Generate(Options{Length: 8})
+	if err != nil {
+		t.Fatalf("Failed to generate password: %v", err)
+	}
+	if len(password) != 20 {
+		t.Errorf("Expected hook to raise length to 20, got %d", len(password))
+	}
+
+	veto := errors.New("policy forbids short passwords")
+	p.UsePreValidate(func(opts *Options) error { return veto })
+	if _, err := p.Generate(Options{Length: 8}); !errors.Is(err, veto) {
+		t.Errorf("Expected veto error, got %v", err)
+	}
+}
+
+// TestPipelinePostGenerateRejects tests that rejected candidates are regenerated
+func TestPipelinePostGenerateRejects(t *testing.T) {
+	p := &Pipeline{}
+	calls := 0
+	p.UsePostGenerate(func(password string) error {
+		calls++
+		if calls < 3 {
+			return ErrRejected
+		}
+		return nil
+	})
+
+	if _, err := p.Generate(Options{Length: 12}); err != nil {
+		t.Fatalf("Failed to generate password: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 candidates to be checked, got %d", calls)
+	}
+}
+
+// TestPipelineStats tests counting candidates and rejections by filter
+func TestPipelineStats(t *testing.T) {
+	p := &Pipeline{}
+	calls := 0
+	p.UsePostGenerate(func(password string) error {
+		calls++
+		switch calls {
+		case 1, 2:
+			return Reject("test filter", "candidate %d", calls)
+		case 3:
+			return ErrRejected
+		}
+		return nil
+	})
+	for range 2 {
+		if _, err := p.Generate(Options{Length: 12}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := p.Stats()
+	if s.Candidates != 5 || s.TotalRejected() != 3 || s.Rejected["test filter"] != 2 || s.Rejected[FilterOther] != 1 {
+		t.Errorf("Stats() = %+v", s)
+	}
+	if loss := s.EntropyLoss(); math.Abs(loss-math.Log2(5.0/2)) > 1e-9 {
+		t.Errorf("EntropyLoss() = %v, want %v", loss, math.Log2(5.0/2))
+	}
+	s.Rejected["test filter"] = 0
+	if p.Stats().Rejected["test filter"] != 2 {
+		t.Error("Stats() shares its map with the pipeline")
+	}
+
+	err := Reject("blocklist", "on the blocklist")
+	if !errors.Is(err, ErrRejected) || err.Error() != "password rejected: on the blocklist" {
+		t.Errorf("Reject() = %v", err)
+	}
+}
+
+// TestPipelineMaxAttempts tests that generation gives up when every candidate is rejected
+func TestPipelineMaxAttempts(t *testing.T) {
+	p := &Pipeline{MaxAttempts: 5}
+	calls := 0
+	p.UsePostGenerate(func(password string) error {
+		calls++
+		return ErrRejected
+	})
+
+	if _, err := p.Generate(Options{Length: 12}); err == nil {
+		t.Error("Expected error when all candidates are rejected")
+	}
+	if calls != 5 {
+		t.Errorf("Expected 5 attempts, got %d", calls)
+	}
+}
+
+// TestPipelinePostGenerateError tests that non-rejection errors abort generation
+func TestPipelinePostGenerateError(t *testing.T) {
+	p := &Pipeline{}
+	boom := errors.New("breach service unavailable")
+	p.UsePostGenerate(func(password string) error { return boom })
+
+	if _, err := p.Generate(Options{Length: 12}); !errors.Is(err, boom) {
+		t.Errorf("Expected hook error, got %v", err)
+	}
+}
+
+// TestPipelineOutput tests that pre-output hooks run in order
+func TestPipelineOutput(t *testing.T) {
+	p := &Pipeline{}
+	p.UsePreOutput(
+		func(password string) (string, error) { return strings.ToLower(password), nil },
+		func(password string) (string, error) { return "[" + password + "]", nil },
+	)
+
+	out, err := p.Output("AbC")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out != "[abc]" {
+		t.Errorf("Expected [abc], got %s", out)
+	}
+}
+
+// TestPipelineAllowSimilar tests that similar characters can be re-enabled
+func TestPipelineAllowSimilar(t *testing.T) {
+	p := &Pipeline{}
+	found := false
+	for i := 0; i < 50 && !found; i++ {
+		password, err := p.Generate(Options{Length: 64, AllowSimilar: true})
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		found = strings.ContainsAny(password, "0OIl1")
+	}
+	if !found {
+		t.Error("Expected similar characters to appear when allowed")
+	}
+}
+
+// TestOptionsMinLength tests the length the set minimums need
+func TestOptionsMinLength(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want int
+	}{
+		{"defaults", Options{}, 3},
+		{"special", Options{IncludeSpecial: true}, 4},
+		{"class minimum", Options{MinCounts: [ClassSpecial + 1]int{ClassDigit: 6}}, 8},
+		{"extra charset", Options{ExtraCharsets: []string{"äöü"}}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.MinLength(); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestGeneratePronounceable tests that consonants and vowels alternate
+func TestGeneratePronounceable(t *testing.T) {
+	for _, length := range []int{1, 2, 7, 16} {
+		password, err := GeneratePronounceable(length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != length {
+			t.Errorf("Got %d characters, want %d", len(password), length)
+		}
+		for i, c := range password {
+			want := PronounceableConsonants
+			if i%2 == 1 {
+				want = PronounceableVowels
+			}
+			if !strings.ContainsRune(want, c) {
+				t.Errorf("Password %q has %q at %d, want one of %q", password, c, i, want)
+			}
+		}
+	}
+	if _, err := GeneratePronounceable(0); err == nil {
+		t.Error("Expected error for length 0")
+	}
+}
+
+// TestPronounceableEntropy tests the keyspace of pronounceable passwords
+func TestPronounceableEntropy(t *testing.T) {
+	want := 8*4 + 8*math.Log2(5)
+	if got := PronounceableEntropy(16); math.Abs(got-want) > 1e-9 {
+		t.Errorf("PronounceableEntropy(16) = %f, want %f", got, want)
+	}
+	if got := PronounceableEntropy(3); math.Abs(got-(8+math.Log2(5))) > 1e-9 {
+		t.Errorf("PronounceableEntropy(3) = %f", got)
+	}
+}
+
+// TestWithPronounceable tests the generator option and its entropy
+func TestWithPronounceable(t *testing.T) {
+	g, err := NewGenerator(WithLength(10), WithPronounceable(), WithExclude("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 10 || strings.Contains(password, "z") {
+			t.Errorf("Unexpected password %q", password)
+		}
+		bits, err := g.Entropy(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bits != PronounceableEntropy(10) {
+			t.Errorf("Entropy = %f, want %f", bits, PronounceableEntropy(10))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"time"
+)
+
+// warmUpBytes is how much entropy CheckRandom reads, enough to cover a
+// typical batch of passwords.
+const warmUpBytes = 256
+
+// CheckRandom reads from the system random number generator and fails if it
+// does not answer within timeout. Early in boot, or in VMs without an
+// entropy source, reads can block for a long time; checking up front lets
+// callers fail fast instead of hanging halfway through a batch.
+func CheckRandom(timeout time.Duration) error {
+	return checkRandom(rand.Reader, timeout)
+}
+
+func checkRandom(r io.Reader, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, warmUpBytes)
+		_, err := io.ReadFull(r, buf)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("system random number generator failed: %v", err)
+		}
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("system random number generator did not respond within %v", timeout)
+	}
+}
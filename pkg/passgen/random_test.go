@@ -0,0 +1,52 @@
+package passgen
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingReader never returns, like an unseeded RNG early in boot
+type blockingReader struct{ release chan struct{} }
+
+func (b blockingReader) Read(p []byte) (int, error) {
+	<-b.release
+	return 0, io.EOF
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("device unavailable")
+}
+
+// TestCheckRandom tests that the system RNG answers promptly
+func TestCheckRandom(t *testing.T) {
+	if err := CheckRandom(5 * time.Second); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestCheckRandomTimeout tests failing fast on a blocked RNG
+func TestCheckRandomTimeout(t *testing.T) {
+	r := blockingReader{release: make(chan struct{})}
+	defer close(r.release)
+
+	start := time.Now()
+	err := checkRandom(r, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "did not respond") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Timeout took too long: %v", elapsed)
+	}
+}
+
+// TestCheckRandomError tests that read errors are reported
+func TestCheckRandomError(t *testing.T) {
+	if err := checkRandom(failingReader{}, time.Second); err == nil {
+		t.Error("Expected error from failing reader")
+	}
+}
@@ -0,0 +1,659 @@
+package passgen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rule is a compiled acceptance rule written in a small CEL-like expression
+// language. A rule is evaluated against every candidate password and must
+// yield true for the candidate to be accepted.
+//
+// Available variables:
+//
+//	password   the candidate itself (string)
+//	length     number of characters
+//	upper      number of uppercase letters
+//	lower      number of lowercase letters
+//	digits     number of digits
+//	special    number of characters that are not letters or digits
+//	unique     number of distinct characters
+//	maxRepeat  highest number of times any single character appears
+//	maxRun     longest run of the same character in a row
+//	keyboardWalk  longest straight keyboard walk, see KeyboardWalk
+//	sequence   longest run of consecutive letters or digits, see Sequence
+//
+// Expressions support integer and string literals, true/false, the
+// operators ! - + * / % < <= > >= == != && || and parentheses, size(s), and
+// the string methods contains, startsWith, endsWith and matches (a regular
+// expression), e.g.
+//
+//	maxRepeat <= 2 && !password.contains("pass")
+type Rule struct {
+	source string
+	root   ruleNode
+}
+
+// CompileRule parses and type-checks a rule expression.
+func CompileRule(expr string) (*Rule, error) {
+	p := &ruleParser{lex: ruleLexer{src: expr}}
+	p.next()
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %v", expr, err)
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("rule %q: unexpected %q", expr, p.tok.text)
+	}
+	if root.typ() != typeBool {
+		return nil, fmt.Errorf("rule %q: must evaluate to a bool, not %s", expr, root.typ())
+	}
+	return &Rule{source: expr, root: root}, nil
+}
+
+// CompileRules compiles rule expressions given one per line, skipping blank
+// lines. It is convenient for bindings that can only pass a single string.
+func CompileRules(text string) ([]*Rule, error) {
+	var rules []*Rule
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		rule, err := CompileRule(line)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// String returns the source expression of the rule.
+func (r *Rule) String() string {
+	return r.source
+}
+
+// Eval reports whether the password satisfies the rule.
+func (r *Rule) Eval(password string) (bool, error) {
+	v, err := r.root.eval(newRuleEnv(password))
+	if err != nil {
+		return false, fmt.Errorf("rule %q: %v", r.source, err)
+	}
+	return v.(bool), nil
+}
+
+// Check is a PostGenerateFunc that rejects passwords failing the rule.
+func (r *Rule) Check(password string) error {
+	ok, err := r.Eval(password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return Reject(fmt.Sprintf("rule %q", r.source), "fails rule %q", r.source)
+	}
+	return nil
+}
+
+// ruleEnv holds the variables a rule can refer to.
+type ruleEnv map[string]any
+
+func newRuleEnv(password string) ruleEnv {
+	env := ruleEnv{"password": password}
+	var length, upper, lower, digits, special, maxRepeat, maxRun, run int64
+	seen := make(map[rune]int64)
+	var prev rune
+	for i, r := range password {
+		length++
+		switch ClassOf(r) {
+		case ClassUpper:
+			upper++
+		case ClassLower:
+			lower++
+		case ClassDigit:
+			digits++
+		default:
+			special++
+		}
+
+		seen[r]++
+		maxRepeat = max(maxRepeat, seen[r])
+
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		maxRun = max(maxRun, run)
+		prev = r
+	}
+	env["length"] = length
+	env["upper"] = upper
+	env["lower"] = lower
+	env["digits"] = digits
+	env["special"] = special
+	env["unique"] = int64(len(seen))
+	env["maxRepeat"] = maxRepeat
+	env["maxRun"] = maxRun
+	env["keyboardWalk"] = int64(KeyboardWalk(password))
+	env["sequence"] = int64(Sequence(password))
+	return env
+}
+
+// ruleVariables maps each variable to its type.
+var ruleVariables = map[string]ruleType{
+	"password":  typeString,
+	"length":    typeInt,
+	"upper":     typeInt,
+	"lower":     typeInt,
+	"digits":    typeInt,
+	"special":   typeInt,
+	"unique":    typeInt,
+	"maxRepeat": typeInt,
+	"maxRun":    typeInt,
+	"sequence":  typeInt,
+
+	"keyboardWalk": typeInt,
+}
+
+type ruleType int
+
+const (
+	typeInt ruleType = iota
+	typeString
+	typeBool
+)
+
+func (t ruleType) String() string {
+	switch t {
+	case typeInt:
+		return "int"
+	case typeString:
+		return "string"
+	default:
+		return "bool"
+	}
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokInt
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type ruleLexer struct {
+	src string
+	pos int
+}
+
+func (l *ruleLexer) next() (token, error) {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t' || l.src[l.pos] == '\n') {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.src) && l.src[l.pos] >= '0' && l.src[l.pos] <= '9' {
+			l.pos++
+		}
+		return token{kind: tokInt, text: l.src[start:l.pos]}, nil
+	case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+		for l.pos < len(l.src) && isIdentByte(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos]}, nil
+	case c == '"' || c == '\'':
+		l.pos++
+		var b strings.Builder
+		for l.pos < len(l.src) && l.src[l.pos] != c {
+			if l.src[l.pos] == '\\' && l.pos+1 < len(l.src) {
+				l.pos++
+			}
+			b.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return token{}, fmt.Errorf("unterminated string")
+		}
+		l.pos++
+		return token{kind: tokString, text: b.String()}, nil
+	}
+
+	for _, op := range []string{"&&", "||", "==", "!=", "<=", ">="} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op}, nil
+		}
+	}
+	if strings.ContainsRune("!+-*/%<>().,", rune(c)) {
+		l.pos++
+		return token{kind: tokOp, text: string(c)}, nil
+	}
+	return token{}, fmt.Errorf("unexpected character %q", c)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+// Parser
+
+type ruleParser struct {
+	lex ruleLexer
+	tok token
+	err error
+}
+
+func (p *ruleParser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+// binaryPrecedence returns the binding power of a binary operator, or 0.
+func binaryPrecedence(op string) int {
+	switch op {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "==", "!=", "<", "<=", ">", ">=":
+		return 3
+	case "+", "-":
+		return 4
+	case "*", "/", "%":
+		return 5
+	}
+	return 0
+}
+
+func (p *ruleParser) parseExpr(minPrec int) (ruleNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp {
+		op := p.tok.text
+		prec := binaryPrecedence(op)
+		if prec == 0 || prec <= minPrec {
+			break
+		}
+		p.next()
+		right, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newBinaryNode(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, p.err
+}
+
+func (p *ruleParser) parseUnary() (ruleNode, error) {
+	if p.tok.kind == tokOp && (p.tok.text == "!" || p.tok.text == "-") {
+		op := p.tok.text
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		want := typeBool
+		if op == "-" {
+			want = typeInt
+		}
+		if operand.typ() != want {
+			return nil, fmt.Errorf("operator %s needs a %s operand, not %s", op, want, operand.typ())
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *ruleParser) parsePostfix() (ruleNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && p.tok.text == "." {
+		p.next()
+		if p.tok.kind != tokIdent {
+			return nil, fmt.Errorf("expected method name after '.'")
+		}
+		method := p.tok.text
+		p.next()
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if node, err = newCallNode(method, append([]ruleNode{node}, args...)); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func (p *ruleParser) parseArgs() ([]ruleNode, error) {
+	if p.tok.kind != tokOp || p.tok.text != "(" {
+		return nil, fmt.Errorf("expected '('")
+	}
+	p.next()
+	var args []ruleNode
+	for !(p.tok.kind == tokOp && p.tok.text == ")") {
+		if len(args) > 0 {
+			if p.tok.kind != tokOp || p.tok.text != "," {
+				return nil, fmt.Errorf("expected ',' or ')'")
+			}
+			p.next()
+		}
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+	return args, p.err
+}
+
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	tok := p.tok
+	switch tok.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		p.next()
+		return &literalNode{value: n, t: typeInt}, nil
+	case tokString:
+		p.next()
+		return &literalNode{value: tok.text, t: typeString}, nil
+	case tokIdent:
+		p.next()
+		switch tok.text {
+		case "true", "false":
+			return &literalNode{value: tok.text == "true", t: typeBool}, nil
+		}
+		if p.tok.kind == tokOp && p.tok.text == "(" {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return newCallNode(tok.text, args)
+		}
+		t, ok := ruleVariables[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q", tok.text)
+		}
+		return &varNode{name: tok.text, t: t}, nil
+	case tokOp:
+		if tok.text == "(" {
+			p.next()
+			node, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokOp || p.tok.text != ")" {
+				return nil, fmt.Errorf("expected ')'")
+			}
+			p.next()
+			return node, p.err
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
+
+// AST
+
+type ruleNode interface {
+	typ() ruleType
+	eval(env ruleEnv) (any, error)
+}
+
+type literalNode struct {
+	value any
+	t     ruleType
+}
+
+func (n *literalNode) typ() ruleType             { return n.t }
+func (n *literalNode) eval(ruleEnv) (any, error) { return n.value, nil }
+
+type varNode struct {
+	name string
+	t    ruleType
+}
+
+func (n *varNode) typ() ruleType                 { return n.t }
+func (n *varNode) eval(env ruleEnv) (any, error) { return env[n.name], nil }
+
+type unaryNode struct {
+	op      string
+	operand ruleNode
+}
+
+func (n *unaryNode) typ() ruleType { return n.operand.typ() }
+
+func (n *unaryNode) eval(env ruleEnv) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !v.(bool), nil
+	}
+	return -v.(int64), nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right ruleNode
+	t           ruleType
+}
+
+func newBinaryNode(op string, left, right ruleNode) (ruleNode, error) {
+	lt, rt := left.typ(), right.typ()
+	n := &binaryNode{op: op, left: left, right: right}
+	switch op {
+	case "&&", "||":
+		if lt != typeBool || rt != typeBool {
+			return nil, fmt.Errorf("operator %s needs bool operands, not %s and %s", op, lt, rt)
+		}
+		n.t = typeBool
+	case "==", "!=":
+		if lt != rt {
+			return nil, fmt.Errorf("cannot compare %s with %s", lt, rt)
+		}
+		n.t = typeBool
+	case "<", "<=", ">", ">=":
+		if lt != rt || lt == typeBool {
+			return nil, fmt.Errorf("operator %s cannot order %s and %s", op, lt, rt)
+		}
+		n.t = typeBool
+	case "+":
+		if lt != rt || lt == typeBool {
+			return nil, fmt.Errorf("operator + cannot add %s and %s", lt, rt)
+		}
+		n.t = lt
+	default:
+		if lt != typeInt || rt != typeInt {
+			return nil, fmt.Errorf("operator %s needs int operands, not %s and %s", op, lt, rt)
+		}
+		n.t = typeInt
+	}
+	return n, nil
+}
+
+func (n *binaryNode) typ() ruleType { return n.t }
+
+func (n *binaryNode) eval(env ruleEnv) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit the logical operators
+	switch n.op {
+	case "&&":
+		if !l.(bool) {
+			return false, nil
+		}
+		return n.right.eval(env)
+	case "||":
+		if l.(bool) {
+			return true, nil
+		}
+		return n.right.eval(env)
+	}
+
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	if ls, ok := l.(string); ok {
+		rs := r.(string)
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		default:
+			return ls >= rs, nil
+		}
+	}
+
+	li, ri := l.(int64), r.(int64)
+	switch n.op {
+	case "+":
+		return li + ri, nil
+	case "-":
+		return li - ri, nil
+	case "*":
+		return li * ri, nil
+	case "/", "%":
+		if ri == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if n.op == "/" {
+			return li / ri, nil
+		}
+		return li % ri, nil
+	case "<":
+		return li < ri, nil
+	case "<=":
+		return li <= ri, nil
+	case ">":
+		return li > ri, nil
+	default:
+		return li >= ri, nil
+	}
+}
+
+type callNode struct {
+	args []ruleNode
+	fn   func(env ruleEnv, args []ruleNode) (any, error)
+	t    ruleType
+}
+
+func (n *callNode) typ() ruleType                 { return n.t }
+func (n *callNode) eval(env ruleEnv) (any, error) { return n.fn(env, n.args) }
+
+// ruleFunctions lists the callable functions with their parameter and
+// result types. Methods receive their receiver as the first argument.
+var ruleFunctions = map[string]struct {
+	params []ruleType
+	result ruleType
+	impl   func(args []any) (any, error)
+}{
+	"size": {[]ruleType{typeString}, typeInt, func(a []any) (any, error) {
+		return int64(len([]rune(a[0].(string)))), nil
+	}},
+	"contains": {[]ruleType{typeString, typeString}, typeBool, func(a []any) (any, error) {
+		return strings.Contains(a[0].(string), a[1].(string)), nil
+	}},
+	"startsWith": {[]ruleType{typeString, typeString}, typeBool, func(a []any) (any, error) {
+		return strings.HasPrefix(a[0].(string), a[1].(string)), nil
+	}},
+	"endsWith": {[]ruleType{typeString, typeString}, typeBool, func(a []any) (any, error) {
+		return strings.HasSuffix(a[0].(string), a[1].(string)), nil
+	}},
+	"matches": {[]ruleType{typeString, typeString}, typeBool, func(a []any) (any, error) {
+		re, err := regexp.Compile(a[1].(string))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(a[0].(string)), nil
+	}},
+}
+
+func newCallNode(name string, args []ruleNode) (ruleNode, error) {
+	fn, ok := ruleFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) != len(fn.params) {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", name, len(fn.params), len(args))
+	}
+	for i, arg := range args {
+		if arg.typ() != fn.params[i] {
+			return nil, fmt.Errorf("%s: argument %d must be %s, not %s", name, i+1, fn.params[i], arg.typ())
+		}
+	}
+
+	// Compile constant regular expressions up front so typos fail early
+	if lit, ok := args[len(args)-1].(*literalNode); ok && name == "matches" {
+		if _, err := regexp.Compile(lit.value.(string)); err != nil {
+			return nil, fmt.Errorf("matches: %v", err)
+		}
+	}
+
+	return &callNode{
+		args: args,
+		t:    fn.result,
+		fn: func(env ruleEnv, args []ruleNode) (any, error) {
+			values := make([]any, len(args))
+			for i, arg := range args {
+				v, err := arg.eval(env)
+				if err != nil {
+					return nil, err
+				}
+				values[i] = v
+			}
+			return fn.impl(values)
+		},
+	}, nil
+}
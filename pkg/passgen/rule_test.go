@@ -0,0 +1,127 @@
+package passgen
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRuleEval tests evaluating rules against fixed passwords
+func TestRuleEval(t *testing.T) {
+	tests := []struct {
+		rule     string
+		password string
+		want     bool
+	}{
+		{"maxRepeat <= 2", "aabbcc", true},
+		{"maxRepeat <= 2", "aaabc", false},
+		{"maxRun < 3", "abaaba", true},
+		{"maxRun < 3", "abaaab", false},
+		{"digits >= 2 && special >= 1", "ab12!", true},
+		{"digits >= 2 && special >= 1", "ab1!", false},
+		{"upper == 2 || lower == 0", "ABc", true},
+		{"!password.contains(\"pass\")", "mypassword", false},
+		{"password.startsWith('A') && password.endsWith(\"9\")", "Axy9", true},
+		{"password.matches(\"^[A-Z]\")", "abc", false},
+		{"size(password) == length", "héllo", true},
+		{"unique * 2 >= length", "aabb", true},
+		{"(length - 1) % 3 == 0", "abcd", true},
+		{"-length < 0", "a", true},
+		{"true", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.password, func(t *testing.T) {
+			rule, err := CompileRule(tt.rule)
+			if err != nil {
+				t.Fatalf("Failed to compile rule: %v", err)
+			}
+			got, err := rule.Eval(tt.password)
+			if err != nil {
+				t.Fatalf("Failed to evaluate rule: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestCompileRuleErrors tests that malformed or ill-typed rules are rejected
+func TestCompileRuleErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"length",
+		"length >",
+		"length > \"a\"",
+		"unknown > 1",
+		"password.nope()",
+		"size(1) > 0",
+		"!length",
+		"(length > 1",
+		"password.matches(\"[\")",
+		"length > 1 extra",
+		"\"unterminated",
+		"length # 2",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := CompileRule(expr); err == nil {
+				t.Errorf("Expected compile error for %q", expr)
+			}
+		})
+	}
+}
+
+// TestRuleCheck tests that failing rules reject the candidate
+func TestRuleCheck(t *testing.T) {
+	rule, err := CompileRule("maxRepeat <= 2")
+	if err != nil {
+		t.Fatalf("Failed to compile rule: %v", err)
+	}
+	if err := rule.Check("aaab"); !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected ErrRejected, got %v", err)
+	}
+	if err := rule.Check("aab"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	division, _ := CompileRule("length / (length - length) > 0")
+	if err := division.Check("ab"); err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Expected evaluation error, got %v", err)
+	}
+}
+
+// TestRuleInPipeline tests rules as post-generate hooks
+func TestRuleInPipeline(t *testing.T) {
+	rule, err := CompileRule("maxRepeat <= 1")
+	if err != nil {
+		t.Fatalf("Failed to compile rule: %v", err)
+	}
+
+	p := &Pipeline{}
+	p.UsePostGenerate(rule.Check)
+	for i := 0; i < 20; i++ {
+		password, err := p.Generate(Options{Length: 8})
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		if ok, _ := rule.Eval(password); !ok {
+			t.Errorf("Password %s violates rule", password)
+		}
+	}
+}
+
+// TestCompileRules tests compiling one rule per line
+func TestCompileRules(t *testing.T) {
+	rules, err := CompileRules("length > 3\n\n  digits >= 1  \n")
+	if err != nil {
+		t.Fatalf("Failed to compile rules: %v", err)
+	}
+	if len(rules) != 2 || rules[1].String() != "digits >= 1" {
+		t.Errorf("Unexpected rules: %v", rules)
+	}
+	if _, err := CompileRules("length > 3\nlength >"); err == nil {
+		t.Error("Expected error for invalid rule")
+	}
+}
@@ -0,0 +1,93 @@
+package passgen
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// DefaultMaxSequence is the longest sequence WithoutSequences tolerates by
+// default. Three consecutive characters such as "abc" or "987" occur by
+// chance too often to reject.
+const DefaultMaxSequence = 3
+
+// DefaultMaxRun is the longest run of one character WithoutRepeats
+// tolerates by default, so "aa" passes and "aaa" does not.
+const DefaultMaxRun = 2
+
+// Sequence returns the length of the longest run of consecutive letters or
+// digits in password, ascending or descending, such as "abcd", "9876" or
+// "XYZ". Case is ignored within a run, so "aBcD" counts like "abcd". A
+// password without two consecutive characters in a row returns 1, an empty
+// one 0.
+func Sequence(password string) int {
+	longest, run := 0, 0
+	var prev, step rune
+	for _, r := range password {
+		r = unicode.ToLower(r)
+		switch d := r - prev; {
+		case run > 0 && sequenceClass(r) != 0 && sequenceClass(r) == sequenceClass(prev) && (d == 1 || d == -1):
+			if run > 1 && d != step {
+				// A turn starts a new sequence from the previous character
+				run = 1
+			}
+			run++
+			step = d
+		default:
+			run = 1
+		}
+		longest = max(longest, run)
+		prev = r
+	}
+	return longest
+}
+
+// LongestRun returns the length of the longest run of one character in
+// password, such as 3 for "baaad".
+func LongestRun(password string) int {
+	longest, run := 0, 0
+	var prev rune
+	for i, r := range password {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		prev = r
+	}
+	return longest
+}
+
+// WithoutSequences rejects passwords containing a sequence longer than
+// maxSequence characters, see Sequence.
+func WithoutSequences(maxSequence int) GeneratorOption {
+	return func(g *Generator) error {
+		if maxSequence < 1 {
+			return fmt.Errorf("longest sequence must be at least 1")
+		}
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if seq := Sequence(password); seq > maxSequence {
+				return Reject("sequence", "contains a sequence of %d characters", seq)
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// WithoutRepeats rejects passwords repeating a character more than maxRun
+// times in a row, see LongestRun.
+func WithoutRepeats(maxRun int) GeneratorOption {
+	return func(g *Generator) error {
+		if maxRun < 1 {
+			return fmt.Errorf("longest run must be at least 1")
+		}
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if run := LongestRun(password); run > maxRun {
+				return Reject("repeated character", "repeats a character %d times in a row", run)
+			}
+			return nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSequence tests ascending and descending runs, case and turns
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"x9Kp", 1},
+		{"aa", 1},
+		{"abcd", 4},
+		{"9876", 4},
+		{"xXYZ!", 3},
+		{"aBcD", 4},
+		{"z-abc-1", 3},
+		{"abcba", 3},
+		{"a1b2c3", 1},
+		{"89ab", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := Sequence(tt.password); got != tt.want {
+				t.Errorf("Sequence(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLongestRun tests runs of one character
+func TestLongestRun(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"baaad", 3},
+		{"aAa", 1},
+		{"éééx", 3},
+	}
+	for _, tt := range tests {
+		if got := LongestRun(tt.password); got != tt.want {
+			t.Errorf("LongestRun(%q) = %d, want %d", tt.password, got, tt.want)
+		}
+	}
+}
+
+// TestWithoutSequencesAndRepeats tests that generated passwords avoid both
+func TestWithoutSequencesAndRepeats(t *testing.T) {
+	g, err := NewGenerator(WithLength(64), WithoutSequences(DefaultMaxSequence), WithoutRepeats(DefaultMaxRun), WithMaxAttempts(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seq := Sequence(password); seq > DefaultMaxSequence {
+			t.Errorf("Password has a sequence of %d: %s", seq, password)
+		}
+		if run := LongestRun(password); run > DefaultMaxRun {
+			t.Errorf("Password has a run of %d: %s", run, password)
+		}
+	}
+
+	rule, err := CompileRule("sequence <= 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rule.Check("xx9876"); err == nil || !strings.Contains(err.Error(), "sequence") {
+		t.Errorf("Expected the rule to reject a sequence, got %v", err)
+	}
+	if _, err := NewGenerator(WithoutSequences(0)); err == nil {
+		t.Error("Expected an error for a longest sequence of 0")
+	}
+	if _, err := NewGenerator(WithoutRepeats(0)); err == nil {
+		t.Error("Expected an error for a longest run of 0")
+	}
+}
@@ -0,0 +1,470 @@
+package passgen
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Strength is the realistic strength of a password, estimated in the manner
+// of zxcvbn: how many guesses an attacker needs who tries common passwords,
+// dictionary words, keyboard walks, repeats, sequences and years before
+// brute force.
+type Strength struct {
+	// GuessesLog10 is the base 10 logarithm of the estimated number of
+	// guesses. The number itself overflows for long passwords.
+	GuessesLog10 float64
+	// Score rates the guesses from 0 (too guessable) to 4 (very
+	// unguessable) with the thresholds of zxcvbn: 10^3, 10^6, 10^8 and
+	// 10^10 guesses.
+	Score int
+	// Patterns describes the guessable parts found, e.g.
+	// `dictionary word "dragon"`, or is empty for random passwords.
+	Patterns []string
+}
+
+// MaxScore is the highest strength score.
+const MaxScore = 4
+
+// scoreThresholds are the guesses, as powers of ten, needed for scores 1
+// to 4. The extra 5 guesses of zxcvbn are negligible at these scales.
+var scoreThresholds = []float64{3, 6, 8, 10}
+
+// Guess counts of the matchers, in the spirit of zxcvbn.
+const (
+	// bruteforceCardinality is the guesses per brute-forced character.
+	bruteforceCardinality = 10
+	// minSubmatchGuesses are the fewest guesses a pattern of one or of
+	// several characters is credited with.
+	minSubmatchGuessesSingle = 10
+	minSubmatchGuessesMulti  = 50
+	// matchPenaltyLog10 is log10 of the guesses added per extra pattern, so
+	// splitting a password into many tiny patterns does not pay.
+	matchPenaltyLog10 = 4
+	// dictionaryWordGuesses is the rank credited to words of the EFF
+	// wordlist, which is not ordered by frequency: half its length.
+	dictionaryWordGuesses = 3888
+	// keyboardStarts and keyboardDegree are the number of keys and the
+	// average number of neighbours of a key on a QWERTY keyboard.
+	keyboardStarts = 94
+	keyboardDegree = 4.6
+	// minYearSpace is the fewest years a year pattern is credited with.
+	minYearSpace = 20
+	// maxDictionaryWord is the longest substring looked up.
+	maxDictionaryWord = 24
+)
+
+// strengthDictionaries holds the ranked words the dictionary matcher looks
+// for, loaded on first use.
+var strengthDictionaries = sync.OnceValue(func() []rankedDictionary {
+	common := rankedDictionary{name: "common password", ranks: map[string]int{}}
+	for i, w := range commonPasswords() {
+		common.ranks[ToLowerASCII(w)] = i + 1
+	}
+	english := rankedDictionary{name: "dictionary word", ranks: map[string]int{}}
+	if words, err := Wordlist(WordlistEFFLong); err == nil {
+		for _, w := range words {
+			if len(w) >= 3 {
+				english.ranks[w] = dictionaryWordGuesses
+			}
+		}
+	}
+	return []rankedDictionary{common, english}
+})
+
+type rankedDictionary struct {
+	name  string
+	ranks map[string]int
+}
+
+// readEmbeddedList reads an embedded list in the format of ReadWordlist.
+func readEmbeddedList(path string) ([]string, error) {
+	f, err := wordlistFiles.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWordlist(f)
+}
+
+// l33tTable undoes common character substitutions.
+var l33tTable = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '{': 'c', '3': 'e', '6': 'g',
+	'1': 'i', '!': 'i', '|': 'i', '0': 'o', '$': 's', '5': 's', '7': 't',
+	'+': 't', '2': 'z',
+}
+
+// strengthMatch is a guessable part of a password, runes [i, j).
+type strengthMatch struct {
+	i, j         int
+	guessesLog10 float64
+	pattern      string
+}
+
+// EstimateStrength estimates how many guesses a password takes for an
+// attacker who knows how people pick passwords. It finds every guessable
+// pattern, then the cheapest way to cover the password with patterns and
+// brute force, like zxcvbn.
+func EstimateStrength(password string) Strength {
+	runes := []rune(password)
+	guesses, patterns := cheapestCover(runes, findMatches(runes))
+	s := Strength{GuessesLog10: guesses, Patterns: patterns}
+	for _, threshold := range scoreThresholds {
+		if guesses >= threshold {
+			s.Score++
+		}
+	}
+	return s
+}
+
+// cheapestCover returns log10 of the guesses of the cheapest cover of runes
+// by matches and brute force, and the patterns it uses. Covering with l
+// patterns costs l! times the product of their guesses, since they may come
+// in any order, plus 10^4 for every pattern after the first.
+func cheapestCover(runes []rune, matches []strengthMatch) (float64, []string) {
+	n := len(runes)
+	if n == 0 {
+		return 0, nil
+	}
+	byEnd := make([][]strengthMatch, n+1)
+	for _, m := range matches {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+	type step struct {
+		cost  float64
+		match strengthMatch
+	}
+	// best[j][l] is the cheapest product covering runes[:j] with l
+	// patterns, in log10
+	best := make([][]step, n+1)
+	for j := range best {
+		best[j] = make([]step, n+1)
+		for l := range best[j] {
+			best[j][l].cost = math.Inf(1)
+		}
+	}
+	best[0][0].cost = 0
+	for j := 1; j <= n; j++ {
+		candidates := slices.Clip(byEnd[j])
+		for i := 0; i < j; i++ {
+			candidates = append(candidates, strengthMatch{i: i, j: j, guessesLog10: float64(j-i) * math.Log10(bruteforceCardinality)})
+		}
+		for _, m := range candidates {
+			for l := 0; l < j; l++ {
+				prev := best[m.i][l].cost
+				if math.IsInf(prev, 1) {
+					continue
+				}
+				if cost := prev + m.guessesLog10; cost < best[j][l+1].cost {
+					best[j][l+1] = step{cost, m}
+				}
+			}
+		}
+	}
+
+	total, count := math.Inf(1), 0
+	for l := 1; l <= n; l++ {
+		if math.IsInf(best[n][l].cost, 1) {
+			continue
+		}
+		factorial, _ := math.Lgamma(float64(l + 1))
+		guesses := addLog10(best[n][l].cost+factorial/math.Ln10, float64(l-1)*matchPenaltyLog10)
+		if guesses < total {
+			total, count = guesses, l
+		}
+	}
+	var patterns []string
+	for j, l := n, count; j > 0; l-- {
+		m := best[j][l].match
+		if m.pattern != "" {
+			patterns = append([]string{m.pattern}, patterns...)
+		}
+		j = m.i
+	}
+	return total, patterns
+}
+
+// addLog10 returns log10(10^a + 10^b).
+func addLog10(a, b float64) float64 {
+	hi, lo := max(a, b), min(a, b)
+	return hi + math.Log10(1+math.Pow(10, lo-hi))
+}
+
+// findMatches returns every guessable pattern in runes.
+func findMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	matches = append(matches, dictionaryMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+	for k, m := range matches {
+		floor := float64(minSubmatchGuessesMulti)
+		if m.j-m.i == 1 {
+			floor = minSubmatchGuessesSingle
+		}
+		matches[k].guessesLog10 = max(m.guessesLog10, math.Log10(floor))
+	}
+	return matches
+}
+
+// dictionaryMatches finds dictionary words, also reversed, capitalized or
+// with l33t substitutions such as p@ssw0rd.
+func dictionaryMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := range runes {
+		for j := i + 1; j <= len(runes) && j-i <= maxDictionaryWord; j++ {
+			word := runes[i:j]
+			lower := string(toLowerRunes(word))
+			unleet, subs := undoL33t(word)
+			reversed := reverseRunes(toLowerRunes(word))
+			variants := []dictionaryVariant{
+				{lower, 0, ""},
+				{string(reversed), math.Log10(2), " (reversed)"},
+			}
+			if subs > 0 {
+				variants = append(variants, dictionaryVariant{unleet, float64(subs) * math.Log10(2), " (l33t)"})
+			}
+			for _, dict := range strengthDictionaries() {
+				for _, v := range variants {
+					rank, ok := dict.ranks[v.text]
+					if !ok {
+						continue
+					}
+					matches = append(matches, strengthMatch{
+						i: i, j: j,
+						guessesLog10: math.Log10(float64(rank)) + uppercaseVariations(word) + v.extra,
+						pattern:      fmt.Sprintf("%s %q%s", dict.name, string(word), v.note),
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// dictionaryVariant is a form of a substring looked up in the
+// dictionaries, with the extra guesses, in log10, of undoing it.
+type dictionaryVariant struct {
+	text  string
+	extra float64
+	note  string
+}
+
+// uppercaseVariations returns log10 of the ways the capitalization of word
+// could have been chosen: none for lowercase, two for a capital first or
+// last letter or all capitals, else every mix of as many capitals.
+func uppercaseVariations(word []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 0
+	}
+	if lower == 0 || upper == 1 && (unicode.IsUpper(word[0]) || unicode.IsUpper(word[len(word)-1])) {
+		return math.Log10(2)
+	}
+	var ways float64
+	for k := 1; k <= min(upper, lower); k++ {
+		ways += binomial(upper+lower, k)
+	}
+	return math.Log10(ways)
+}
+
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+// undoL33t lowercases word, undoes l33t substitutions and counts them.
+func undoL33t(word []rune) (string, int) {
+	out := toLowerRunes(word)
+	subs := 0
+	for k, r := range out {
+		if plain, ok := l33tTable[r]; ok {
+			out[k] = plain
+			subs++
+		}
+	}
+	return string(out), subs
+}
+
+func toLowerRunes(word []rune) []rune {
+	out := make([]rune, len(word))
+	for k, r := range word {
+		out[k] = unicode.ToLower(r)
+	}
+	return out
+}
+
+func reverseRunes(word []rune) []rune {
+	out := make([]rune, len(word))
+	for k, r := range word {
+		out[len(word)-1-k] = r
+	}
+	return out
+}
+
+// keyboardMatches finds straight walks of three or more keys on a QWERTY
+// keyboard, see KeyboardWalk.
+func keyboardMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := range runes {
+		from, ok := qwertyKeys[runes[i]]
+		if !ok {
+			continue
+		}
+		var dir keyPos
+		for j := i + 1; j < len(runes); j++ {
+			to, ok := qwertyKeys[runes[j]]
+			if !ok {
+				break
+			}
+			step, adjacent := keyStep(from, to)
+			if !adjacent || j > i+1 && step != dir {
+				break
+			}
+			dir, from = step, to
+			if length := j + 1 - i; length >= 3 {
+				guesses := math.Log10(keyboardStarts * keyboardDegree * float64(length-1))
+				if shifted(runes[i : j+1]) {
+					guesses += math.Log10(2)
+				}
+				matches = append(matches, strengthMatch{i: i, j: j + 1, guessesLog10: guesses,
+					pattern: fmt.Sprintf("keyboard walk %q", string(runes[i:j+1]))})
+			}
+		}
+	}
+	return matches
+}
+
+// shifted reports whether any key of a walk was typed with shift.
+func shifted(walk []rune) bool {
+	for _, r := range walk {
+		if unicode.IsUpper(r) || strings.ContainsRune(qwertyShifted, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatMatches finds a character or block repeated back to back, such as
+// "aaa" or "abcabc". A repeat costs the guesses of its block times the
+// number of repeats.
+func repeatMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := range runes {
+		for size := 1; i+2*size <= len(runes); size++ {
+			block := runes[i : i+size]
+			count := 1
+			for i+(count+1)*size <= len(runes) && string(runes[i+count*size:i+(count+1)*size]) == string(block) {
+				count++
+			}
+			// A single character must repeat at least three times
+			if count < 2 || size == 1 && count < 3 {
+				continue
+			}
+			blockGuesses, _ := cheapestCover(block, findMatches(block))
+			j := i + count*size
+			matches = append(matches, strengthMatch{i: i, j: j,
+				guessesLog10: blockGuesses + math.Log10(float64(count)),
+				pattern:      fmt.Sprintf("repeated %q", string(runes[i:j]))})
+		}
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of three or more letters or digits that go up
+// or down by a fixed step of at most five, such as "abcd", "9753" or "ZYX".
+func sequenceMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+2 < len(runes); i++ {
+		delta := runes[i+1] - runes[i]
+		if delta == 0 || delta > 5 || delta < -5 || sequenceClass(runes[i]) == 0 {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && runes[j]-runes[j-1] == delta && sequenceClass(runes[j]) == sequenceClass(runes[i]) {
+			j++
+		}
+		if j-i < 3 {
+			continue
+		}
+		// Obvious starts are tried first
+		base := 26.0
+		switch {
+		case strings.ContainsRune("aAzZ019", runes[i]):
+			base = 4
+		case unicode.IsDigit(runes[i]):
+			base = 10
+		}
+		guesses := math.Log10(base * float64(j-i))
+		if delta < 0 {
+			guesses += math.Log10(2)
+		}
+		matches = append(matches, strengthMatch{i: i, j: j, guessesLog10: guesses,
+			pattern: fmt.Sprintf("sequence %q", string(runes[i:j]))})
+	}
+	return matches
+}
+
+// sequenceClass returns the class of characters a sequence stays within.
+func sequenceClass(r rune) rune {
+	switch {
+	case 'a' <= r && r <= 'z':
+		return 'a'
+	case 'A' <= r && r <= 'Z':
+		return 'A'
+	case '0' <= r && r <= '9':
+		return '0'
+	}
+	return 0
+}
+
+// yearMatches finds years from 1900 to 2039, which people add to
+// passwords. The closer a year is to now, the likelier.
+func yearMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	now := time.Now().Year()
+	for i := 0; i+4 <= len(runes); i++ {
+		year, err := strconv.Atoi(string(runes[i : i+4]))
+		if err != nil || year < 1900 || year > 2039 || !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		space := max(math.Abs(float64(year-now)), minYearSpace)
+		matches = append(matches, strengthMatch{i: i, j: i + 4, guessesLog10: math.Log10(space),
+			pattern: fmt.Sprintf("year %q", string(runes[i:i+4]))})
+	}
+	return matches
+}
+
+// WithMinScore rejects passwords whose estimated strength, see
+// EstimateStrength, scores below min.
+func WithMinScore(min int) GeneratorOption {
+	return func(g *Generator) error {
+		if min < 0 || min > MaxScore {
+			return fmt.Errorf("minimum strength score must be between 0 and %d", MaxScore)
+		}
+		g.pipeline.UsePostGenerate(func(password string) error {
+			if s := EstimateStrength(password); s.Score < min {
+				return Reject("strength score", "strength score %d is below %d", s.Score, min)
+			}
+			return nil
+		})
+		return nil
+	}
+}
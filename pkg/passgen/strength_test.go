@@ -0,0 +1,95 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestEstimateStrength tests scores and patterns of typical passwords
+func TestEstimateStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		maxScore int
+		minScore int
+		pattern  string
+	}{
+		{"password", 0, 0, `common password "password"`},
+		{"P@ssw0rd", 0, 0, "(l33t)"},
+		{"drowssap", 0, 0, "(reversed)"},
+		{"qwertyuiop", 0, 0, "common password"},
+		{"aaaaaaaa", 0, 0, `repeated "aaaaaaaa"`},
+		{"abcabcabc", 0, 0, "repeated"},
+		{"zyxwvu", 0, 0, `sequence "zyxwvu"`},
+		{"dragon1990", 1, 0, `year "1990"`},
+		{"xcvbnm", 1, 0, `keyboard walk "xcvbnm"`},
+		{"correcthorsebatterystaple", MaxScore, 3, `dictionary word "battery"`},
+		{"xK9#mQ2$vL7p", MaxScore, MaxScore, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			s := EstimateStrength(tt.password)
+			if s.Score < tt.minScore || s.Score > tt.maxScore {
+				t.Errorf("Score %d, want %d to %d", s.Score, tt.minScore, tt.maxScore)
+			}
+			patterns := strings.Join(s.Patterns, "; ")
+			if tt.pattern == "" && patterns != "" || !strings.Contains(patterns, tt.pattern) {
+				t.Errorf("Patterns %q, want one with %q", patterns, tt.pattern)
+			}
+		})
+	}
+
+	if s := EstimateStrength(""); s.Score != 0 || s.GuessesLog10 != 0 {
+		t.Errorf("Empty password: %+v", s)
+	}
+	// Capitals add a little, but not much
+	if EstimateStrength("Battery").GuessesLog10 <= EstimateStrength("battery").GuessesLog10 {
+		t.Error("Expected a capital letter to add guesses")
+	}
+}
+
+// TestUppercaseVariations tests the guesses added by capitalization
+func TestUppercaseVariations(t *testing.T) {
+	tests := []struct {
+		word string
+		want float64
+	}{
+		{"dragon", 1},
+		{"Dragon", 2},
+		{"dragoN", 2},
+		{"DRAGON", 2},
+		{"DrAgon", 6 + 15},
+	}
+	for _, tt := range tests {
+		if got := uppercaseVariations([]rune(tt.word)); math.Abs(math.Pow(10, got)-tt.want) > 1e-9 {
+			t.Errorf("uppercaseVariations(%q) = 10^%.3f, want %g", tt.word, got, tt.want)
+		}
+	}
+}
+
+// TestWithMinScore tests rejecting weak candidates
+func TestWithMinScore(t *testing.T) {
+	g, err := NewGenerator(WithLength(12), WithMinScore(MaxScore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := EstimateStrength(password); s.Score < MaxScore {
+		t.Errorf("Password %q scored %d", password, s.Score)
+	}
+
+	g, err = NewGenerator(WithLength(4), WithoutClass(ClassUpper), WithoutClass(ClassLower), WithMinScore(MaxScore), WithMaxAttempts(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Generate(); err == nil {
+		t.Errorf("Expected four digits never to score %d, got %v", MaxScore, err)
+	}
+	if _, err := NewGenerator(WithMinScore(MaxScore + 1)); err == nil {
+		t.Error("Expected an error for a score above the maximum")
+	}
+}
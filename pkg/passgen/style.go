@@ -0,0 +1,177 @@
+package passgen
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Caps selects how the words of a passphrase are capitalized.
+type Caps int
+
+const (
+	// CapsNone leaves the words as they are in the wordlist.
+	CapsNone Caps = iota
+	// CapsFirst capitalizes the first letter of every word.
+	CapsFirst
+	// CapsRandom capitalizes the first letter of each word with a
+	// probability of one half, adding a bit of entropy per word.
+	CapsRandom
+	// CapsAll writes every word in upper case.
+	CapsAll
+)
+
+var capsNames = []string{"none", "first", "random", "all"}
+
+// ParseCaps parses a capitalization mode by name: none, first, random or
+// all.
+func ParseCaps(name string) (Caps, error) {
+	for i, n := range capsNames {
+		if EqualFoldASCII(n, name) {
+			return Caps(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown capitalization %q (use none, first, random or all)", name)
+}
+
+func (c Caps) String() string {
+	if c < 0 || int(c) >= len(capsNames) {
+		return fmt.Sprintf("Caps(%d)", int(c))
+	}
+	return capsNames[c]
+}
+
+// Capitalize returns the words capitalized according to caps.
+func Capitalize(words []string, caps Caps) ([]string, error) {
+	out := make([]string, len(words))
+	for i, w := range words {
+		switch caps {
+		case CapsNone:
+			out[i] = w
+		case CapsFirst:
+			out[i] = upperFirst(w)
+		case CapsRandom:
+			n, err := randomInt(2)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = w
+			if n == 1 {
+				out[i] = upperFirst(w)
+			}
+		case CapsAll:
+			out[i] = strings.ToUpper(w)
+		default:
+			return nil, fmt.Errorf("unknown capitalization %d", int(caps))
+		}
+	}
+	return out, nil
+}
+
+func upperFirst(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if size == 0 {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// CapsEntropy returns the bits caps adds to a passphrase of count words.
+// Only CapsRandom adds any; the other modes are assumed known to attackers.
+func CapsEntropy(caps Caps, count int) float64 {
+	if caps != CapsRandom {
+		return 0
+	}
+	return float64(count)
+}
+
+// PassphraseSymbols are the symbols Pad draws from. They are easy to type on
+// most keyboard layouts and need no escaping in common shells.
+const PassphraseSymbols = "!#%*+=?@^_"
+
+// Placement selects which word Pad attaches digits and symbols to.
+type Placement int
+
+const (
+	// PlaceEnd appends them to the last word.
+	PlaceEnd Placement = iota
+	// PlaceRandom appends them to a word chosen at random.
+	PlaceRandom
+)
+
+var placementNames = []string{"end", "random"}
+
+// ParsePlacement parses a placement by name: end or random.
+func ParsePlacement(name string) (Placement, error) {
+	for i, n := range placementNames {
+		if EqualFoldASCII(n, name) {
+			return Placement(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown placement %q (use end or random)", name)
+}
+
+func (p Placement) String() string {
+	if p < 0 || int(p) >= len(placementNames) {
+		return fmt.Sprintf("Placement(%d)", int(p))
+	}
+	return placementNames[p]
+}
+
+// Pad attaches the given number of random digits followed by random
+// PassphraseSymbols to one of the words, e.g. "battery" becomes
+// "battery47!", so a passphrase satisfies composition policies that demand
+// digits and symbols.
+func Pad(words []string, digits, symbols int, place Placement) ([]string, error) {
+	if len(words) == 0 {
+		return nil, fmt.Errorf("passphrase must have at least 1 word")
+	}
+	if digits < 0 || symbols < 0 {
+		return nil, fmt.Errorf("digit and symbol counts cannot be negative")
+	}
+	if place < PlaceEnd || place > PlaceRandom {
+		return nil, fmt.Errorf("unknown placement %d", int(place))
+	}
+	out := append([]string(nil), words...)
+	if digits == 0 && symbols == 0 {
+		return out, nil
+	}
+
+	var b strings.Builder
+	for _, draw := range []struct {
+		n       int
+		charset string
+	}{{digits, Digits}, {symbols, PassphraseSymbols}} {
+		for i := 0; i < draw.n; i++ {
+			c, err := getRandomChar(draw.charset)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(c)
+		}
+	}
+	i := len(out) - 1
+	if place == PlaceRandom {
+		var err error
+		if i, err = randomInt(len(out)); err != nil {
+			return nil, err
+		}
+	}
+	out[i] += b.String()
+	return out, nil
+}
+
+// PadEntropy returns the bits Pad adds to a passphrase of count words.
+func PadEntropy(digits, symbols, count int, place Placement) float64 {
+	if digits <= 0 && symbols <= 0 {
+		return 0
+	}
+	bits := float64(max(digits, 0))*math.Log2(float64(len(Digits))) +
+		float64(max(symbols, 0))*math.Log2(float64(len(PassphraseSymbols)))
+	if place == PlaceRandom && count > 1 {
+		bits += math.Log2(float64(count))
+	}
+	return bits
+}
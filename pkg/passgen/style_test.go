@@ -0,0 +1,113 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestParseCaps tests capitalization names
+func TestParseCaps(t *testing.T) {
+	for i, name := range []string{"none", "First", "RANDOM", "all"} {
+		caps, err := ParseCaps(name)
+		if err != nil || caps != Caps(i) {
+			t.Errorf("ParseCaps(%q) = %v, %v", name, caps, err)
+		}
+	}
+	if _, err := ParseCaps("title"); err == nil {
+		t.Error("Expected error for an unknown mode")
+	}
+}
+
+// TestCapitalize tests every capitalization mode
+func TestCapitalize(t *testing.T) {
+	words := []string{"correct", "horse", "élan"}
+	tests := []struct {
+		caps Caps
+		want string
+	}{
+		{CapsNone, "correct horse élan"},
+		{CapsFirst, "Correct Horse Élan"},
+		{CapsAll, "CORRECT HORSE ÉLAN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caps.String(), func(t *testing.T) {
+			got, err := Capitalize(words, tt.caps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("Capitalize = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		got, err := Capitalize([]string{"horse"}, CapsRandom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[got[0]] = true
+	}
+	if !seen["horse"] || !seen["Horse"] || len(seen) != 2 {
+		t.Errorf("CapsRandom produced %v", seen)
+	}
+	if got := CapsEntropy(CapsRandom, 6); got != 6 {
+		t.Errorf("CapsEntropy(random, 6) = %f, want 6", got)
+	}
+	if got := CapsEntropy(CapsFirst, 6); got != 0 {
+		t.Errorf("CapsEntropy(first, 6) = %f, want 0", got)
+	}
+}
+
+// TestPad tests where and what Pad attaches
+func TestPad(t *testing.T) {
+	words := []string{"correct", "horse", "battery"}
+	got, err := Pad(words, 2, 1, PlaceEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := got[2]
+	if len(last) != len("battery")+3 || !strings.HasPrefix(last, "battery") ||
+		!strings.ContainsAny(last[7:9], Digits) || !strings.ContainsAny(last[9:], PassphraseSymbols) {
+		t.Errorf("Pad(end) = %q", got)
+	}
+	if got[0] != "correct" || got[1] != "horse" || words[2] != "battery" {
+		t.Errorf("Pad changed other words or its input: %q, %q", got, words)
+	}
+
+	padded := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		got, err := Pad(words, 1, 0, PlaceRandom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range words {
+			if got[j] != words[j] {
+				padded[j] = true
+			}
+		}
+	}
+	if len(padded) != 3 {
+		t.Errorf("PlaceRandom only padded words %v", padded)
+	}
+
+	if _, err := Pad(words, -1, 0, PlaceEnd); err == nil {
+		t.Error("Expected error for a negative count")
+	}
+	if _, err := Pad(nil, 1, 0, PlaceEnd); err == nil {
+		t.Error("Expected error for no words")
+	}
+}
+
+// TestPadEntropy tests the credit for digits, symbols and placement
+func TestPadEntropy(t *testing.T) {
+	want := 2*math.Log2(10) + math.Log2(10) + 2
+	if got := PadEntropy(2, 1, 4, PlaceRandom); math.Abs(got-want) > 1e-9 {
+		t.Errorf("PadEntropy = %f, want %f", got, want)
+	}
+	if got := PadEntropy(0, 0, 4, PlaceRandom); got != 0 {
+		t.Errorf("PadEntropy without padding = %f, want 0", got)
+	}
+}
@@ -0,0 +1,94 @@
+package passgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Long names accepted for the Unicode general categories, in addition to
+// the abbreviations of unicode.Categories such as "P" or "Lu".
+var categoryNames = map[string]string{
+	"letter":      "L",
+	"uppercase":   "Lu",
+	"lowercase":   "Ll",
+	"mark":        "M",
+	"number":      "N",
+	"digit":       "Nd",
+	"punctuation": "P",
+	"symbol":      "S",
+	"separator":   "Z",
+	"other":       "C",
+}
+
+// LookupCategory returns the Unicode general category with the given name,
+// either its abbreviation ("P", "Lu") or its long name ("Punctuation",
+// "Uppercase"). Names are matched case-insensitively.
+func LookupCategory(name string) (*unicode.RangeTable, error) {
+	if short, ok := categoryNames[ToLowerASCII(name)]; ok {
+		name = short
+	}
+	for n, table := range unicode.Categories {
+		if EqualFoldASCII(n, name) {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown Unicode category %q", name)
+}
+
+// LookupScript returns the Unicode script with the given name, e.g.
+// "Latin" or "Greek". Names are matched case-insensitively.
+func LookupScript(name string) (*unicode.RangeTable, error) {
+	for n, table := range unicode.Scripts {
+		if EqualFoldASCII(n, name) {
+			return table, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown Unicode script %q", name)
+}
+
+// WithIncludeTables limits passwords to characters in at least one of the
+// tables, typically Unicode categories or scripts from LookupCategory and
+// LookupScript. Character sets left empty are no longer required.
+func WithIncludeTables(tables ...*unicode.RangeTable) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.IncludeTables = append(g.opts.IncludeTables, tables...)
+		return nil
+	}
+}
+
+// WithExcludeTables keeps the characters of every table out of passwords.
+func WithExcludeTables(tables ...*unicode.RangeTable) GeneratorOption {
+	return func(g *Generator) error {
+		g.opts.ExcludeTables = append(g.opts.ExcludeTables, tables...)
+		return nil
+	}
+}
+
+// filtered reports whether the options restrict characters beyond the
+// character sets themselves.
+func (o Options) filtered() bool {
+	return o.Exclude != "" || len(o.IncludeTables) > 0 || len(o.ExcludeTables) > 0 ||
+		o.Disabled != [ClassSpecial + 1]bool{}
+}
+
+// allows reports whether r may appear in a password.
+func (o Options) allows(r rune) bool {
+	if o.Disabled[ClassOf(r)] {
+		return false
+	}
+	if len(o.IncludeTables) > 0 && !unicode.IsOneOf(o.IncludeTables, r) {
+		return false
+	}
+	return !unicode.IsOneOf(o.ExcludeTables, r) && !strings.ContainsRune(o.Exclude, r)
+}
+
+// allowsAll reports whether every character of password may appear.
+func (o Options) allowsAll(password string) bool {
+	for _, r := range password {
+		if !o.allows(r) {
+			return false
+		}
+	}
+	return true
+}
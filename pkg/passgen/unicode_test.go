@@ -0,0 +1,112 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestLookupCategory tests category abbreviations and long names
+func TestLookupCategory(t *testing.T) {
+	tests := []struct {
+		name string
+		want *unicode.RangeTable
+	}{
+		{"P", unicode.P},
+		{"Punctuation", unicode.P},
+		{"lu", unicode.Lu},
+		{"UPPERCASE", unicode.Lu},
+		{"Digit", unicode.Nd},
+		{"Symbol", unicode.S},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupCategory(tt.name)
+			if err != nil || got != tt.want {
+				t.Errorf("LookupCategory(%q) = %p, %v", tt.name, got, err)
+			}
+		})
+	}
+	if _, err := LookupCategory("Emoji"); err == nil {
+		t.Error("Expected error for an unknown category")
+	}
+}
+
+// TestLookupScript tests script names
+func TestLookupScript(t *testing.T) {
+	if got, err := LookupScript("greek"); err != nil || got != unicode.Greek {
+		t.Errorf("LookupScript(greek) = %p, %v", got, err)
+	}
+	if _, err := LookupScript("Punctuation"); err == nil {
+		t.Error("Expected error for an unknown script")
+	}
+}
+
+// TestUnicodeTables tests which characters survive include and exclude tables
+func TestUnicodeTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		excluded string
+		kept     string
+		sets     int
+	}{
+		{"exclude punctuation", Options{IncludeSpecial: true, ExcludeTables: []*unicode.RangeTable{unicode.P}}, "!@#%&*()_-[]{},.;:?", "$^+=|<>aZ7", 4},
+		{"include latin", Options{IncludeSpecial: true, IncludeTables: []*unicode.RangeTable{unicode.Latin}}, "23456789!@#", "aZ", 2},
+		{"include latin and digits", Options{IncludeTables: []*unicode.RangeTable{unicode.Latin, unicode.Nd}}, "", "aZ7", 3},
+		{"exclude uppercase", Options{ExcludeTables: []*unicode.RangeTable{unicode.Lu}}, "ABCZ", "az7", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets := tt.opts.Charsets()
+			all := strings.Join(sets, "")
+			if strings.ContainsAny(all, tt.excluded) {
+				t.Errorf("Charsets %q contain one of %q", all, tt.excluded)
+			}
+			for _, c := range tt.kept {
+				if !strings.ContainsRune(all, c) {
+					t.Errorf("Charsets %q should contain %q", all, c)
+				}
+			}
+			if len(sets) != tt.sets {
+				t.Errorf("Got %d charsets, want %d", len(sets), tt.sets)
+			}
+		})
+	}
+}
+
+// TestWithUnicodeTables tests generating from a non-ASCII charset filtered by script
+func TestWithUnicodeTables(t *testing.T) {
+	g, err := NewGenerator(
+		WithLength(20),
+		WithCharset("αβγδεζηθλμπσφψω"),
+		WithIncludeTables(unicode.Greek),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(password); n != 20 {
+		t.Errorf("Password %q has %d characters, want 20", password, n)
+	}
+	for _, r := range password {
+		if !unicode.Is(unicode.Greek, r) {
+			t.Errorf("Password %q contains non-Greek %q", password, r)
+		}
+	}
+	bits, err := g.Entropy(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := EstimateEntropyWith(strings.Repeat("α", 20), []string{"αβγδεζηθλμπσφψω"}); bits != want {
+		t.Errorf("Entropy = %.1f, want %.1f", bits, want)
+	}
+
+	if _, err := NewGenerator(WithIncludeTables(unicode.Cyrillic)); err == nil {
+		t.Error("Expected error when every character is excluded")
+	}
+}
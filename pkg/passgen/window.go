@@ -0,0 +1,40 @@
+package passgen
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is the span of time a rotating password is valid for.
+type Window struct {
+	Start, End time.Time
+}
+
+// WindowAt returns the window of the given period containing t. Windows
+// are counted from the Unix epoch, so daily windows change at midnight UTC.
+func WindowAt(t time.Time, period time.Duration) (Window, error) {
+	if period < time.Second || period%time.Second != 0 {
+		return Window{}, fmt.Errorf("the rotation period must be a whole number of seconds")
+	}
+	secs, n := t.Unix(), int64(period/time.Second)
+	index := secs / n
+	if secs%n < 0 {
+		index--
+	}
+	start := time.Unix(index*n, 0).UTC()
+	return Window{Start: start, End: start.Add(period)}, nil
+}
+
+// WindowPassword returns the password derived from secret for window w,
+// like a TOTP code but satisfying a full password policy: everyone holding
+// the secret computes the same password until the window ends. name tells
+// apart the passwords of several machines sharing one secret and may be
+// empty. See DeriveChild for length and charsets.
+func WindowPassword(secret []byte, name string, w Window, length int, charsets []string) (string, error) {
+	period := w.End.Sub(w.Start)
+	if period <= 0 {
+		return "", fmt.Errorf("the window must end after it starts")
+	}
+	info := fmt.Sprintf("%s@%d/%d", name, w.Start.Unix(), int64(period/time.Second))
+	return DeriveChild(secret, info, length, charsets)
+}
@@ -0,0 +1,98 @@
+package passgen
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWindowAt tests window boundaries
+func TestWindowAt(t *testing.T) {
+	at := time.Date(2026, 10, 16, 15, 4, 5, 0, time.FixedZone("CEST", 2*3600))
+	w, err := WindowAt(at, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	if !w.Start.Equal(want) || !w.End.Equal(want.Add(24*time.Hour)) {
+		t.Errorf("WindowAt = %v to %v, want %v to a day later", w.Start, w.End, want)
+	}
+	if w.Start.Location() != time.UTC {
+		t.Errorf("Window start is in %v, want UTC", w.Start.Location())
+	}
+
+	hour, err := WindowAt(at, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC); !hour.Start.Equal(want) {
+		t.Errorf("Hourly window starts at %v, want %v", hour.Start, want)
+	}
+
+	// Weekly windows start on Thursdays, like the Unix epoch
+	week, err := WindowAt(at, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC); !week.Start.Equal(want) {
+		t.Errorf("Weekly window starts at %v, want %v", week.Start, want)
+	}
+	before, err := WindowAt(time.Unix(-1, 0), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Start.Unix() != -3600 {
+		t.Errorf("Window before the epoch starts at %d, want -3600", before.Start.Unix())
+	}
+
+	if _, err := WindowAt(at, 1500*time.Millisecond); err == nil {
+		t.Error("Expected error for a fractional period")
+	}
+	if _, err := WindowAt(at, 0); err == nil {
+		t.Error("Expected error for a zero period")
+	}
+}
+
+// TestWindowPassword tests that passwords change with the window and name only
+func TestWindowPassword(t *testing.T) {
+	secret := []byte("shared lab secret")
+	charsets := Options{}.Charsets()
+	day := func(d int) Window {
+		w, err := WindowAt(time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC), 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+	password := func(name string, w Window) string {
+		p, err := WindowPassword(secret, name, w, 12, charsets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	morning, err := WindowAt(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password("", day(16)) != password("", morning) {
+		t.Error("The same window should give the same password")
+	}
+	if password("", day(16)) == password("", day(17)) {
+		t.Error("The next window should give a different password")
+	}
+	if password("lab-1", day(16)) == password("lab-2", day(16)) {
+		t.Error("Different names should give different passwords")
+	}
+
+	hourly, err := WindowAt(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password("", hourly) == password("", day(16)) {
+		t.Error("Windows of different periods starting together should differ")
+	}
+	if _, err := WindowPassword(secret, "", Window{}, 12, charsets); err == nil {
+		t.Error("Expected error for an empty window")
+	}
+}
@@ -0,0 +1,115 @@
+package passgen
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Names of the embedded wordlists.
+const (
+	// WordlistEFFLong is the EFF large wordlist of 7776 words, about 12.9
+	// bits per word. It is the default for passphrases.
+	WordlistEFFLong = "eff-long"
+	// WordlistEFFShort is the EFF short wordlist 2.0 of 1296 longer but
+	// more memorable words with unique three-letter prefixes, about 10.3
+	// bits per word.
+	WordlistEFFShort = "eff-short"
+)
+
+// MinWordlistSize is the smallest wordlist CheckWordlist accepts: fewer
+// than 10 bits per word would need impractically many words for a strong
+// passphrase.
+const MinWordlistSize = 1024
+
+//go:embed wordlists/*.txt
+var wordlistFiles embed.FS
+
+var wordlistPaths = map[string]string{
+	WordlistEFFLong:  "wordlists/eff_large_wordlist.txt",
+	WordlistEFFShort: "wordlists/eff_short_wordlist_2_0.txt",
+}
+
+// Wordlists returns the names of the embedded wordlists.
+func Wordlists() []string {
+	return []string{WordlistEFFLong, WordlistEFFShort}
+}
+
+// Wordlist returns the words of an embedded wordlist.
+func Wordlist(name string) ([]string, error) {
+	path, ok := wordlistPaths[ToLowerASCII(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown wordlist %q (want one of %s)", name, strings.Join(Wordlists(), ", "))
+	}
+	f, err := wordlistFiles.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWordlist(f)
+}
+
+// ReadWordlist reads one word per line. Blank lines and # comments are
+// skipped, and a leading dice number is dropped, so diceware lists of the
+// form "11111<TAB>abacus" work unchanged. Any other line with several
+// fields, such as "two words", is an error.
+func ReadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) == 2 && strings.Trim(fields[0], allNumbers) == "" {
+			fields = fields[1:]
+		}
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("line %d: %q is not a single word", line, strings.TrimSpace(scanner.Text()))
+		}
+		words = append(words, fields[0])
+	}
+	return words, scanner.Err()
+}
+
+// CheckWordlist reports why a wordlist is unfit for passphrases: it is
+// smaller than MinWordlistSize, or it contains empty words, words with
+// whitespace or duplicate words, which would make some words likelier than
+// others and the entropy lower than its length suggests. Words differing
+// only in case are duplicates, since capitalization options change the
+// case of words anyway.
+func CheckWordlist(words []string) error {
+	if len(words) < MinWordlistSize {
+		return fmt.Errorf("wordlist has %d words, at least %d are required", len(words), MinWordlistSize)
+	}
+	seen := make(map[string]string, len(words))
+	var dups []string
+	for _, w := range words {
+		if w == "" {
+			return fmt.Errorf("wordlist contains an empty word")
+		}
+		if strings.IndexFunc(w, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("wordlist contains %q, which is not a single word", w)
+		}
+		folded := strings.ToLower(w)
+		if first, ok := seen[folded]; ok {
+			if first == w {
+				dups = append(dups, fmt.Sprintf("%q twice", w))
+			} else {
+				dups = append(dups, fmt.Sprintf("%q and %q", first, w))
+			}
+			continue
+		}
+		seen[folded] = w
+	}
+	switch {
+	case len(dups) == 1:
+		return fmt.Errorf("wordlist contains %s", dups[0])
+	case len(dups) > 1:
+		return fmt.Errorf("wordlist contains %d duplicate words, e.g. %s", len(dups), dups[0])
+	}
+	return nil
+}
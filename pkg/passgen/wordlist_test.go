@@ -0,0 +1,84 @@
+package passgen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReadWordlist tests plain and diceware formatted lists
+func TestReadWordlist(t *testing.T) {
+	input := "# EFF style\n11111\tabacus\n11112\tabdomen\n\nzebra\n"
+	words, err := ReadWordlist(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadWordlist failed: %v", err)
+	}
+	if want := []string{"abacus", "abdomen", "zebra"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("ReadWordlist = %q, want %q", words, want)
+	}
+	if _, err := ReadWordlist(strings.NewReader("zebra\ntwo words\n")); err == nil || !strings.Contains(err.Error(), `line 2: "two words"`) {
+		t.Errorf("Expected an error for a line of two words, got %v", err)
+	}
+}
+
+// TestWordlist tests the embedded EFF wordlists
+func TestWordlist(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		first, last string
+	}{
+		{WordlistEFFLong, 7776, "abacus", "zoom"},
+		{"EFF-Short", 1296, "aardvark", "zucchini"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := Wordlist(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(words) != tt.size || words[0] != tt.first || words[len(words)-1] != tt.last {
+				t.Errorf("Got %d words from %q to %q", len(words), words[0], words[len(words)-1])
+			}
+			if err := CheckWordlist(words); err != nil {
+				t.Errorf("CheckWordlist: %v", err)
+			}
+		})
+	}
+	if _, err := Wordlist("bip39"); err == nil {
+		t.Error("Expected error for an unknown wordlist")
+	}
+}
+
+// TestCheckWordlist tests the size and duplicate checks
+func TestCheckWordlist(t *testing.T) {
+	words := make([]string, MinWordlistSize)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	if err := CheckWordlist(words); err != nil {
+		t.Errorf("Valid list rejected: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		words   []string
+		wantErr string
+	}{
+		{"too small", words[:100], "has 100 words"},
+		{"one duplicate", append(append([]string(nil), words...), "word7"), `contains "word7" twice`},
+		{"duplicates", append(append([]string(nil), words...), "word7", "word8"), "2 duplicate words"},
+		{"case duplicate", append(append([]string(nil), words...), "WORD7"), `contains "word7" and "WORD7"`},
+		{"whitespace", append(append([]string(nil), words...), "two words"), `"two words", which is not a single word`},
+		{"empty word", append(append([]string(nil), words...), ""), "empty word"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWordlist(tt.words)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckWordlist error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func init() {
+	features = append(features, "plugins")
+}
+
+// Plugins are executables named passgen-<name> found on PATH. Each call runs
+// the executable once, writes a single JSON request to its stdin and reads a
+// single JSON response from its stdout.
+const pluginPrefix = "passgen-"
+
+// Plugin capabilities, as reported in the response to a "describe" request.
+const (
+	capabilityChecker = "checker"
+	capabilityCharset = "charset"
+	capabilitySink    = "sink"
+	// capabilityRollback marks sinks that can remove a batch they stored.
+	capabilityRollback = "rollback"
+	// capabilityRead marks sinks that can return a batch they stored, for
+	// -verify-sink.
+	capabilityRead = "read"
+)
+
+// pluginRequest is the message sent to a plugin on stdin.
+type pluginRequest struct {
+	Type        string       `json:"type"`
+	Password    string       `json:"password,omitempty"`
+	Passwords   []string     `json:"passwords,omitempty"`
+	Credentials []credential `json:"credentials,omitempty"`
+	Labels      []string     `json:"labels,omitempty"`
+	// IdempotencyKey is the same for every delivery attempt of a batch.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+// pluginResponse is the message a plugin writes to stdout. Fields that do not
+// apply to the request type are left empty.
+type pluginResponse struct {
+	Name         string       `json:"name,omitempty"`
+	Capabilities []string     `json:"capabilities,omitempty"`
+	OK           bool         `json:"ok,omitempty"`
+	Reason       string       `json:"reason,omitempty"`
+	Charset      string       `json:"charset,omitempty"`
+	Credentials  []credential `json:"credentials,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// plugin is an external executable that extends passgen.
+type plugin struct {
+	name         string
+	path         string
+	capabilities []string
+	charset      string
+
+	// The last batch written, kept so it can be rolled back
+	lastKey   string
+	lastCreds []credential
+}
+
+// discoverPlugins returns the passgen-<name> executables on PATH, keyed by
+// name. Earlier PATH entries win, matching how the shell resolves commands.
+func discoverPlugins() map[string]string {
+	found := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), pluginPrefix)
+			if !ok || name == "" || entry.IsDir() {
+				continue
+			}
+			if _, seen := found[name]; seen {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if info, err := os.Stat(path); err != nil || info.Mode()&0111 == 0 {
+				continue
+			}
+			found[name] = path
+		}
+	}
+	return found
+}
+
+// loadPlugin resolves a plugin by name and asks it for its capabilities.
+func loadPlugin(name string) (*plugin, error) {
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %q not found on PATH", name)
+	}
+
+	p := &plugin{name: name, path: path}
+	resp, err := p.call(pluginRequest{Type: "describe"})
+	if err != nil {
+		return nil, err
+	}
+	p.capabilities = resp.Capabilities
+	return p, nil
+}
+
+// call runs the plugin with a single request and decodes its response.
+func (p *plugin) call(req pluginRequest) (*pluginResponse, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(p.path)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("plugin %s: %s", p.name, msg)
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid response: %v", p.name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.name, resp.Error)
+	}
+	return &resp, nil
+}
+
+// has reports whether the plugin declared the given capability.
+func (p *plugin) has(capability string) bool {
+	for _, c := range p.capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// register wires the plugin's capabilities into the generation pipeline.
+func (p *plugin) register(pipeline *passgen.Pipeline) {
+	if p.has(capabilityCharset) {
+		pipeline.UsePreValidate(func(opts *passgen.Options) error {
+			// The charset is fetched once and reused for the whole batch
+			if p.charset == "" {
+				resp, err := p.call(pluginRequest{Type: "charset"})
+				if err != nil {
+					return err
+				}
+				if resp.Charset == "" {
+					return fmt.Errorf("plugin %s returned an empty charset", p.name)
+				}
+				p.charset = resp.Charset
+			}
+			opts.ExtraCharsets = append(opts.ExtraCharsets, p.charset)
+			return nil
+		})
+	}
+	if p.has(capabilityChecker) {
+		pipeline.UsePostGenerate(func(password string) error {
+			resp, err := p.call(pluginRequest{Type: "check", Password: password})
+			if err != nil {
+				return err
+			}
+			if !resp.OK {
+				return passgen.Reject("plugin "+p.name, "by plugin %s: %s", p.name, resp.Reason)
+			}
+			return nil
+		})
+	}
+}
+
+// enablePlugins loads the named plugins and wires them into the pipeline,
+// returning those that act as sinks.
+func enablePlugins(names []string, pipeline *passgen.Pipeline) ([]sink, error) {
+	var sinks []sink
+	for _, name := range names {
+		p, err := loadPlugin(name)
+		if err != nil {
+			return nil, err
+		}
+		p.register(pipeline)
+		if p.has(capabilitySink) {
+			sinks = append(sinks, p)
+		}
+	}
+	return sinks, nil
+}
+
+// write hands a finished batch of credentials to the plugin.
+func (p *plugin) write(creds []credential) error {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return err
+	}
+	return p.writeOnce(key, creds)
+}
+
+// writeOnce delivers a batch under an idempotency key. The bare passwords are
+// sent too for plugins written before labels existed. A plugin that answers
+// ok:false has refused the batch, which retrying will not change.
+func (p *plugin) writeOnce(key string, creds []credential) error {
+	passwords := make([]string, len(creds))
+	for i, c := range creds {
+		passwords[i] = c.Password
+	}
+	resp, err := p.call(pluginRequest{Type: "sink", Passwords: passwords, Credentials: creds, IdempotencyKey: key})
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("plugin %s %w the passwords: %s", p.name, errSinkRejected, resp.Reason)
+	}
+	p.lastKey, p.lastCreds = key, creds
+	return nil
+}
+
+// undo asks the plugin to remove the last batch it stored. The request
+// carries the batch's idempotency key so the plugin can find it.
+func (p *plugin) undo() error {
+	if !p.has(capabilityRollback) {
+		return fmt.Errorf("plugin %s cannot be rolled back and keeps the credentials", p.name)
+	}
+	if p.lastCreds == nil {
+		return nil
+	}
+	resp, err := p.call(pluginRequest{Type: "rollback", Credentials: p.lastCreds, IdempotencyKey: p.lastKey})
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("plugin %s did not roll back: %s", p.name, resp.Reason)
+	}
+	p.lastKey, p.lastCreds = "", nil
+	return nil
+}
+
+func (p *plugin) canUndo() bool {
+	return p.has(capabilityRollback)
+}
+
+func (p *plugin) canReadBack() bool {
+	return p.has(capabilityRead)
+}
+
+// readBack asks the plugin for the credentials of the last batch it stored,
+// identified by the batch's idempotency key and labels.
+func (p *plugin) readBack(labels []string) ([]credential, error) {
+	if !p.has(capabilityRead) {
+		return nil, fmt.Errorf("plugin %s cannot be read back", p.name)
+	}
+	resp, err := p.call(pluginRequest{Type: "read", Labels: labels, IdempotencyKey: p.lastKey})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Credentials, nil
+}
+
+func (p *plugin) String() string {
+	return "plugin " + p.name
+}
+
+// printPlugins lists the plugins available on PATH.
+func printPlugins() {
+	found := discoverPlugins()
+	if len(found) == 0 {
+		fmt.Println("No plugins found on PATH")
+		return
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%-16s %s\n", name, found[name])
+	}
+}
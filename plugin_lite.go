@@ -0,0 +1,25 @@
+//go:build passgen_lite
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+var errPluginsUnavailable = errors.New("plugins are not available in this build (built with passgen_lite)")
+
+// enablePlugins fails for any requested plugin, since lite builds do not
+// execute external programs.
+func enablePlugins(names []string, pipeline *passgen.Pipeline) ([]sink, error) {
+	if len(names) > 0 {
+		return nil, errPluginsUnavailable
+	}
+	return nil, nil
+}
+
+func printPlugins() {
+	fmt.Println(errPluginsUnavailable)
+}
@@ -0,0 +1,126 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// testPluginScript answers describe, charset, check and sink requests. It
+// rejects any password containing the letter "Z".
+const testPluginScript = `#!/bin/sh
+read -r request
+case "$request" in
+*'"describe"'*) echo '{"name":"test","capabilities":["checker","charset","sink"]}' ;;
+*'"charset"'*) echo '{"charset":"~"}' ;;
+*'"check"'*Z*) echo '{"ok":false,"reason":"contains Z"}' ;;
+*'"check"'*) echo '{"ok":true}' ;;
+*'"sink"'*) echo "$request" > "${0%/*}/sunk"; echo '{"ok":true}' ;;
+esac
+`
+
+// installTestPlugin writes the test plugin into a fresh directory on PATH.
+func installTestPlugin(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, pluginPrefix+"test")
+	if err := os.WriteFile(path, []byte(testPluginScript), 0755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+// TestDiscoverPlugins tests that passgen-<name> executables are found on PATH
+func TestDiscoverPlugins(t *testing.T) {
+	dir := installTestPlugin(t)
+	if err := os.WriteFile(filepath.Join(dir, pluginPrefix+"noexec"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	found := discoverPlugins()
+	if _, ok := found["test"]; !ok {
+		t.Errorf("Expected plugin 'test' to be discovered, got %v", found)
+	}
+	if _, ok := found["noexec"]; ok {
+		t.Error("Non-executable file should not be discovered as a plugin")
+	}
+}
+
+// TestPluginPipeline tests that plugin capabilities are wired into generation
+func TestPluginPipeline(t *testing.T) {
+	dir := installTestPlugin(t)
+
+	p, err := loadPlugin("test")
+	if err != nil {
+		t.Fatalf("Failed to load plugin: %v", err)
+	}
+	for _, c := range []string{capabilityChecker, capabilityCharset, capabilitySink} {
+		if !p.has(c) {
+			t.Errorf("Expected plugin to declare %q", c)
+		}
+	}
+
+	pipeline := &passgen.Pipeline{}
+	p.register(pipeline)
+	for i := 0; i < 5; i++ {
+		password, err := pipeline.Generate(passgen.Options{Length: 12})
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		if !strings.Contains(password, "~") {
+			t.Errorf("Password should contain the plugin charset: %s", password)
+		}
+		if strings.Contains(password, "Z") {
+			t.Errorf("Password should have been rejected by the checker: %s", password)
+		}
+	}
+
+	if err := p.write([]credential{{Password: "first"}, {Label: "db", Password: "second"}}); err != nil {
+		t.Fatalf("Failed to sink passwords: %v", err)
+	}
+	sunk, err := os.ReadFile(filepath.Join(dir, "sunk"))
+	if err != nil {
+		t.Fatalf("Plugin did not receive the batch: %v", err)
+	}
+	if !strings.Contains(string(sunk), `"passwords":["first","second"]`) {
+		t.Errorf("Unexpected sink request: %s", sunk)
+	}
+	if !strings.Contains(string(sunk), `{"label":"db","password":"second"}`) {
+		t.Errorf("Sink request should carry labeled credentials: %s", sunk)
+	}
+	if !strings.Contains(string(sunk), `"idempotencyKey":"`) {
+		t.Errorf("Sink request should carry an idempotency key: %s", sunk)
+	}
+}
+
+// TestLoadMissingPlugin tests the error for a plugin that is not installed
+func TestLoadMissingPlugin(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := loadPlugin("missing"); err == nil {
+		t.Error("Expected error for missing plugin")
+	}
+}
+
+// TestPluginErrorResponse tests that a plugin-reported error is surfaced
+func TestPluginErrorResponse(t *testing.T) {
+	installTestPlugin(t)
+	p := &plugin{name: "test", path: filepath.Join(os.Getenv("PATH"), pluginPrefix+"test")}
+
+	// The test plugin prints nothing for unknown requests
+	_, err := p.call(pluginRequest{Type: "unknown"})
+	if err == nil || errors.Is(err, passgen.ErrRejected) {
+		t.Errorf("Expected invalid response error, got %v", err)
+	}
+}
@@ -0,0 +1,629 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printPolicyUsage(programName string) {
+	fmt.Printf("Usage: %s policy lint -in FILE [OPTIONS]\n", programName)
+	fmt.Println("Read a system password policy and report whether passwords generated with")
+	fmt.Println("passgen's defaults satisfy it, then print the passgen settings that do.")
+	fmt.Println("Exits non-zero if the defaults fall short.")
+	fmt.Println("Options:")
+	fmt.Println("  -in FILE     Policy file: pwquality.conf, a pam.d file using pam_pwquality")
+	fmt.Println("               or pam_cracklib, login.defs or sssd.conf")
+	fmt.Println("  -format NAME")
+	fmt.Printf("               Format of FILE: %s (default: from the file name)\n", strings.Join(policyFormatNames(), ", "))
+	fmt.Println("  -h           Show this help message")
+	fmt.Println()
+	fmt.Printf("Usage: %s policy import -okta DOMAIN|-azuread [OPTIONS]\n", programName)
+	fmt.Println("Fetch the password policy of an Okta org or a Microsoft Entra ID (Azure AD)")
+	fmt.Println("tenant and convert it into a policy file for -policy-file. The API token is")
+	fmt.Printf("read from %s or %s.\n", oktaTokenEnv, azureTokenEnv)
+	fmt.Println("Options:")
+	fmt.Println("  -okta DOMAIN Okta org, e.g. acme.okta.com, with the API token of a read-only admin")
+	fmt.Println("  -azuread     Entra ID tenant of the Microsoft Graph token, which needs")
+	fmt.Println("               Domain.Read.All and Directory.Read.All")
+	fmt.Println("  -name NAME   Okta password policy to import (default: the default policy)")
+	fmt.Println("  -out FILE    Write the policy file, and custom banned passwords next to it,")
+	fmt.Println("               instead of printing it")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s policy lint -in /etc/security/pwquality.conf\n", programName)
+	fmt.Printf("  %s policy import -okta acme.okta.com -out okta-policy.yaml\n", programName)
+}
+
+// Environment variables holding the API tokens of policy import.
+const (
+	oktaTokenEnv  = "OKTA_API_TOKEN"
+	azureTokenEnv = "AZURE_ACCESS_TOKEN"
+)
+
+// systemPolicy is a password policy read from a system configuration file,
+// in the terms passgen can enforce.
+type systemPolicy struct {
+	MinLength                                 int
+	MinUpper, MinLower, MinDigits, MinSpecial int
+	// MaxLength is the longest password accepted, 0 for no limit.
+	MaxLength int
+	// Exclude lists characters the policy does not accept.
+	Exclude string
+	// RejectCommon rejects the embedded list of common passwords, and
+	// Blocklist the passwords of the policy's own lists.
+	RejectCommon bool
+	Blocklist    *passgen.Blocklist
+	// MinClasses is the number of character classes a password must use.
+	MinClasses int
+	// MaxRun is the longest run of one repeated character, 0 for any.
+	MaxRun int
+	// MaxAge is how long a password may be used, 0 for no limit.
+	MaxAge time.Duration
+	// Notes explains settings that passgen cannot map.
+	Notes []string
+}
+
+// policyFormats parse the supported configuration files, in the order they
+// are listed in the help.
+var policyFormats = []struct {
+	name  string
+	parse func(r io.Reader, p *systemPolicy) error
+}{
+	{"pwquality", parsePwquality},
+	{"pam", parsePAMPolicy},
+	{"login.defs", parseLoginDefs},
+	{"sssd", parseSSSD},
+}
+
+func policyFormatNames() []string {
+	names := make([]string, len(policyFormats))
+	for i, f := range policyFormats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// detectPolicyFormat guesses the format of a policy file from its name.
+func detectPolicyFormat(path string) (string, error) {
+	base := filepath.Base(path)
+	switch {
+	case strings.Contains(base, "pwquality"):
+		return "pwquality", nil
+	case strings.Contains(base, "login.defs"):
+		return "login.defs", nil
+	case strings.Contains(base, "sssd"):
+		return "sssd", nil
+	case filepath.Base(filepath.Dir(path)) == "pam.d":
+		return "pam", nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s; use -format %s", path, strings.Join(policyFormatNames(), "|"))
+}
+
+// parsePolicy reads a policy file in the named format.
+func parsePolicy(r io.Reader, format string) (*systemPolicy, error) {
+	for _, f := range policyFormats {
+		if f.name == format {
+			p := &systemPolicy{}
+			if err := f.parse(r, p); err != nil {
+				return nil, err
+			}
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown policy format %q (use %s)", format, strings.Join(policyFormatNames(), ", "))
+}
+
+// readPolicy reads a policy file, guessing its format from the name when
+// format is empty.
+func readPolicy(path, format string) (*systemPolicy, error) {
+	if format == "" {
+		var err error
+		if format, err = detectPolicyFormat(path); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := parsePolicy(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// configLines calls fn with each line of r that is neither blank nor a
+// comment, trimmed.
+func configLines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	num := 0
+	for scanner.Scan() {
+		num++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("line %d: %v", num, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parsePwquality reads /etc/security/pwquality.conf, which holds one
+// "name = value" setting per line.
+func parsePwquality(r io.Reader, p *systemPolicy) error {
+	p.MinLength = 8 // pwquality's built-in minlen
+	return configLines(r, func(line string) error {
+		name, value, _ := strings.Cut(line, "=")
+		return p.setPwquality(strings.TrimSpace(name), strings.TrimSpace(value))
+	})
+}
+
+// parsePAMPolicy reads a pam.d file and takes the settings from the
+// arguments of its pam_pwquality or pam_cracklib password line.
+func parsePAMPolicy(r io.Reader, p *systemPolicy) error {
+	found := false
+	err := configLines(r, func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "password" {
+			return nil
+		}
+		// The control field may be a [bracketed list] of several words
+		i := 1
+		if strings.HasPrefix(fields[i], "[") {
+			for i < len(fields) && !strings.HasSuffix(fields[i], "]") {
+				i++
+			}
+		}
+		if i+1 >= len(fields) {
+			return nil
+		}
+		module := filepath.Base(fields[i+1])
+		if module != "pam_pwquality.so" && module != "pam_cracklib.so" {
+			return nil
+		}
+		found = true
+		// pam_cracklib's minlen defaults to 9, pwquality's to 8
+		p.MinLength = 8
+		if module == "pam_cracklib.so" {
+			p.MinLength = 9
+		}
+		for _, arg := range fields[i+2:] {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				continue
+			}
+			if err := p.setPwquality(name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("no password line uses pam_pwquality.so or pam_cracklib.so")
+	}
+	return err
+}
+
+// setPwquality applies one pwquality or cracklib setting.
+func (p *systemPolicy) setPwquality(name, value string) error {
+	n, err := strconv.Atoi(value)
+	number := func() (int, error) {
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a number, not %q", name, value)
+		}
+		return n, nil
+	}
+	// A negative credit is a minimum count; a positive one lets characters
+	// of the class count towards minlen, which passgen ignores to be safe
+	credit := func(min *int) error {
+		n, err := number()
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			*min = -n
+		} else if n > 0 {
+			p.Notes = append(p.Notes, fmt.Sprintf("%s = %d lets characters count towards minlen; passgen meets minlen without the credit", name, n))
+		}
+		return nil
+	}
+	switch name {
+	case "minlen":
+		p.MinLength, err = number()
+		return err
+	case "ucredit":
+		return credit(&p.MinUpper)
+	case "lcredit":
+		return credit(&p.MinLower)
+	case "dcredit":
+		return credit(&p.MinDigits)
+	case "ocredit":
+		return credit(&p.MinSpecial)
+	case "minclass":
+		p.MinClasses, err = number()
+		return err
+	case "maxrepeat":
+		p.MaxRun, err = number()
+		return err
+	case "maxclassrepeat", "maxsequence":
+		if n, err := number(); err != nil || n == 0 {
+			return err
+		}
+		p.Notes = append(p.Notes, fmt.Sprintf("%s = %s cannot be mapped; check passwords with passgen check before use", name, value))
+	case "dictcheck", "usercheck", "gecoscheck":
+		if value == "0" {
+			return nil
+		}
+		fallthrough
+	case "badwords", "dictpath":
+		p.Notes = append(p.Notes, fmt.Sprintf("%s: random passwords rarely contain words, but this is not guaranteed", name))
+	}
+	// Settings about retries, old passwords and root do not concern
+	// generated passwords
+	return nil
+}
+
+// parseLoginDefs reads /etc/login.defs for the shadow suite's minimum
+// length and maximum age.
+func parseLoginDefs(r io.Reader, p *systemPolicy) error {
+	return configLines(r, func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil
+		}
+		switch fields[0] {
+		case "PASS_MIN_LEN":
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return fmt.Errorf("PASS_MIN_LEN must be a number, not %q", fields[1])
+			}
+			p.MinLength = n
+		case "PASS_MAX_DAYS":
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return fmt.Errorf("PASS_MAX_DAYS must be a number, not %q", fields[1])
+			}
+			// 99999 is the customary "never expires"
+			if n > 0 && n < 99999 {
+				p.MaxAge = time.Duration(n) * 24 * time.Hour
+			}
+		}
+		return nil
+	})
+}
+
+// parseSSSD reads sssd.conf. SSSD passes password changes on to its
+// identity provider, so the policy is that of the provider: the defaults
+// of Active Directory and FreeIPA are assumed, anything else is noted.
+func parseSSSD(r io.Reader, p *systemPolicy) error {
+	var domain string
+	var domains []string
+	settings := make(map[string]map[string]string)
+	err := configLines(r, func(line string) error {
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			domain = ""
+			if name, ok := strings.CutPrefix(line[1:len(line)-1], "domain/"); ok {
+				domain = name
+				domains = append(domains, name)
+				settings[name] = make(map[string]string)
+			}
+			return nil
+		}
+		if domain != "" {
+			name, value, _ := strings.Cut(line, "=")
+			settings[domain][strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(domains) == 0 {
+		return fmt.Errorf("no [domain/...] sections")
+	}
+	for _, domain := range domains {
+		// chpass_provider falls back to auth_provider, then id_provider
+		s := settings[domain]
+		provider := cmp.Or(s["chpass_provider"], s["auth_provider"], s["id_provider"])
+		switch provider {
+		case "ad":
+			// Default Domain Policy: 7 characters and 3 of the 4 classes
+			p.MinLength = max(p.MinLength, 7)
+			p.MinClasses = max(p.MinClasses, 3)
+			p.Notes = append(p.Notes, fmt.Sprintf("domain %s uses Active Directory; assuming the Default Domain Policy, check the domain's actual policy", domain))
+		case "ipa":
+			// global_policy: 8 characters
+			p.MinLength = max(p.MinLength, 8)
+			p.Notes = append(p.Notes, fmt.Sprintf("domain %s uses FreeIPA; assuming global_policy, check with ipa pwpolicy-show", domain))
+		default:
+			if provider == "" {
+				provider = "none"
+			}
+			p.Notes = append(p.Notes, fmt.Sprintf("domain %s changes passwords through provider %s, whose policy cannot be read here", domain, provider))
+		}
+	}
+	return nil
+}
+
+// policyFinding is one requirement of a policy checked against passgen's
+// defaults.
+type policyFinding struct {
+	OK   bool
+	Text string
+}
+
+// lintDefaults checks the policy against passwords generated with
+// passgen's defaults: 12 characters of upper, lower and digits with at
+// least one of each.
+func lintDefaults(p *systemPolicy) []policyFinding {
+	var findings []policyFinding
+	add := func(ok bool, format string, args ...any) {
+		findings = append(findings, policyFinding{ok, fmt.Sprintf(format, args...)})
+	}
+	add(p.MinLength <= 12, "minimum length %d (default length 12)", p.MinLength)
+	for _, class := range []struct {
+		name string
+		min  int
+		have int
+	}{{"uppercase", p.MinUpper, 1}, {"lowercase", p.MinLower, 1}, {"digits", p.MinDigits, 1}, {"special", p.MinSpecial, 0}} {
+		if class.min > 0 {
+			add(class.min <= class.have, "at least %d %s (default guarantees %d)", class.min, class.name, class.have)
+		}
+	}
+	if p.MinClasses > 0 {
+		add(p.MinClasses <= 3, "at least %d character classes (default uses 3)", p.MinClasses)
+	}
+	if p.MaxRun > 0 {
+		add(false, "no character repeated more than %d times in a row (not guaranteed by default)", p.MaxRun)
+	}
+	return findings
+}
+
+// results checks an existing password against the policy.
+func (p *systemPolicy) results(password string) []checkResult {
+	var results []checkResult
+	add := func(ok bool, format string, args ...any) {
+		results = append(results, checkResult{ok, fmt.Sprintf(format, args...)})
+	}
+	if p.MinLength > 0 {
+		add(len([]rune(password)) >= p.MinLength, "policy: at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 {
+		add(len([]rune(password)) <= p.MaxLength, "policy: at most %d characters", p.MaxLength)
+	}
+	if p.Exclude != "" {
+		add(!strings.ContainsAny(password, p.Exclude), "policy: none of the characters %s", p.Exclude)
+	}
+	counts := classCounts(password)
+	for _, class := range []struct {
+		name string
+		min  int
+		have int
+	}{{"uppercase", p.MinUpper, counts[passgen.ClassUpper]}, {"lowercase", p.MinLower, counts[passgen.ClassLower]},
+		{"digits", p.MinDigits, counts[passgen.ClassDigit]}, {"special", p.MinSpecial, counts[passgen.ClassSpecial]}} {
+		if class.min > 0 {
+			add(class.have >= class.min, "policy: at least %d %s", class.min, class.name)
+		}
+	}
+	if p.MinClasses > 0 {
+		used := 0
+		for _, n := range counts {
+			if n > 0 {
+				used++
+			}
+		}
+		add(used >= p.MinClasses, "policy: at least %d character classes", p.MinClasses)
+	}
+	if p.MaxRun > 0 {
+		add(passgen.LongestRun(password) <= p.MaxRun, "policy: no character repeated more than %d times in a row", p.MaxRun)
+	}
+	if p.RejectCommon {
+		add(!commonBlocklist().Contains(password), "policy: not a common password")
+	}
+	if p.Blocklist != nil {
+		add(!p.Blocklist.Contains(password), "policy: not on the policy's blocklists")
+	}
+	return results
+}
+
+// manifestSettings returns the settings of a cron manifest secret that
+// satisfy the policy, in manifest order.
+func manifestSettings(p *systemPolicy) []string {
+	special := p.MinSpecial > 0 || p.MinClasses >= 4
+	length := max(12, p.MinLength, max(p.MinUpper, 1)+max(p.MinLower, 1)+max(p.MinDigits, 1)+p.MinSpecial)
+	var settings []string
+	if p.MaxAge > 0 {
+		settings = append(settings, fmt.Sprintf("max-age: %dd", int(p.MaxAge.Hours()/24)))
+	}
+	settings = append(settings, fmt.Sprintf("length: %d", length))
+	if special {
+		settings = append(settings, "special: true")
+	}
+	for _, m := range []struct {
+		key string
+		n   int
+	}{{"min-upper", p.MinUpper}, {"min-lower", p.MinLower}, {"min-digits", p.MinDigits}, {"min-special", p.MinSpecial}} {
+		if m.n > 1 {
+			settings = append(settings, fmt.Sprintf("%s: %d", m.key, m.n))
+		}
+	}
+	if p.MaxRun > 0 {
+		settings = append(settings, fmt.Sprintf(`rules: ["maxRun <= %d"]`, p.MaxRun))
+	}
+	return settings
+}
+
+// runPolicy implements the policy subcommand.
+func runPolicy(programName string, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		printPolicyUsage(programName)
+		return nil
+	}
+	switch args[0] {
+	case "lint":
+	case "import":
+		return runPolicyImport(programName, args[1:])
+	default:
+		return fmt.Errorf("unknown policy command %q (use lint or import)", args[0])
+	}
+
+	fs := flag.NewFlagSet("policy lint", flag.ContinueOnError)
+	in := fs.String("in", "", "Policy file to read")
+	format := fs.String("format", "", "Format of the policy file")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printPolicyUsage(programName) }
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *help {
+		printPolicyUsage(programName)
+		return nil
+	}
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if *format == "" {
+		var err error
+		if *format, err = detectPolicyFormat(*in); err != nil {
+			return err
+		}
+	}
+	policy, err := readPolicy(*in, *format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Policy: %s (%s)\n\n", *in, *format)
+	failed := 0
+	for _, finding := range lintDefaults(policy) {
+		mark := "ok  "
+		if !finding.OK {
+			mark = "FAIL"
+			failed++
+		}
+		fmt.Printf("%s %s\n", mark, finding.Text)
+	}
+	for _, note := range policy.Notes {
+		fmt.Printf("note %s\n", note)
+	}
+	fmt.Println("\nA cron manifest secret that satisfies it:")
+	fmt.Println("  - label: CHANGE-ME")
+	for _, setting := range manifestSettings(policy) {
+		fmt.Printf("    %s\n", setting)
+	}
+	if policy.MaxAge == 0 {
+		fmt.Println("    max-age: 90d  # the policy sets none; pick a rotation period")
+	}
+	if failed > 0 {
+		return fmt.Errorf("passgen defaults fail %d requirement(s) of %s", failed, *in)
+	}
+	fmt.Println("\npassgen defaults satisfy this policy.")
+	return nil
+}
+
+// oktaOrgURL returns the base URL of an Okta org given as a domain or an
+// https URL.
+func oktaOrgURL(org string) (string, error) {
+	if !strings.Contains(org, "://") {
+		org = "https://" + org
+	}
+	// The API token would otherwise be sent in the clear
+	if !strings.HasPrefix(org, "https://") {
+		return "", fmt.Errorf("-okta %s must use https", org)
+	}
+	return strings.TrimRight(org, "/"), nil
+}
+
+// runPolicyImport implements policy import.
+func runPolicyImport(programName string, args []string) error {
+	fs := flag.NewFlagSet("policy import", flag.ContinueOnError)
+	okta := fs.String("okta", "", "Okta org to import from")
+	azureAD := fs.Bool("azuread", false, "Import from the Entra ID tenant of the Graph token")
+	name := fs.String("name", "", "Okta password policy to import")
+	out := fs.String("out", "", "Write the policy file to FILE")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printPolicyUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printPolicyUsage(programName)
+		return nil
+	}
+	if (*okta == "") == !*azureAD {
+		return fmt.Errorf("give one of -okta DOMAIN or -azuread")
+	}
+	if *name != "" && *okta == "" {
+		return fmt.Errorf("-name only applies to -okta")
+	}
+
+	provider, baseURL, tokenEnv, scheme := "azuread", graphURL, azureTokenEnv, "Bearer "
+	if *okta != "" {
+		var err error
+		if baseURL, err = oktaOrgURL(*okta); err != nil {
+			return err
+		}
+		provider, tokenEnv, scheme = "okta", oktaTokenEnv, "SSWS "
+	}
+	token := strings.TrimSpace(os.Getenv(tokenEnv))
+	if token == "" {
+		return fmt.Errorf("set %s to an API token", tokenEnv)
+	}
+	s, banned, err := importPolicy(provider, baseURL, scheme+token, *name)
+	if err != nil {
+		return err
+	}
+	return savePolicyImport(s, banned, *out)
+}
+
+// savePolicyImport writes an imported policy to out, or stdout if it is
+// empty. Banned passwords go to a blocklist next to out.
+func savePolicyImport(s *standardPolicy, banned []string, out string) error {
+	bannedPath := ""
+	if len(banned) > 0 {
+		if out == "" {
+			s.policy.Notes = append(s.policy.Notes, fmt.Sprintf("%d custom banned passwords were not saved; use -out", len(banned)))
+		} else {
+			bannedPath = strings.TrimSuffix(out, filepath.Ext(out)) + "-banned.txt"
+			s.blocklistFiles = []string{filepath.Base(bannedPath)}
+		}
+	}
+	var b strings.Builder
+	if err := writePolicyFile(&b, s); err != nil {
+		return err
+	}
+	// Check the result reads back before anyone relies on it
+	if _, err := parseStandardPolicy(s.title, b.String()); err != nil {
+		return fmt.Errorf("imported policy is invalid: %v", err)
+	}
+	if out == "" {
+		_, err := io.WriteString(os.Stdout, b.String())
+		return err
+	}
+	if bannedPath != "" {
+		if err := os.WriteFile(bannedPath, []byte(strings.Join(banned, "\n")+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(out, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported %s to %s\n", s.title, out)
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParsePolicy tests reading the supported policy formats
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		text   string
+		want   systemPolicy
+	}{
+		{"pwquality", "pwquality", "# comment\nminlen = 14\ndcredit = -2\nucredit = 1\nminclass = 3\nmaxrepeat = 2\nretry = 3\n",
+			systemPolicy{MinLength: 14, MinDigits: 2, MinClasses: 3, MaxRun: 2}},
+		{"pwquality defaults", "pwquality", "", systemPolicy{MinLength: 8}},
+		{"pam cracklib", "pam", "password requisite pam_cracklib.so retry=3 ocredit=-1\npassword sufficient pam_unix.so\n",
+			systemPolicy{MinLength: 9, MinSpecial: 1}},
+		{"pam control list", "pam", "password [success=1 default=ignore] /lib/security/pam_pwquality.so minlen=16\n",
+			systemPolicy{MinLength: 16}},
+		{"login.defs", "login.defs", "PASS_MAX_DAYS\t90\nPASS_MIN_LEN\t10\nPASS_WARN_AGE 7\n",
+			systemPolicy{MinLength: 10, MaxAge: 90 * 24 * time.Hour}},
+		{"login.defs never expires", "login.defs", "PASS_MAX_DAYS 99999\n", systemPolicy{}},
+		{"sssd ad", "sssd", "[sssd]\ndomains = corp\n\n[domain/corp]\nid_provider = ad\n",
+			systemPolicy{MinLength: 7, MinClasses: 3}},
+		{"sssd ipa chpass", "sssd", "[domain/corp]\nid_provider = ldap\nchpass_provider = ipa\n",
+			systemPolicy{MinLength: 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsePolicy(strings.NewReader(tt.text), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Notes = nil
+			if !reflect.DeepEqual(*p, tt.want) {
+				t.Errorf("Got %+v, want %+v", *p, tt.want)
+			}
+		})
+	}
+}
+
+// TestParsePolicyErrors tests malformed and unsupported policy files
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		format, text string
+	}{
+		{"pwquality", "minlen = many\n"},
+		{"pam", "password required pam_unix.so\n"},
+		{"login.defs", "PASS_MIN_LEN x\n"},
+		{"sssd", "[sssd]\ndomains = none\n"},
+		{"shadow", ""},
+	}
+	for _, tt := range tests {
+		if _, err := parsePolicy(strings.NewReader(tt.text), tt.format); err == nil {
+			t.Errorf("Expected error for %s %q", tt.format, tt.text)
+		}
+	}
+}
+
+// TestDetectPolicyFormat tests guessing the format from the file name
+func TestDetectPolicyFormat(t *testing.T) {
+	tests := map[string]string{
+		"/etc/security/pwquality.conf": "pwquality",
+		"/etc/login.defs":              "login.defs",
+		"/etc/sssd/sssd.conf":          "sssd",
+		"/etc/pam.d/common-password":   "pam",
+	}
+	for path, want := range tests {
+		if got, err := detectPolicyFormat(path); err != nil || got != want {
+			t.Errorf("detectPolicyFormat(%q) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+	if _, err := detectPolicyFormat("policy.txt"); err == nil {
+		t.Error("Expected error for an unknown file name")
+	}
+}
+
+// TestLintDefaults tests which requirements passgen's defaults meet and
+// the manifest settings suggested instead
+func TestLintDefaults(t *testing.T) {
+	p := &systemPolicy{MinLength: 14, MinDigits: 2, MinSpecial: 1, MinClasses: 4, MaxRun: 3, MaxAge: 30 * 24 * time.Hour}
+	var failed []string
+	for _, f := range lintDefaults(p) {
+		if !f.OK {
+			failed = append(failed, f.Text)
+		}
+	}
+	if len(failed) != 5 {
+		t.Errorf("Got %d failures, want 5: %q", len(failed), failed)
+	}
+	want := []string{"max-age: 30d", "length: 14", "special: true", "min-digits: 2", `rules: ["maxRun <= 3"]`}
+	if got := manifestSettings(p); !slices.Equal(got, want) {
+		t.Errorf("manifestSettings = %q, want %q", got, want)
+	}
+
+	for _, f := range lintDefaults(&systemPolicy{MinLength: 8, MinClasses: 3}) {
+		if !f.OK {
+			t.Errorf("Defaults should meet %q", f.Text)
+		}
+	}
+}
+
+// TestManifestSettingsParse tests that suggested settings form a valid
+// manifest secret
+func TestManifestSettingsParse(t *testing.T) {
+	p := &systemPolicy{MinLength: 10, MinUpper: 4, MinLower: 4, MinDigits: 4, MinSpecial: 2, MaxAge: 24 * time.Hour}
+	text := "out: creds.csv\nsecrets:\n  - label: db\n    " + strings.Join(manifestSettings(p), "\n    ") + "\n"
+	m, err := parseManifest(text)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, text)
+	}
+	if s := m.Secrets[0]; s.Length != 14 || s.MinSpecial != 2 {
+		t.Errorf("Got %+v", s)
+	}
+}
+
+// TestPolicyResults tests checking an existing password against a policy
+func TestPolicyResults(t *testing.T) {
+	p := &systemPolicy{MinLength: 10, MinDigits: 2, MinClasses: 3, MaxRun: 2}
+	tests := []struct {
+		password string
+		failed   []string
+	}{
+		{"Zq9vkL2pXw", nil},
+		{"Zq9vkL2p", []string{"policy: at least 10 characters"}},
+		{"zq9vkl2pxw", []string{"policy: at least 3 character classes"}},
+		{"Zqqqvkl2pX9", []string{"policy: no character repeated more than 2 times in a row"}},
+		{"Zq9vkLApXw", []string{"policy: at least 2 digits"}},
+	}
+	for _, tt := range tests {
+		var failed []string
+		for _, r := range p.results(tt.password) {
+			if !r.OK {
+				failed = append(failed, r.Text)
+			}
+		}
+		if strings.Join(failed, ";") != strings.Join(tt.failed, ";") {
+			t.Errorf("%q failed %q, want %q", tt.password, failed, tt.failed)
+		}
+	}
+}
+
+// TestReadPolicy tests reading a policy file with and without a format
+func TestReadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwquality.conf")
+	if err := os.WriteFile(path, []byte("minlen = 14\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"", "pwquality"} {
+		p, err := readPolicy(path, format)
+		if err != nil || p.MinLength != 14 {
+			t.Errorf("readPolicy(%q) = %+v, %v", format, p, err)
+		}
+	}
+	if _, err := readPolicy(path, "nope"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+// TestOktaOrgURL tests the accepted Okta orgs
+func TestOktaOrgURL(t *testing.T) {
+	tests := []struct {
+		org  string
+		want string
+	}{
+		{"acme.okta.com", "https://acme.okta.com"},
+		{"https://acme.okta.com/", "https://acme.okta.com"},
+		{"http://acme.okta.com", ""},
+	}
+	for _, tt := range tests {
+		got, err := oktaOrgURL(tt.org)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("oktaOrgURL(%q) = %q, %v, want %q", tt.org, got, err, tt.want)
+		}
+	}
+}
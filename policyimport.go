@@ -0,0 +1,258 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func init() {
+	features = append(features, "policy-import")
+}
+
+// graphURL is the Microsoft Graph API, which serves the Microsoft Entra ID
+// (Azure AD) settings.
+const graphURL = "https://graph.microsoft.com"
+
+// passwordRuleSettings is the template of the tenant setting that holds
+// Entra ID's custom banned passwords.
+const passwordRuleSettings = "5cf42378-d67d-4f36-ba46-e8b86229381d"
+
+// maxPolicyResponse bounds an API response; policies are a few kilobytes.
+const maxPolicyResponse = 10 << 20
+
+// importPolicy fetches the password policy of an identity provider, okta or
+// azuread, from baseURL with the Authorization header auth. name picks an
+// Okta policy. It returns the policy and the provider's custom banned
+// passwords.
+func importPolicy(provider, baseURL, auth, name string) (*standardPolicy, []string, error) {
+	c := newPolicyClient(baseURL, auth)
+	if provider == "okta" {
+		s, err := importOkta(c, name)
+		return s, nil, err
+	}
+	return importAzureAD(c)
+}
+
+// policyClient fetches password policies from identity provider APIs.
+type policyClient struct {
+	baseURL string
+	// auth is the Authorization header.
+	auth   string
+	client *http.Client
+}
+
+func newPolicyClient(baseURL, auth string) *policyClient {
+	return &policyClient{baseURL: baseURL, auth: auth, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// get decodes the JSON response to a GET of path into v.
+func (c *policyClient) get(path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.auth)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "passgen/"+version)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPolicyResponse)).Decode(v); err != nil {
+		return fmt.Errorf("GET %s: %w", path, err)
+	}
+	return nil
+}
+
+// oktaPolicy is a password policy of the Okta policy API, reduced to the
+// settings passgen can use.
+type oktaPolicy struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	System   bool   `json:"system"`
+	Settings struct {
+		Password struct {
+			Complexity struct {
+				MinLength         int      `json:"minLength"`
+				MinLowerCase      int      `json:"minLowerCase"`
+				MinUpperCase      int      `json:"minUpperCase"`
+				MinNumber         int      `json:"minNumber"`
+				MinSymbol         int      `json:"minSymbol"`
+				ExcludeUsername   bool     `json:"excludeUsername"`
+				ExcludeAttributes []string `json:"excludeAttributes"`
+				Dictionary        struct {
+					Common struct {
+						Exclude bool `json:"exclude"`
+					} `json:"common"`
+				} `json:"dictionary"`
+			} `json:"complexity"`
+			Age struct {
+				MaxAgeDays   int `json:"maxAgeDays"`
+				HistoryCount int `json:"historyCount"`
+			} `json:"age"`
+		} `json:"password"`
+	} `json:"settings"`
+}
+
+// importOkta converts the password policy of an Okta org named name, or
+// without a name its default policy.
+func importOkta(c *policyClient, name string) (*standardPolicy, error) {
+	var policies []oktaPolicy
+	if err := c.get("/api/v1/policies?type=PASSWORD", &policies); err != nil {
+		return nil, fmt.Errorf("reading Okta password policies: %w", err)
+	}
+	return oktaStandard(policies, name)
+}
+
+// oktaStandard picks a policy and converts it. The default policy applies
+// to everyone the other policies do not cover.
+func oktaStandard(policies []oktaPolicy, name string) (*standardPolicy, error) {
+	var names []string
+	var picked *oktaPolicy
+	for i := range policies {
+		op := &policies[i]
+		names = append(names, op.Name)
+		if name != "" && strings.EqualFold(op.Name, name) || name == "" && op.System {
+			picked = op
+		}
+	}
+	if picked == nil {
+		if name == "" {
+			return nil, fmt.Errorf("no default Okta password policy; choose one with -name: %s", strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("no Okta password policy %q; the org has %s", name, strings.Join(names, ", "))
+	}
+
+	complexity := &picked.Settings.Password.Complexity
+	age := &picked.Settings.Password.Age
+	s := &standardPolicy{
+		title:   "Okta: " + picked.Name,
+		special: complexity.MinSymbol > 0,
+		policy: systemPolicy{
+			MinLength:    complexity.MinLength,
+			MinUpper:     complexity.MinUpperCase,
+			MinLower:     complexity.MinLowerCase,
+			MinDigits:    complexity.MinNumber,
+			MinSpecial:   complexity.MinSymbol,
+			MaxAge:       time.Duration(age.MaxAgeDays) * 24 * time.Hour,
+			RejectCommon: complexity.Dictionary.Common.Exclude,
+		},
+	}
+	s.length = max(s.policy.MinLength, 16)
+	p := &s.policy
+	if picked.Status != "ACTIVE" {
+		p.Notes = append(p.Notes, fmt.Sprintf("the Okta policy is %s", strings.ToLower(picked.Status)))
+	}
+	if complexity.ExcludeUsername {
+		p.Notes = append(p.Notes, "Okta rejects passwords containing the user name; add -avoid with it")
+	}
+	if len(complexity.ExcludeAttributes) > 0 {
+		p.Notes = append(p.Notes, fmt.Sprintf("Okta rejects passwords containing the user's %s; add -avoid with them", strings.Join(complexity.ExcludeAttributes, ", ")))
+	}
+	if age.HistoryCount > 0 {
+		p.Notes = append(p.Notes, fmt.Sprintf("Okta rejects the last %d passwords of a user", age.HistoryCount))
+	}
+	if others := slices.DeleteFunc(names, func(n string) bool { return n == picked.Name }); len(others) > 0 {
+		p.Notes = append(p.Notes, fmt.Sprintf("other Okta password policies apply to some groups: %s", strings.Join(others, ", ")))
+	}
+	return s, nil
+}
+
+// graphDomains is the response of the Graph domains API.
+type graphDomains struct {
+	Value []struct {
+		ID        string `json:"id"`
+		IsDefault bool   `json:"isDefault"`
+		// PasswordValidityPeriodInDays is 2147483647 when passwords
+		// never expire.
+		PasswordValidityPeriodInDays int `json:"passwordValidityPeriodInDays"`
+	} `json:"value"`
+}
+
+// graphSettings is the response of the Graph group settings API, which
+// holds the tenant-wide settings.
+type graphSettings struct {
+	Value []struct {
+		TemplateID string `json:"templateId"`
+		Values     []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"values"`
+	} `json:"value"`
+}
+
+// importAzureAD converts the password policy of a Microsoft Entra ID
+// tenant, returning its custom banned passwords separately.
+func importAzureAD(c *policyClient) (*standardPolicy, []string, error) {
+	var domains graphDomains
+	if err := c.get("/v1.0/domains", &domains); err != nil {
+		return nil, nil, fmt.Errorf("reading Entra ID domains: %w", err)
+	}
+	var settings graphSettings
+	if err := c.get("/v1.0/groupSettings", &settings); err != nil {
+		return nil, nil, fmt.Errorf("reading Entra ID settings: %w", err)
+	}
+	s, banned := azureADStandard(&domains, &settings)
+	return s, banned, nil
+}
+
+// azureADStandard converts the tenant's settings. Entra ID's complexity
+// rules are fixed: 8 to 256 characters from 3 of the 4 classes, screened
+// against a global list of banned passwords.
+func azureADStandard(domains *graphDomains, settings *graphSettings) (*standardPolicy, []string) {
+	s := &standardPolicy{
+		title:   "Microsoft Entra ID",
+		length:  16,
+		special: true,
+		policy: systemPolicy{
+			MinLength:    8,
+			MaxLength:    256,
+			MinClasses:   3,
+			RejectCommon: true,
+		},
+	}
+	p := &s.policy
+	for _, d := range domains.Value {
+		if d.IsDefault {
+			s.title += ": " + d.ID
+			if days := d.PasswordValidityPeriodInDays; days > 0 && days < 1<<31-1 {
+				p.MaxAge = time.Duration(days) * 24 * time.Hour
+			}
+		}
+	}
+
+	var banned []string
+	for _, setting := range settings.Value {
+		if setting.TemplateID != passwordRuleSettings {
+			continue
+		}
+		enabled := false
+		var list string
+		for _, v := range setting.Values {
+			switch v.Name {
+			case "EnableBannedPasswordCheck":
+				enabled = passgen.EqualFoldASCII(v.Value, "true")
+			case "BannedPasswordList":
+				list = v.Value
+			}
+		}
+		if enabled {
+			banned = strings.FieldsFunc(list, func(r rune) bool { return r == '\t' || r == '\n' || r == '\r' })
+		}
+	}
+	p.Notes = append(p.Notes, "Entra ID matches banned passwords fuzzily, also in l33t and as substrings; passgen only rejects exact matches")
+	return s, banned
+}
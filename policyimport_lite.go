@@ -0,0 +1,14 @@
+//go:build passgen_lite
+
+package main
+
+import "errors"
+
+// graphURL is the Microsoft Graph API, which serves the Microsoft Entra ID
+// (Azure AD) settings.
+const graphURL = "https://graph.microsoft.com"
+
+// importPolicy fails, since lite builds do not talk to remote services.
+func importPolicy(provider, baseURL, auth, name string) (*standardPolicy, []string, error) {
+	return nil, nil, errors.New("policy import is not available in this build (built with passgen_lite)")
+}
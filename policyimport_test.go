@@ -0,0 +1,132 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const oktaPoliciesJSON = `[
+  {"name": "Default Policy", "status": "ACTIVE", "system": true,
+   "settings": {"password": {
+     "complexity": {"minLength": 8, "minLowerCase": 1, "minUpperCase": 1, "minNumber": 1, "minSymbol": 0,
+       "excludeUsername": true, "dictionary": {"common": {"exclude": true}}},
+     "age": {"maxAgeDays": 0, "historyCount": 4}}}},
+  {"name": "Admins", "status": "ACTIVE", "system": false,
+   "settings": {"password": {
+     "complexity": {"minLength": 20, "minSymbol": 1, "excludeAttributes": ["firstName", "lastName"]},
+     "age": {"maxAgeDays": 60}}}}
+]`
+
+// TestImportOkta tests reading and converting Okta password policies
+func TestImportOkta(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/policies" || r.URL.Query().Get("type") != "PASSWORD" || r.Header.Get("Authorization") != "SSWS secret" {
+			http.Error(w, "unexpected request", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(oktaPoliciesJSON))
+	}))
+	defer srv.Close()
+
+	s, banned, err := importPolicy("okta", srv.URL, "SSWS secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if banned != nil {
+		t.Errorf("Okta returned banned passwords %q", banned)
+	}
+	p := s.policy
+	if s.title != "Okta: Default Policy" || s.special || s.length != 16 || p.MinLength != 8 || p.MinUpper != 1 || p.MinDigits != 1 || !p.RejectCommon || p.MaxAge != 0 {
+		t.Errorf("Unexpected default policy %+v", s)
+	}
+	if len(p.Notes) != 3 || !strings.Contains(p.Notes[0], "-avoid") || !strings.Contains(p.Notes[2], "Admins") {
+		t.Errorf("Unexpected notes %q", p.Notes)
+	}
+
+	s, _, err = importPolicy("okta", srv.URL, "SSWS secret", "admins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.special || s.length != 20 || s.policy.MinSpecial != 1 || s.policy.MaxAge != 60*24*time.Hour || s.policy.RejectCommon {
+		t.Errorf("Unexpected Admins policy %+v", s)
+	}
+	if _, _, err := importPolicy("okta", srv.URL, "SSWS secret", "Contractors"); err == nil || !strings.Contains(err.Error(), "Default Policy, Admins") {
+		t.Errorf("Expected an error listing the policies, got %v", err)
+	}
+	if _, _, err := importPolicy("okta", srv.URL, "SSWS wrong", ""); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a 403 error, got %v", err)
+	}
+}
+
+// TestImportAzureAD tests reading and converting the Entra ID settings
+func TestImportAzureAD(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unexpected token", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1.0/domains":
+			w.Write([]byte(`{"value": [
+				{"id": "acme.onmicrosoft.com", "isDefault": false, "passwordValidityPeriodInDays": 2147483647},
+				{"id": "acme.com", "isDefault": true, "passwordValidityPeriodInDays": 90}]}`))
+		case "/v1.0/groupSettings":
+			w.Write([]byte(`{"value": [
+				{"templateId": "08d542b9-071f-4e16-94b0-74abb372e3d9", "values": [{"name": "EnableGroupCreation", "value": "false"}]},
+				{"templateId": "` + passwordRuleSettings + `", "values": [
+					{"name": "EnableBannedPasswordCheck", "value": "True"},
+					{"name": "BannedPasswordList", "value": "acme\tacmecorp\troadrunner"}]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	s, banned, err := importPolicy("azuread", srv.URL, "Bearer secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := s.policy
+	if s.title != "Microsoft Entra ID: acme.com" || p.MinLength != 8 || p.MaxLength != 256 || p.MinClasses != 3 || p.MaxAge != 90*24*time.Hour {
+		t.Errorf("Unexpected policy %+v", s)
+	}
+	if !slices.Equal(banned, []string{"acme", "acmecorp", "roadrunner"}) {
+		t.Errorf("banned = %q", banned)
+	}
+	if _, _, err := importPolicy("azuread", srv.URL, "Bearer wrong", ""); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected a 401 error, got %v", err)
+	}
+}
+
+// TestSavePolicyImport tests that a saved import reads back as a policy
+// file with its banned passwords
+func TestSavePolicyImport(t *testing.T) {
+	s := &standardPolicy{title: "Microsoft Entra ID: acme.com", length: 16, special: true,
+		policy: systemPolicy{MinLength: 8, MaxLength: 256, MinClasses: 3, RejectCommon: true, Notes: []string{"fuzzy: matching"}}}
+	out := filepath.Join(t.TempDir(), "entra.yaml")
+	if err := savePolicyImport(s, []string{"acme", "roadrunner"}, out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(out), "entra-banned.txt"))
+	if err != nil || string(data) != "acme\nroadrunner\n" {
+		t.Errorf("Banned passwords file = %q, %v", data, err)
+	}
+	got, err := loadPolicyFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.title != s.title || got.policy.MaxLength != 256 || !slices.Equal(got.policy.Notes, s.policy.Notes) {
+		t.Errorf("Read back %+v", got)
+	}
+	if got.policy.Blocklist == nil || !got.policy.Blocklist.Contains("roadrunner") {
+		t.Error("Banned passwords were not read back")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// preset is a named set of generate defaults for one kind of secret. Flags
+// given on the command line or in the environment take precedence, except
+// that the preset's exclusions always apply.
+type preset struct {
+	name    string
+	summary string
+	length  int
+	special bool
+	// exclude lists characters the destination cannot take. They are
+	// excluded on top of any -exclude.
+	exclude string
+	// target names the service limits the length is checked against, see
+	// targetLimits.
+	target string
+	// protocol is the network protocol of a device shared secret, which
+	// selects the snippet printed by -o cisco and -o junos.
+	protocol string
+}
+
+// deviceSafeSpecial are the special characters network devices take in a
+// shared secret without quoting. Spaces, quotes and backslashes break
+// configuration lines, ? opens the IOS context help, and !, # and $ start
+// comments or encrypted values on some platforms.
+const deviceSafeSpecial = "%*+-.=@_"
+
+// presets lists the presets of -preset in the order they appear in the help.
+var presets = []preset{
+	{
+		name:     "radius",
+		summary:  "RADIUS shared secret for switches, routers and access points",
+		length:   32,
+		special:  true,
+		exclude:  unsafeSpecial(deviceSafeSpecial),
+		target:   "radius",
+		protocol: "radius",
+	},
+	{
+		name:     "tacacs",
+		summary:  "TACACS+ key for device administration",
+		length:   32,
+		special:  true,
+		exclude:  unsafeSpecial(deviceSafeSpecial),
+		target:   "tacacs",
+		protocol: "tacacs",
+	},
+	{
+		// Read out or handed over once and typed in by the user, so it is
+		// short and without special characters or the y and z that
+		// QWERTY and QWERTZ keyboards swap
+		name:    "temporary",
+		summary: "Temporary password to be changed at first login, short and easy to type",
+		length:  12,
+		exclude: "yzYZ",
+	},
+}
+
+// tempPreset is the preset of -temp.
+const tempPreset = "temporary"
+
+// unsafeSpecial returns the special characters that are not in safe.
+func unsafeSpecial(safe string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(safe, r) {
+			return -1
+		}
+		return r
+	}, passgen.Special)
+}
+
+func presetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.name
+	}
+	return names
+}
+
+// lookupPreset returns the preset with the given name.
+func lookupPreset(name string) (preset, error) {
+	for _, p := range presets {
+		if passgen.EqualFoldASCII(p.name, name) {
+			return p, nil
+		}
+	}
+	return preset{}, fmt.Errorf("unknown preset %q (use %s)", name, strings.Join(presetNames(), ", "))
+}
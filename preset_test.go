@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestLookupPreset tests finding presets and their targets
+func TestLookupPreset(t *testing.T) {
+	for _, name := range presetNames() {
+		p, err := lookupPreset(strings.ToUpper(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.target != "" {
+			target, err := lookupTarget(p.target)
+			if err != nil {
+				t.Errorf("Preset %s: %v", name, err)
+			} else if warning := target.check(p.length); warning != "" {
+				t.Errorf("Preset %s does not suit its own target: %s", name, warning)
+			}
+		}
+	}
+	if _, err := lookupPreset("ldap"); err == nil || !strings.Contains(err.Error(), "radius, tacacs") {
+		t.Errorf("Expected an error listing the presets, got %v", err)
+	}
+}
+
+// TestDevicePresetCharacters tests that device secrets only use characters
+// devices take without quoting
+func TestDevicePresetCharacters(t *testing.T) {
+	p, err := lookupPreset("radius")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := passgen.NewGenerator(passgen.WithLength(p.length), passgen.WithSpecial(p.special), passgen.WithExclude(p.exclude))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		secret, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.ContainsAny(secret, " ?\"'\\!#$") {
+			t.Fatalf("Secret %q holds a character devices cannot take", secret)
+		}
+	}
+	if got := unsafeSpecial(deviceSafeSpecial); strings.ContainsAny(got, deviceSafeSpecial) {
+		t.Errorf("unsafeSpecial kept safe characters: %q", got)
+	}
+}
+
+// TestTempPreset tests that temporary passwords are easy to type
+func TestTempPreset(t *testing.T) {
+	p, err := lookupPreset(tempPreset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := passgen.NewGenerator(passgen.WithLength(p.length), passgen.WithSpecial(p.special), passgen.WithExclude(p.exclude))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		password, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 12 || strings.ContainsAny(password, "yzYZ"+passgen.Special) {
+			t.Fatalf("Temporary password %q is not easy to type", password)
+		}
+	}
+}
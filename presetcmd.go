@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func printPresetUsage(programName string) {
+	fmt.Printf("Usage: %s preset list\n", programName)
+	fmt.Printf("       %s preset diff [OPTIONS] NAME NAME...\n", programName)
+	fmt.Println("List the standard policies of -policy, or compare the constraints of two or")
+	fmt.Println("more side by side to choose one or to write your own. Rows that differ are")
+	fmt.Println("marked with *.")
+	fmt.Println("Options:")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s preset diff aws-iam windows-ad\n", programName)
+}
+
+// presetConstraint is one row of preset diff: a constraint and its value
+// in each policy, - where a policy has none.
+type presetConstraint struct {
+	Name    string   `json:"name"`
+	Values  []string `json:"values"`
+	Differs bool     `json:"differs"`
+}
+
+type presetDiffOutput struct {
+	Schema      string             `json:"schema"`
+	Policies    []string           `json:"policies"`
+	Constraints []presetConstraint `json:"constraints"`
+}
+
+// constraints describes a policy as rows in the order preset diff shows
+// them.
+func (s *standardPolicy) constraints() [][2]string {
+	p := &s.policy
+	atLeast := func(n int) string {
+		if n == 0 {
+			return "-"
+		}
+		return "at least " + strconv.Itoa(n)
+	}
+	number := func(n int) string {
+		if n == 0 {
+			return "-"
+		}
+		return strconv.Itoa(n)
+	}
+	classes, run, age, blocklist, exclude, special := "-", "-", "-", "-", "-", "no"
+	if p.MinClasses > 0 {
+		classes = fmt.Sprintf("%d of 4", p.MinClasses)
+	}
+	if p.MaxRun > 0 {
+		run = fmt.Sprintf("at most %d in a row", p.MaxRun)
+	}
+	if p.MaxAge > 0 {
+		age = fmt.Sprintf("%d days", int(p.MaxAge.Hours()/24))
+	}
+	if p.RejectCommon {
+		blocklist = "rejected"
+	}
+	if p.Exclude != "" {
+		exclude = p.Exclude
+	}
+	if s.special {
+		special = "yes"
+	}
+	return [][2]string{
+		{"Standard", s.title},
+		{"Minimum length", number(p.MinLength)},
+		{"Maximum length", number(p.MaxLength)},
+		{"Uppercase", atLeast(p.MinUpper)},
+		{"Lowercase", atLeast(p.MinLower)},
+		{"Digits", atLeast(p.MinDigits)},
+		{"Special characters", atLeast(p.MinSpecial)},
+		{"Character classes", classes},
+		{"Repeated characters", run},
+		{"Maximum age", age},
+		{"Common passwords", blocklist},
+		{"Excluded characters", exclude},
+		{"Generated length", strconv.Itoa(s.length)},
+		{"Generated special", special},
+	}
+}
+
+// diffStandards lines up the constraints of the policies.
+func diffStandards(policies []standardPolicy) []presetConstraint {
+	var rows []presetConstraint
+	for i, s := range policies {
+		for j, c := range s.constraints() {
+			if i == 0 {
+				rows = append(rows, presetConstraint{Name: c[0]})
+			}
+			rows[j].Values = append(rows[j].Values, c[1])
+			if c[1] != rows[j].Values[0] {
+				rows[j].Differs = true
+			}
+		}
+	}
+	return rows
+}
+
+// printPresetDiff prints the rows as columns, one per policy.
+func printPresetDiff(w io.Writer, names []string, rows []presetConstraint) {
+	widths := make([]int, len(names)+1)
+	for i, name := range names {
+		widths[i+1] = utf8.RuneCountInString(name)
+	}
+	for _, row := range rows {
+		widths[0] = max(widths[0], utf8.RuneCountInString(row.Name))
+		for i, v := range row.Values {
+			widths[i+1] = max(widths[i+1], utf8.RuneCountInString(v))
+		}
+	}
+	line := func(mark string, cells []string) {
+		var b strings.Builder
+		b.WriteString(mark)
+		for i, cell := range cells {
+			b.WriteString(" ")
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+1))
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+	line(" ", append([]string{""}, names...))
+	for _, row := range rows {
+		mark := " "
+		if row.Differs {
+			mark = "*"
+		}
+		line(mark, append([]string{row.Name}, row.Values...))
+	}
+}
+
+// runPreset implements the preset subcommand.
+func runPreset(programName string, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		printPresetUsage(programName)
+		return nil
+	}
+	switch args[0] {
+	case "list":
+		for _, s := range standardPolicies() {
+			aliases := ""
+			if len(s.aliases) > 0 {
+				aliases = " (also " + strings.Join(s.aliases, ", ") + ")"
+			}
+			fmt.Printf("%-12s %s%s\n", s.name, s.title, aliases)
+		}
+		return nil
+	case "diff":
+	default:
+		return fmt.Errorf("unknown preset command %q (use list or diff)", args[0])
+	}
+
+	fs := flag.NewFlagSet("preset diff", flag.ContinueOnError)
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printPresetUsage(programName) }
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *help {
+		printPresetUsage(programName)
+		return nil
+	}
+	if fs.NArg() < 2 {
+		return fmt.Errorf("give at least two policies to compare, e.g. %s preset diff nist pci", programName)
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	var policies []standardPolicy
+	for _, name := range fs.Args() {
+		s, err := lookupStandard(name)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, s)
+	}
+
+	rows := diffStandards(policies)
+	if *format == "json" {
+		return writeJSON(os.Stdout, presetDiffOutput{Schema: outputSchema, Policies: fs.Args(), Constraints: rows})
+	}
+	printPresetDiff(os.Stdout, fs.Args(), rows)
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestDiffStandards tests lining up and marking the constraints of policies
+func TestDiffStandards(t *testing.T) {
+	var policies []standardPolicy
+	for _, name := range []string{"aws-iam", "windows-ad"} {
+		s, err := lookupStandard(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		policies = append(policies, s)
+	}
+	rows := diffStandards(policies)
+	differs := make(map[string]bool)
+	for _, row := range rows {
+		if len(row.Values) != 2 {
+			t.Fatalf("Row %s has %d values", row.Name, len(row.Values))
+		}
+		differs[row.Name] = row.Differs
+	}
+	for name, want := range map[string]bool{
+		"Minimum length":      true,
+		"Maximum length":      true,
+		"Character classes":   false,
+		"Common passwords":    false,
+		"Excluded characters": true,
+	} {
+		if differs[name] != want {
+			t.Errorf("%s differs = %v, want %v", name, differs[name], want)
+		}
+	}
+	if !slices.Equal(rows[1].Values, []string{"8", "14"}) {
+		t.Errorf("Minimum length values %q", rows[1].Values)
+	}
+}
+
+// TestPrintPresetDiff tests the column layout and the difference marks
+func TestPrintPresetDiff(t *testing.T) {
+	var buf bytes.Buffer
+	printPresetDiff(&buf, []string{"a", "long-name"}, []presetConstraint{
+		{Name: "Minimum length", Values: []string{"8", "14"}, Differs: true},
+		{Name: "Digits", Values: []string{"-", "-"}},
+	})
+	want := "                  a  long-name\n" +
+		"* Minimum length  8  14\n" +
+		"  Digits          -  -\n"
+	if buf.String() != want {
+		t.Errorf("printPresetDiff wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// TestRunPresetArgs tests the rejected arguments
+func TestRunPresetArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"show", "nist"},
+		{"diff", "nist"},
+		{"diff", "nist", "iso27001"},
+		{"diff", "-o", "yaml", "nist", "pci"},
+	} {
+		if err := runPreset("passgen", args); err == nil {
+			t.Errorf("Expected error for %q", strings.Join(args, " "))
+		}
+	}
+}
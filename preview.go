@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// printPreview renders a password for -preview in styles that leave no
+// doubt about each character: spaced out, one bracket per character, and
+// the Unicode code point of each character aligned underneath.
+func printPreview(w io.Writer, password, indent string) {
+	runes := []rune(password)
+	codes := make([]string, len(runes))
+	width := 1
+	for i, r := range runes {
+		codes[i] = fmt.Sprintf("%02x", r)
+		width = max(width, len(codes[i]))
+	}
+
+	var spaced, bracketed, hex strings.Builder
+	for i, r := range runes {
+		pad := strings.Repeat(" ", width-1)
+		// A space would vanish into the gaps
+		shown := string(r)
+		if r == ' ' {
+			shown = "␣"
+		}
+		fmt.Fprintf(&spaced, "%s%s ", shown, pad)
+		fmt.Fprintf(&bracketed, "[%s]", shown)
+		fmt.Fprintf(&hex, "%-*s ", width, codes[i])
+	}
+	fmt.Fprintf(w, "%sspaced:    %s\n", indent, strings.TrimRight(spaced.String(), " "))
+	fmt.Fprintf(w, "%shex:       %s\n", indent, strings.TrimRight(hex.String(), " "))
+	fmt.Fprintf(w, "%sbracketed: %s\n", indent, bracketed.String())
+}
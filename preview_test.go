@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestPrintPreview tests that every style lines up with the characters
+func TestPrintPreview(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"ascii", "aB$", "" +
+			"  spaced:    a  B  $\n" +
+			"  hex:       61 42 24\n" +
+			"  bracketed: [a][B][$]\n"},
+		{"space", "a b", "" +
+			"  spaced:    a  ␣  b\n" +
+			"  hex:       61 20 62\n" +
+			"  bracketed: [a][␣][b]\n"},
+		{"wide code", "aα", "" +
+			"  spaced:    a   α\n" +
+			"  hex:       61  3b1\n" +
+			"  bracketed: [a][α]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printPreview(&out, tt.password, "  ")
+			if out.String() != tt.want {
+				t.Errorf("printPreview =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"time"
+)
+
+// errSinkRejected marks a sink failure that retrying cannot fix, such as a
+// sink refusing the credentials outright.
+var errSinkRejected = errors.New("rejected")
+
+// idempotentSink is implemented by sinks that can recognize a repeated
+// delivery of the same batch. Every attempt to deliver one batch carries the
+// same key, so a write that succeeded remotely but whose reply was lost is
+// not stored twice when it is retried.
+type idempotentSink interface {
+	sink
+	writeOnce(key string, creds []credential) error
+}
+
+// newIdempotencyKey returns a random key identifying one batch.
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// retryPolicy configures retries of failed sink writes.
+type retryPolicy struct {
+	// Retries is the number of attempts after the first one.
+	Retries int
+	// Backoff is the delay before the first retry. It doubles for every
+	// further retry, up to maxBackoff, with random jitter so many clients
+	// do not retry in lockstep.
+	Backoff time.Duration
+}
+
+const maxBackoff = 30 * time.Second
+
+// defaultRetryPolicy is used for remote sinks unless configured otherwise.
+var defaultRetryPolicy = retryPolicy{Retries: 3, Backoff: time.Second}
+
+// delay returns how long to wait before the given retry, counted from 0.
+func (p retryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < retry && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff)
+	if d <= 0 {
+		return 0
+	}
+	// Wait between half and all of the computed delay
+	return d/2 + mathrand.N(d/2+1)
+}
+
+// retryingSink retries transient failures of the sink it wraps.
+type retryingSink struct {
+	sink   sink
+	policy retryPolicy
+	// sleep waits between attempts; tests replace it.
+	sleep func(time.Duration)
+}
+
+// withRetry wraps each sink so its writes are retried according to policy.
+func withRetry(sinks []sink, policy retryPolicy) []sink {
+	wrapped := make([]sink, len(sinks))
+	for i, s := range sinks {
+		wrapped[i] = &retryingSink{sink: s, policy: policy, sleep: time.Sleep}
+	}
+	return wrapped
+}
+
+// undo rolls back the wrapped sink, if it supports that.
+func (r *retryingSink) undo() error {
+	if s, ok := r.sink.(reversibleSink); ok {
+		return s.undo()
+	}
+	return fmt.Errorf("%s cannot be rolled back and keeps the credentials", sinkName(r.sink))
+}
+
+func (r *retryingSink) canUndo() bool {
+	return reversible(r.sink)
+}
+
+func (r *retryingSink) canReadBack() bool {
+	s, ok := r.sink.(readableSink)
+	return ok && s.canReadBack()
+}
+
+// readBack reads back from the wrapped sink, if it supports that.
+func (r *retryingSink) readBack(labels []string) ([]credential, error) {
+	if s, ok := r.sink.(readableSink); ok {
+		return s.readBack(labels)
+	}
+	return nil, fmt.Errorf("%s cannot be read back", sinkName(r.sink))
+}
+
+func (r *retryingSink) String() string {
+	return sinkName(r.sink)
+}
+
+func (r *retryingSink) write(creds []credential) error {
+	write := r.sink.write
+	if s, ok := r.sink.(idempotentSink); ok {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return err
+		}
+		write = func(creds []credential) error { return s.writeOnce(key, creds) }
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = write(creds); err == nil || errors.Is(err, errSinkRejected) {
+			return err
+		}
+		if attempt == r.policy.Retries {
+			break
+		}
+		r.sleep(r.policy.delay(attempt))
+	}
+	if r.policy.Retries == 0 {
+		return err
+	}
+	return fmt.Errorf("%w (gave up after %d attempts)", err, r.policy.Retries+1)
+}
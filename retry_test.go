@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakySink fails a number of times before accepting writes, recording the
+// idempotency key of every attempt
+type flakySink struct {
+	failures int
+	err      error
+	keys     []string
+	stored   int
+}
+
+func (s *flakySink) write(creds []credential) error {
+	return s.writeOnce("", creds)
+}
+
+func (s *flakySink) writeOnce(key string, creds []credential) error {
+	s.keys = append(s.keys, key)
+	if len(s.keys) <= s.failures {
+		return s.err
+	}
+	s.stored += len(creds)
+	return nil
+}
+
+// TestRetryingSink tests retries, give-up and permanent errors
+func TestRetryingSink(t *testing.T) {
+	transient := errors.New("connection reset")
+	permanent := fmt.Errorf("vault %w the passwords", errSinkRejected)
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		retries      int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"first try", 0, transient, 3, 1, false},
+		{"recovers", 2, transient, 3, 3, false},
+		{"gives up", 10, transient, 3, 4, true},
+		{"no retries", 1, transient, 0, 1, true},
+		{"permanent", 10, permanent, 3, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakySink{failures: tt.failures, err: tt.err}
+			var slept []time.Duration
+			s := &retryingSink{
+				sink:   inner,
+				policy: retryPolicy{Retries: tt.retries, Backoff: time.Second},
+				sleep:  func(d time.Duration) { slept = append(slept, d) },
+			}
+
+			err := s.write([]credential{{Password: "pw"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("write error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(inner.keys) != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", len(inner.keys), tt.wantAttempts)
+			}
+			if len(slept) != tt.wantAttempts-1 {
+				t.Errorf("Slept %d times, want %d", len(slept), tt.wantAttempts-1)
+			}
+			for _, key := range inner.keys {
+				if key == "" || key != inner.keys[0] {
+					t.Errorf("Every attempt should carry the same key, got %q", inner.keys)
+					break
+				}
+			}
+			if tt.name == "gives up" && !strings.Contains(err.Error(), "gave up after 4 attempts") {
+				t.Errorf("Error should say how often it tried: %v", err)
+			}
+		})
+	}
+}
+
+// TestRetryPolicyDelay tests exponential growth, jitter and the cap
+func TestRetryPolicyDelay(t *testing.T) {
+	p := retryPolicy{Backoff: time.Second}
+	for retry, full := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if d := p.delay(retry); d < full/2 || d > full {
+				t.Errorf("delay(%d) = %v, want between %v and %v", retry, d, full/2, full)
+			}
+		}
+	}
+	if d := p.delay(20); d > maxBackoff {
+		t.Errorf("delay(20) = %v, should be capped at %v", d, maxBackoff)
+	}
+	if d := (retryPolicy{}).delay(3); d != 0 {
+		t.Errorf("Zero backoff should not wait, got %v", d)
+	}
+}
+
+// TestRetryingSinkNewKeyPerBatch tests that separate batches get separate keys
+func TestRetryingSinkNewKeyPerBatch(t *testing.T) {
+	inner := &flakySink{}
+	s := withRetry([]sink{inner}, defaultRetryPolicy)[0]
+	s.write([]credential{{Password: "a"}})
+	s.write([]credential{{Password: "b"}})
+	if len(inner.keys) != 2 || inner.keys[0] == inner.keys[1] {
+		t.Errorf("Expected distinct keys per batch, got %q", inner.keys)
+	}
+}
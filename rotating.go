@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printRotatingUsage(programName string) {
+	fmt.Printf("Usage: %s rotating -secret-file FILE [OPTIONS]\n", programName)
+	fmt.Println("Derive the password of the current time window from a shared secret, like")
+	fmt.Println("a TOTP code but as a full password, for kiosk or lab machines whose")
+	fmt.Println("passwords rotate on a schedule. Everyone with the secret gets the same")
+	fmt.Println("password until the window ends.")
+	fmt.Println("Options:")
+	fmt.Println("  -secret-file FILE")
+	fmt.Println("               Read the shared secret from the first line of FILE, or stdin for -")
+	fmt.Println("  -period DURATION")
+	fmt.Println("               Length of a window, counted from midnight UTC (default: 24h)")
+	fmt.Println("  -name NAME   Give each machine sharing the secret its own password")
+	fmt.Println("  -at TIME     Derive the password valid at TIME (RFC 3339 or YYYY-MM-DD)")
+	fmt.Println("               instead of now")
+	fmt.Println("  -c COUNT     Also show the passwords of the following windows (default: 1)")
+	fmt.Println("  -l LENGTH    Password length (default: 12)")
+	fmt.Println("  -s           Include special characters")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s rotating -secret-file lab.key -name lab-3 -c 7\n", programName)
+}
+
+// runRotating implements the rotating subcommand.
+func runRotating(programName string, args []string) error {
+	fs := flag.NewFlagSet("rotating", flag.ContinueOnError)
+	secretFile := fs.String("secret-file", "", "Read the shared secret from FILE, or stdin for -")
+	period := fs.Duration("period", 24*time.Hour, "Length of a window")
+	name := fs.String("name", "", "Give each machine sharing the secret its own password")
+	at := fs.String("at", "", "Derive the password valid at TIME instead of now")
+	count := fs.Int("c", 1, "Number of consecutive windows to show")
+	length := fs.Int("l", 12, "Password length")
+	includeSpecial := fs.Bool("s", false, "Include special characters")
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printRotatingUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printRotatingUsage(programName)
+		return nil
+	}
+	if *secretFile == "" {
+		return fmt.Errorf("-secret-file is required")
+	}
+	if *length < minLength || *length > maxLength {
+		return fmt.Errorf("password length must be between %d and %d", minLength, maxLength)
+	}
+	if *count < 1 || *count > maxCount {
+		return fmt.Errorf("count must be between 1 and %d", maxCount)
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	when := time.Now()
+	if *at != "" {
+		var err error
+		if when, err = parseTime(*at); err != nil {
+			return err
+		}
+	}
+	window, err := passgen.WindowAt(when, *period)
+	if err != nil {
+		return err
+	}
+	secret, err := readParent(*secretFile)
+	if err != nil {
+		return err
+	}
+
+	charsets := passgen.Options{IncludeSpecial: *includeSpecial}.Charsets()
+	secretBits := passgen.EstimateEntropy(secret)
+	results := make([]passwordOutput, 0, *count)
+	for i := 0; i < *count; i++ {
+		password, err := passgen.WindowPassword([]byte(secret), *name, window, *length, charsets)
+		if err != nil {
+			return err
+		}
+		bits := min(passgen.EstimateEntropyWith(password, charsets), secretBits)
+		results = append(results, passwordOutput{
+			Label:      *name,
+			Password:   password,
+			Entropy:    bits,
+			ValidFrom:  window.Start.Format(time.RFC3339),
+			ValidUntil: window.End.Format(time.RFC3339),
+		})
+		window = passgen.Window{Start: window.End, End: window.End.Add(*period)}
+	}
+
+	if *format == "json" {
+		return writeJSON(os.Stdout, generateOutput{Schema: outputSchema, Length: *length, Charsets: charsets, Passwords: results})
+	}
+	fmt.Println("Rotating password:")
+	fmt.Printf("Length: %d characters\n", *length)
+	fmt.Printf("Period: %s\n", *period)
+	if *name != "" {
+		fmt.Printf("Name: %s\n", *name)
+	}
+	fmt.Println()
+	for _, r := range results {
+		fmt.Printf("%s to %s: %s\n", r.ValidFrom, r.ValidUntil, r.Password)
+	}
+	return nil
+}
+
+// parseTime parses an RFC 3339 timestamp or a date, taken as midnight UTC.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use RFC 3339 or YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
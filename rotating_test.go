@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTime tests timestamps and dates for -at
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2026-10-16", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), false},
+		{"2026-10-16T09:30:00Z", time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), false},
+		{"2026-10-16T09:30:00+02:00", time.Date(2026, 10, 16, 7, 30, 0, 0, time.UTC), false},
+		{"16/10/2026", time.Time{}, true},
+		{"tomorrow", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTime(tt.in)
+			if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+				t.Errorf("parseTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,557 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printRPCUsage(programName string) {
+	fmt.Printf("Usage: %s rpc [-hash-config FILE] [-group-dir DIR -allow-group GROUP...]\n", programName)
+	fmt.Println("Serve JSON-RPC 2.0 on stdin/stdout, one request per line, so scripts can")
+	fmt.Println("drive passgen through a single long-lived subprocess.")
+	fmt.Println("\nMethods:")
+	fmt.Println("  generate    {length, special, allowSimilar, ambiguity, exclude, charsets,")
+	fmt.Println("               includeCategories, excludeCategories, includeScripts, excludeScripts,")
+	fmt.Println("               noUpper, noLower, noDigits, minUpper, minLower, minDigits, minSpecial,")
+	fmt.Println("               noConfusables, fingerprint, pronounceable, pattern, appleStyle,")
+	fmt.Println("               count, rules, markov, markovOrder, hashFormat}")
+	fmt.Println("               -> {passwords, entropy, fingerprints, hashes}")
+	fmt.Println("  check       {password, rules} -> {ok, failed, entropy}")
+	fmt.Println("  entropy     {password} -> bits")
+	fmt.Println("  equivalent  {password} -> random password of the same shape")
+	fmt.Println("  groupSecret {group, at, length, special} -> {group, password, validFrom, validUntil}")
+	fmt.Println("              today's password of a group, derived from DIR/GROUP.key;")
+	fmt.Println("              at may be an earlier day, not a later one")
+	fmt.Println("\nOptions:")
+	fmt.Println("  -group-dir DIR")
+	fmt.Println("              Enable groupSecret with the group secrets in DIR")
+	fmt.Println("  -hash-config FILE")
+	fmt.Println("              Argon2id parameters for hashFormat saved by hash-tune -config FILE")
+	fmt.Println("  -allow-group GROUP")
+	fmt.Println("              A group whose secret this caller may read (repeatable, required")
+	fmt.Println("              with -group-dir)")
+	fmt.Println("  -h          Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf(`  echo '{"jsonrpc":"2.0","id":1,"method":"generate","params":{"length":16}}' | %s rpc`+"\n", programName)
+}
+
+// runRPC implements the rpc subcommand.
+func runRPC(programName string, args []string) error {
+	fs := flag.NewFlagSet("rpc", flag.ContinueOnError)
+	groupDir := fs.String("group-dir", "", "Enable groupSecret with the group secrets in DIR")
+	hashConfig := fs.String("hash-config", "", "Argon2id parameters for hashFormat saved by hash-tune -config FILE")
+	var allowGroups stringList
+	fs.Var(&allowGroups, "allow-group", "A group whose secret this caller may read (repeatable)")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printRPCUsage(programName) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printRPCUsage(programName)
+		return nil
+	}
+	// Every caller of a server reads with its rights, so which groups it
+	// may read is set per server, e.g. per SSH key with command=
+	if *groupDir != "" && len(allowGroups) == 0 {
+		return fmt.Errorf("-group-dir needs -allow-group for each group the caller may read")
+	}
+	if *groupDir == "" && len(allowGroups) > 0 {
+		return fmt.Errorf("-allow-group needs -group-dir")
+	}
+	s := newRPCServer()
+	s.groupDir = *groupDir
+	s.hashConfig = *hashConfig
+	for _, group := range allowGroups {
+		if !groupNamePattern.MatchString(group) {
+			return fmt.Errorf("invalid group name %q", group)
+		}
+		s.groups[group] = true
+	}
+	return s.serve(os.Stdin, os.Stdout)
+}
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func invalidParams(format string, args ...any) *rpcError {
+	return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// rpcServer answers JSON-RPC requests. Trained Markov models are cached for
+// the lifetime of the process so repeated calls stay cheap.
+type rpcServer struct {
+	models map[string]*passgen.MarkovModel
+	// groupDir holds the secrets of groupSecret, one GROUP.key file per
+	// group. Empty disables the method.
+	groupDir string
+	// hashConfig holds the Argon2id parameters of hashFormat, see
+	// newHasher.
+	hashConfig string
+	// groups are the groups in groupDir the caller may read.
+	groups map[string]bool
+}
+
+func newRPCServer() *rpcServer {
+	return &rpcServer{models: make(map[string]*passgen.MarkovModel), groups: make(map[string]bool)}
+}
+
+// serve handles one request per line until r is exhausted.
+func (s *rpcServer) serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	enc := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle processes a single request line. It returns nil for notifications,
+// which get no response.
+func (s *rpcServer) handle(line []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: rpcParseError, Message: err.Error()}}
+	}
+
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+		return resp
+	}
+
+	result, err := s.call(req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+		resp.Error = rerr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+func (s *rpcServer) call(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "generate":
+		var p rpcGenerateParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.generate(p)
+	case "check":
+		var p rpcCheckParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.check(p)
+	case "entropy":
+		var p rpcPasswordParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return passgen.EstimateEntropy(p.Password), nil
+	case "equivalent":
+		var p rpcPasswordParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return passgen.Equivalent(p.Password)
+	case "groupSecret":
+		var p rpcGroupSecretParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.groupSecret(p)
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+type rpcGenerateParams struct {
+	Length        int      `json:"length"`
+	Special       bool     `json:"special"`
+	AllowSimilar  bool     `json:"allowSimilar"`
+	Ambiguity     string   `json:"ambiguity"`
+	Exclude       string   `json:"exclude"`
+	Charsets      []string `json:"charsets"`
+	NoUpper       bool     `json:"noUpper"`
+	NoLower       bool     `json:"noLower"`
+	NoDigits      bool     `json:"noDigits"`
+	MinUpper      int      `json:"minUpper"`
+	MinLower      int      `json:"minLower"`
+	MinDigits     int      `json:"minDigits"`
+	MinSpecial    int      `json:"minSpecial"`
+	NoConfusables bool     `json:"noConfusables"`
+	Fingerprint   string   `json:"fingerprint"`
+	HashFormat    string   `json:"hashFormat"`
+	Pronounceable bool     `json:"pronounceable"`
+	Pattern       string   `json:"pattern"`
+	AppleStyle    bool     `json:"appleStyle"`
+	Entropy       float64  `json:"entropy"`
+	MinScore      int      `json:"minScore"`
+	NoBlocklist   bool     `json:"noBlocklist"`
+	NoWords       bool     `json:"noWords"`
+	Avoid         []string `json:"avoid"`
+	Count         int      `json:"count"`
+	Rules         []string `json:"rules"`
+	Markov        string   `json:"markov"`
+	MarkovOrder   int      `json:"markovOrder"`
+	unicodeFilter
+}
+
+type rpcGenerateResult struct {
+	Passwords    []string  `json:"passwords"`
+	Entropy      []float64 `json:"entropy"`
+	Fingerprints []string  `json:"fingerprints,omitempty"`
+	Hashes       []string  `json:"hashes,omitempty"`
+}
+
+func (s *rpcServer) generate(p rpcGenerateParams) (*rpcGenerateResult, error) {
+	var pattern *passgen.Pattern
+	if p.Pattern != "" {
+		if p.Markov != "" || p.Pronounceable {
+			return nil, invalidParams("pattern cannot be combined with markov or pronounceable")
+		}
+		var err error
+		if pattern, err = passgen.CompilePattern(p.Pattern); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+		p.Length = pattern.Len()
+	}
+	if p.AppleStyle {
+		if p.Markov != "" || p.Pronounceable || pattern != nil {
+			return nil, invalidParams("appleStyle cannot be combined with markov, pronounceable or pattern")
+		}
+		p.Length = passgen.AppleStyleLength
+	}
+	if p.Entropy < 0 {
+		return nil, invalidParams("entropy cannot be negative")
+	}
+	if p.Entropy > 0 && (p.Markov != "" || pattern != nil || p.AppleStyle) {
+		return nil, invalidParams("entropy cannot be combined with markov, pattern or appleStyle")
+	}
+	if p.Length == 0 {
+		p.Length = 12
+	}
+	if p.Count == 0 {
+		p.Count = 1
+	}
+	if p.Length < minLength || p.Length > maxLength {
+		return nil, invalidParams("length must be between %d and %d", minLength, maxLength)
+	}
+	if p.Count < 1 || p.Count > maxCount {
+		return nil, invalidParams("count must be between 1 and %d", maxCount)
+	}
+
+	if p.MinSpecial > 0 {
+		p.Special = true
+	}
+	ambiguity := passgen.AmbiguityStandard
+	if p.Ambiguity != "" {
+		var err error
+		if ambiguity, err = parseAmbiguity(p.Ambiguity, p.AllowSimilar); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+	}
+	genOpts := []passgen.GeneratorOption{
+		passgen.WithLength(p.Length),
+		passgen.WithSpecial(p.Special),
+		passgen.WithSimilar(p.AllowSimilar),
+		passgen.WithAmbiguity(ambiguity),
+		passgen.WithExclude(p.Exclude),
+	}
+	genOpts = append(genOpts, minCountOptions(p.MinUpper, p.MinLower, p.MinDigits, p.MinSpecial)...)
+	genOpts = append(genOpts, disableOptions(p.NoUpper, p.NoLower, p.NoDigits)...)
+	for _, charset := range p.Charsets {
+		genOpts = append(genOpts, passgen.WithCharset(charset))
+	}
+	filterOpts, err := p.unicodeFilter.options()
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+	genOpts = append(genOpts, filterOpts...)
+	if p.Pronounceable {
+		if p.Markov != "" {
+			return nil, invalidParams("pronounceable cannot be combined with markov")
+		}
+		genOpts = append(genOpts, passgen.WithPronounceable())
+	}
+	if pattern != nil {
+		genOpts = append(genOpts, passgen.WithPattern(pattern))
+	}
+	if p.AppleStyle {
+		genOpts = append(genOpts, passgen.WithAppleStyle())
+	}
+	if p.Markov != "" {
+		model, err := s.model(p.Markov, p.MarkovOrder)
+		if err != nil {
+			return nil, err
+		}
+		genOpts = append(genOpts, passgen.WithMarkov(model))
+	}
+	for _, expr := range p.Rules {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			return nil, invalidParams("%v", err)
+		}
+		genOpts = append(genOpts, passgen.WithRules(rule))
+	}
+	if p.NoConfusables {
+		genOpts = append(genOpts, passgen.WithoutConfusables())
+	}
+	if p.MinScore != 0 {
+		genOpts = append(genOpts, passgen.WithMinScore(p.MinScore))
+	}
+	if !p.NoBlocklist {
+		genOpts = append(genOpts, passgen.WithBlocklist(commonBlocklist()))
+	}
+	if p.NoWords {
+		genOpts = append(genOpts, passgen.WithoutDictionaryWords(embeddedDictionary()))
+	}
+	if len(p.Avoid) > 0 {
+		genOpts = append(genOpts, passgen.WithAvoid(p.Avoid...))
+	}
+	if p.Entropy > 0 {
+		n, err := entropyLength(p.Entropy, genOpts)
+		if err != nil {
+			return nil, invalidParams("%v", err)
+		}
+		genOpts = append(genOpts, passgen.WithLength(n))
+	}
+	fingerprintOf, err := fingerprinter(p.Fingerprint)
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+	var hasher *passgen.Hasher
+	if p.HashFormat != "" {
+		if hasher, err = newHasher(p.HashFormat, s.hashConfig); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+	}
+	gen, err := passgen.NewGenerator(genOpts...)
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+
+	result := &rpcGenerateResult{}
+	for i := 0; i < p.Count; i++ {
+		password, err := gen.Generate()
+		if err != nil {
+			return nil, err
+		}
+		bits, err := gen.Entropy(password)
+		if err != nil {
+			return nil, err
+		}
+		result.Passwords = append(result.Passwords, password)
+		result.Entropy = append(result.Entropy, bits)
+		if fingerprintOf != nil {
+			result.Fingerprints = append(result.Fingerprints, fingerprintOf(password))
+		}
+		if hasher != nil {
+			hash, err := hasher.Hash(password)
+			if err != nil {
+				return nil, err
+			}
+			result.Hashes = append(result.Hashes, hash)
+		}
+	}
+	return result, nil
+}
+
+// model returns the Markov model for a corpus file, training it on first use.
+func (s *rpcServer) model(path string, order int) (*passgen.MarkovModel, error) {
+	if order == 0 {
+		order = 2
+	}
+	key := fmt.Sprintf("%d:%s", order, path)
+	if m, ok := s.models[key]; ok {
+		return m, nil
+	}
+	m, err := loadMarkovModel(path, order)
+	if err != nil {
+		return nil, err
+	}
+	s.models[key] = m
+	return m, nil
+}
+
+type rpcCheckParams struct {
+	Password string   `json:"password"`
+	Rules    []string `json:"rules"`
+}
+
+type rpcCheckResult struct {
+	OK      bool     `json:"ok"`
+	Failed  []string `json:"failed"`
+	Entropy float64  `json:"entropy"`
+}
+
+func (s *rpcServer) check(p rpcCheckParams) (*rpcCheckResult, error) {
+	result := &rpcCheckResult{OK: true, Failed: []string{}, Entropy: passgen.EstimateEntropy(p.Password)}
+	for _, expr := range p.Rules {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			return nil, invalidParams("%v", err)
+		}
+		ok, err := rule.Eval(p.Password)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			result.OK = false
+			result.Failed = append(result.Failed, expr)
+		}
+	}
+	return result, nil
+}
+
+type rpcPasswordParams struct {
+	Password string `json:"password"`
+}
+
+// groupNamePattern keeps group names to plain file names inside groupDir.
+var groupNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type rpcGroupSecretParams struct {
+	Group   string `json:"group"`
+	At      string `json:"at"`
+	Length  int    `json:"length"`
+	Special bool   `json:"special"`
+}
+
+type rpcGroupSecretResult struct {
+	Group      string `json:"group"`
+	Password   string `json:"password"`
+	ValidFrom  string `json:"validFrom"`
+	ValidUntil string `json:"validUntil"`
+}
+
+// groupSecret returns the password of the day for a group, the same one
+// "passgen rotating -secret-file DIR/GROUP.key -name GROUP" prints. The
+// secret is read on every call so it can be replaced without a restart.
+// Passwords of days to come are refused, since handing them out early would
+// outlast the caller's access.
+func (s *rpcServer) groupSecret(p rpcGroupSecretParams) (*rpcGroupSecretResult, error) {
+	if s.groupDir == "" {
+		return nil, &rpcError{Code: rpcServerError, Message: "group secrets are not enabled (start rpc with -group-dir)"}
+	}
+	if !groupNamePattern.MatchString(p.Group) {
+		return nil, invalidParams("invalid group name %q", p.Group)
+	}
+	if !s.groups[p.Group] {
+		return nil, &rpcError{Code: rpcServerError, Message: fmt.Sprintf("group %q is not allowed (start rpc with -allow-group %s)", p.Group, p.Group)}
+	}
+	if p.Length == 0 {
+		p.Length = 12
+	}
+	if p.Length < minLength || p.Length > maxLength {
+		return nil, invalidParams("length must be between %d and %d", minLength, maxLength)
+	}
+	when := time.Now()
+	if p.At != "" {
+		var err error
+		if when, err = parseTime(p.At); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+	}
+
+	secret, err := readParent(filepath.Join(s.groupDir, p.Group+".key"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, invalidParams("unknown group %q", p.Group)
+	} else if err != nil {
+		return nil, err
+	}
+	window, err := passgen.WindowAt(when, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	current, err := passgen.WindowAt(time.Now(), 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if window.Start.After(current.Start) {
+		return nil, invalidParams("at %s is after the current day, which ends %s", p.At, current.End.Format(time.RFC3339))
+	}
+	charsets := passgen.Options{IncludeSpecial: p.Special}.Charsets()
+	password, err := passgen.WindowPassword([]byte(secret), p.Group, window, p.Length, charsets)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcGroupSecretResult{
+		Group:      p.Group,
+		Password:   password,
+		ValidFrom:  window.Start.Format(time.RFC3339),
+		ValidUntil: window.End.Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// rpcRoundTrip sends request lines to a fresh server and decodes the responses
+func rpcRoundTrip(t *testing.T, requests ...string) []map[string]any {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := newRPCServer().serve(strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatalf("Server failed: %v", err)
+	}
+
+	var responses []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("Invalid response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func rpcErrorCode(resp map[string]any) int {
+	e, ok := resp["error"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	return int(e["code"].(float64))
+}
+
+// TestRPCGenerate tests the generate method
+func TestRPCGenerate(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"length":16,"count":3,"rules":["digits >= 2"]}}`)
+	if len(responses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(responses))
+	}
+
+	result := responses[0]["result"].(map[string]any)
+	passwords := result["passwords"].([]any)
+	if len(passwords) != 3 {
+		t.Fatalf("Expected 3 passwords, got %d", len(passwords))
+	}
+	for _, p := range passwords {
+		if len(p.(string)) != 16 {
+			t.Errorf("Expected length 16, got %q", p)
+		}
+	}
+	if responses[0]["id"].(float64) != 1 {
+		t.Errorf("Response id does not match request: %v", responses[0]["id"])
+	}
+}
+
+// TestRPCFingerprints tests that fingerprints match the passwords
+func TestRPCFingerprints(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"count":2,"fingerprint":"hex"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"fingerprint":"base64"}}`)
+	result := responses[0]["result"].(map[string]any)
+	passwords := result["passwords"].([]any)
+	fingerprints := result["fingerprints"].([]any)
+	if len(fingerprints) != len(passwords) {
+		t.Fatalf("Got %d fingerprints for %d passwords", len(fingerprints), len(passwords))
+	}
+	for i, p := range passwords {
+		if want := passgen.Fingerprint(p.(string)); fingerprints[i] != want {
+			t.Errorf("Fingerprint of %q = %v, want %s", p, fingerprints[i], want)
+		}
+	}
+	if code := rpcErrorCode(responses[1]); code != rpcInvalidParams {
+		t.Errorf("Unknown format gave error code %d, want %d", code, rpcInvalidParams)
+	}
+}
+
+// TestRPCCheck tests the check method
+func TestRPCCheck(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":"a","method":"check","params":{"password":"aaab","rules":["maxRepeat <= 2","length == 4"]}}`)
+
+	result := responses[0]["result"].(map[string]any)
+	if result["ok"].(bool) {
+		t.Error("Expected check to fail")
+	}
+	failed := result["failed"].([]any)
+	if len(failed) != 1 || failed[0] != "maxRepeat <= 2" {
+		t.Errorf("Unexpected failed rules: %v", failed)
+	}
+}
+
+// TestRPCErrors tests protocol error handling
+func TestRPCErrors(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`not json`,
+		`{"jsonrpc":"1.0","id":1,"method":"generate"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"generate","params":{"length":2}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"check","params":{"rules":["length >"]}}`,
+		`{"jsonrpc":"2.0","method":"entropy","params":{"password":"x"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"entropy","params":{"password":"abc"}}`,
+	)
+
+	want := []int{rpcParseError, rpcInvalidRequest, rpcMethodNotFound, rpcInvalidParams, rpcInvalidParams, 0}
+	if len(responses) != len(want) {
+		t.Fatalf("Expected %d responses (notifications get none), got %d", len(want), len(responses))
+	}
+	for i, code := range want {
+		if got := rpcErrorCode(responses[i]); got != code {
+			t.Errorf("Response %d: expected error code %d, got %d", i, code, got)
+		}
+	}
+	if responses[5]["result"].(float64) <= 0 {
+		t.Errorf("Expected positive entropy, got %v", responses[5]["result"])
+	}
+}
+
+// TestRPCGroupSecret tests daily group passwords and their errors
+func TestRPCGroupSecret(t *testing.T) {
+	dir := t.TempDir()
+	for _, group := range []string{"lab", "admins"} {
+		if err := os.WriteFile(filepath.Join(dir, group+".key"), []byte(group+" secret\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := newRPCServer()
+	s.groupDir = dir
+	s.groups["lab"] = true
+	s.groups["office"] = true
+
+	got, err := s.groupSecret(rpcGroupSecretParams{Group: "lab", At: "2026-10-16T15:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	window, err := passgen.WindowAt(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := passgen.WindowPassword([]byte("lab secret"), "lab", window, 12, passgen.Options{}.Charsets())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Password != want || got.ValidFrom != "2026-10-16T00:00:00Z" || got.ValidUntil != "2026-10-17T00:00:00Z" {
+		t.Errorf("groupSecret = %+v, want password %s for 2026-10-16", got, want)
+	}
+
+	tests := []struct {
+		name   string
+		server *rpcServer
+		params rpcGroupSecretParams
+		code   int
+	}{
+		{"disabled", newRPCServer(), rpcGroupSecretParams{Group: "lab"}, rpcServerError},
+		{"unknown group", s, rpcGroupSecretParams{Group: "office"}, rpcInvalidParams},
+		{"path traversal", s, rpcGroupSecretParams{Group: "../lab"}, rpcInvalidParams},
+		{"bad time", s, rpcGroupSecretParams{Group: "lab", At: "today"}, rpcInvalidParams},
+		{"bad length", s, rpcGroupSecretParams{Group: "lab", Length: 2}, rpcInvalidParams},
+		{"not allowed", s, rpcGroupSecretParams{Group: "admins"}, rpcServerError},
+		{"tomorrow", s, rpcGroupSecretParams{Group: "lab", At: time.Now().Add(24 * time.Hour).Format(time.RFC3339)}, rpcInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.server.groupSecret(tt.params)
+			var rerr *rpcError
+			if !errors.As(err, &rerr) || rerr.Code != tt.code {
+				t.Errorf("groupSecret error = %v, want code %d", err, tt.code)
+			}
+		})
+	}
+}
+
+// TestRPCPronounceable tests pronounceable passwords and their entropy
+func TestRPCPronounceable(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"length":16,"pronounceable":true}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"pronounceable":true,"markov":"corpus.txt"}}`)
+	result := responses[0]["result"].(map[string]any)
+	password := result["passwords"].([]any)[0].(string)
+	if strings.Trim(password, passgen.PronounceableConsonants+passgen.PronounceableVowels) != "" {
+		t.Errorf("Password %q is not pronounceable", password)
+	}
+	if bits := result["entropy"].([]any)[0].(float64); bits != passgen.PronounceableEntropy(16) {
+		t.Errorf("Entropy = %f, want %f", bits, passgen.PronounceableEntropy(16))
+	}
+	if code := rpcErrorCode(responses[1]); code != rpcInvalidParams {
+		t.Errorf("pronounceable with markov gave error code %d, want %d", code, rpcInvalidParams)
+	}
+}
+
+// TestRPCPattern tests pattern passwords and their length
+func TestRPCPattern(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"pattern":"u{4}-d{4}","length":30}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"pattern":"dx"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"generate","params":{"pattern":"dddd","pronounceable":true}}`)
+	result := responses[0]["result"].(map[string]any)
+	password := result["passwords"].([]any)[0].(string)
+	if len(password) != 9 || password[4] != '-' {
+		t.Errorf("Password %q does not match u{4}-d{4}", password)
+	}
+	for i, resp := range responses[1:] {
+		if code := rpcErrorCode(resp); code != rpcInvalidParams {
+			t.Errorf("request %d gave error code %d, want %d", i+2, code, rpcInvalidParams)
+		}
+	}
+}
+
+// TestRPCEntropy tests picking the length from a target entropy over RPC
+func TestRPCEntropy(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"entropy":80,"noUpper":true,"noLower":true}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"entropy":80,"appleStyle":true}}`)
+	result := responses[0]["result"].(map[string]any)
+	password := result["passwords"].([]any)[0].(string)
+	if len(password) != 27 {
+		t.Errorf("Password %q has %d digits, want 27 for 80 bits", password, len(password))
+	}
+	if code := rpcErrorCode(responses[1]); code != rpcInvalidParams {
+		t.Errorf("entropy with appleStyle gave error code %d, want %d", code, rpcInvalidParams)
+	}
+}
+
+// TestRPCMinScore tests the strength score threshold over RPC
+func TestRPCMinScore(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"length":16,"minScore":4}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"minScore":5}}`)
+	result := responses[0]["result"].(map[string]any)
+	password := result["passwords"].([]any)[0].(string)
+	if s := passgen.EstimateStrength(password); s.Score < 4 {
+		t.Errorf("Password %q scored %d, want 4", password, s.Score)
+	}
+	if code := rpcErrorCode(responses[1]); code != rpcInvalidParams {
+		t.Errorf("minScore 5 gave error code %d, want %d", code, rpcInvalidParams)
+	}
+}
+
+// TestRPCBlocklist tests that common passwords are regenerated unless
+// noBlocklist is set
+func TestRPCBlocklist(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"length":4,"noUpper":true,"noLower":true,"count":100}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"length":4,"noUpper":true,"noLower":true,"noBlocklist":true}}`)
+	common := passgen.CommonPasswords()
+	for _, password := range responses[0]["result"].(map[string]any)["passwords"].([]any) {
+		if common.Contains(password.(string)) {
+			t.Errorf("Generated common password %q", password)
+		}
+	}
+	if _, ok := responses[1]["result"]; !ok {
+		t.Errorf("noBlocklist gave %v", responses[1])
+	}
+}
+
+// TestRPCHashFormat tests hashing generated passwords over RPC
+func TestRPCHashFormat(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"hashFormat":"bcrypt","count":2}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"hashFormat":"md5"}}`)
+	hashes := responses[0]["result"].(map[string]any)["hashes"].([]any)
+	if len(hashes) != 2 || !strings.HasPrefix(hashes[0].(string), "$2a$12$") {
+		t.Errorf("hashes = %v", hashes)
+	}
+	if code := rpcErrorCode(responses[1]); code != rpcInvalidParams {
+		t.Errorf("Unknown format gave error code %d, want %d", code, rpcInvalidParams)
+	}
+}
+
+// TestRPCAppleStyle tests Apple-style passwords over RPC
+func TestRPCAppleStyle(t *testing.T) {
+	responses := rpcRoundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"appleStyle":true}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"generate","params":{"appleStyle":true,"pattern":"dddd"}}`)
+	result := responses[0]["result"].(map[string]any)
+	password := result["passwords"].([]any)[0].(string)
+	if len(password) != passgen.AppleStyleLength || strings.Count(password, "-") != 2 {
+		t.Errorf("Password %q is not Apple style", password)
+	}
+	if code := rpcErrorCode(responses[1]); code != rpcInvalidParams {
+		t.Errorf("appleStyle with pattern gave error code %d, want %d", code, rpcInvalidParams)
+	}
+}
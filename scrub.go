@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printScrubUsage(programName string) {
+	fmt.Printf("Usage: %s scrub -in FILE [OPTIONS]\n", programName)
+	fmt.Println("Replace the passwords in a CSV file with random equivalents of the same")
+	fmt.Println("length and character classes, so the data can be shared safely.")
+	fmt.Println("Options:")
+	fmt.Println("  -in FILE       CSV file to scrub (- for stdin)")
+	fmt.Println("  -out FILE      Where to write the scrubbed CSV (default: stdout)")
+	fmt.Println("  -column NAME   Header name or 1-based index of the password column (default: password)")
+	fmt.Println("  -no-header     The first row is data, not a header")
+	fmt.Println("  -keep-reuse    Give identical passwords identical replacements")
+	fmt.Println("  -h             Show this help message")
+	fmt.Println("\nExamples:")
+	fmt.Printf("  %s scrub -in dump.csv -out shared.csv\n", programName)
+	fmt.Printf("  %s scrub -in dump.csv -column 3 -no-header\n", programName)
+}
+
+// runScrub implements the scrub subcommand.
+func runScrub(programName string, args []string) error {
+	fs := flag.NewFlagSet("scrub", flag.ContinueOnError)
+	in := fs.String("in", "", "CSV file to scrub")
+	out := fs.String("out", "", "Where to write the scrubbed CSV")
+	column := fs.String("column", "password", "Header name or 1-based index of the password column")
+	noHeader := fs.Bool("no-header", false, "The first row is data, not a header")
+	keepReuse := fs.Bool("keep-reuse", false, "Give identical passwords identical replacements")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printScrubUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printScrubUsage(programName)
+		return nil
+	}
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	s := &scrubber{column: *column, header: !*noHeader}
+	if *keepReuse {
+		s.seen = make(map[string]string)
+	}
+	n, err := s.scrub(r, w)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Scrubbed %d passwords\n", n)
+	return nil
+}
+
+// scrubber rewrites the password column of a CSV stream.
+type scrubber struct {
+	column string
+	header bool
+	// seen maps original passwords to their replacements when reuse
+	// should be preserved; nil otherwise.
+	seen map[string]string
+}
+
+// scrub copies CSV rows from r to w with the password column replaced and
+// returns how many passwords were replaced.
+func (s *scrubber) scrub(r io.Reader, w io.Writer) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	writer := csv.NewWriter(w)
+
+	index := -1
+	if n, err := strconv.Atoi(s.column); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("column index must be at least 1")
+		}
+		index = n - 1
+	} else if !s.header {
+		return 0, fmt.Errorf("column must be a number when the file has no header")
+	}
+
+	count := 0
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if row == 0 && s.header {
+			if index < 0 {
+				for i, name := range record {
+					if passgen.EqualFoldASCII(strings.TrimSpace(name), s.column) {
+						index = i
+						break
+					}
+				}
+				if index < 0 {
+					return 0, fmt.Errorf("no %q column in header", s.column)
+				}
+			}
+		} else if index < len(record) && record[index] != "" {
+			replacement, err := s.replace(record[index])
+			if err != nil {
+				return count, err
+			}
+			record[index] = replacement
+			count++
+		}
+
+		if err := writer.Write(record); err != nil {
+			return count, err
+		}
+	}
+
+	writer.Flush()
+	return count, writer.Error()
+}
+
+func (s *scrubber) replace(password string) (string, error) {
+	if replacement, ok := s.seen[password]; ok {
+		return replacement, nil
+	}
+	replacement, err := passgen.Equivalent(password)
+	if err != nil {
+		return "", err
+	}
+	if s.seen != nil {
+		s.seen[password] = replacement
+	}
+	return replacement, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+// TestScrubByHeader tests replacing the password column found by header name
+func TestScrubByHeader(t *testing.T) {
+	input := "user,Password,note\nalice,Hunter2!,keep me\nbob,,empty\n"
+
+	var out bytes.Buffer
+	s := &scrubber{column: "password", header: true}
+	n, err := s.scrub(strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatalf("Failed to scrub: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 password scrubbed, got %d", n)
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Scrubbed output is not valid CSV: %v", err)
+	}
+	if rows[0][1] != "Password" || rows[1][0] != "alice" || rows[1][2] != "keep me" {
+		t.Errorf("Other fields should be untouched: %v", rows)
+	}
+	if rows[1][1] == "Hunter2!" || len(rows[1][1]) != len("Hunter2!") {
+		t.Errorf("Password not replaced by an equivalent: %q", rows[1][1])
+	}
+	if rows[2][1] != "" {
+		t.Errorf("Empty password should stay empty, got %q", rows[2][1])
+	}
+}
+
+// TestScrubByIndex tests selecting the column by index without a header
+func TestScrubByIndex(t *testing.T) {
+	input := "alice,secret1\nbob,secret1\n"
+
+	var out bytes.Buffer
+	s := &scrubber{column: "2", seen: make(map[string]string)}
+	if _, err := s.scrub(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Failed to scrub: %v", err)
+	}
+
+	rows, _ := csv.NewReader(&out).ReadAll()
+	if rows[0][1] == "secret1" {
+		t.Error("First row was not scrubbed")
+	}
+	if rows[0][1] != rows[1][1] {
+		t.Errorf("Expected reused password to keep one replacement, got %q and %q", rows[0][1], rows[1][1])
+	}
+}
+
+// TestScrubErrors tests invalid column selections
+func TestScrubErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		s    scrubber
+	}{
+		{"missing header column", scrubber{column: "pw", header: true}},
+		{"named column without header", scrubber{column: "password"}},
+		{"zero index", scrubber{column: "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := tt.s.scrub(strings.NewReader("user,password\n"), &out); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// Signature algorithms of signed JSON output.
+const (
+	signHMAC    = "hmac-sha256"
+	signEd25519 = "ed25519"
+)
+
+// minHMACKey is the shortest HMAC key accepted, in bytes.
+const minHMACKey = 32
+
+// signatureOutput signs a JSON document so recipients can tell it was not
+// changed in transit. Records holds one signature per password record,
+// which tells which records were changed; Document covers the whole
+// document apart from the signature itself, which also catches records
+// that were removed or reordered.
+type signatureOutput struct {
+	Algorithm string `json:"algorithm"`
+	// KeyID identifies an Ed25519 key: the start of the SHA-256 of its
+	// public key. HMAC keys are secret and get none.
+	KeyID    string   `json:"keyId,omitempty"`
+	Records  []string `json:"records"`
+	Document string   `json:"document"`
+}
+
+// signingKey signs or verifies output with an HMAC secret or an Ed25519
+// key. Verifying with Ed25519 only needs the public key.
+type signingKey struct {
+	algorithm string
+	secret    []byte
+	private   ed25519.PrivateKey
+	public    ed25519.PublicKey
+}
+
+// loadSigningKey reads a key file: a PEM Ed25519 private or public key, as
+// written by `openssl genpkey -algorithm ed25519`, or else the raw bytes of
+// an HMAC secret of at least 32 bytes.
+func loadSigningKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		secret := bytes.TrimRight(data, "\r\n")
+		if len(secret) < minHMACKey {
+			return nil, fmt.Errorf("%s: HMAC key must be at least %d bytes", path, minHMACKey)
+		}
+		return &signingKey{algorithm: signHMAC, secret: secret}, nil
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: only Ed25519 keys are supported", path)
+		}
+		return &signingKey{algorithm: signEd25519, private: private, public: private.Public().(ed25519.PublicKey)}, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		public, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: only Ed25519 keys are supported", path)
+		}
+		return &signingKey{algorithm: signEd25519, public: public}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+}
+
+// keyID identifies the key in signed output.
+func (k *signingKey) keyID() string {
+	if k.algorithm != signEd25519 {
+		return ""
+	}
+	sum := sha256.Sum256(k.public)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (k *signingKey) sign(data []byte) ([]byte, error) {
+	switch {
+	case k.algorithm == signHMAC:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case k.private != nil:
+		return ed25519.Sign(k.private, data), nil
+	}
+	return nil, fmt.Errorf("signing needs an Ed25519 private key, not a public key")
+}
+
+func (k *signingKey) verify(data, sig []byte) bool {
+	if k.algorithm == signHMAC {
+		want, _ := k.sign(data)
+		return hmac.Equal(sig, want)
+	}
+	return ed25519.Verify(k.public, data, sig)
+}
+
+// canonicalJSON returns the bytes that are signed for a JSON value: compact,
+// with the keys of objects sorted, so the signature survives re-indenting
+// and does not depend on how the writer ordered its fields.
+func canonicalJSON(data []byte) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// signOutput signs the password records of out and then out as a whole.
+func signOutput(k *signingKey, out *generateOutput) error {
+	out.Signature = nil
+	sig := &signatureOutput{Algorithm: k.algorithm, KeyID: k.keyID(), Records: []string{}}
+	for _, record := range out.Passwords {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		s, err := k.signJSON(data)
+		if err != nil {
+			return err
+		}
+		sig.Records = append(sig.Records, s)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	if sig.Document, err = k.signJSON(data); err != nil {
+		return err
+	}
+	out.Signature = sig
+	return nil
+}
+
+// signJSON signs the canonical form of a JSON value and returns the
+// signature in base64.
+func (k *signingKey) signJSON(data []byte) (string, error) {
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		return "", err
+	}
+	sig, err := k.sign(canonical)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// verifyJSON reports whether sig, in base64, signs the canonical form of a
+// JSON value.
+func (k *signingKey) verifyJSON(data []byte, sig string) (bool, error) {
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false, fmt.Errorf("invalid signature: %v", err)
+	}
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		return false, err
+	}
+	return k.verify(canonical, raw), nil
+}
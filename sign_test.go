@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeEd25519Keys writes a fresh Ed25519 key pair as PEM files and returns
+// their paths.
+func writeEd25519Keys(t *testing.T) (private, public string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	private, public = filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem")
+	if err := os.WriteFile(private, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return private, public
+}
+
+// TestLoadSigningKey tests reading Ed25519 and HMAC key files
+func TestLoadSigningKey(t *testing.T) {
+	private, public := writeEd25519Keys(t)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		algorithm string
+		canSign   bool
+		wantErr   string
+	}{
+		{"ed25519 private", private, signEd25519, true, ""},
+		{"ed25519 public", public, signEd25519, false, ""},
+		{"hmac", write("hmac.key", strings.Repeat("k", minHMACKey)+"\n"), signHMAC, true, ""},
+		{"short hmac", write("short.key", "secret\n"), "", false, "at least 32 bytes"},
+		{"other pem", write("cert.pem", "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"), "", false, "unsupported PEM block"},
+		{"missing", filepath.Join(dir, "missing"), "", false, "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := loadSigningKey(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadSigningKey error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k.algorithm != tt.algorithm {
+				t.Errorf("algorithm = %q, want %q", k.algorithm, tt.algorithm)
+			}
+			if _, err := k.sign([]byte("x")); (err == nil) != tt.canSign {
+				t.Errorf("sign error = %v, want signing %v", err, tt.canSign)
+			}
+		})
+	}
+}
+
+// TestSignOutput tests that signed output verifies and tampering is caught
+func TestSignOutput(t *testing.T) {
+	private, public := writeEd25519Keys(t)
+	signer, err := loadSigningKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := loadSigningKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := generateOutput{Schema: outputSchema, Length: 12, Passwords: []passwordOutput{
+		{Label: "db", Password: "a<b&c>d12345", Entropy: 70.5},
+		{Label: "web", Password: "Zx9kQ2mLp4Rt", Entropy: 70.5},
+	}}
+	if err := signOutput(signer, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Signature.KeyID != verifier.keyID() || len(out.Signature.Records) != 2 {
+		t.Fatalf("Signature = %+v", out.Signature)
+	}
+
+	// The recipient sees the indented document, not what was signed
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, out); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Passwords []json.RawMessage `json:"passwords"`
+		Signature signatureOutput   `json:"signature"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for i, record := range doc.Passwords {
+		if ok, err := verifier.verifyJSON(record, doc.Signature.Records[i]); !ok || err != nil {
+			t.Errorf("Record %d does not verify: %v", i, err)
+		}
+	}
+
+	tampered := bytes.Replace(doc.Passwords[1], []byte("Zx9k"), []byte("Zx9j"), 1)
+	if ok, _ := verifier.verifyJSON(tampered, doc.Signature.Records[1]); ok {
+		t.Error("Tampered record verified")
+	}
+	if ok, _ := verifier.verifyJSON(doc.Passwords[0], doc.Signature.Records[1]); ok {
+		t.Error("Record verified with the signature of another record")
+	}
+}
+
+// TestCanonicalJSON tests that layout and key order do not change what is signed
+func TestCanonicalJSON(t *testing.T) {
+	a, err := canonicalJSON([]byte(`{"b": 1.50, "a": ["x", {"d": true, "c": null}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := canonicalJSON([]byte("{\n  \"a\": [\"x\", {\"c\": null, \"d\": true}],\n  \"b\": 1.50\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":["x",{"c":null,"d":true}],"b":1.50}`; string(a) != want || string(b) != want {
+		t.Errorf("canonicalJSON = %s and %s, want %s", a, b, want)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// features lists the optional integrations compiled into this binary.
+// Integrations that shell out to OS tools or talk to remote services live in
+// files built with the !passgen_lite constraint and register themselves here,
+// so `go build -tags passgen_lite` produces a minimal static binary.
+var features []string
+
+// credential is a generated password together with the context that travels
+// with it to sinks.
+type credential struct {
+	Label    string `json:"label,omitempty"`
+	Password string `json:"password"`
+	Note     string `json:"note,omitempty"`
+}
+
+// sink is a destination that receives the finished batch of credentials, in
+// addition to the normal output.
+type sink interface {
+	write(creds []credential) error
+}
+
+// reversibleSink is a sink that can take back its most recent successful
+// write, which lets a batch be delivered to several sinks as a unit.
+type reversibleSink interface {
+	sink
+	undo() error
+}
+
+// undoChecker is implemented by reversible sinks that can only sometimes
+// take a write back, such as wrappers and plugins.
+type undoChecker interface {
+	canUndo() bool
+}
+
+// reversible reports whether s can take back its writes.
+func reversible(s sink) bool {
+	if c, ok := s.(undoChecker); ok {
+		return c.canUndo()
+	}
+	_, ok := s.(reversibleSink)
+	return ok
+}
+
+// orderSinks puts the sinks that can be rolled back ahead of those that
+// cannot, keeping the order within each. A failing sink then never follows
+// an irreversible write that could have waited for it.
+func orderSinks(sinks []sink) []sink {
+	ordered := make([]sink, 0, len(sinks))
+	for _, s := range sinks {
+		if reversible(s) {
+			ordered = append(ordered, s)
+		}
+	}
+	for _, s := range sinks {
+		if !reversible(s) {
+			ordered = append(ordered, s)
+		}
+	}
+	return ordered
+}
+
+// writeSinks delivers creds to every sink with all-or-nothing semantics: if
+// a sink fails, the sinks that already accepted the batch are rolled back so
+// the credentials end up everywhere or nowhere. With continueOnError the
+// remaining sinks still receive the batch and all failures are reported
+// together instead.
+func writeSinks(sinks []sink, creds []credential, continueOnError bool) error {
+	var written []sink
+	var errs []error
+	for _, s := range sinks {
+		if err := s.write(creds); err != nil {
+			errs = append(errs, err)
+			if continueOnError {
+				continue
+			}
+			return errors.Join(append(errs, rollback(written))...)
+		}
+		written = append(written, s)
+	}
+	return errors.Join(errs...)
+}
+
+// rollback undoes the latest write of each sink, newest first.
+func rollback(sinks []sink) error {
+	var errs []error
+	for i := len(sinks) - 1; i >= 0; i-- {
+		r, ok := sinks[i].(reversibleSink)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s cannot be rolled back and keeps the credentials", sinkName(sinks[i])))
+			continue
+		}
+		if err := r.undo(); err != nil {
+			errs = append(errs, fmt.Errorf("rolling back %s: %w", sinkName(sinks[i]), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sinkName describes a sink in error messages.
+func sinkName(s sink) string {
+	if n, ok := s.(fmt.Stringer); ok {
+		return n.String()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+// csvSink appends credentials to CSV files, writing a header row when a file
+// is created. The path may be a template, see expandPath.
+type csvSink struct {
+	path string
+	now  time.Time
+	// undoLog records how to restore the files touched by the last write.
+	undoLog []csvUndo
+}
+
+// csvUndo restores a file to its size before a write, or removes it when the
+// write created it.
+type csvUndo struct {
+	path    string
+	size    int64
+	created bool
+}
+
+// newCSVSink returns a sink for the path template, rejecting unknown
+// placeholders before anything is generated.
+func newCSVSink(path string) (*csvSink, error) {
+	s := &csvSink{path: path, now: time.Now()}
+	if _, err := expandPath(path, s.now, "label"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *csvSink) write(creds []credential) error {
+	// Credentials with different labels may land in different files
+	var paths []string
+	byPath := make(map[string][]credential)
+	for _, c := range creds {
+		path, err := expandPath(s.path, s.now, c.Label)
+		if err != nil {
+			return err
+		}
+		if _, ok := byPath[path]; !ok {
+			paths = append(paths, path)
+		}
+		byPath[path] = append(byPath[path], c)
+	}
+
+	s.undoLog = nil
+	for _, path := range paths {
+		if err := s.appendCSV(path, byPath[path]); err != nil {
+			// Keep the write atomic across files
+			return errors.Join(err, s.undo())
+		}
+	}
+	return nil
+}
+
+func (s *csvSink) appendCSV(path string, creds []credential) error {
+	info, err := os.Stat(path)
+	empty := err != nil || info.Size() == 0
+	if err == nil {
+		s.undoLog = append(s.undoLog, csvUndo{path: path, size: info.Size()})
+	} else {
+		s.undoLog = append(s.undoLog, csvUndo{path: path, created: true})
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if empty {
+		w.Write([]string{"label", "password", "note"})
+	}
+	for _, c := range creds {
+		w.Write([]string{c.Label, c.Password, c.Note})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// undo removes the rows appended by the last write.
+func (s *csvSink) undo() error {
+	var errs []error
+	for _, u := range s.undoLog {
+		var err error
+		if u.created {
+			err = os.Remove(u.path)
+		} else {
+			err = os.Truncate(u.path, u.size)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	s.undoLog = nil
+	return errors.Join(errs...)
+}
+
+func (s *csvSink) canReadBack() bool {
+	return true
+}
+
+// readBack rereads the rows appended by the last write and returns them in
+// the order of labels, which may have been spread over several files.
+func (s *csvSink) readBack(labels []string) ([]credential, error) {
+	byPath := make(map[string][]credential)
+	for _, u := range s.undoLog {
+		rows, err := readCSVFrom(u.path, u.size)
+		if err != nil {
+			return nil, err
+		}
+		if u.created && len(rows) > 0 {
+			rows = rows[1:]
+		}
+		for _, row := range rows {
+			if len(row) != 3 {
+				return nil, fmt.Errorf("%s: row has %d fields, want 3", u.path, len(row))
+			}
+			byPath[u.path] = append(byPath[u.path], credential{Label: row[0], Password: row[1], Note: row[2]})
+		}
+	}
+
+	var creds []credential
+	for _, label := range labels {
+		path, err := expandPath(s.path, s.now, label)
+		if err != nil {
+			return nil, err
+		}
+		if len(byPath[path]) == 0 {
+			break
+		}
+		creds = append(creds, byPath[path][0])
+		byPath[path] = byPath[path][1:]
+	}
+	for _, rest := range byPath {
+		creds = append(creds, rest...)
+	}
+	return creds, nil
+}
+
+// readCSVFrom parses the CSV records that start at offset in the file.
+func readCSVFrom(path string, offset int64) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+func (s *csvSink) String() string {
+	return "output file " + s.path
+}
+
+// expandPath fills in the placeholders of an output path template:
+//
+//	{{date}}       the date, e.g. 2025-01-31
+//	{{time}}       the time of day, e.g. 153045
+//	{{timestamp}}  seconds since the Unix epoch
+//	{{label}}      the credential label, made safe for file names
+//
+// so scheduled jobs can write organized artifacts without shell quoting.
+func expandPath(template string, now time.Time, label string) (string, error) {
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in %q", template)
+		}
+		b.WriteString(rest[:start])
+
+		name := strings.TrimSpace(rest[start+2 : start+end])
+		switch name {
+		case "date":
+			b.WriteString(now.Format("2006-01-02"))
+		case "time":
+			b.WriteString(now.Format("150405"))
+		case "timestamp":
+			b.WriteString(strconv.FormatInt(now.Unix(), 10))
+		case "label":
+			if label == "" {
+				return "", fmt.Errorf("%q uses {{label}} but the credential has no label", template)
+			}
+			b.WriteString(safeFileName(label))
+		default:
+			return "", fmt.Errorf("unknown placeholder {{%s}} in %q", name, template)
+		}
+		rest = rest[start+end+2:]
+	}
+}
+
+// safeFileName replaces everything but ASCII letters, digits, dots,
+// underscores and dashes, so a label cannot escape the output directory.
+func safeFileName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			b[i] = '-'
+		}
+	}
+	if name := string(b); name != "." && name != ".." {
+		return name
+	}
+	return "-"
+}
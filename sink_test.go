@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCSVSink tests that the header is written once across appends
+func TestCSVSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "creds.csv")
+	s, err := newCSVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.write([]credential{{Label: "web", Password: "a,b", Note: "until Q3"}}); err != nil {
+		t.Fatalf("First write failed: %v", err)
+	}
+	if err := s.write([]credential{{Password: "second"}}); err != nil {
+		t.Fatalf("Second write failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "label,password,note\nweb,\"a,b\",until Q3\n,second,\n"
+	if string(data) != want {
+		t.Errorf("File contents = %q, want %q", data, want)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("File should not be readable by others, mode %v", info.Mode().Perm())
+	}
+}
+
+// TestCSVSinkTemplate tests that labels route credentials to their own files
+func TestCSVSinkTemplate(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newCSVSink(filepath.Join(dir, "creds-{{label}}.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds := []credential{{Label: "web", Password: "one"}, {Label: "db/main", Password: "two"}, {Label: "web", Password: "three"}}
+	if err := s.write(creds); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"creds-web.csv":     "label,password,note\nweb,one,\nweb,three,\n",
+		"creds-db-main.csv": "label,password,note\ndb/main,two,\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Errorf("Missing %s: %v", file, err)
+			continue
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", file, data, want)
+		}
+	}
+
+	if err := s.write([]credential{{Password: "unlabeled"}}); err == nil {
+		t.Error("Expected error for {{label}} without a label")
+	}
+}
+
+// TestExpandPath tests output path templates
+func TestExpandPath(t *testing.T) {
+	now := time.Date(2025, 1, 31, 15, 30, 45, 0, time.UTC)
+	tests := []struct {
+		template string
+		label    string
+		want     string
+		wantErr  bool
+	}{
+		{"creds.csv", "", "creds.csv", false},
+		{"creds-{{date}}.csv", "", "creds-2025-01-31.csv", false},
+		{"{{date}}T{{time}}-{{ label }}.csv", "web", "2025-01-31T153045-web.csv", false},
+		{"run-{{timestamp}}.csv", "", "run-1738337445.csv", false},
+		{"out/{{label}}.csv", "../etc/passwd", "out/..-etc-passwd.csv", false},
+		{"{{label}}.csv", "..", "-.csv", false},
+		{"{{label}}.csv", "", "", true},
+		{"{{hostname}}.csv", "", "", true},
+		{"creds-{{date.csv", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := expandPath(tt.template, now, tt.label)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandPath(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandPath(%q, %q) = %q, want %q", tt.template, tt.label, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewCSVSinkRejectsUnknownPlaceholder tests that typos fail up front
+func TestNewCSVSinkRejectsUnknownPlaceholder(t *testing.T) {
+	if _, err := newCSVSink("creds-{{dat}}.csv"); err == nil {
+		t.Error("Expected error for unknown placeholder")
+	}
+}
+
+// undoSink is a memory sink that supports rollback
+type undoSink struct {
+	memorySink
+	last int
+}
+
+func (s *undoSink) write(creds []credential) error {
+	s.last = len(s.creds)
+	return s.memorySink.write(creds)
+}
+
+func (s *undoSink) undo() error {
+	s.creds = s.creds[:s.last]
+	return nil
+}
+
+// TestWriteSinks tests all-or-nothing delivery and the continue-on-error escape hatch
+func TestWriteSinks(t *testing.T) {
+	creds := []credential{{Label: "db", Password: "secret"}}
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		first, last := &undoSink{}, &undoSink{}
+		err := writeSinks([]sink{first, failingSink{}, last}, creds, false)
+		if err == nil {
+			t.Fatal("Expected the failing sink's error")
+		}
+		if len(first.creds) != 0 {
+			t.Errorf("First sink kept %v after rollback", first.creds)
+		}
+		if len(last.creds) != 0 {
+			t.Errorf("Sinks after the failure should not be written, got %v", last.creds)
+		}
+	})
+
+	t.Run("reports sinks that cannot roll back", func(t *testing.T) {
+		mem := &memorySink{}
+		err := writeSinks([]sink{mem, failingSink{}}, creds, false)
+		if err == nil || !strings.Contains(err.Error(), "cannot be rolled back") {
+			t.Errorf("Expected a rollback warning, got %v", err)
+		}
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		first, last := &undoSink{}, &undoSink{}
+		err := writeSinks([]sink{first, failingSink{}, last}, creds, true)
+		if err == nil {
+			t.Fatal("Expected the failing sink's error")
+		}
+		if len(first.creds) != 1 || len(last.creds) != 1 {
+			t.Errorf("Other sinks should keep the batch, got %v and %v", first.creds, last.creds)
+		}
+	})
+}
+
+// TestOrderSinks tests that reversible sinks, wrapped or not, go first
+func TestOrderSinks(t *testing.T) {
+	kept, undone := &memorySink{}, &undoSink{}
+	retried := withRetry([]sink{&memorySink{}, &undoSink{}}, retryPolicy{})
+	half := &halfSink{sink: &undoSink{}}
+	sinks := []sink{kept, retried[0], undone, retried[1], half}
+	want := []sink{undone, retried[1], half, kept, retried[0]}
+	got := orderSinks(sinks)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("orderSinks()[%d] = %s, want %s", i, sinkName(got[i]), sinkName(want[i]))
+		}
+	}
+}
+
+// TestCSVSinkUndo tests that undo restores appended files and removes created ones
+func TestCSVSinkUndo(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "creds-web.csv")
+	s, err := newCSVSink(filepath.Join(dir, "creds-{{label}}.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.write([]credential{{Label: "web", Password: "one"}}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.write([]credential{{Label: "web", Password: "two"}, {Label: "db", Password: "three"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.undo(); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+
+	if after, err := os.ReadFile(existing); err != nil || string(after) != string(before) {
+		t.Errorf("Appended file = %q, want %q", after, before)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "creds-db.csv")); !os.IsNotExist(err) {
+		t.Errorf("Created file should be removed, stat error %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printSiteUsage(programName string) {
+	fmt.Printf("Usage: %s site show DOMAIN...\n", programName)
+	fmt.Printf("       %s site update [OPTIONS]\n", programName)
+	fmt.Println("Show the password rules -site applies for a site, such as a maximum length")
+	fmt.Println("or the special characters it accepts, or update the rules database from the")
+	fmt.Println("password-rules.json of Apple's password-manager-resources project.")
+	fmt.Println("Options:")
+	fmt.Println("  -url URL     Download the database from URL (default: the project's main branch)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s site show bankofamerica.com\n", programName)
+	fmt.Printf("  %s site update\n", programName)
+}
+
+// siteRulesURL is the curated database of site password rules that site
+// update downloads.
+const siteRulesURL = "https://raw.githubusercontent.com/apple/password-manager-resources/main/quirks/password-rules.json"
+
+// embeddedSiteRules is a snapshot of the database, used until site update
+// downloads a newer one.
+//
+//go:embed sites/password-rules.json
+var embeddedSiteRules []byte
+
+// siteRulesPath returns where site update keeps the downloaded database.
+func siteRulesPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ".passgen-password-rules.json"
+	}
+	return filepath.Join(dir, "passgen", "password-rules.json")
+}
+
+// decodeSiteRules reads the database: a JSON object mapping domains to
+// {"password-rules": RULES}.
+func decodeSiteRules(data []byte) (map[string]string, error) {
+	var entries map[string]struct {
+		Rules string `json:"password-rules"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	db := make(map[string]string, len(entries))
+	for domain, e := range entries {
+		if e.Rules != "" {
+			db[strings.ToLower(domain)] = e.Rules
+		}
+	}
+	return db, nil
+}
+
+// loadSiteRules returns the downloaded database, or the embedded one when
+// site update has not run.
+func loadSiteRules() (map[string]string, error) {
+	path := siteRulesPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return decodeSiteRules(embeddedSiteRules)
+	}
+	if err != nil {
+		return nil, err
+	}
+	db, err := decodeSiteRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v (run site update again or remove it)", path, err)
+	}
+	return db, nil
+}
+
+// siteDomain reduces a domain or URL to its lowercase host name.
+func siteDomain(site string) (string, error) {
+	host := site
+	if strings.Contains(site, "://") {
+		u, err := url.Parse(site)
+		if err != nil {
+			return "", err
+		}
+		host = u.Hostname()
+	} else {
+		host, _, _ = strings.Cut(host, "/")
+		if i := strings.LastIndexByte(host, ':'); i >= 0 {
+			host = host[:i]
+		}
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || strings.ContainsAny(host, " \t@") {
+		return "", fmt.Errorf("invalid site %q: give a domain such as example.com", site)
+	}
+	return host, nil
+}
+
+// lookupSite returns the rules of a site and the domain they are listed
+// under, which is the site's own or that of a parent domain, so
+// secure.bank.example matches bank.example.
+func lookupSite(db map[string]string, site string) (domain, rules string, err error) {
+	host, err := siteDomain(site)
+	if err != nil {
+		return "", "", err
+	}
+	for d := host; ; {
+		if rules, ok := db[d]; ok {
+			return d, rules, nil
+		}
+		_, parent, ok := strings.Cut(d, ".")
+		if !ok {
+			break
+		}
+		d = parent
+	}
+	return "", "", fmt.Errorf("%w for %s (site update refreshes the database)", errNoSiteRules, host)
+}
+
+// errNoSiteRules reports a site missing from the database, which as far as
+// passgen knows accepts passwords without site-specific settings.
+var errNoSiteRules = errors.New("no known password rules")
+
+// siteRules is a parsed rule set of the password rules language of the
+// HTML passwordrules attribute:
+//
+//	minlength: 8; maxlength: 20; max-consecutive: 3;
+//	required: lower, upper; required: digit; allowed: [-@#*];
+//
+// Each required rule needs one character from its set, and passwords may
+// only use the required and allowed characters. Only ASCII is tracked,
+// since passgen generates nothing else.
+type siteRules struct {
+	minLength, maxLength, maxConsecutive int
+	required                             []string
+	allowed                              string
+}
+
+// Character classes of the rules language. special is every printable
+// ASCII character that is not a letter or digit, and space.
+var siteRuleClasses = map[string]string{
+	"upper":   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"lower":   "abcdefghijklmnopqrstuvwxyz",
+	"digit":   "0123456789",
+	"special": " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+}
+
+// parseSiteRules parses a rule set.
+func parseSiteRules(text string) (siteRules, error) {
+	var r siteRules
+	var allowed []string
+	for _, rule := range splitSiteRules(text) {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(rule, ":")
+		if !ok {
+			return r, fmt.Errorf("rule %q has no value", rule)
+		}
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		switch name {
+		case "minlength", "maxlength", "max-consecutive":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return r, fmt.Errorf("%s: %q is not a number", name, value)
+			}
+			switch name {
+			case "minlength":
+				r.minLength = n
+			case "maxlength":
+				r.maxLength = n
+			default:
+				r.maxConsecutive = n
+			}
+		case "required", "allowed":
+			set, err := parseSiteCharacters(value)
+			if err != nil {
+				return r, fmt.Errorf("%s: %v", name, err)
+			}
+			if name == "required" {
+				r.required = append(r.required, set)
+			} else {
+				allowed = append(allowed, set)
+			}
+		default:
+			return r, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	if r.maxLength > 0 && r.maxLength < r.minLength {
+		return r, fmt.Errorf("maxlength is below minlength")
+	}
+	// Without any character rules, every printable character is allowed
+	if len(r.required) == 0 && len(allowed) == 0 {
+		allowed = append(allowed, siteRuleClasses["upper"]+siteRuleClasses["lower"]+siteRuleClasses["digit"]+siteRuleClasses["special"])
+	}
+	r.allowed = mergeCharacters(append(allowed, r.required...)...)
+	return r, nil
+}
+
+// splitSiteRules splits a rule set at the semicolons outside [custom]
+// character sets, which may contain them.
+func splitSiteRules(text string) []string {
+	var rules []string
+	start, inSet := 0, false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case inSet && c == ']':
+			// The first of ]] is a character of the set
+			if i+1 < len(text) && text[i+1] == ']' {
+				i++
+			}
+			inSet = false
+		case c == '[':
+			inSet = true
+		case c == ';' && !inSet:
+			rules = append(rules, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(rules, text[start:])
+}
+
+// parseSiteCharacters parses the comma-separated classes and [custom]
+// character sets of a required or allowed rule into one set.
+func parseSiteCharacters(value string) (string, error) {
+	var sets []string
+	for rest := strings.TrimSpace(value); rest != ""; {
+		if rest[0] == '[' {
+			// A ] is a character of the set when another one follows it
+			end := 1
+			for end < len(rest) && (rest[end] != ']' || end+1 < len(rest) && rest[end+1] == ']') {
+				end++
+			}
+			if end == len(rest) {
+				return "", fmt.Errorf("unterminated character set in %q", value)
+			}
+			var custom strings.Builder
+			for _, c := range rest[1:end] {
+				if c < 0x80 {
+					custom.WriteRune(c)
+				}
+			}
+			sets = append(sets, custom.String())
+			rest = rest[end+1:]
+		} else {
+			name, _, _ := strings.Cut(rest, ",")
+			name = strings.TrimSpace(name)
+			rest = rest[len(name):]
+			switch class := strings.ToLower(name); class {
+			case "ascii-printable", "unicode":
+				sets = append(sets, siteRuleClasses["upper"]+siteRuleClasses["lower"]+siteRuleClasses["digit"]+siteRuleClasses["special"])
+			default:
+				set, ok := siteRuleClasses[class]
+				if !ok {
+					return "", fmt.Errorf("unknown character class %q", name)
+				}
+				sets = append(sets, set)
+			}
+		}
+		rest = strings.TrimSpace(rest)
+		if rest != "" {
+			if rest[0] != ',' {
+				return "", fmt.Errorf("expected a comma in %q", value)
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+	}
+	return mergeCharacters(sets...), nil
+}
+
+// mergeCharacters returns the distinct characters of the sets in order.
+func mergeCharacters(sets ...string) string {
+	var b strings.Builder
+	for _, set := range sets {
+		for _, c := range set {
+			if !strings.ContainsRune(b.String(), c) {
+				b.WriteRune(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// standard expresses the rules of domain as a policy for generate: the
+// characters the site does not accept are excluded, and a required set of
+// special characters turns them on. The length defaults as in a policy
+// file.
+func (r *siteRules) standard(domain string) (standardPolicy, error) {
+	s := standardPolicy{name: domain, title: "Password rules of " + domain}
+	p := &s.policy
+	p.MinLength, p.MaxLength, p.MaxRun = r.minLength, r.maxLength, r.maxConsecutive
+	// The full alphabets, since -allow-similar adds 0, O, I, l and 1
+	for _, c := range siteRuleClasses["upper"] + siteRuleClasses["lower"] + siteRuleClasses["digit"] + passgen.Special {
+		if !strings.ContainsRune(r.allowed, c) {
+			p.Exclude += string(c)
+		}
+	}
+	for _, set := range r.required {
+		classes := map[passgen.CharClass]bool{}
+		for _, c := range set {
+			classes[passgen.ClassOf(c)] = true
+		}
+		if len(classes) != 1 {
+			// Passwords use every class passgen draws from, which covers
+			// a choice of classes
+			continue
+		}
+		switch {
+		case classes[passgen.ClassUpper]:
+			p.MinUpper = 1
+		case classes[passgen.ClassLower]:
+			p.MinLower = 1
+		case classes[passgen.ClassDigit]:
+			p.MinDigits = 1
+		default:
+			if !slices.ContainsFunc([]rune(set), func(c rune) bool { return strings.ContainsRune(passgen.Special, c) }) {
+				return s, fmt.Errorf("%s requires one of %q, which are not among passgen's special characters", domain, set)
+			}
+			p.MinSpecial = 1
+			s.special = true
+		}
+	}
+	s.length = max(p.MinLength, passgen.DefaultLength)
+	if p.MaxLength > 0 {
+		s.length = min(s.length, p.MaxLength)
+	}
+	return s, nil
+}
+
+// loadSite returns the policy -site applies for site.
+func loadSite(site string) (standardPolicy, error) {
+	db, err := loadSiteRules()
+	if err != nil {
+		return standardPolicy{}, err
+	}
+	domain, text, err := lookupSite(db, site)
+	if err != nil {
+		return standardPolicy{}, err
+	}
+	r, err := parseSiteRules(text)
+	if err != nil {
+		return standardPolicy{}, fmt.Errorf("password rules of %s: %v", domain, err)
+	}
+	return r.standard(domain)
+}
+
+// runSite implements the site subcommand.
+func runSite(programName string, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		printSiteUsage(programName)
+		return nil
+	}
+	switch args[0] {
+	case "show":
+		if len(args) < 2 {
+			return fmt.Errorf("give a site, e.g. %s site show example.com", programName)
+		}
+		db, err := loadSiteRules()
+		if err != nil {
+			return err
+		}
+		for i, site := range args[1:] {
+			domain, text, err := lookupSite(db, site)
+			if err != nil {
+				return err
+			}
+			r, err := parseSiteRules(text)
+			if err != nil {
+				return fmt.Errorf("password rules of %s: %v", domain, err)
+			}
+			s, err := r.standard(domain)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s: %s\n", domain, text)
+			// The rows of preset diff, without the title
+			for _, c := range s.constraints()[1:] {
+				fmt.Printf("  %-20s %s\n", c[0], c[1])
+			}
+		}
+		return nil
+	case "update":
+	default:
+		return fmt.Errorf("unknown site command %q (use show or update)", args[0])
+	}
+
+	fs := flag.NewFlagSet("site update", flag.ContinueOnError)
+	source := fs.String("url", siteRulesURL, "Download the database from URL")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printSiteUsage(programName) }
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *help {
+		printSiteUsage(programName)
+		return nil
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	path := siteRulesPath()
+	n, invalid, err := updateSiteRules(*source, path)
+	if err != nil {
+		return err
+	}
+	if len(invalid) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: -site cannot use the rules of %d sites: %s\n", len(invalid), strings.Join(invalid, ", "))
+	}
+	fmt.Printf("Updated the password rules of %d sites in %s\n", n, path)
+	return nil
+}
+
+// checkSiteRules returns the domains of a database whose rules do not
+// parse, sorted.
+func checkSiteRules(db map[string]string) []string {
+	var invalid []string
+	for domain, text := range db {
+		if _, err := parseSiteRules(text); err != nil {
+			invalid = append(invalid, domain)
+		}
+	}
+	slices.Sort(invalid)
+	return invalid
+}
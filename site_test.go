@@ -0,0 +1,196 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestEmbeddedSiteRules tests that every site of the embedded database
+// converts into a policy its own generated passwords satisfy
+func TestEmbeddedSiteRules(t *testing.T) {
+	db, err := decodeSiteRules(embeddedSiteRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invalid := checkSiteRules(db); len(invalid) > 0 {
+		t.Fatalf("Sites with invalid rules: %q", invalid)
+	}
+	for domain, text := range db {
+		r, _ := parseSiteRules(text)
+		s, err := r.standard(domain)
+		if err != nil {
+			t.Errorf("%s: %v", domain, err)
+			continue
+		}
+		p := &s.policy
+		opts := []passgen.GeneratorOption{passgen.WithLength(s.length), passgen.WithSpecial(s.special), passgen.WithExclude(p.Exclude)}
+		opts = append(opts, minCountOptions(p.MinUpper, p.MinLower, p.MinDigits, p.MinSpecial)...)
+		if p.MaxRun > 0 {
+			opts = append(opts, passgen.WithoutRepeats(p.MaxRun))
+		}
+		gen, err := passgen.NewGenerator(opts...)
+		if err != nil {
+			t.Errorf("%s: %v", domain, err)
+			continue
+		}
+		password, err := gen.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range password {
+			if !strings.ContainsRune(r.allowed, c) {
+				t.Errorf("%s does not accept %q in %q", domain, c, password)
+			}
+		}
+		for _, res := range p.results(password) {
+			if !res.OK {
+				t.Errorf("%s: %q fails %s", domain, password, res.Text)
+			}
+		}
+	}
+}
+
+// TestParseSiteRules tests the password rules language
+func TestParseSiteRules(t *testing.T) {
+	tests := []struct {
+		rules    string
+		want     siteRules
+		allowed  string
+		rejected string
+		err      bool
+	}{
+		{
+			rules:   "minlength: 8; maxlength: 20; max-consecutive: 3; required: lower, upper; required: digit; allowed: [-@#];",
+			want:    siteRules{minLength: 8, maxLength: 20, maxConsecutive: 3},
+			allowed: "aZ9-@#", rejected: "!& ",
+		},
+		// Every printable character without character rules
+		{rules: "minlength: 6", want: siteRules{minLength: 6}, allowed: "aZ9 ~!"},
+		// A ] followed by another is part of the set
+		{rules: "allowed: lower, [-~[]]", allowed: "a-~[]", rejected: "A!"},
+		{rules: "allowed: digit, [;:]; maxlength: 6", want: siteRules{maxLength: 6}, allowed: "1;:", rejected: "a"},
+		{rules: "allowed: [;]]; minlength: 4", want: siteRules{minLength: 4}, allowed: "];", rejected: "a"},
+		{rules: "required: special; allowed: upper", allowed: "A!~ ", rejected: "a1"},
+		{rules: "maxlength: 6; minlength: 8", err: true},
+		{rules: "minlength: eight", err: true},
+		{rules: "required: symbols", err: true},
+		{rules: "allowed: [abc", err: true},
+		{rules: "passwordrules", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSiteRules(tt.rules)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseSiteRules(%q): expected an error", tt.rules)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSiteRules(%q): %v", tt.rules, err)
+			continue
+		}
+		if got.minLength != tt.want.minLength || got.maxLength != tt.want.maxLength || got.maxConsecutive != tt.want.maxConsecutive {
+			t.Errorf("parseSiteRules(%q) = %+v", tt.rules, got)
+		}
+		for _, c := range tt.allowed {
+			if !strings.ContainsRune(got.allowed, c) {
+				t.Errorf("parseSiteRules(%q) does not allow %q", tt.rules, c)
+			}
+		}
+		if strings.ContainsAny(got.allowed, tt.rejected) {
+			t.Errorf("parseSiteRules(%q) allows one of %q: %q", tt.rules, tt.rejected, got.allowed)
+		}
+	}
+}
+
+// TestSiteStandard tests converting site rules into a policy
+func TestSiteStandard(t *testing.T) {
+	r, err := parseSiteRules("minlength: 8; maxlength: 10; max-consecutive: 2; required: upper; required: digit; required: [!#~]; allowed: lower;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := r.standard("bank.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &s.policy
+	if s.length != 10 || !s.special || p.MinUpper != 1 || p.MinDigits != 1 || p.MinSpecial != 1 || p.MinLower != 0 || p.MaxRun != 2 || p.MaxLength != 10 {
+		t.Errorf("Unexpected policy %+v", s)
+	}
+	if p.Exclude != "@$%^&*()_+-=[]{}|;:,.<>?" {
+		t.Errorf("Exclude = %q", p.Exclude)
+	}
+
+	// Choices of classes are met by every class passgen uses, and the
+	// required characters are the only ones allowed
+	r, _ = parseSiteRules("minlength: 16; required: lower, upper, [!]")
+	if s, _ := r.standard("shop.example"); s.special || s.length != 16 || s.policy.MinUpper != 0 || s.policy.Exclude != "0123456789"+strings.ReplaceAll(passgen.Special, "!", "") {
+		t.Errorf("Unexpected policy %+v", s)
+	}
+
+	r, _ = parseSiteRules("required: [~`]")
+	if _, err := r.standard("odd.example"); err == nil {
+		t.Error("Expected an error for special characters passgen never generates")
+	}
+}
+
+// TestLookupSite tests matching sites, subdomains and URLs to the database
+func TestLookupSite(t *testing.T) {
+	db := map[string]string{"bank.example": "maxlength: 20", "login.shop.example": "maxlength: 30"}
+	tests := []struct {
+		site string
+		want string
+	}{
+		{"bank.example", "bank.example"},
+		{"BANK.example.", "bank.example"},
+		{"secure.bank.example", "bank.example"},
+		{"https://www.bank.example:8443/login?next=/", "bank.example"},
+		{"bank.example/login", "bank.example"},
+		{"login.shop.example", "login.shop.example"},
+		{"shop.example", ""},
+		{"example", ""},
+		{"https://", ""},
+	}
+	for _, tt := range tests {
+		domain, _, err := lookupSite(db, tt.site)
+		if domain != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("lookupSite(%q) = %q, %v, want %q", tt.site, domain, err, tt.want)
+		}
+	}
+}
+
+// TestLoadSiteRules tests that a downloaded database replaces the embedded
+// one
+func TestLoadSiteRules(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	s, err := loadSite("https://www.paypal.com/signin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.name != "paypal.com" || s.policy.MaxLength != 20 {
+		t.Errorf("Embedded paypal.com = %+v", s)
+	}
+
+	path := siteRulesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"paypal.com": {"password-rules": "minlength: 10; maxlength: 64;"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = loadSite("paypal.com"); err != nil || s.policy.MaxLength != 64 || s.length != 12 {
+		t.Errorf("Downloaded paypal.com = %+v, %v", s, err)
+	}
+	if _, err := loadSite("chase.com"); err == nil {
+		t.Error("Expected the downloaded database to replace the embedded one")
+	}
+	os.WriteFile(path, []byte("<html>"), 0600)
+	if _, err := loadSite("paypal.com"); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected an error naming the broken database, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	features = append(features, "site-update")
+}
+
+// maxSiteRulesBytes bounds a downloaded rules database, which is about
+// 100 KB.
+const maxSiteRulesBytes = 10 << 20
+
+// updateSiteRules downloads the rules database from source and saves it to
+// path once it decodes. It returns the number of sites and those whose
+// rules -site cannot use.
+func updateSiteRules(source, path string) (int, []string, error) {
+	return downloadSiteRules(&http.Client{Timeout: 30 * time.Second}, source, path)
+}
+
+func downloadSiteRules(client *http.Client, source, path string) (int, []string, error) {
+	// A database fetched in the clear could be swapped for one that caps
+	// every site at a few characters
+	if !strings.HasPrefix(source, "https://") {
+		return 0, nil, fmt.Errorf("rules database URL %s must use https", source)
+	}
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("User-Agent", "passgen/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("downloading site rules: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, nil, fmt.Errorf("downloading site rules: %s returned %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSiteRulesBytes+1))
+	if err != nil {
+		return 0, nil, fmt.Errorf("downloading site rules: %w", err)
+	}
+	if len(data) > maxSiteRulesBytes {
+		return 0, nil, fmt.Errorf("downloading site rules: %s is larger than %d bytes", source, maxSiteRulesBytes)
+	}
+	db, err := decodeSiteRules(data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s is not a password rules database: %v", source, err)
+	}
+	if len(db) == 0 {
+		return 0, nil, fmt.Errorf("%s lists no sites", source)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return 0, nil, fmt.Errorf("saving site rules: %w", err)
+	}
+	return len(db), checkSiteRules(db), nil
+}
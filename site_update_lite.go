@@ -0,0 +1,10 @@
+//go:build passgen_lite
+
+package main
+
+import "errors"
+
+// updateSiteRules fails, since lite builds do not talk to remote services.
+func updateSiteRules(source, path string) (int, []string, error) {
+	return 0, nil, errors.New("site update is not available in this build (built with passgen_lite)")
+}
@@ -0,0 +1,60 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestDownloadSiteRules tests updating the site rules database
+func TestDownloadSiteRules(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/password-rules.json":
+			w.Write([]byte(`{
+				"bank.example": {"password-rules": "minlength: 8; maxlength: 20;"},
+				"odd.example": {"password-rules": "required: emoji;"},
+				"other.example": {"other-key": "ignored"}
+			}`))
+		case "/empty.json":
+			w.Write([]byte(`{}`))
+		case "/page.html":
+			w.Write([]byte(`<html></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "passgen", "password-rules.json")
+
+	n, invalid, err := downloadSiteRules(srv.Client(), srv.URL+"/password-rules.json", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || !slices.Equal(invalid, []string{"odd.example"}) {
+		t.Errorf("Got %d sites, invalid %q", n, invalid)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "bank.example") {
+		t.Errorf("Saved database = %q, %v", data, err)
+	}
+
+	for _, name := range []string{"empty.json", "page.html", "missing.json"} {
+		if _, _, err := downloadSiteRules(srv.Client(), srv.URL+"/"+name, path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, _, err := downloadSiteRules(srv.Client(), strings.Replace(srv.URL, "https://", "http://", 1)+"/password-rules.json", path); err == nil {
+		t.Error("Expected an error for a plain HTTP URL")
+	}
+	// Failed updates keep the last good database
+	if data2, _ := os.ReadFile(path); string(data2) != string(data) {
+		t.Error("A failed update replaced the database")
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// standardPolicy is the password policy of a published standard, for
+// generate -policy, or of a policy file, for -policy-file. Its
+// requirements are expressed as a systemPolicy so check verifies them the
+// same way as a policy read from a system file.
+type standardPolicy struct {
+	name    string
+	aliases []string
+	title   string
+	// length is the length generated unless -l or -preset says otherwise,
+	// a margin above the minimum.
+	length  int
+	special bool
+	// blocklistFiles are the blocklists of a policy file, relative to it.
+	blocklistFiles []string
+	policy         systemPolicy
+}
+
+// The standard policies are data rather than code, one file per policy
+// in the manifest's YAML subset. Policy files use the same schema:
+//
+//	title: PCI DSS 4.0 requirement 8.3
+//	aliases: [pci-dss]
+//	length: 16
+//	min-length: 12
+//	min-digits: 1
+//	exclude: "<>"
+//	max-age: 90d
+//	reject-common: true
+//	blocklists: [banned.txt]
+//	notes:
+//	  - letters and digits
+//
+//go:embed policies/*.yaml
+var policyFiles embed.FS
+
+// standardPolicies returns the policies of -policy, named after their
+// files and in file name order.
+var standardPolicies = sync.OnceValue(func() []standardPolicy {
+	entries, err := fs.ReadDir(policyFiles, "policies")
+	if err != nil {
+		panic(err)
+	}
+	var policies []standardPolicy
+	for _, e := range entries {
+		data, err := policyFiles.ReadFile("policies/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		s, err := parseStandardPolicy(strings.TrimSuffix(e.Name(), ".yaml"), string(data))
+		if err == nil && len(s.blocklistFiles) > 0 {
+			err = fmt.Errorf("embedded policies cannot list blocklists")
+		}
+		if err != nil {
+			panic(fmt.Sprintf("policies/%s: %v", e.Name(), err))
+		}
+		policies = append(policies, s)
+	}
+	return policies
+})
+
+// parseStandardPolicy decodes and validates the policy file of name.
+func parseStandardPolicy(name, text string) (standardPolicy, error) {
+	s := standardPolicy{name: name}
+	root, err := parseYAML(text)
+	if err != nil {
+		return s, err
+	}
+	top, ok := root.(yamlMap)
+	if !ok {
+		return s, fmt.Errorf("policy must be a mapping of keys to values")
+	}
+	p := &s.policy
+	for _, entry := range top {
+		var err error
+		switch entry.key {
+		case "title":
+			s.title, err = yamlString(entry)
+		case "aliases":
+			s.aliases, err = yamlStrings(entry)
+		case "length":
+			s.length, err = yamlInt(entry)
+		case "special":
+			s.special, err = yamlBool(entry)
+		case "exclude":
+			p.Exclude, err = yamlString(entry)
+		case "reject-common":
+			p.RejectCommon, err = yamlBool(entry)
+		case "blocklists":
+			s.blocklistFiles, err = yamlStrings(entry)
+		case "min-length":
+			p.MinLength, err = yamlInt(entry)
+		case "max-length":
+			p.MaxLength, err = yamlInt(entry)
+		case "min-upper":
+			p.MinUpper, err = yamlInt(entry)
+		case "min-lower":
+			p.MinLower, err = yamlInt(entry)
+		case "min-digits":
+			p.MinDigits, err = yamlInt(entry)
+		case "min-special":
+			p.MinSpecial, err = yamlInt(entry)
+		case "min-classes":
+			p.MinClasses, err = yamlInt(entry)
+		case "max-run":
+			p.MaxRun, err = yamlInt(entry)
+		case "max-age":
+			var v string
+			if v, err = yamlString(entry); err == nil {
+				p.MaxAge, err = parseAge(v)
+			}
+		case "notes":
+			p.Notes, err = yamlStrings(entry)
+		default:
+			err = fmt.Errorf("unknown key %q", entry.key)
+		}
+		if err != nil {
+			return s, fmt.Errorf("line %d: %v", entry.line, err)
+		}
+	}
+
+	switch {
+	case p.MinLength < 0 || p.MinUpper < 0 || p.MinLower < 0 || p.MinDigits < 0 || p.MinSpecial < 0 || p.MaxRun < 0:
+		return s, fmt.Errorf("minimums cannot be negative")
+	case p.MinClasses > 4:
+		return s, fmt.Errorf("min-classes cannot exceed the 4 character classes")
+	case p.MaxLength > 0 && p.MaxLength < p.MinLength:
+		return s, fmt.Errorf("max-length is below min-length")
+	}
+	if s.length == 0 {
+		s.length = max(p.MinLength, passgen.DefaultLength)
+		if p.MaxLength > 0 {
+			s.length = min(s.length, p.MaxLength)
+		}
+	}
+	if p.MinSpecial > 0 {
+		s.special = true
+	}
+	classes := 3
+	if s.special {
+		classes++
+	}
+	if err := s.check(s.length, classes, p.RejectCommon); err != nil {
+		return s, fmt.Errorf("the policy's own length and special characters fail it: %v", err)
+	}
+	return s, nil
+}
+
+func standardNames() []string {
+	policies := standardPolicies()
+	names := make([]string, len(policies))
+	for i, s := range policies {
+		names[i] = s.name
+	}
+	return names
+}
+
+// loadPolicyFile reads a policy file and the blocklists it lists. Without
+// a title, the policy is known by its path.
+func loadPolicyFile(path string) (standardPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return standardPolicy{}, err
+	}
+	s, err := parseStandardPolicy(path, string(data))
+	if err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	if s.title == "" {
+		s.title = path
+	}
+	if len(s.blocklistFiles) > 0 {
+		s.policy.Blocklist = passgen.NewBlocklist()
+		for _, name := range s.blocklistFiles {
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(filepath.Dir(path), name)
+			}
+			if err := readBlocklist(s.policy.Blocklist, name); err != nil {
+				return s, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// lookupStandard returns the standard policy with the given name or alias.
+func lookupStandard(name string) (standardPolicy, error) {
+	for _, s := range standardPolicies() {
+		if passgen.EqualFoldASCII(s.name, name) {
+			return s, nil
+		}
+		for _, alias := range s.aliases {
+			if passgen.EqualFoldASCII(alias, name) {
+				return s, nil
+			}
+		}
+	}
+	return standardPolicy{}, fmt.Errorf("unknown policy %q (use %s)", name, strings.Join(standardNames(), ", "))
+}
+
+// check reports the first generate setting that falls short of the
+// policy: a length outside its limits, too few character classes or not
+// rejecting common passwords. classes is the number of classes passwords
+// draw from, each of which is guaranteed at least once.
+func (s *standardPolicy) check(length, classes int, rejectCommon bool) error {
+	p := &s.policy
+	if length < p.MinLength {
+		return fmt.Errorf("policy %s requires at least %d characters, not %d", s.name, p.MinLength, length)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("policy %s allows at most %d characters, not %d", s.name, p.MaxLength, length)
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("policy %s requires %d character classes, not %d", s.name, p.MinClasses, classes)
+	}
+	if p.RejectCommon && !rejectCommon {
+		return fmt.Errorf("policy %s requires rejecting common passwords and cannot be combined with -no-blocklist", s.name)
+	}
+	return nil
+}
+
+// writePolicyFile writes the policy in the schema parseStandardPolicy
+// reads, leaving out what is unset.
+func writePolicyFile(w io.Writer, s *standardPolicy) error {
+	p := &s.policy
+	var b strings.Builder
+	str := func(key, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, strconv.Quote(v))
+		}
+	}
+	num := func(key string, n int) {
+		if n != 0 {
+			fmt.Fprintf(&b, "%s: %d\n", key, n)
+		}
+	}
+	list := func(key string, items []string) {
+		if len(items) > 0 {
+			fmt.Fprintf(&b, "%s:\n", key)
+			for _, item := range items {
+				fmt.Fprintf(&b, "  - %s\n", strconv.Quote(item))
+			}
+		}
+	}
+	str("title", s.title)
+	num("length", s.length)
+	if s.special {
+		b.WriteString("special: true\n")
+	}
+	num("min-length", p.MinLength)
+	num("max-length", p.MaxLength)
+	num("min-upper", p.MinUpper)
+	num("min-lower", p.MinLower)
+	num("min-digits", p.MinDigits)
+	num("min-special", p.MinSpecial)
+	num("min-classes", p.MinClasses)
+	num("max-run", p.MaxRun)
+	str("exclude", p.Exclude)
+	if p.MaxAge > 0 {
+		fmt.Fprintf(&b, "max-age: %dd\n", int(p.MaxAge.Hours()/24))
+	}
+	if p.RejectCommon {
+		b.WriteString("reject-common: true\n")
+	}
+	list("blocklists", s.blocklistFiles)
+	list("notes", p.Notes)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
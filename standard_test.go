@@ -0,0 +1,200 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestStandardPolicies tests that passwords generated with the defaults of
+// each standard pass its own policy
+func TestStandardPolicies(t *testing.T) {
+	for _, name := range standardNames() {
+		s, err := lookupStandard(strings.ToUpper(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.title == "" || !s.policy.RejectCommon {
+			t.Errorf("Policy %s needs a title and to reject common passwords", name)
+		}
+		classes := 3
+		if s.special {
+			classes++
+		}
+		if err := s.check(s.length, classes, true); err != nil {
+			t.Errorf("Policy %s rejects its own defaults: %v", name, err)
+		}
+		g, err := passgen.NewGenerator(append([]passgen.GeneratorOption{passgen.WithLength(s.length), passgen.WithSpecial(s.special), passgen.WithExclude(s.policy.Exclude)},
+			minCountOptions(s.policy.MinUpper, s.policy.MinLower, s.policy.MinDigits, s.policy.MinSpecial)...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 20 {
+			password, err := g.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.policy.Exclude != "" && strings.ContainsAny(password, s.policy.Exclude) {
+				t.Errorf("Policy %s: %q holds an excluded character", name, password)
+			}
+			for _, r := range s.policy.results(password) {
+				if !r.OK {
+					t.Errorf("Policy %s: %q fails %s", name, password, r.Text)
+				}
+			}
+		}
+	}
+	for alias, name := range map[string]string{"windows-ad": "ad", "PCI-DSS": "pci"} {
+		if s, err := lookupStandard(alias); err != nil || s.name != name {
+			t.Errorf("lookupStandard(%q) = %q, %v, want %q", alias, s.name, err, name)
+		}
+	}
+	if _, err := lookupStandard("iso27001"); err == nil || !strings.Contains(err.Error(), "ad, aws-iam, nist, pci") {
+		t.Errorf("Expected an error listing the policies, got %v", err)
+	}
+}
+
+// TestStandardPolicyCheck tests the settings that fall short of a policy
+func TestStandardPolicyCheck(t *testing.T) {
+	ad, err := lookupStandard("ad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		length, classes int
+		blocklist       bool
+		want            string
+	}{
+		{16, 4, true, ""},
+		{14, 3, true, ""},
+		{13, 4, true, "at least 14 characters"},
+		{16, 2, true, "3 character classes"},
+		{16, 4, false, "-no-blocklist"},
+	}
+	for _, tt := range tests {
+		err := ad.check(tt.length, tt.classes, tt.blocklist)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("check(%d, %d, %v) = %v, want %q", tt.length, tt.classes, tt.blocklist, err, tt.want)
+		}
+	}
+}
+
+// TestParseStandardPolicy tests decoding and validating policy files
+func TestParseStandardPolicy(t *testing.T) {
+	s, err := parseStandardPolicy("corp", "title: Corp\nmin-length: 20\nmin-special: 2\nmax-run: 2\nmax-age: 30d\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := systemPolicy{MinLength: 20, MinSpecial: 2, MaxRun: 2, MaxAge: 30 * 24 * time.Hour}
+	if !reflect.DeepEqual(s.policy, want) || s.length != 20 || !s.special {
+		t.Errorf("Unexpected policy %+v", s)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"title: X\nmin-length: -1\n", "negative"},
+		{"title: X\nmax-length: 10\nlength: 16\n", "at most 10 characters"},
+		{"title: X\ncolour: red\n", `line 2: unknown key "colour"`},
+		{"title: X\nmin-length: eight\n", "whole number"},
+		{"title: X\nmin-classes: 5\n", "4 character classes"},
+		{"title: X\nmin-length: 16\nmax-length: 12\n", "below min-length"},
+		{"title: X\nlength: 8\nmin-length: 12\n", "at least 12 characters"},
+		{"title: X\nmin-classes: 4\n", "4 character classes, not 3"},
+		{"- title: X\n", "mapping"},
+	}
+	for _, tt := range tests {
+		if _, err := parseStandardPolicy("x", tt.text); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseStandardPolicy(%q) error = %v, want %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+// TestLoadPolicyFile tests that generate and check read the same policy
+// file, with blocklists relative to it
+func TestLoadPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "corp.yaml")
+	text := "min-length: 10\nmax-length: 20\nmin-digits: 2\nexclude: \"<>\"\nreject-common: true\nblocklists: [banned.txt]\n"
+	if err := os.WriteFile(policy, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicyFile(policy); err == nil || !strings.Contains(err.Error(), "banned.txt") {
+		t.Errorf("Expected an error about the missing blocklist, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "banned.txt"), []byte("Acme2024Acme\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadPolicyFile(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.title != policy || s.length != 12 {
+		t.Errorf("Unexpected title %q and length %d", s.title, s.length)
+	}
+
+	f := &checkFlags{policyFile: policy}
+	check, err := f.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		password string
+		fails    string
+	}{
+		{"xK4mP9wQ2zR7", ""},
+		{"xK4mPawQbzRc", "at least 2 digits"},
+		{"xK4mP9wQ2z<7", "none of the characters <>"},
+		{"xK4mP9wQ2zR7xK4mP9wQ2", "at most 20 characters"},
+		{"acme2024acme", "not on the policy's blocklists"},
+		{"password123", "not a common password"},
+	}
+	for _, tt := range tests {
+		results, err := check.results(tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var failed []string
+		for _, r := range results {
+			if !r.OK {
+				failed = append(failed, r.Text)
+			}
+		}
+		if tt.fails == "" && len(failed) > 0 || tt.fails != "" && (len(failed) != 1 || !strings.Contains(failed[0], tt.fails)) {
+			t.Errorf("%q failed %q, want %q", tt.password, failed, tt.fails)
+		}
+	}
+
+	f.policy = "/etc/security/pwquality.conf"
+	if _, err := f.compile(); err == nil {
+		t.Error("Expected an error for -policy with -policy-file")
+	}
+}
+
+// TestWritePolicyFile tests that written policies read back unchanged
+func TestWritePolicyFile(t *testing.T) {
+	for _, name := range standardNames() {
+		s, err := lookupStandard(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.aliases = nil
+		var b strings.Builder
+		if err := writePolicyFile(&b, &s); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseStandardPolicy(name, b.String())
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", name, err, b.String())
+		}
+		if !reflect.DeepEqual(got, s) {
+			t.Errorf("%s read back as %+v, want %+v", name, got, s)
+		}
+	}
+}
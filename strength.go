@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// strengthOutput is the estimated strength of a password in JSON output.
+type strengthOutput struct {
+	Score        int      `json:"score"`
+	GuessesLog10 float64  `json:"guessesLog10"`
+	Patterns     []string `json:"patterns,omitempty"`
+}
+
+func newStrengthOutput(s passgen.Strength) *strengthOutput {
+	return &strengthOutput{Score: s.Score, GuessesLog10: s.GuessesLog10, Patterns: s.Patterns}
+}
+
+// printStrength writes the score, the guesses and the guessable patterns of
+// a password for -strength.
+func printStrength(w io.Writer, s *strengthOutput, indent string) {
+	fmt.Fprintf(w, "%sstrength: %d/%d, about 10^%.1f guesses\n", indent, s.Score, passgen.MaxScore, s.GuessesLog10)
+	if len(s.Patterns) > 0 {
+		fmt.Fprintf(w, "%spatterns: %s\n", indent, strings.Join(s.Patterns, ", "))
+	}
+}
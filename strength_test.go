@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestPrintStrength tests the strength lines under a password
+func TestPrintStrength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"random", "xK9#mQ2$vL7p", "" +
+			"  strength: 4/4, about 10^12.0 guesses\n"},
+		{"common", "password", "" +
+			"  strength: 0/4, about 10^1.7 guesses\n" +
+			"  patterns: common password \"password\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printStrength(&out, newStrengthOutput(passgen.EstimateStrength(tt.password)), "  ")
+			if out.String() != tt.want {
+				t.Errorf("printStrength =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// targetLimit is the range of password lengths a known service accepts.
+type targetLimit struct {
+	name     string
+	min, max int
+	// truncates is set when the service silently cuts longer passwords to
+	// max instead of rejecting them, so only the first max characters count.
+	truncates bool
+}
+
+// targetLimits lists services with password length limits that are easy to
+// trip over. A zero min means the service has no minimum beyond passgen's.
+var targetLimits = []targetLimit{
+	{name: "bcrypt", max: 72, truncates: true},
+	{name: "wpa2", min: 8, max: 63},
+	{name: "ipmi", max: 20},
+	{name: "oracle", max: 30},
+	{name: "rds-mysql", min: 8, max: 41},
+	{name: "rds-oracle", min: 8, max: 30},
+	{name: "mysql-replication", max: 32},
+	// RFC 2865 prefers secrets of at least 16 octets; many devices take
+	// no more than 63 characters
+	{name: "radius", min: 16, max: 63},
+	{name: "tacacs", max: 63},
+}
+
+// lookupTarget returns the limits of the named service.
+func lookupTarget(name string) (targetLimit, error) {
+	names := make([]string, len(targetLimits))
+	for i, t := range targetLimits {
+		if passgen.EqualFoldASCII(t.name, name) {
+			return t, nil
+		}
+		names[i] = t.name
+	}
+	return targetLimit{}, fmt.Errorf("unknown target %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// check describes how a password of the given length would fare with the
+// service, or returns "" if it fits.
+func (t targetLimit) check(length int) string {
+	switch {
+	case length > t.max && t.truncates:
+		return fmt.Sprintf("%s silently truncates passwords to %d characters, so only %d of the %d count", t.name, t.max, t.max, length)
+	case length > t.max:
+		return fmt.Sprintf("%s accepts at most %d characters, but passwords have %d", t.name, t.max, length)
+	case length < t.min:
+		return fmt.Sprintf("%s requires at least %d characters, but passwords have %d", t.name, t.min, length)
+	}
+	return ""
+}
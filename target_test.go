@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestTargetCheck tests the warnings for lengths outside a service's limits
+func TestTargetCheck(t *testing.T) {
+	tests := []struct {
+		target string
+		length int
+		want   string
+	}{
+		{"bcrypt", 72, ""},
+		{"bcrypt", 80, "silently truncates passwords to 72 characters"},
+		{"wpa2", 64, "accepts at most 63 characters"},
+		{"wpa2", 6, "requires at least 8 characters"},
+		{"RDS-MySQL", 41, ""},
+		{"radius", 12, "requires at least 16 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			target, err := lookupTarget(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := target.check(tt.length)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("check(%d) = %q, want %q", tt.length, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := lookupTarget("mainframe"); err == nil || !strings.Contains(err.Error(), "bcrypt, wpa2") {
+		t.Errorf("Expected an error listing the targets, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// pipe or a file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// readHiddenPassword prompts on stderr and reads a password from the
+// terminal on stdin without echoing it.
+func readHiddenPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	restore, err := disableEcho(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("hiding input: %w", err)
+	}
+	// An interrupt must not leave the terminal without echo
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupted:
+			restore()
+			fmt.Fprintln(os.Stderr)
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	password, err := readPassword(os.Stdin)
+	signal.Stop(interrupted)
+	close(done)
+	restore()
+	fmt.Fprintln(os.Stderr)
+	return password, err
+}
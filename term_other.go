@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// disableEcho is not supported on this platform, where passwords must be
+// piped in instead of typed at a prompt.
+func disableEcho(f *os.File) (func(), error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// enableEchoInput is the console mode flag that echoes typed characters.
+const enableEchoInput = 0x4
+
+// disableEcho turns off echo on the console f and returns a function that
+// turns it back on.
+func disableEcho(f *os.File) (func(), error) {
+	h := syscall.Handle(f.Fd())
+	var saved uint32
+	if err := syscall.GetConsoleMode(h, &saved); err != nil {
+		return nil, err
+	}
+	if err := setConsoleMode(h, saved&^enableEchoInput); err != nil {
+		return nil, err
+	}
+	return func() { setConsoleMode(h, saved) }, nil
+}
+
+func setConsoleMode(h syscall.Handle, mode uint32) error {
+	if ok, _, err := procSetConsoleMode.Call(uintptr(h), uintptr(mode)); ok == 0 {
+		return err
+	}
+	return nil
+}
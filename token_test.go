@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+// TestLookupTokenEncoding tests every encoding on the same bytes
+func TestLookupTokenEncoding(t *testing.T) {
+	in := []byte{127, 0, 0, 1}
+	tests := map[string]string{
+		"hex":        "7f000001",
+		"base64url":  "fwAAAQ",
+		"proquint":   "lusab-babad",
+		"Koremutake": "destabababe",
+	}
+	for name, want := range tests {
+		encode, err := lookupTokenEncoding(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := encode(in); err != nil || got != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := lookupTokenEncoding("base58"); err == nil {
+		t.Error("Expected error for an unknown encoding")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// unicodeFilter collects the Unicode categories and scripts passwords are
+// limited to or kept away from.
+type unicodeFilter struct {
+	IncludeCategories []string `json:"includeCategories"`
+	ExcludeCategories []string `json:"excludeCategories"`
+	IncludeScripts    []string `json:"includeScripts"`
+	ExcludeScripts    []string `json:"excludeScripts"`
+}
+
+// options turns the filter into generator options, failing on unknown
+// category or script names.
+func (f unicodeFilter) options() ([]passgen.GeneratorOption, error) {
+	var include, exclude []*unicode.RangeTable
+	for _, group := range []struct {
+		names  []string
+		lookup func(string) (*unicode.RangeTable, error)
+		tables *[]*unicode.RangeTable
+	}{
+		{f.IncludeCategories, passgen.LookupCategory, &include},
+		{f.ExcludeCategories, passgen.LookupCategory, &exclude},
+		{f.IncludeScripts, passgen.LookupScript, &include},
+		{f.ExcludeScripts, passgen.LookupScript, &exclude},
+	} {
+		for _, name := range group.names {
+			table, err := group.lookup(name)
+			if err != nil {
+				return nil, err
+			}
+			*group.tables = append(*group.tables, table)
+		}
+	}
+
+	var opts []passgen.GeneratorOption
+	if len(include) > 0 {
+		opts = append(opts, passgen.WithIncludeTables(include...))
+	}
+	if len(exclude) > 0 {
+		opts = append(opts, passgen.WithExcludeTables(exclude...))
+	}
+	return opts, nil
+}
+
+// String describes the filter for the text output, e.g.
+// "only category Nd and script Latin; not category Punctuation".
+func (f unicodeFilter) String() string {
+	describe := func(categories, scripts []string) string {
+		var parts []string
+		if len(categories) > 0 {
+			parts = append(parts, "category "+strings.Join(categories, ", "))
+		}
+		if len(scripts) > 0 {
+			parts = append(parts, "script "+strings.Join(scripts, ", "))
+		}
+		return strings.Join(parts, " and ")
+	}
+	var parts []string
+	if s := describe(f.IncludeCategories, f.IncludeScripts); s != "" {
+		parts = append(parts, "only "+s)
+	}
+	if s := describe(f.ExcludeCategories, f.ExcludeScripts); s != "" {
+		parts = append(parts, "not "+s)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+// TestUnicodeFilter tests turning category and script names into generator options
+func TestUnicodeFilter(t *testing.T) {
+	f := unicodeFilter{
+		IncludeScripts:    []string{"Latin"},
+		IncludeCategories: []string{"Nd"},
+		ExcludeCategories: []string{"Uppercase"},
+	}
+	opts, err := f.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := passgen.NewGenerator(append(opts, passgen.WithLength(32), passgen.WithSpecial(true))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := gen.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range password {
+		if unicode.IsUpper(r) || !unicode.IsLower(r) && !unicode.IsDigit(r) {
+			t.Errorf("Password %q contains filtered %q", password, r)
+		}
+	}
+	if got, want := f.String(), "only category Nd and script Latin; not category Uppercase"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+
+	for _, bad := range []unicodeFilter{
+		{IncludeCategories: []string{"Latin"}},
+		{ExcludeScripts: []string{"Klingon"}},
+	} {
+		if _, err := bad.options(); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+}
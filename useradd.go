@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printUserAddUsage(programName string) {
+	fmt.Printf("Usage: %s useradd [OPTIONS] USER\n", programName)
+	fmt.Println("Create a local user with a generated temporary password that must be")
+	fmt.Println("changed at the first login. The password is printed for handing over and")
+	fmt.Println("can also be stored in sinks; if storing it fails, no account is created.")
+	fmt.Println("Options:")
+	fmt.Println("  -l LENGTH    Password length (default: 16)")
+	fmt.Println("  -s           Include special characters")
+	fmt.Println("  -comment TEXT")
+	fmt.Println("               Full name or other comment for the account")
+	fmt.Println("  -groups LIST Comma-separated supplementary groups, e.g. wheel,docker")
+	fmt.Println("  -shell PATH  Login shell (default: the system default)")
+	fmt.Println("  -no-print    Do not print the password, only store it in the sinks")
+	fmt.Println("  -out FILE    Also append the password to a CSV file; FILE may contain")
+	fmt.Println("               {{date}}, {{time}}, {{timestamp}} and {{label}}")
+	fmt.Println("  -vault-path PATH")
+	fmt.Println("               Also store the password at a Vault KV path with the vault command")
+	fmt.Println("  -plugin NAME Also store the password with the passgen-NAME sink plugin (repeatable)")
+	fmt.Println("  -label TEXT  Label stored with the password (default: HOST/USER)")
+	fmt.Println("  -audit-log FILE")
+	fmt.Println("               Append the account creation, with a fingerprint of the password, to FILE")
+	fmt.Println("  -dry-run     Describe the account without creating it")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample, run as root:")
+	fmt.Printf("  %s useradd -comment 'Alice Smith' -groups developers alice\n", programName)
+}
+
+// userNamePattern accepts the portable user names of POSIX and shadow-utils:
+// a lowercase letter or underscore, then lowercase letters, digits, dashes
+// and underscores.
+var userNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// newUser describes a local account to create.
+type newUser struct {
+	Name    string
+	Comment string
+	Groups  []string
+	Shell   string
+}
+
+// newUserSink creates a local account with the password it is given, which
+// expires at once so the user has to choose their own. Like the local
+// account sink of local-admin it goes after every sink that keeps a copy.
+type newUserSink struct {
+	user newUser
+}
+
+func (s *newUserSink) write(creds []credential) error {
+	if len(creds) != 1 {
+		return fmt.Errorf("new user %s takes exactly one password", s.user.Name)
+	}
+	return createLocalUser(s.user, creds[0].Password)
+}
+
+func (s *newUserSink) String() string {
+	return "new user " + s.user.Name
+}
+
+// runUserAdd implements the useradd subcommand.
+func runUserAdd(programName string, args []string) error {
+	fs := flag.NewFlagSet("useradd", flag.ContinueOnError)
+	length := fs.Int("l", 16, "Password length")
+	includeSpecial := fs.Bool("s", false, "Include special characters")
+	comment := fs.String("comment", "", "Full name or other comment for the account")
+	groups := fs.String("groups", "", "Comma-separated supplementary groups")
+	shell := fs.String("shell", "", "Login shell")
+	noPrint := fs.Bool("no-print", false, "Do not print the password")
+	outFile := fs.String("out", "", "Also append the password to a CSV file")
+	vaultPath := fs.String("vault-path", "", "Also store the password at this Vault KV path")
+	var pluginNames stringList
+	fs.Var(&pluginNames, "plugin", "Also store the password with the passgen-NAME sink plugin (repeatable)")
+	label := fs.String("label", "", "Label stored with the password")
+	auditLog := fs.String("audit-log", "", "Append the account creation to FILE")
+	dryRun := fs.Bool("dry-run", false, "Describe the account without creating it")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printUserAddUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printUserAddUsage(programName)
+		return nil
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("give exactly one user name, e.g. %s useradd alice", programName)
+	}
+	user := newUser{Name: fs.Arg(0), Comment: *comment, Shell: *shell}
+	if !userNamePattern.MatchString(user.Name) {
+		return fmt.Errorf("invalid user name %q: use up to 32 lowercase letters, digits, - and _, starting with a letter or _", user.Name)
+	}
+	if strings.ContainsAny(user.Comment, ":\n") || strings.ContainsAny(user.Shell, ":\n") {
+		return fmt.Errorf("-comment and -shell cannot contain : or a newline")
+	}
+	if *groups != "" {
+		user.Groups = strings.Split(*groups, ",")
+		for _, group := range user.Groups {
+			if !userNamePattern.MatchString(group) {
+				return fmt.Errorf("invalid group name %q", group)
+			}
+		}
+	}
+	if *length < minLength || *length > maxLength {
+		return fmt.Errorf("password length must be between %d and %d", minLength, maxLength)
+	}
+	if *includeSpecial && *length < 4 {
+		return fmt.Errorf("password length must be at least 4 when using special characters")
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	if *label == "" {
+		*label = host + "/" + user.Name
+	}
+
+	gen, err := passgen.NewGenerator(passgen.WithLength(*length), passgen.WithSpecial(*includeSpecial))
+	if err != nil {
+		return err
+	}
+	sinks, err := enablePlugins(pluginNames, gen.Pipeline())
+	if err != nil {
+		return err
+	}
+	sinks = withRetry(sinks, defaultRetryPolicy)
+	if *outFile != "" {
+		out, err := newCSVSink(*outFile)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, out)
+	}
+	if *vaultPath != "" {
+		vault, err := newVaultSink(*vaultPath)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, vault)
+	}
+	// A password nobody sees would lock the user out from the start
+	if *noPrint && len(sinks) == 0 {
+		return fmt.Errorf("-no-print needs -out, -vault-path or a sink -plugin to store the password")
+	}
+	stored := deliveredTo(sinks, false)
+	sinks = append(sinks, &newUserSink{user: user})
+	var auditKey []byte
+	if *auditLog != "" && !*dryRun {
+		if auditKey, err = loadAuditKey(auditKeyPath(*auditLog), true); err != nil {
+			return err
+		}
+	}
+
+	password, err := gen.Generate()
+	if err != nil {
+		return fmt.Errorf("generating password: %w", err)
+	}
+	fingerprint := passgen.Fingerprint(password)
+	if *dryRun {
+		fmt.Printf("Would create %s on %s with a temporary password", user.Name, host)
+		if len(stored) > 0 {
+			fmt.Printf(" stored in %s", strings.Join(stored, ", "))
+		}
+		fmt.Println()
+		return nil
+	}
+	cred := []credential{{Label: *label, Password: password, Note: "temporary password, expires at first login"}}
+	if err := writeSinks(sinks, cred, false); err != nil {
+		return err
+	}
+	if *auditLog != "" {
+		rec := auditRecord{
+			Command:      "useradd",
+			Mode:         "random",
+			Label:        *label,
+			Count:        1,
+			Length:       *length,
+			Fingerprints: []string{auditFingerprint(auditKey, password)},
+			DeliveredTo:  deliveredTo(sinks, !*noPrint),
+		}
+		if err := appendAudit(*auditLog, rec, time.Now()); err != nil {
+			return fmt.Errorf("writing audit log: %w", err)
+		}
+	}
+	fmt.Printf("Created %s on %s\n", user.Name, host)
+	if !*noPrint {
+		fmt.Printf("Temporary password: %s\n", password)
+	}
+	if len(stored) > 0 {
+		fmt.Printf("Stored in: %s\n", strings.Join(stored, ", "))
+	}
+	fmt.Printf("Fingerprint: %s\n", fingerprint)
+	fmt.Println("The password must be changed at the first login.")
+	return nil
+}
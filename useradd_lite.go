@@ -0,0 +1,11 @@
+//go:build passgen_lite || windows
+
+package main
+
+import "errors"
+
+// createLocalUser fails, since lite builds leave out the integrations that
+// change the system and Windows has no shadow-utils.
+func createLocalUser(u newUser, password string) error {
+	return errors.New("creating users is only available on Unix, in builds without passgen_lite")
+}
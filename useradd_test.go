@@ -0,0 +1,92 @@
+//go:build !passgen_lite && !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestUserNamePattern tests which user names useradd accepts
+func TestUserNamePattern(t *testing.T) {
+	for _, name := range []string{"alice", "_svc", "build-01", "a_b"} {
+		if !userNamePattern.MatchString(name) {
+			t.Errorf("Expected %q to be accepted", name)
+		}
+	}
+	for _, name := range []string{"", "Alice", "-rf", "1st", "al:ice", "a b", strings.Repeat("a", 33)} {
+		if userNamePattern.MatchString(name) {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+// fakeTools replaces the account commands with shell scripts that log
+// their arguments and stdin to a file, failing for the named command.
+func fakeTools(t *testing.T, failing string) string {
+	log := filepath.Join(t.TempDir(), "tools.log")
+	tool := func(name string) []string {
+		script := `echo "` + name + ` $*" >> ` + log + `; cat >> ` + log
+		if name == failing {
+			script += "; echo 'failed' >&2; exit 1"
+		}
+		return []string{"sh", "-c", script, name}
+	}
+	oldUseradd, oldChpasswd, oldChage, oldUserdel := useraddCommand, chpasswdCommand, chageCommand, userdelCommand
+	t.Cleanup(func() {
+		useraddCommand, chpasswdCommand, chageCommand, userdelCommand = oldUseradd, oldChpasswd, oldChage, oldUserdel
+	})
+	useraddCommand, chpasswdCommand, chageCommand, userdelCommand = tool("useradd"), tool("chpasswd"), tool("chage"), tool("userdel")
+	return log
+}
+
+// TestCreateLocalUser tests the commands run to create a user
+func TestCreateLocalUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing string
+		want    string
+		wantErr bool
+	}{
+		{"created", "", "useradd -m -c Alice Smith -G wheel,docker -s /bin/zsh alice\nchpasswd \nalice:s3cret\nchage -d 0 alice\n", false},
+		{"useradd fails", "useradd", "useradd -m -c Alice Smith -G wheel,docker -s /bin/zsh alice\n", true},
+		{"chage fails", "chage", "useradd -m -c Alice Smith -G wheel,docker -s /bin/zsh alice\nchpasswd \nalice:s3cret\nchage -d 0 alice\nuserdel -r alice\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := fakeTools(t, tt.failing)
+			u := newUser{Name: "alice", Comment: "Alice Smith", Groups: []string{"wheel", "docker"}, Shell: "/bin/zsh"}
+			err := createLocalUser(u, "s3cret")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("createLocalUser error = %v, wantErr %v", err, tt.wantErr)
+			}
+			data, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Commands run:\n%s\nwant:\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewUserSinkLast tests that a failed account creation rolls back the
+// stored copy of the temporary password
+func TestNewUserSinkLast(t *testing.T) {
+	fakeTools(t, "useradd")
+	path := filepath.Join(t.TempDir(), "users.csv")
+	out, err := newCSVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sinks := []sink{out, &newUserSink{user: newUser{Name: "alice"}}}
+	if err := writeSinks(sinks, []credential{{Label: "host/alice", Password: "secret"}}, false); err == nil {
+		t.Fatal("Expected the account creation to fail")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("CSV file should have been rolled back, stat error = %v", err)
+	}
+}
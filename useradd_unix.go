@@ -0,0 +1,50 @@
+//go:build !passgen_lite && !windows
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	features = append(features, "useradd")
+}
+
+// The shadow-utils commands useradd runs, replaceable in tests.
+var (
+	useraddCommand = []string{"useradd"}
+	chageCommand   = []string{"chage"}
+	userdelCommand = []string{"userdel"}
+)
+
+// createLocalUser creates an account with a home directory, sets its
+// password with chpasswd and expires it so it must be changed at the first
+// login. If the password cannot be set or expired, the account is removed
+// again rather than left half configured.
+func createLocalUser(u newUser, password string) error {
+	args := []string{"-m"}
+	if u.Comment != "" {
+		args = append(args, "-c", u.Comment)
+	}
+	if len(u.Groups) > 0 {
+		args = append(args, "-G", strings.Join(u.Groups, ","))
+	}
+	if u.Shell != "" {
+		args = append(args, "-s", u.Shell)
+	}
+	if err := runTool(useraddCommand, "", append(args, u.Name)...); err != nil {
+		return err
+	}
+	err := setLocalPassword(u.Name, password)
+	if err == nil {
+		err = runTool(chageCommand, "", "-d", "0", u.Name)
+	}
+	if err != nil {
+		if delErr := runTool(userdelCommand, "", "-r", u.Name); delErr != nil {
+			return fmt.Errorf("%w; removing the new user also failed: %v", err, delErr)
+		}
+		return err
+	}
+	return nil
+}
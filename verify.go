@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errReadBackMismatch reports that a sink stored something other than the
+// credentials it was given.
+var errReadBackMismatch = errors.New("read-back mismatch")
+
+// readableSink is a sink that can return the credentials of its most recent
+// write as it actually stored them. Wrappers and plugins implement it for
+// every sink and report through canReadBack whether reading back works.
+type readableSink interface {
+	sink
+	canReadBack() bool
+	readBack(labels []string) ([]credential, error)
+}
+
+// verifyingSink reads every batch back from the sink it wraps and compares it
+// with what was sent, catching truncation or encoding corruption before the
+// old credential is revoked.
+type verifyingSink struct {
+	sink readableSink
+}
+
+// withVerify wraps the sinks that support reading back. The names of those
+// that do not are returned so the caller can warn about them.
+func withVerify(sinks []sink) (wrapped []sink, unsupported []string) {
+	wrapped = make([]sink, len(sinks))
+	for i, s := range sinks {
+		r, ok := s.(readableSink)
+		if !ok || !r.canReadBack() {
+			wrapped[i] = s
+			unsupported = append(unsupported, sinkName(s))
+			continue
+		}
+		wrapped[i] = &verifyingSink{sink: r}
+	}
+	return wrapped, unsupported
+}
+
+// write delivers the batch and verifies it. A batch that does not read back
+// intact is rolled back from this sink, if possible, so a corrupt copy is not
+// left behind.
+func (v *verifyingSink) write(creds []credential) error {
+	if err := v.sink.write(creds); err != nil {
+		return err
+	}
+	labels := make([]string, len(creds))
+	for i, c := range creds {
+		labels[i] = c.Label
+	}
+	stored, err := v.sink.readBack(labels)
+	if err == nil {
+		err = compareReadBack(creds, stored)
+	}
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("verifying %s: %w", sinkName(v.sink), err)
+	if r, ok := v.sink.(reversibleSink); ok {
+		return errors.Join(err, r.undo())
+	}
+	return err
+}
+
+// undo rolls back the wrapped sink, if it supports that.
+func (v *verifyingSink) undo() error {
+	if r, ok := v.sink.(reversibleSink); ok {
+		return r.undo()
+	}
+	return fmt.Errorf("%s cannot be rolled back and keeps the credentials", sinkName(v.sink))
+}
+
+func (v *verifyingSink) canUndo() bool {
+	return reversible(v.sink)
+}
+
+func (v *verifyingSink) String() string {
+	return sinkName(v.sink)
+}
+
+// compareReadBack checks stored against sent, credential by credential. The
+// error names the credential but never includes either password.
+func compareReadBack(sent, stored []credential) error {
+	if len(stored) != len(sent) {
+		return fmt.Errorf("%w: %d credentials stored, %d sent", errReadBackMismatch, len(stored), len(sent))
+	}
+	for i, want := range sent {
+		got := stored[i]
+		name := fmt.Sprintf("credential %d", i+1)
+		if want.Label != "" {
+			name = fmt.Sprintf("credential %q", want.Label)
+		}
+		switch {
+		case got.Label != want.Label:
+			return fmt.Errorf("%w: %s stored with label %q", errReadBackMismatch, name, got.Label)
+		case got.Password == want.Password:
+		case len(got.Password) < len(want.Password) && want.Password[:len(got.Password)] == got.Password:
+			return fmt.Errorf("%w: %s was truncated from %d to %d bytes", errReadBackMismatch, name, len(want.Password), len(got.Password))
+		default:
+			return fmt.Errorf("%w: %s does not match what was sent", errReadBackMismatch, name)
+		}
+	}
+	return nil
+}
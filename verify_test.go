@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corruptingSink stores passwords cut short, like a destination with a
+// field length limit
+type corruptingSink struct {
+	undoSink
+	limit int
+}
+
+func (s *corruptingSink) write(creds []credential) error {
+	stored := make([]credential, len(creds))
+	for i, c := range creds {
+		c.Password = c.Password[:min(len(c.Password), s.limit)]
+		stored[i] = c
+	}
+	return s.undoSink.write(stored)
+}
+
+func (s *corruptingSink) canReadBack() bool {
+	return true
+}
+
+func (s *corruptingSink) readBack(labels []string) ([]credential, error) {
+	return s.creds[s.last:], nil
+}
+
+// TestCompareReadBack tests that mismatches are described without passwords
+func TestCompareReadBack(t *testing.T) {
+	sent := []credential{{Label: "db", Password: "correct-horse"}, {Password: "battery"}}
+	tests := []struct {
+		name   string
+		stored []credential
+		want   string
+	}{
+		{"identical", sent, ""},
+		{"missing", sent[:1], "1 credentials stored, 2 sent"},
+		{"truncated", []credential{{Label: "db", Password: "correct"}, sent[1]}, `credential "db" was truncated from 13 to 7 bytes`},
+		{"corrupted", []credential{sent[0], {Password: "batt�y"}}, "credential 2 does not match"},
+		{"relabeled", []credential{{Label: "web", Password: "correct-horse"}, sent[1]}, `stored with label "web"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compareReadBack(sent, tt.stored)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, errReadBackMismatch) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected mismatch containing %q, got %v", tt.want, err)
+			}
+			if strings.Contains(err.Error(), "horse") || strings.Contains(err.Error(), "battery") {
+				t.Errorf("Error leaks a password: %v", err)
+			}
+		})
+	}
+}
+
+// TestVerifyingSink tests that a corrupted batch fails and is rolled back
+func TestVerifyingSink(t *testing.T) {
+	bad := &corruptingSink{limit: 8}
+	sinks, unsupported := withVerify([]sink{bad, &memorySink{}})
+	if len(unsupported) != 1 {
+		t.Errorf("Expected the memory sink to be reported as unverifiable, got %v", unsupported)
+	}
+
+	err := sinks[0].write([]credential{{Label: "db", Password: "a-long-password"}})
+	if !errors.Is(err, errReadBackMismatch) {
+		t.Fatalf("Expected a read-back mismatch, got %v", err)
+	}
+	if len(bad.creds) != 0 {
+		t.Errorf("Corrupted batch should be rolled back, got %v", bad.creds)
+	}
+
+	if err := sinks[0].write([]credential{{Label: "db", Password: "short"}}); err != nil {
+		t.Errorf("Intact batch should verify, got %v", err)
+	}
+}
+
+// TestCSVSinkReadBack tests reading back a batch spread over several files
+func TestCSVSinkReadBack(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newCSVSink(filepath.Join(dir, "creds-{{label}}.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.write([]credential{{Label: "web", Password: "old"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	creds := []credential{{Label: "web", Password: "a,b"}, {Label: "db", Password: "two", Note: "x"}, {Label: "web", Password: "\"three\""}}
+	sinks, _ := withVerify([]sink{s})
+	if err := sinks[0].write(creds); err != nil {
+		t.Fatalf("Verified write failed: %v", err)
+	}
+	got, err := s.readBack([]string{"web", "db", "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compareReadBack(creds, got); err != nil {
+		t.Errorf("Read-back differs: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func printVerifyManifestUsage(programName string) {
+	fmt.Printf("Usage: %s verify-manifest FILE -key KEY [OPTIONS]\n", programName)
+	fmt.Println("Check the signatures of JSON output written with -sign-key and report which")
+	fmt.Println("password records are intact. Exits non-zero if any signature fails.")
+	fmt.Println("Options:")
+	fmt.Println("  -key FILE    Ed25519 public key in PEM form, or the HMAC secret the output")
+	fmt.Println("               was signed with")
+	fmt.Println("  -o FORMAT    Output format: text or json (default: text)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s verify-manifest batch.json -key sign.pub.pem\n", programName)
+}
+
+// recordVerification is the outcome of verifying one password record.
+type recordVerification struct {
+	Index int    `json:"index"`
+	Label string `json:"label,omitempty"`
+	Valid bool   `json:"valid"`
+	// Problem explains a failure.
+	Problem string `json:"problem,omitempty"`
+}
+
+// manifestVerification is the outcome of verifying signed output, and the
+// JSON document verify-manifest prints.
+type manifestVerification struct {
+	Schema    string               `json:"schema"`
+	Algorithm string               `json:"algorithm"`
+	KeyID     string               `json:"keyId,omitempty"`
+	Records   []recordVerification `json:"records"`
+	// Document tells whether the whole document is as signed, which also
+	// rules out removed and reordered records.
+	Document bool `json:"document"`
+	Valid    bool `json:"valid"`
+}
+
+// failed counts the records and document signatures that do not verify.
+func (v *manifestVerification) failed() int {
+	n := 0
+	for _, r := range v.Records {
+		if !r.Valid {
+			n++
+		}
+	}
+	if !v.Document {
+		n++
+	}
+	return n
+}
+
+// verifyManifest checks the signatures of a signed JSON document against
+// key. Documents that are not signed, or signed with another algorithm or
+// key, are errors; signatures that do not match are reported per record.
+func verifyManifest(data []byte, key *signingKey) (*manifestVerification, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not a JSON document: %v", err)
+	}
+	rawSig, ok := doc["signature"]
+	if !ok {
+		return nil, fmt.Errorf("document is not signed")
+	}
+	var sig signatureOutput
+	if err := json.Unmarshal(rawSig, &sig); err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(doc["passwords"], &records); err != nil {
+		return nil, fmt.Errorf("invalid passwords: %v", err)
+	}
+	if sig.Algorithm != key.algorithm {
+		return nil, fmt.Errorf("document is signed with %s, but the key is for %s", sig.Algorithm, key.algorithm)
+	}
+	if sig.KeyID != "" && sig.KeyID != key.keyID() {
+		return nil, fmt.Errorf("document is signed with key %s, not %s", sig.KeyID, key.keyID())
+	}
+
+	v := &manifestVerification{Schema: outputSchema, Algorithm: sig.Algorithm, KeyID: sig.KeyID}
+	for i, record := range records {
+		r := recordVerification{Index: i + 1}
+		var fields struct {
+			Label string `json:"label"`
+		}
+		json.Unmarshal(record, &fields)
+		r.Label = fields.Label
+		if i >= len(sig.Records) {
+			r.Problem = "not signed"
+		} else if ok, err := key.verifyJSON(record, sig.Records[i]); err != nil {
+			r.Problem = err.Error()
+		} else if !ok {
+			r.Problem = "changed since it was signed"
+		}
+		r.Valid = r.Problem == ""
+		v.Records = append(v.Records, r)
+	}
+	// Signatures left over belong to records that were removed
+	for i := len(records); i < len(sig.Records); i++ {
+		v.Records = append(v.Records, recordVerification{Index: i + 1, Problem: "missing"})
+	}
+
+	delete(doc, "signature")
+	unsigned, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if v.Document, err = key.verifyJSON(unsigned, sig.Document); err != nil {
+		return nil, err
+	}
+	v.Valid = v.failed() == 0
+	return v, nil
+}
+
+// printManifestVerification writes a line per record and one for the
+// document as a whole.
+func printManifestVerification(w io.Writer, path string, v *manifestVerification) {
+	fmt.Fprintf(w, "Manifest: %s\n", path)
+	if v.KeyID != "" {
+		fmt.Fprintf(w, "Signed with: %s key %s\n\n", v.Algorithm, v.KeyID)
+	} else {
+		fmt.Fprintf(w, "Signed with: %s\n\n", v.Algorithm)
+	}
+	for _, r := range v.Records {
+		status := "PASS"
+		if !r.Valid {
+			status = "FAIL"
+		}
+		name := fmt.Sprintf("record %d", r.Index)
+		if r.Label != "" {
+			name += " (" + r.Label + ")"
+		}
+		if r.Problem != "" {
+			name += ": " + r.Problem
+		}
+		fmt.Fprintf(w, "%s  %s\n", status, name)
+	}
+	if v.Document {
+		fmt.Fprintln(w, "PASS  document")
+	} else {
+		fmt.Fprintln(w, "FAIL  document: records removed, reordered or other fields changed")
+	}
+}
+
+// runVerifyManifest implements the verify-manifest subcommand.
+func runVerifyManifest(programName string, args []string) error {
+	fs := flag.NewFlagSet("verify-manifest", flag.ContinueOnError)
+	keyFile := fs.String("key", "", "Public key or HMAC secret to verify with")
+	format := fs.String("o", "text", "Output format: text or json")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printVerifyManifestUsage(programName) }
+
+	// The file may come before the options, as in the usage line
+	var path string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printVerifyManifestUsage(programName)
+		return nil
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	} else if path == "" || fs.NArg() > 0 {
+		return fmt.Errorf("give exactly one file, e.g. %s verify-manifest batch.json -key sign.pub.pem", programName)
+	}
+	if *keyFile == "" {
+		return fmt.Errorf("-key is required")
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+
+	key, err := loadSigningKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	v, err := verifyManifest(data, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if *format == "json" {
+		if err := writeJSON(os.Stdout, v); err != nil {
+			return err
+		}
+	} else {
+		printManifestVerification(os.Stdout, path, v)
+	}
+	if failed := v.failed(); failed > 0 {
+		return fmt.Errorf("%s failed %d of %d checks", path, failed, len(v.Records)+1)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// signedBatch returns signed generate output with two records, and the key
+// to verify it.
+func signedBatch(t *testing.T) ([]byte, *signingKey) {
+	t.Helper()
+	private, public := writeEd25519Keys(t)
+	signer, err := loadSigningKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := loadSigningKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := generateOutput{Schema: outputSchema, Length: 12, Passwords: []passwordOutput{
+		{Label: "db", Password: "q7XvR2mKpT9w", Entropy: 70.5},
+		{Label: "web", Password: "Hn3bVk8RtW2p", Entropy: 70.5},
+	}}
+	if err := signOutput(signer, &out); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, out); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), verifier
+}
+
+// TestVerifyManifest tests per-record results for intact and tampered batches
+func TestVerifyManifest(t *testing.T) {
+	data, key := signedBatch(t)
+	edit := func(fn func(doc map[string]any)) []byte {
+		var doc map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatal(err)
+		}
+		fn(doc)
+		out, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	record := func(doc map[string]any, i int) map[string]any {
+		return doc["passwords"].([]any)[i].(map[string]any)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		records  string
+		document bool
+	}{
+		{"intact", data, "ok ok", true},
+		{"changed password", edit(func(doc map[string]any) { record(doc, 1)["password"] = "Hn3bVk8RtW2q" }), "ok changed since it was signed", false},
+		{"changed header", edit(func(doc map[string]any) { doc["length"] = 13 }), "ok ok", false},
+		{"swapped", edit(func(doc map[string]any) {
+			p := doc["passwords"].([]any)
+			p[0], p[1] = p[1], p[0]
+		}), "changed since it was signed changed since it was signed", false},
+		{"removed", edit(func(doc map[string]any) { doc["passwords"] = doc["passwords"].([]any)[:1] }), "ok missing", false},
+		{"added", edit(func(doc map[string]any) { doc["passwords"] = append(doc["passwords"].([]any), record(doc, 0)) }), "ok ok not signed", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := verifyManifest(tt.data, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var records []string
+			for _, r := range v.Records {
+				if r.Valid {
+					records = append(records, "ok")
+				} else {
+					records = append(records, r.Problem)
+				}
+			}
+			if got := strings.Join(records, " "); got != tt.records || v.Document != tt.document {
+				t.Errorf("records %q, document %v; want %q, %v", got, v.Document, tt.records, tt.document)
+			}
+			if v.Valid != (tt.name == "intact") {
+				t.Errorf("Valid = %v with %d failures", v.Valid, v.failed())
+			}
+		})
+	}
+}
+
+// TestVerifyManifestErrors tests documents that cannot be verified at all
+func TestVerifyManifestErrors(t *testing.T) {
+	data, key := signedBatch(t)
+	_, other := signedBatch(t)
+	hmacKey := &signingKey{algorithm: signHMAC, secret: bytes.Repeat([]byte("k"), minHMACKey)}
+
+	tests := []struct {
+		name string
+		data []byte
+		key  *signingKey
+		want string
+	}{
+		{"unsigned", []byte(`{"schema":"passgen/v1","passwords":[]}`), key, "not signed"},
+		{"not json", []byte("db,secret\n"), key, "not a JSON document"},
+		{"other key", data, other, "signed with key"},
+		{"other algorithm", data, hmacKey, "signed with ed25519"},
+	}
+	for _, tt := range tests {
+		if _, err := verifyManifest(tt.data, tt.key); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
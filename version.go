@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+)
+
+func printVersionUsage(programName string) {
+	fmt.Printf("Usage: %s version\n", programName)
+	fmt.Println("Print the version of this build.")
+}
+
+// runVersion implements the version subcommand.
+func runVersion(programName string, args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printVersionUsage(programName) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printVersionUsage(programName)
+		return nil
+	}
+	fmt.Printf("passgen %s (%s %s/%s)\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func printWizardUsage(programName string) {
+	fmt.Printf("Usage: %s wizard [OPTIONS]\n", programName)
+	fmt.Println("Guided session for creating credentials by hand: prompt for a label,")
+	fmt.Println("generate a password, confirm it and store it, then repeat. Enter an")
+	fmt.Println("empty label to finish.")
+	fmt.Println("Options:")
+	fmt.Println("  -l LENGTH    Password length (default: 12)")
+	fmt.Println("  -s           Include special characters")
+	fmt.Println("  -allow-similar")
+	fmt.Println("               Include similar-looking characters (0, O, I, l, 1)")
+	fmt.Println("  -ambiguity-level LEVEL")
+	fmt.Println("               Look-alikes to exclude: none, standard or extended (default: standard)")
+	fmt.Println("  -rule EXPR   Only accept passwords matching the expression (repeatable)")
+	fmt.Println("  -out FILE    Append confirmed credentials to a CSV file; FILE may contain")
+	fmt.Println("               {{date}}, {{time}}, {{timestamp}} and {{label}}")
+	fmt.Println("  -note TEXT   Comment stored with every credential of the session")
+	fmt.Println("  -plugin NAME Enable the passgen-NAME plugin, e.g. as a sink (repeatable)")
+	fmt.Println("  -h           Show this help message")
+	fmt.Println("\nExample:")
+	fmt.Printf("  %s wizard -l 16 -s -out accounts.csv\n", programName)
+}
+
+// runWizard implements the wizard subcommand.
+func runWizard(programName string, args []string) error {
+	fs := flag.NewFlagSet("wizard", flag.ContinueOnError)
+	length := fs.Int("l", 12, "Password length")
+	includeSpecial := fs.Bool("s", false, "Include special characters")
+	allowSimilar := fs.Bool("allow-similar", false, "Include similar-looking characters (0, O, I, l, 1)")
+	ambiguityLevel := fs.String("ambiguity-level", "standard", "Look-alike characters to exclude: none, standard or extended")
+	var rules stringList
+	fs.Var(&rules, "rule", "Only accept passwords matching the expression (repeatable)")
+	outFile := fs.String("out", "", "Append confirmed credentials to a CSV file")
+	note := fs.String("note", "", "Comment stored with every credential of the session")
+	var pluginNames stringList
+	fs.Var(&pluginNames, "plugin", "Enable the passgen-NAME plugin (repeatable)")
+	help := fs.Bool("h", false, "Show help message")
+	fs.Usage = func() { printWizardUsage(programName) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *help {
+		printWizardUsage(programName)
+		return nil
+	}
+	if *length < minLength || *length > maxLength {
+		return fmt.Errorf("password length must be between %d and %d", minLength, maxLength)
+	}
+	if *includeSpecial && *length < 4 {
+		return fmt.Errorf("password length must be at least 4 when using special characters")
+	}
+	ambiguity, err := parseAmbiguity(*ambiguityLevel, *allowSimilar)
+	if err != nil {
+		return err
+	}
+
+	genOpts := []passgen.GeneratorOption{
+		passgen.WithLength(*length),
+		passgen.WithSpecial(*includeSpecial),
+		passgen.WithSimilar(*allowSimilar),
+		passgen.WithAmbiguity(ambiguity),
+	}
+	for _, expr := range rules {
+		rule, err := passgen.CompileRule(expr)
+		if err != nil {
+			return err
+		}
+		genOpts = append(genOpts, passgen.WithRules(rule))
+	}
+	gen, err := passgen.NewGenerator(genOpts...)
+	if err != nil {
+		return err
+	}
+	sinks, err := enablePlugins(pluginNames, gen.Pipeline())
+	if err != nil {
+		return err
+	}
+	sinks = withRetry(sinks, defaultRetryPolicy)
+	if *outFile != "" {
+		out, err := newCSVSink(*outFile)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, out)
+	}
+	if len(sinks) == 0 {
+		return fmt.Errorf("choose where to store credentials with -out or a sink -plugin")
+	}
+
+	wz := &wizard{
+		in:       bufio.NewScanner(os.Stdin),
+		out:      os.Stdout,
+		generate: gen.Generate,
+		sinks:    sinks,
+		note:     *note,
+	}
+	stored, err := wz.run()
+	fmt.Fprintf(os.Stderr, "Stored %d credentials\n", stored)
+	return err
+}
+
+// wizard drives an interactive credential creation session.
+type wizard struct {
+	in       *bufio.Scanner
+	out      io.Writer
+	generate func() (string, error)
+	sinks    []sink
+	note     string
+}
+
+// ask prints a prompt and reads the trimmed answer. It reports false once
+// the input is exhausted.
+func (wz *wizard) ask(prompt string) (string, bool) {
+	fmt.Fprint(wz.out, prompt)
+	if !wz.in.Scan() {
+		fmt.Fprintln(wz.out)
+		return "", false
+	}
+	return strings.TrimSpace(wz.in.Text()), true
+}
+
+// run loops until an empty label or end of input and returns how many
+// credentials were stored. Each confirmed credential is written to the sinks
+// immediately, so an interrupted session keeps what it already stored.
+func (wz *wizard) run() (int, error) {
+	stored := 0
+	for {
+		label, ok := wz.ask("Label (empty to finish): ")
+		if !ok || label == "" {
+			return stored, wz.in.Err()
+		}
+
+		for {
+			password, err := wz.generate()
+			if err != nil {
+				return stored, err
+			}
+			fmt.Fprintf(wz.out, "%s: %s\n", label, password)
+
+			answer, ok := wz.ask("Store it? [Y/n/r to regenerate]: ")
+			if !ok {
+				return stored, wz.in.Err()
+			}
+			switch passgen.ToLowerASCII(answer) {
+			case "", "y", "yes":
+				cred := []credential{{Label: label, Password: password, Note: wz.note}}
+				if err := writeSinks(wz.sinks, cred, false); err != nil {
+					return stored, err
+				}
+				stored++
+				fmt.Fprintf(wz.out, "Stored %s\n\n", label)
+			case "r":
+				continue
+			default:
+				fmt.Fprintf(wz.out, "Discarded %s\n\n", label)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// memorySink collects credentials written to it
+type memorySink struct {
+	creds []credential
+}
+
+func (s *memorySink) write(creds []credential) error {
+	s.creds = append(s.creds, creds...)
+	return nil
+}
+
+// TestWizardRun tests the label, confirm and regenerate loop
+func TestWizardRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantStored []credential
+	}{
+		{"empty label finishes", "\n", nil},
+		{"end of input finishes", "", nil},
+		{"confirm by default", "web\n\n\n", []credential{{Label: "web", Password: "pw1"}}},
+		{"discard", "web\nn\n\n", nil},
+		{"regenerate", "web\nr\nY\n\n", []credential{{Label: "web", Password: "pw2"}}},
+		{"several labels", "web\ny\ndb\nyes\n", []credential{{Label: "web", Password: "pw1"}, {Label: "db", Password: "pw2"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := 0
+			mem := &memorySink{}
+			var out bytes.Buffer
+			wz := &wizard{
+				in:  bufio.NewScanner(strings.NewReader(tt.input)),
+				out: &out,
+				generate: func() (string, error) {
+					n++
+					return fmt.Sprintf("pw%d", n), nil
+				},
+				sinks: []sink{mem},
+			}
+
+			stored, err := wz.run()
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if stored != len(tt.wantStored) {
+				t.Errorf("stored = %d, want %d", stored, len(tt.wantStored))
+			}
+			if fmt.Sprint(mem.creds) != fmt.Sprint(tt.wantStored) {
+				t.Errorf("Sink got %v, want %v\nOutput:\n%s", mem.creds, tt.wantStored, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/junedkhatri31/passgen/pkg/passgen"
+)
+
+func init() {
+	features = append(features, "wordlist-url")
+}
+
+// maxWordlistBytes bounds a downloaded wordlist. The EFF large list is
+// about 100 KB.
+const maxWordlistBytes = 10 << 20
+
+// wordlistCache downloads wordlists from HTTPS URLs and keeps a copy in
+// dir, so passphrases can be generated offline after the first use.
+type wordlistCache struct {
+	dir    string
+	client *http.Client
+}
+
+// defaultWordlistCacheDir returns where downloaded wordlists are kept.
+func defaultWordlistCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ".passgen-wordlists"
+	}
+	return filepath.Join(dir, "passgen", "wordlists")
+}
+
+// fetchWordlist returns the words at url, downloading them on first use or
+// when refresh is set.
+func fetchWordlist(url string, refresh bool) ([]string, error) {
+	c := &wordlistCache{dir: defaultWordlistCacheDir(), client: &http.Client{Timeout: 30 * time.Second}}
+	return c.load(url, refresh)
+}
+
+// load returns the words at url from the cache, downloading them when they
+// are not cached yet or refresh is set.
+func (c *wordlistCache) load(url string, refresh bool) ([]string, error) {
+	// A wordlist fetched in the clear could be swapped for a tiny one
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("wordlist URL %s must use https", url)
+	}
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(c.dir, hex.EncodeToString(sum[:8])+".txt")
+
+	data, err := os.ReadFile(path)
+	if refresh || errors.Is(err, os.ErrNotExist) {
+		if data, err = c.download(url); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return nil, fmt.Errorf("caching wordlist: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return passgen.ReadWordlist(bytes.NewReader(data))
+}
+
+func (c *wordlistCache) download(url string) ([]byte, error) {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("downloading wordlist: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading wordlist: %s returned %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWordlistBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("downloading wordlist: %w", err)
+	}
+	if len(data) > maxWordlistBytes {
+		return nil, fmt.Errorf("downloading wordlist: %s is larger than %d bytes", url, maxWordlistBytes)
+	}
+	return data, nil
+}
+
+// writeFileAtomic replaces path with data, creating its directory, so an
+// interrupted download never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,10 @@
+//go:build passgen_lite
+
+package main
+
+import "errors"
+
+// fetchWordlist fails, since lite builds do not talk to remote services.
+func fetchWordlist(url string, refresh bool) ([]string, error) {
+	return nil, errors.New("wordlist URLs are not available in this build (built with passgen_lite)")
+}
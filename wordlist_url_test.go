@@ -0,0 +1,60 @@
+//go:build !passgen_lite
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWordlistCache tests downloading, caching and refreshing wordlist URLs
+func TestWordlistCache(t *testing.T) {
+	version := "first"
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/words.txt":
+			fmt.Fprintf(w, "11111\t%s\n11112\tsecond\n", version)
+		case "/huge.txt":
+			w.Write([]byte(strings.Repeat("a", maxWordlistBytes+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := &wordlistCache{dir: t.TempDir(), client: srv.Client()}
+	url := srv.URL + "/words.txt"
+
+	words, err := c.load(url, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(words, " ") != "first second" {
+		t.Errorf("Got words %q", words)
+	}
+
+	version = "changed"
+	if words, err = c.load(url, false); err != nil || words[0] != "first" {
+		t.Errorf("Cached load = %q, %v; want the first download", words, err)
+	}
+	if words, err = c.load(url, true); err != nil || words[0] != "changed" {
+		t.Errorf("Refreshed load = %q, %v; want a new download", words, err)
+	}
+
+	if _, err := c.load(srv.URL+"/missing.txt", false); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected a 404 error, got %v", err)
+	}
+	if _, err := c.load(srv.URL+"/huge.txt", false); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected a size error, got %v", err)
+	}
+	if _, err := c.load(strings.Replace(url, "https://", "http://", 1), false); err == nil {
+		t.Error("Expected error for a plain HTTP URL")
+	}
+
+	srv.Close()
+	if words, err = c.load(url, false); err != nil || words[0] != "changed" {
+		t.Errorf("Offline load = %q, %v; want the cached copy", words, err)
+	}
+}